@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	gr "github.com/thedahv/git-reviewer/src"
+)
+
+// actionsEvent holds the fields of the GitHub Actions event payload we use.
+type actionsEvent struct {
+	PullRequest *struct {
+		User struct {
+			Login string `json:"login"`
+		} `json:"user"`
+	} `json:"pull_request"`
+}
+
+// githubActions suggests reviewers from a GitHub Actions step: the reviewers,
+// their GitHub usernames and a few metrics become step outputs in
+// GITHUB_OUTPUT, and a table of suggestions goes to the step summary. The
+// author of the pull request that triggered the workflow is left out of the
+// usernames so they can be passed straight to a review request. Usernames
+// are found through 'provider', and left empty if it is nil.
+func githubActions(r *gr.ContributionCounter, files []string, provider gr.ReviewProvider) {
+	stats, err := r.SuggestReviewers(files)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, tr("There was an error finding reviewers: %v\n"), err)
+		os.Exit(1)
+	}
+
+	author := pullRequestAuthor(os.Getenv("GITHUB_EVENT_PATH"))
+	outputs, summary := actionsResults(stats, len(files), r.Show, author, provider)
+
+	if err := appendFile(os.Getenv("GITHUB_OUTPUT"), outputs); err != nil {
+		fmt.Fprintf(os.Stderr, tr("Unable to write step outputs: %v\n"), err)
+		os.Exit(1)
+	}
+	if path := os.Getenv("GITHUB_STEP_SUMMARY"); path != "" {
+		if err := appendFile(path, summary); err != nil {
+			fmt.Fprintf(os.Stderr, tr("Unable to write step summary: %v\n"), err)
+		}
+	}
+
+	fmt.Print(outputs)
+}
+
+// actionsResults builds the step outputs and the step summary of reviewers
+// suggested for 'files' changed files, showing their experience the way
+// 'show' asks for. Usernames are found through 'provider', if not nil, and
+// 'author' is left out of them.
+func actionsResults(stats gr.Stats, files int, show, author string,
+	provider gr.ReviewProvider) (string, string) {
+	var (
+		emails, logins []string
+		summary        bytes.Buffer
+	)
+	fmt.Fprintf(&summary, "### %s\n\n", tr("Suggested reviewers"))
+	fmt.Fprintf(&summary, "| %s | GitHub | %s |\n| --- | --- | --- |\n", tr("Reviewer"),
+		tr("Experience"))
+	for _, s := range stats {
+		emails = append(emails, s.Reviewer)
+
+		account := ""
+		if provider != nil {
+			if a, err := provider.User(s.Reviewer); err == nil && a.Name != "" {
+				account = "@" + a.Name
+				if !strings.EqualFold(a.Name, author) {
+					logins = append(logins, a.Name)
+				}
+			}
+		}
+		fmt.Fprintf(&summary, "| %s%s | %s | %s |\n", s.Reviewer, s.Notes(), account,
+			s.Experience(show))
+	}
+	fmt.Fprintf(&summary, "\n"+tr("Based on %d changed files.")+"\n", files)
+
+	var topShare float64
+	if len(stats) > 0 {
+		topShare = stats[0].Percentage * 100
+	}
+
+	outputs := fmt.Sprintf("reviewers=%s\nlogins=%s\nchanged-files=%d\ntop-share=%.2f\n",
+		strings.Join(emails, ","), strings.Join(logins, ","), files, topShare)
+
+	return outputs, summary.String()
+}
+
+// pullRequestAuthor reads the login of the author of the pull request that
+// triggered the workflow from the event payload at 'path'. It returns an
+// empty string for other events.
+func pullRequestAuthor(path string) string {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+
+	var event actionsEvent
+	if err := json.Unmarshal(b, &event); err != nil || event.PullRequest == nil {
+		return ""
+	}
+	return event.PullRequest.User.Login
+}
+
+// appendFile adds 'content' to the end of the file at 'path', creating it if
+// needed, as GitHub Actions expects for its command files.
+func appendFile(path, content string) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
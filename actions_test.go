@@ -0,0 +1,104 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gr "github.com/thedahv/git-reviewer/src"
+)
+
+// fakeProvider finds the accounts of the emails it maps to usernames.
+type fakeProvider map[string]string
+
+func (f fakeProvider) User(email string) (gr.Account, error) {
+	return gr.Account{ID: f[email], Name: f[email]}, nil
+}
+
+func (f fakeProvider) PullRequest(branch string) (*gr.PullRequest, error) {
+	return nil, nil
+}
+
+func (f fakeProvider) AddReviewers(pr *gr.PullRequest, accounts []gr.Account) error {
+	return nil
+}
+
+func TestActionsResults(t *testing.T) {
+	stats := gr.Stats{
+		{Reviewer: "abe@git-reviewer.com", Percentage: 0.625},
+		{Reviewer: "ben@git-reviewer.com", Percentage: 0.25},
+		{Reviewer: "sec@git-reviewer.com", Required: []string{"security"}},
+	}
+	accounts := fakeProvider{"abe@git-reviewer.com": "abe", "ben@git-reviewer.com": "benf"}
+
+	cases := []struct {
+		Name     string
+		Stats    gr.Stats
+		Author   string
+		Provider gr.ReviewProvider
+		Outputs  string
+		Summary  string
+	}{
+		{"accounts", stats, "", accounts,
+			"reviewers=abe@git-reviewer.com,ben@git-reviewer.com,sec@git-reviewer.com\n" +
+				"logins=abe,benf\nchanged-files=3\ntop-share=62.50\n",
+			"### Suggested reviewers\n\n" +
+				"| Reviewer | GitHub | Experience |\n| --- | --- | --- |\n" +
+				"| abe@git-reviewer.com | @abe | 62.50% |\n" +
+				"| ben@git-reviewer.com | @benf | 25.00% |\n" +
+				"| sec@git-reviewer.com (mandatory: security) |  | 0.00% |\n" +
+				"\nBased on 3 changed files.\n"},
+		{"author left out", stats, "BenF", accounts,
+			"reviewers=abe@git-reviewer.com,ben@git-reviewer.com,sec@git-reviewer.com\n" +
+				"logins=abe\nchanged-files=3\ntop-share=62.50\n", ""},
+		{"no provider", stats[:1], "", nil,
+			"reviewers=abe@git-reviewer.com\nlogins=\nchanged-files=3\ntop-share=62.50\n",
+			"### Suggested reviewers\n\n" +
+				"| Reviewer | GitHub | Experience |\n| --- | --- | --- |\n" +
+				"| abe@git-reviewer.com |  | 62.50% |\n" +
+				"\nBased on 3 changed files.\n"},
+		{"nobody", nil, "", accounts,
+			"reviewers=\nlogins=\nchanged-files=3\ntop-share=0.00\n", ""},
+	}
+
+	for _, c := range cases {
+		outputs, summary := actionsResults(c.Stats, 3, "", c.Author, c.Provider)
+		if outputs != c.Outputs {
+			t.Errorf("%s: got outputs %q, expected %q\n", c.Name, outputs, c.Outputs)
+		}
+		if c.Summary != "" && summary != c.Summary {
+			t.Errorf("%s: got summary %q, expected %q\n", c.Name, summary, c.Summary)
+		}
+	}
+}
+
+func TestPullRequestAuthor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "git-reviewer-actions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cases := []struct {
+		Name, Event, Expected string
+	}{
+		{"pull request", `{"pull_request": {"user": {"login": "carl"}}}`, "carl"},
+		{"push", `{"ref": "refs/heads/main"}`, ""},
+		{"invalid", `{`, ""},
+	}
+
+	for _, c := range cases {
+		path := filepath.Join(dir, "event.json")
+		if err := ioutil.WriteFile(path, []byte(c.Event), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if got := pullRequestAuthor(path); got != c.Expected {
+			t.Errorf("%s: got '%s', expected '%s'\n", c.Name, got, c.Expected)
+		}
+	}
+
+	if got := pullRequestAuthor(filepath.Join(dir, "missing.json")); got != "" {
+		t.Errorf("Got '%s' without an event, expected none\n", got)
+	}
+}
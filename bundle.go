@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	gr "github.com/thedahv/git-reviewer/src"
+)
+
+// writeBundle saves the bundle captured while suggesting reviewers to
+// 'path'. Nothing is written if the run stopped before capturing anything,
+// such as when the branch has no changes.
+func writeBundle(path string, b *gr.Bundle) {
+	if len(b.Paths) == 0 {
+		return
+	}
+
+	f, err := os.Create(path)
+	if err == nil {
+		err = b.Write(f)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		fmt.Printf(tr("Unable to write the bundle: %v\n"), err)
+	}
+}
+
+// replay prints the suggestion captured in the bundle at 'path', recomputed
+// without the repository it came from.
+func replay(path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		fmt.Printf(tr("Unable to open the bundle: %v\n"), err)
+		os.Exit(1)
+	}
+	defer f.Close()
+
+	b, err := gr.ReadBundle(f)
+	if err != nil {
+		fmt.Printf(tr("Unable to read the bundle: %v\n"), err)
+		os.Exit(1)
+	}
+
+	fmt.Printf(tr("Replaying %.7s..%.7s since %s, captured by git-reviewer %s on %s\n\n"),
+		b.Base, b.Head, b.Since, b.Version, b.Now)
+
+	reviewers, err := gr.Replay(b)
+	if err != nil {
+		fmt.Printf(tr("There was an error finding reviewers: %v\n"), err)
+		os.Exit(1)
+	}
+	fmt.Println(reviewers)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	gr "github.com/thedahv/git-reviewer/src"
+)
+
+// conflicts prints who owns the other side of each conflict of the merge,
+// rebase, cherry-pick or revert in progress, then the people to consult
+// ordered by how many conflicts they own.
+func conflicts(r *gr.ContributionCounter) {
+	c, err := r.FindConflictOwners()
+	if err != nil {
+		fmt.Printf(tr("There was an error finding conflicts: %v\n"), err)
+		os.Exit(1)
+	}
+
+	if len(c.Owners) == 0 {
+		fmt.Printf(tr("No conflicts left to resolve in this %s\n"), c.Operation)
+		return
+	}
+
+	fmt.Printf(tr("Owners of the other side of each conflict, from %.7s (%s):\n\n"),
+		c.Other, c.Operation)
+
+	counts := make(map[string]int)
+	for _, o := range c.Owners {
+		fmt.Println(o.Format(r.Show))
+		if o.Reviewer != "" {
+			counts[o.Reviewer]++
+		}
+	}
+
+	if len(counts) == 0 {
+		return
+	}
+
+	var people []string
+	for p := range counts {
+		people = append(people, p)
+	}
+	sort.Slice(people, func(i, j int) bool {
+		if counts[people[i]] != counts[people[j]] {
+			return counts[people[i]] > counts[people[j]]
+		}
+		return people[i] < people[j]
+	})
+
+	fmt.Print(tr("\nPeople to consult:\n\n"))
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', 0)
+	fmt.Fprintln(tw, tr("Reviewer\tConflicts"))
+	fmt.Fprintln(tw, "--------\t---------")
+	for _, p := range people {
+		fmt.Fprintf(tw, "%s\t%d\n", p, counts[p])
+	}
+	tw.Flush()
+}
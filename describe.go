@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	gr "github.com/thedahv/git-reviewer/src"
+)
+
+// describe prints a markdown section for the description of a pull request:
+// the packages the changes touch, the suggested reviewers along with why, and
+// the changed files worth a closer look. It is meant to be pasted, or piped to
+// 'gh pr create --body-file -'.
+func describe(r *gr.ContributionCounter, files []string) {
+	stats, err := r.SuggestReviewers(files)
+	if _, ok := err.(gr.NoReviewersErr); err != nil && !ok {
+		fmt.Fprintf(os.Stderr, tr("There was an error finding reviewers: %v\n"), err)
+		os.Exit(1)
+	}
+
+	packages, err := r.Packages(files)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, tr("There was an error finding packages: %v\n"), err)
+		os.Exit(1)
+	}
+
+	annotations, err := r.Annotations(files)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, tr("There was an error finding annotations: %v\n"), err)
+		os.Exit(1)
+	}
+
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "### %s\n\n", tr("Changed areas"))
+	for _, p := range packages {
+		fmt.Fprintf(&b, "- `%s`: %s\n", p.Name(), countFiles(len(p.Files)))
+	}
+
+	fmt.Fprintf(&b, "\n### %s\n\n", tr("Suggested reviewers"))
+	if len(stats) == 0 {
+		fmt.Fprintf(&b, "%s\n", tr("Nobody has enough experience with these changes."))
+	}
+	for _, s := range stats {
+		fmt.Fprintf(&b, "- %s", s.Reviewer)
+		if rationale := s.Rationale(); rationale != "" {
+			fmt.Fprintf(&b, ": %s", rationale)
+		}
+		fmt.Fprintln(&b)
+	}
+
+	fmt.Fprintf(&b, "\n### %s\n\n", tr("Risks"))
+	if len(annotations) == 0 {
+		fmt.Fprintf(&b, "%s\n", tr("None found."))
+	}
+	for _, a := range annotations {
+		fmt.Fprintf(&b, "- `%s`: %s\n", a.Path, a.Message)
+	}
+
+	os.Stdout.Write(b.Bytes())
+}
+
+// countFiles describes a number of changed files.
+func countFiles(n int) string {
+	if n == 1 {
+		return tr("1 file")
+	}
+	return fmt.Sprintf(tr("%d files"), n)
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strings"
+
+	gr "github.com/thedahv/git-reviewer/src"
+	"github.com/thedahv/git-reviewer/src/gitcmd"
+)
+
+// check is the outcome of one of the doctor's checks. Checks that don't apply,
+// such as those of providers that aren't set up, are skipped.
+type check struct {
+	name    string
+	detail  string
+	err     error
+	fix     string
+	skipped bool
+}
+
+// print writes the outcome of the check on one line, followed by how to fix
+// it if it failed.
+func (c check) print() {
+	switch {
+	case c.err != nil:
+		fmt.Printf("  FAIL  %s: %v\n", c.name, c.err)
+		fmt.Printf("        %s\n", tr(c.fix))
+	case c.skipped:
+		fmt.Printf("  skip  %s: %s\n", c.name, tr(c.detail))
+	default:
+		fmt.Printf("  ok    %s: %s\n", c.name, c.detail)
+	}
+}
+
+// doctor checks the environment git-reviewer needs, from the git binary to
+// the cache directory, and prints whether each check passed along with how to
+// fix those that failed. It returns false if any check failed.
+func doctor(dir, base string) bool {
+	var checks, failed int
+	report := func(c check) {
+		checks++
+		if c.err != nil {
+			failed++
+		}
+		c.print()
+	}
+
+	fmt.Println(tr("Checking the environment git-reviewer runs in:"))
+
+	runner := gitcmd.Runner{Git: gitBin}
+	if v, err := runner.CheckVersion(context.Background()); err != nil {
+		report(check{name: "git", err: err,
+			fix: "Install a recent git and make sure it is on your PATH, or point --git-bin at it"})
+	} else if !v.AtLeast(gitcmd.IgnoreRevVersion) {
+		report(check{name: "git", detail: fmt.Sprintf(tr("git %s, too old for --ignore-merges"), v)})
+	} else {
+		report(check{name: "git", detail: "git " + v.String()})
+	}
+
+	repo, root, err := gr.OpenRepository(dir)
+	if err != nil {
+		report(check{name: "repository", err: err,
+			fix: "Run git reviewer from inside a git repository"})
+	} else {
+		report(check{name: "repository", detail: root})
+	}
+
+	r := gr.ContributionCounter{Repo: repo, Dir: root, Base: base}
+	cfg := &gr.Config{}
+	if repo != nil {
+		if cfg, err = r.LoadConfig(); err != nil {
+			report(check{name: "config", err: err,
+				fix: fmt.Sprintf(tr("Check the syntax of %s and your git config"), gr.ConfigFile)})
+			cfg = &gr.Config{}
+		} else {
+			report(check{name: "config", detail: fmt.Sprintf(tr("%d settings"), len(cfg.Entries))})
+		}
+
+		report(baseCheck(&r))
+		report(mailmapCheck(root))
+	}
+
+	report(githubCheck(dir))
+	report(jiraCheck(cfg))
+	report(cacheCheck())
+
+	if failed > 0 {
+		fmt.Printf(tr("\n%d of %d checks failed.\n"), failed, checks)
+		return false
+	}
+	fmt.Println(tr("\nAll checks passed."))
+	return true
+}
+
+// baseCheck checks that the branch to compare to, and the checked out branch,
+// can be found.
+func baseCheck(r *gr.ContributionCounter) check {
+	if r.Base == "" {
+		if detected, err := r.DetectBase(); err == nil {
+			r.Base = detected
+		}
+	}
+
+	if err := r.Validate(); err != nil {
+		for _, p := range err.(gr.ValidationErrors) {
+			if p.Option == "base" || p.Option == "head" {
+				return check{name: "base", err: fmt.Errorf("%s: %s", p.Option, p.Problem),
+					fix: p.Fix}
+			}
+		}
+	}
+
+	return check{name: "base", detail: r.Base}
+}
+
+// mailmapCheck checks that the mailmap files merging the identities of
+// collaborators only hold lines git understands.
+func mailmapCheck(root string) check {
+	paths := []string{filepath.Join(root, ".mailmap"), filepath.Join(root, "mailmap")}
+	if u, err := user.Current(); err == nil {
+		paths = append([]string{filepath.Join(u.HomeDir, ".mailmap")}, paths...)
+	}
+
+	var found []string
+	for _, p := range paths {
+		if _, err := os.Stat(p); err != nil {
+			continue
+		}
+		if err := gr.CheckMailmap(p); err != nil {
+			return check{name: "mailmap", err: err,
+				fix: "Write each line as 'Name <email>' optionally followed by 'Other Name <other email>'"}
+		}
+		found = append(found, p)
+	}
+
+	if len(found) == 0 {
+		return check{name: "mailmap", detail: "no mailmap files", skipped: true}
+	}
+	return check{name: "mailmap", detail: strings.Join(found, ", ")}
+}
+
+// githubCheck checks that gh, which the gh command relies on, is logged in.
+func githubCheck(dir string) check {
+	if _, err := exec.LookPath("gh"); err != nil {
+		return check{name: "GitHub", detail: "gh is not installed, only the gh command needs it",
+			skipped: true}
+	}
+
+	if _, err := gh(dir, "auth", "status"); err != nil {
+		return check{name: "GitHub", err: err, fix: "Run 'gh auth login'"}
+	}
+	return check{name: "GitHub", detail: tr("logged in with gh")}
+}
+
+// jiraCheck checks that Jira accepts the credentials --tickets looks tickets
+// up with, if a Jira instance is set up.
+func jiraCheck(cfg *gr.Config) check {
+	j, ok := cfg.TicketProvider().(*gr.JiraProvider)
+	if !ok {
+		return check{name: "Jira", detail: "reviewer.jiraUrl is not set, only --tickets needs it",
+			skipped: true}
+	}
+
+	if err := j.CheckAuth(); err != nil {
+		return check{name: "Jira", err: err,
+			fix: "Set JIRA_API_TOKEN to an API token of reviewer.jiraUser"}
+	}
+	return check{name: "Jira", detail: j.URL}
+}
+
+// cacheCheck checks that suggestions can be cached.
+func cacheCheck() check {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return check{name: "cache", err: err, fix: "Set HOME, or run with --no-cache"}
+	}
+
+	dir = filepath.Join(dir, "git-reviewer")
+	if err := gr.NewFileCache(dir).Writable(); err != nil {
+		return check{name: "cache", err: err,
+			fix: "Make the directory writable, or run with --no-cache"}
+	}
+	return check{name: "cache", detail: dir}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	gr "github.com/thedahv/git-reviewer/src"
+)
+
+// sourceFlag is the source of settings given on the command line.
+const sourceFlag = "command line"
+
+// effectiveConfig is the configuration a run uses, the settings read from
+// config files and defaults followed by every flag.
+type effectiveConfig struct {
+	Config []gr.Setting `json:"config"`
+	Flags  []gr.Setting `json:"flags"`
+}
+
+// printEffective prints the settings and flags in effect and where each came
+// from, as a table, YAML or JSON.
+func printEffective(cfg *gr.Config, format string) {
+	e := effectiveConfig{Config: cfg.Effective()}
+
+	set := givenFlags()
+	flag.VisitAll(func(f *flag.Flag) {
+		s := gr.Setting{Key: f.Name, Value: f.Value.String(), Source: gr.SourceDefault}
+		if set[f.Name] {
+			s.Source = sourceFlag
+		}
+		e.Flags = append(e.Flags, s)
+	})
+
+	switch format {
+	case "json":
+		b, _ := json.MarshalIndent(e, "", "  ")
+		fmt.Println(string(b))
+		return
+	case "yaml":
+		writeSettingsYAML("config", e.Config)
+		writeSettingsYAML("flags", e.Flags)
+		return
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', 0)
+	fmt.Fprintln(tw, tr("Setting\tValue\tSource"))
+	fmt.Fprintln(tw, "-------\t-----\t------")
+	for _, s := range e.Config {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", s.Key, s.Value, s.Source)
+	}
+	for _, s := range e.Flags {
+		fmt.Fprintf(tw, "--%s\t%s\t%s\n", s.Key, s.Value, s.Source)
+	}
+	tw.Flush()
+}
+
+// writeSettingsYAML writes 'settings' as a YAML sequence under 'name'. The
+// strings are quoted as JSON, which YAML reads as double-quoted scalars.
+func writeSettingsYAML(name string, settings []gr.Setting) {
+	quote := func(s string) string {
+		b, _ := json.Marshal(s)
+		return string(b)
+	}
+
+	if len(settings) == 0 {
+		fmt.Printf("%s: []\n", name)
+		return
+	}
+
+	fmt.Printf("%s:\n", name)
+	for _, s := range settings {
+		fmt.Printf("  - key: %s\n", quote(s.Key))
+		fmt.Printf("    value: %s\n", quote(s.Value))
+		fmt.Printf("    source: %s\n", quote(s.Source))
+	}
+}
+
+// givenFlags names the flags given on the command line, which take precedence
+// over settings.
+func givenFlags() map[string]bool {
+	set := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	return set
+}
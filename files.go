@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	gr "github.com/thedahv/git-reviewer/src"
+)
+
+// listFiles prints every file changed on the branch, how it changed and why it
+// is left out of suggestions if it is, or which files stand in for it if it
+// was added, as a table or JSON.
+func listFiles(r *gr.ContributionCounter, format string) {
+	files, err := r.ChangedFiles()
+	if err != nil {
+		if format == "json" {
+			reportError(format, "", err)
+		} else {
+			fmt.Fprintf(os.Stderr, tr("There was an error finding files: %v\n"), err)
+		}
+		os.Exit(1)
+	}
+
+	if format == "json" {
+		if files == nil {
+			files = []gr.ChangedFile{}
+		}
+		b, _ := json.MarshalIndent(files, "", "  ")
+		fmt.Println(string(b))
+		return
+	}
+
+	if len(files) == 0 {
+		fmt.Println(tr("No changes on this branch!"))
+		return
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', 0)
+	fmt.Fprintln(tw, tr("Path\tChange\tAdded\tRemoved\tExcluded"))
+	fmt.Fprintln(tw, "----\t------\t-----\t-------\t--------")
+	for _, f := range files {
+		path := f.Path
+		if f.From != "" {
+			path = f.From + " => " + f.Path
+		}
+		added, removed := fmt.Sprintf("+%d", f.Added), fmt.Sprintf("-%d", f.Removed)
+		if f.Binary {
+			added, removed = "-", "-"
+		}
+		excluded := f.Excluded
+		if len(f.Relatives) > 0 {
+			excluded = fmt.Sprintf(tr("through %s"), strings.Join(f.Relatives, ", "))
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", path, f.Change, added, removed, excluded)
+	}
+	tw.Flush()
+}
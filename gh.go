@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	gr "github.com/thedahv/git-reviewer/src"
+)
+
+// ghExtension is the name of the executable gh runs for `gh reviewer`. Run
+// under that name, git-reviewer behaves as if given the gh command.
+const ghExtension = "gh-reviewer"
+
+// gh runs a gh command in 'dir' and returns its standard output, with gh's
+// error message on failure.
+func gh(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("gh", args...)
+	cmd.Dir = dir
+
+	out, err := cmd.Output()
+	if exit, ok := err.(*exec.ExitError); ok {
+		return nil, fmt.Errorf("gh %s: %s", args[0], strings.TrimSpace(string(exit.Stderr)))
+	}
+
+	return out, err
+}
+
+// pullRequestBase picks the revision to compare a pull request to: its base
+// branch, or the remote-tracking one if it isn't checked out locally.
+func pullRequestBase(dir, branch string) string {
+	if git(dir, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch).Run() == nil {
+		return branch
+	}
+
+	return "origin/" + branch
+}
+
+// ghToken returns the token gh is logged in to 'host' with, or an empty
+// string if gh isn't installed or logged in, so the GitHub API can be called
+// as the user without a token of its own.
+func ghToken(dir, host string) string {
+	if host == "" || host == "ssh.github.com" {
+		host = "github.com"
+	}
+
+	out, err := gh(dir, "auth", "token", "--hostname", host)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// reviewProvider builds the ReviewProvider of the repository origin points
+// to, looking accounts up in 'ids' first if not nil. The GitHub API is called
+// with the token gh is logged in with if GH_TOKEN and GITHUB_TOKEN are unset.
+// Under 'github', origin is taken to be on GitHub, or GitHub Enterprise,
+// unless it is known to be elsewhere.
+func reviewProvider(r *gr.ContributionCounter, cfg *gr.Config, ids *gr.IdentityCache,
+	github bool) (gr.ReviewProvider, error) {
+	remote, err := r.Remote("origin")
+	if err != nil {
+		return nil, err
+	}
+	if github && remote.Provider == "" {
+		remote.Provider = gr.ProviderGitHub
+	}
+
+	provider, err := r.ReviewProvider(cfg, remote)
+	if err != nil {
+		return nil, err
+	}
+	if g, ok := provider.(*gr.GitHub); ok && g.Token == "" {
+		g.Token = ghToken(r.Dir, remote.Host)
+	}
+	if ids != nil {
+		provider = gr.CachedProvider(provider, ids, remote.Host)
+	}
+
+	return provider, nil
+}
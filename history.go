@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	gr "github.com/thedahv/git-reviewer/src"
+)
+
+// history prints who reviewed whose code since the 'since' date, either as
+// raw CSV rows or as a table of review load per reviewer per month.
+func history(r *gr.ContributionCounter, format string) {
+	reviews, err := r.ReviewHistory()
+	if err != nil {
+		fmt.Printf(tr("There was an error reading review history: %v\n"), err)
+		return
+	}
+
+	if format == "csv" {
+		w := csv.NewWriter(os.Stdout)
+		w.Write([]string{"date", "commit", "author", "reviewer"})
+		for _, rv := range reviews {
+			w.Write([]string{rv.Date.Format("2006-01-02"), rv.Commit, rv.Author,
+				rv.Reviewer})
+		}
+		w.Flush()
+		return
+	}
+
+	if len(reviews) == 0 {
+		fmt.Printf(tr("No reviews recorded in commit trailers since %s\n"), r.Since)
+		return
+	}
+
+	type load struct {
+		month, reviewer string
+	}
+	counts := make(map[load]int)
+	for _, rv := range reviews {
+		counts[load{rv.Date.Format("2006-01"), rv.Reviewer}]++
+	}
+
+	var keys []load
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].month != keys[j].month {
+			return keys[i].month < keys[j].month
+		}
+		return counts[keys[i]] > counts[keys[j]]
+	})
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', 0)
+	fmt.Fprintln(tw, "Month\tReviewer\tReviews")
+	fmt.Fprintln(tw, "-----\t--------\t-------")
+	for _, k := range keys {
+		fmt.Fprintf(tw, "%s\t%s\t%d\n", k.month, k.reviewer, counts[k])
+	}
+	tw.Flush()
+}
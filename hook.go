@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	gr "github.com/thedahv/git-reviewer/src"
+)
+
+// zeroRev is what git passes to hooks for a ref that doesn't exist, such as
+// the remote side of a new branch.
+const zeroRev = "0000000000000000000000000000000000000000"
+
+// prePush checks the refs git is about to push, read from 'in' as the
+// pre-push hook gets them, and warns about changed files nobody active owns
+// and files on sensitive paths, along with who to contact about them. Only
+// the pushed changes are blamed, and no reviewers are ranked, to keep pushing
+// fast. It returns false if the push should be blocked, which only happens
+// with reviewer.prePushBlock.
+func prePush(r *gr.ContributionCounter, cfg *gr.Config, in io.Reader) bool {
+	base := r.Base
+
+	var flagged int
+	scn := bufio.NewScanner(in)
+	for scn.Scan() {
+		// <local ref> <local sha> <remote ref> <remote sha>
+		fields := strings.Fields(scn.Text())
+		if len(fields) != 4 || fields[1] == zeroRev {
+			continue
+		}
+
+		r.Head, r.Base = fields[1], fields[3]
+		if r.Base == zeroRev {
+			r.Base = base
+		}
+
+		files, err := r.FindFiles()
+		if err == nil && len(files) > 0 {
+			var annotations []gr.Annotation
+			if annotations, err = r.Annotations(files); err == nil && len(annotations) > 0 {
+				flagged += len(annotations)
+				printAnnotations(fields[0], annotations)
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, tr("git-reviewer: unable to check %s: %v\n"), fields[0], err)
+		}
+	}
+
+	if flagged == 0 || !cfg.GetBool("reviewer.prePushBlock") {
+		return true
+	}
+	fmt.Fprintln(os.Stderr, tr("git-reviewer: push blocked by reviewer.prePushBlock. "+
+		"Get the changes reviewed, or push with --no-verify."))
+	return false
+}
+
+// printAnnotations lists the files of the pushed 'ref' that need attention,
+// followed by everyone to contact about them.
+func printAnnotations(ref string, annotations []gr.Annotation) {
+	fmt.Fprintf(os.Stderr, tr("git-reviewer: %s changes files that need attention:\n"), ref)
+
+	var (
+		contacts []string
+		seen     = make(map[string]bool)
+	)
+	for _, a := range annotations {
+		fmt.Fprintf(os.Stderr, "  %s: %s\n", a.Path, a.Message)
+		for _, c := range a.Contacts {
+			if !seen[c] {
+				seen[c] = true
+				contacts = append(contacts, c)
+			}
+		}
+	}
+
+	if len(contacts) > 0 {
+		fmt.Fprintf(os.Stderr, tr("Suggested contacts: %s\n"), strings.Join(contacts, ", "))
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runAsMain is set in the environment of the test binary when it stands in
+// for git-reviewer, such as when git runs the installed hook.
+const runAsMain = "GIT_REVIEWER_RUN_AS_MAIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(runAsMain) != "" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// fakeInstall puts a git-reviewer on a new PATH that runs the test binary as
+// main, so git finds it as the reviewer subcommand, and returns the
+// environment to run git with.
+func fakeInstall(t *testing.T, dir string) []string {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bin := filepath.Join(dir, "bin")
+	if err := os.MkdirAll(bin, 0755); err != nil {
+		t.Fatal(err)
+	}
+	script := "#!/bin/sh\n" + runAsMain + "=1 exec '" + self + "' \"$@\"\n"
+	if err := ioutil.WriteFile(filepath.Join(bin, "git-reviewer"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	return append(os.Environ(), "PATH="+bin+string(os.PathListSeparator)+os.Getenv("PATH"),
+		"XDG_CACHE_HOME="+filepath.Join(dir, "cache"), "HOME="+dir,
+		"GIT_AUTHOR_NAME=Abraham Lincoln", "GIT_AUTHOR_EMAIL=abe@git-reviewer.com",
+		"GIT_COMMITTER_NAME=Abraham Lincoln", "GIT_COMMITTER_EMAIL=abe@git-reviewer.com")
+}
+
+func TestPrePushHookBlocksUnownedFiles(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "git-reviewer-hook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	env := fakeInstall(t, tmp)
+
+	git := func(dir string, extra []string, args ...string) (string, error) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(append([]string{}, env...), extra...)
+		out, err := cmd.CombinedOutput()
+		return string(out), err
+	}
+	mustGit := func(dir string, extra []string, args ...string) {
+		if out, err := git(dir, extra, args...); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	remote, work := filepath.Join(tmp, "remote.git"), filepath.Join(tmp, "work")
+	mustGit(tmp, nil, "init", "-q", "--bare", remote)
+	mustGit(tmp, nil, "init", "-q", work)
+	mustGit(work, nil, "symbolic-ref", "HEAD", "refs/heads/master")
+	mustGit(work, nil, "remote", "add", "origin", remote)
+
+	// Ben wrote the file long ago and isn't active anymore
+	if err := ioutil.WriteFile(filepath.Join(work, "a.go"),
+		[]byte("package a\n\nvar x = 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	old := []string{"GIT_AUTHOR_NAME=Ben Franklin", "GIT_AUTHOR_EMAIL=ben@git-reviewer.com",
+		"GIT_AUTHOR_DATE=2001-01-01T12:00:00Z", "GIT_COMMITTER_DATE=2001-01-01T12:00:00Z"}
+	mustGit(work, old, "add", ".")
+	mustGit(work, old, "commit", "-q", "-m", "Add a")
+	if err := ioutil.WriteFile(filepath.Join(work, "b.go"), []byte("package a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mustGit(work, nil, "add", ".")
+	mustGit(work, nil, "commit", "-q", "-m", "Add b")
+	mustGit(work, nil, "push", "-q", "origin", "master")
+
+	mustGit(work, nil, "checkout", "-q", "-b", "feature")
+	if err := ioutil.WriteFile(filepath.Join(work, "a.go"),
+		[]byte("package a\n\nvar x = 2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mustGit(work, nil, "commit", "-q", "-a", "-m", "Change x")
+
+	mustGit(work, nil, "reviewer", "hook", "install", "--pre-push")
+	mustGit(work, nil, "config", "reviewer.prePushBlock", "true")
+
+	out, err := git(work, nil, "push", "-q", "origin", "feature")
+	if err == nil {
+		t.Fatalf("Expected the push to be blocked, got:\n%s", out)
+	}
+	if !strings.Contains(out, "a.go: Nobody active owns more than 10% of this file.") {
+		t.Errorf("Got:\n%s\nexpected a.go to be flagged\n", out)
+	}
+
+	// Without an alert threshold only sensitive paths are checked
+	mustGit(work, nil, "config", "reviewer.ownershipAlert", "0")
+	if out, err := git(work, nil, "push", "-q", "origin", "feature"); err != nil {
+		t.Errorf("Got %v, expected the push to go through:\n%s", err, out)
+	}
+}
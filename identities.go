@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	gr "github.com/thedahv/git-reviewer/src"
+)
+
+// identityCachePath is the file accounts resolved by providers are
+// remembered in, in the user cache directory, such as $XDG_CACHE_HOME.
+func identityCachePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "git-reviewer", "identities.json"), nil
+}
+
+// loadIdentityCache opens the identity cache, or returns nil if it can't be
+// read, in which case providers are asked about every reviewer.
+func loadIdentityCache() *gr.IdentityCache {
+	path, err := identityCachePath()
+	if err != nil {
+		return nil
+	}
+
+	ids, err := gr.LoadIdentityCache(path, 0)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, tr("Ignoring the identity cache: %v\n"), err)
+		return nil
+	}
+	return ids
+}
+
+// saveIdentities saves the accounts resolved during the run.
+func saveIdentities(ids *gr.IdentityCache) {
+	if ids == nil {
+		return
+	}
+	if err := ids.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, tr("Unable to save the identity cache: %v\n"), err)
+	}
+}
+
+// identities lists the accounts reviewers were resolved to or, with
+// 'refresh', forgets those of 'emails', or all of them if none are given, so
+// they are looked up again.
+func identities(refresh bool, emails []string, format string) {
+	path, err := identityCachePath()
+	if err != nil {
+		reportError(format, "Unable to find the identity cache: %v\n", err)
+		os.Exit(1)
+	}
+
+	ids, err := gr.LoadIdentityCache(path, 0)
+	if err != nil {
+		reportError(format, "Unable to read the identity cache: %v\n", err)
+		os.Exit(1)
+	}
+
+	if refresh {
+		n := ids.Refresh(emails...)
+		if err := ids.Save(); err != nil {
+			reportError(format, "Unable to save the identity cache: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf(tr("Forgot %d cached accounts; they will be looked up again.\n"), n)
+		return
+	}
+
+	entries := ids.Entries()
+	if format == "json" {
+		b, _ := json.MarshalIndent(entries, "", "  ")
+		fmt.Println(string(b))
+		return
+	}
+
+	if len(entries) == 0 {
+		fmt.Println(tr("No accounts cached yet."))
+		return
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', 0)
+	fmt.Fprintln(tw, tr("Host\tEmail\tAccount\tResolved"))
+	fmt.Fprintln(tw, "----\t-----\t-------\t--------")
+	for _, e := range entries {
+		account := e.Account.Name
+		if account == "" {
+			account = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", e.Provider, e.Email, account,
+			e.Resolved.Format("2006-01-02"))
+	}
+	tw.Flush()
+}
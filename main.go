@@ -1,33 +1,81 @@
 package main
 
 import (
-	"errors"
+	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"os/exec"
 	"os/user"
-	"regexp"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	gr "github.com/thedahv/git-reviewer/src"
-	gogit "gopkg.in/src-d/go-git.v4"
+	"github.com/thedahv/git-reviewer/src/gitcmd"
 )
 
-const version = "0.0.5"
+// commandFormats lists the subcommands and the output formats each supports.
+// The empty command suggests reviewers for the current branch.
+var commandFormats = map[string][]string{
+	"":           {"table", "editor", "emails", "github", "sarif", "junit", "assignments"},
+	"watch":      {"table"},
+	"annotate":   {"table"},
+	"history":    {"table", "csv"},
+	"gh":         {"table"},
+	"doctor":     {"table"},
+	"files":      {"table", "json"},
+	"ownership":  {"table", "json", "csv"},
+	"project":    {"table", "json", "csv"},
+	"pr":         {"table"},
+	"hook":       {"table"},
+	"describe":   {"table"},
+	"config":     {"table", "yaml", "json"},
+	"conflicts":  {"table"},
+	"identities": {"table", "json"},
+	"query":      {"table"},
+}
+
+// lang is the language messages are displayed in, from --lang or the locale.
+var lang = gr.LanguageEnglish
+
+// gitBin is the git executable to run, from --git-bin.
+var gitBin string
 
-// dateRx helps us ensure date arguments confirm to YYYY-MM-DD format
-var dateRx *regexp.Regexp
+// git prepares a git command the command line runs itself in 'dir', in the
+// clean environment gitcmd sets up.
+func git(dir string, args ...string) *exec.Cmd {
+	return gitcmd.Runner{Git: gitBin, Dir: dir}.Command(context.Background(), args...)
+}
 
-func init() {
-	dateRx = regexp.MustCompile("\\d{4}-\\d{2}-\\d{2}")
+// tr translates a message into lang.
+func tr(msg string) string {
+	return gr.Translate(lang, msg)
 }
 
 func main() {
 	showFiles := flag.Bool("show-files", false, "Show changed files for reviewing")
-	verbose := flag.Bool("verbose", false, "Show progress and errors information")
+	verbose := flag.Bool("verbose", false, "Show progress and errors information,"+
+		" and a summary of the run on stderr")
 	force := flag.Bool("force", false, "Continue processing despite checks or errors")
+	strict := flag.Bool("strict-branch-check", false, "Stop instead of warning"+
+		" when the branch is behind master (--force overrides)")
 	since := flag.String("since", "", "Consider commits after date when finding"+
-		" reviewers. Defaults to 6 months ago (format 'YYYY-MM-DD')")
+		" reviewers. Defaults to 6 months before the branch diverged from its base"+
+		" (format 'YYYY-MM-DD')")
+	sinceFrom := flag.String("since-from", gr.SinceFromMergeBase, "What the default"+
+		" --since counts 6 months back from: 'merge-base', where the branch diverged"+
+		" from its base, or 'today'")
+	sortFlag := flag.String("sort", "", "List suggested reviewers, or owners with"+
+		" 'ownership', by 'percentage', 'recency', 'name' or 'lines' instead of the"+
+		" order they were picked in")
+	ranker := flag.String("ranker", gr.RankerHeuristic, "How to rank candidates:"+
+		" 'heuristic', by the share of the changed lines they own, or 'learned'"+
+		" (experimental), by a model trained on the review trailers of past commits")
 	ie := flag.String("ignore-extension", "", "Exclude changed paths that end with"+
 		" these extensions (--ignore-extension svg,png,jpg)")
 	oe := flag.String("only-extension", "", "Only consider changed paths that end with"+
@@ -36,12 +84,143 @@ func main() {
 		" (--ignore-path main.go,src)")
 	op := flag.String("only-path", "", "Only consider file or files under path"+
 		" (--only-path main.go,src)")
-	v := flag.Bool("version", false, "Print the program version and exit")
+	preset := flag.String("preset", "", "Add the filters of these presets for common"+
+		" kinds of projects: 'frontend', 'go-service', 'data' or presets defined in"+
+		" the config (--preset frontend,go-service)")
+	noDefaultIgnores := flag.Bool("no-default-ignores", false, "Consider files"+
+		" with extensions that are ignored by default (svg, json, nock, xml)")
+	noCache := flag.Bool("no-cache", false, "Ignore cached suggestions and API"+
+		" responses and recompute reviewers from scratch")
+	availability := flag.Bool("availability", false, "Slightly favor reviewers"+
+		" whose working hours overlap yours, according to the reviewer.timezone"+
+		" setting")
+	balanceLoad := flag.Bool("balance-load", false, "Don't suggest people with"+
+		" as many open reviews as their reviewer.capacity, with the gh and pr commands")
+	warnTakeovers := flag.Bool("warn-takeovers", false, "Warn about changed files"+
+		" the branch makes its author the main owner of, taking them over from"+
+		" someone else")
+	noExec := flag.Bool("no-exec", false, "Never run git or other programs, nor"+
+		" reach the network: read history with go-git and API responses from the"+
+		" cache only")
+	base := flag.String("base", "", "Branch to compare to. Defaults to master"+
+		" or main, whichever exists")
+	merge := flag.String("merge", "", "Suggest who should review an already"+
+		" merged change, given its merge commit, by comparing it to its first parent")
+	exportBundle := flag.String("export-bundle", "", "Save everything the"+
+		" suggestion is computed from to this .tar.gz file, to reproduce it with --replay")
+	replayFlag := flag.String("replay", "", "Recompute the suggestion saved with"+
+		" --export-bundle in this file, without the repository")
+	dumpSignals := flag.String("dump-signals", "", "Write the raw signals"+
+		" suggestions are made from, per author, to this JSON file")
+	diverse := flag.Bool("diverse", false, "Make sure suggested reviewers don't all"+
+		" come from the same team or work in the same directory")
+	ignoreMerges := flag.Bool("ignore-merges", false, "Credit lines from merge and"+
+		" revert commits to the commits they brought in")
+	firstParent := flag.Bool("first-parent", false, "Follow only the first parent"+
+		" of merges when blaming and reading history, crediting merged branches to"+
+		" their merge")
+	maxHistory := flag.String("max-history", "", "Only follow this much history"+
+		" when blaming, such as '90d', '6w', '18m' or '2y', which is faster on old"+
+		" files. Older lines are credited to nobody")
+	includeAdded := flag.Bool("include-added", false, "Suggest owners of similar"+
+		" files in the same directory for files added on the branch")
+	dirWeight := flag.Float64("dir-weight", 1, "How much the lines of similar"+
+		" files considered for added files count compared to changed files, from 0"+
+		" to 1")
+	includeUntracked := flag.Bool("include-untracked", false, "Also suggest owners"+
+		" of similar files for new files that aren't committed yet, looking at the"+
+		" working tree as watch does")
+	includeLFS := flag.Bool("include-lfs", false, "Consider files tracked by Git"+
+		" LFS, attributing them by commit history instead of blame")
+	initialImport := flag.Bool("initial-import", false, "Credit lines from"+
+		" boundary commits, such as an imported project's root commit, to an"+
+		" '(initial import)' pseudo-author instead of suggesting whoever imported them")
+	learners := flag.Bool("include-learners", false, "Reserve a slot for a"+
+		" learning reviewer with little but some recent activity in the changes")
+	perFile := flag.Bool("per-file", false, "Weigh every changed file equally"+
+		" instead of by its number of lines when computing ownership")
+	recentDays := flag.Int("recent-days", 0, "Make sure at least one suggested"+
+		" reviewer touched the changed code within this many days")
+	tickets := flag.Bool("tickets", false, "Boost collaborators who worked on"+
+		" tickets in the same areas as the tickets the branch's commits reference")
+	topics := flag.Bool("topics", false, "Boost collaborators whose past commit"+
+		" messages mention the same topics as the branch's commits")
+	teams := flag.String("teams", "", "Read the team of each collaborator from"+
+		" a file with lines like 'Team Name <email>'")
+	alert := flag.Float64("ownership-alert", 10, "Warn about changed files in"+
+		" which nobody active owns more than this percentage of lines (0 disables)")
+	format := flag.String("format", "table", "Output format: 'table', 'editor'"+
+		" (file:line: owner per changed hunk), 'emails' (one reviewer per line),"+
+		" 'github' (workflow command annotations), 'sarif', 'junit'"+
+		" (a test case per changed file, failing without a qualified owner),"+
+		" 'assignments' (the changed files split among the reviewers, in Markdown),"+
+		" 'csv' for history, ownership and project or 'json' for version, ownership,"+
+		" project and files")
+	asOf := flag.String("as-of", "", "Measure ownership as it was at this revision"+
+		" or YYYY-MM-DD date, blaming there and leaving out later commits")
+	blameAt := flag.String("blame-at", gr.BlameAtBase, "Revision to measure ownership"+
+		" at: 'base' before the changes, 'head' after them or 'merge-base' where the"+
+		" branch started")
+	show := flag.String("show", gr.ShowPercent, "Display experience as"+
+		" 'percent' of lines owned, raw 'counts' of lines and files, or 'both'")
+	assign := flag.Bool("assign", false, "Request reviews from the suggested"+
+		" reviewers on the pull request, with the gh and pr commands")
+	packages := flag.Bool("by-package", false, "Suggest reviewers for the changes"+
+		" to each package, as marked by go.mod, package.json, BUILD and similar"+
+		" files, and for all of them")
+	split := flag.Bool("split-tests", false, "Suggest reviewers for changes to"+
+		" production code and to tests separately")
+	stackFlag := flag.String("stack", "", "Suggest reviewers for each branch of a"+
+		" stack, listed from the bottom up, and for the whole stack (--stack feat-1,feat-2)")
+	langFlag := flag.String("lang", "", "Language of messages: 'en' or 'es'."+
+		" Defaults to the language of LANG")
+	v := flag.Bool("version", false, "Print the program version and build information and exit")
+	maxFileSize := flag.Int64("max-file-size", gr.DefaultMaxFileSize>>20, "Skip"+
+		" changed files larger than this many megabytes instead of blaming them (0 disables)")
+	blameTimeout := flag.Duration("blame-timeout", gr.DefaultBlameTimeout, "Skip"+
+		" changed files that take longer than this to blame (0 disables)")
+	actions := flag.Bool("github-actions", false, "Write suggested reviewers and"+
+		" metrics to the step outputs and summary of a GitHub Actions workflow")
+	all := flag.Bool("all", false, "Measure ownership of the whole repository with"+
+		" 'ownership'")
+	top := flag.Int("top", 10, "Number of owners 'ownership' lists, or 'project' lists"+
+		" per path (0 lists all)")
+	prePushFlag := flag.Bool("pre-push", false, "Install or remove the pre-push"+
+		" hook with 'hook install' or 'hook uninstall'")
+	gitBinFlag := flag.String("git-bin", "", "Git executable to run, 'git' from"+
+		" PATH by default")
+	effective := flag.Bool("effective", false, "Print the settings and flags in"+
+		" effect and where each came from, with the config command")
+	interval := flag.Duration("interval", 2*time.Second, "How often 'watch'"+
+		" checks the working tree for changes, which costs about a git status each time")
+	refresh := flag.Bool("refresh", false, "Forget the cached accounts of the"+
+		" emails given after the flags, or of everyone, with 'identities'")
 
-	flag.Parse()
+	// Everything before the flags names a subcommand. Running without one
+	// suggests reviewers for the current branch.
+	args := os.Args[1:]
+	var command string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+	// The hook command takes what to do with hooks before its flags
+	var action string
+	if command == "hook" && len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		action, args = args[0], args[1:]
+	}
+	if strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe") == ghExtension {
+		command, args = "gh", os.Args[1:]
+	}
+	flag.CommandLine.Parse(args)
+	lang = gr.DetectLanguage(*langFlag)
+	gitBin = *gitBinFlag
 
 	if *v {
-		fmt.Printf("git-reviewer version %s\n", version)
+		if *format != "table" && *format != "json" {
+			fmt.Printf(tr("Unknown output format '%s'. Run 'git reviewer -h'\n"), *format)
+			return
+		}
+		printVersion(*format)
 		return
 	}
 
@@ -57,27 +236,64 @@ func main() {
 	onlyExtensions := strings.FieldsFunc(*oe, spaceOrComma)
 	ignoredPaths := strings.FieldsFunc(*ip, spaceOrComma)
 	onlyPaths := strings.FieldsFunc(*op, spaceOrComma)
+	presets := strings.FieldsFunc(*preset, spaceOrComma)
 
-	err := checkDateArg(*since)
-	if len(*since) > 0 && err != nil {
-		fmt.Println("Problem with input format for 'since' argument. Run 'git reviewer -h'")
+	branches := strings.FieldsFunc(*stackFlag, spaceOrComma)
+
+	// Every problem with the arguments is reported at once so they can all be
+	// fixed in one go.
+	problems := argumentProblems(arguments{command: command, action: action,
+		rest: flag.Args(), format: *format, stack: branches, merge: *merge, base: *base, interval: *interval,
+		dumpSignals: *dumpSignals, exportBundle: *exportBundle, replay: *replayFlag,
+		packages: *packages, split: *split, actions: *actions, all: *all,
+		effective: *effective, prePush: *prePushFlag, assign: *assign,
+		balanceLoad: *balanceLoad, noExec: *noExec, firstParent: *firstParent,
+		refresh: *refresh})
+
+	// Replaying a bundle needs nothing but the bundle
+	if *replayFlag != "" {
+		if len(problems) > 0 {
+			reportProblems(problems, *format)
+			return
+		}
+		replay(*replayFlag)
+		return
+	}
+
+	// The identity cache lives outside of any repository
+	if command == "identities" {
+		if len(problems) > 0 {
+			reportProblems(problems, *format)
+			return
+		}
+		identities(*refresh, flag.Args(), *format)
 		return
 	}
 
 	dir, err := os.Getwd()
 	if err != nil {
-		fmt.Printf("Unable to open current directory: %v\n", err)
+		reportError(*format, "Unable to open current directory: %v\n", err)
 		return
 	}
 
-	repo, err := gogit.PlainOpen(dir)
-	if err != nil {
-		fmt.Printf("Unable to open repository: %v\n", err)
+	// The doctor reports problems with the environment itself, so it runs
+	// even when there is no repository to open
+	if command == "doctor" {
+		if !doctor(dir, *base) {
+			os.Exit(1)
+		}
 		return
 	}
 
+	repo, root, err := gr.OpenRepository(dir)
+	if err != nil {
+		problems = append(problems, gr.ValidationError{Option: "repository",
+			Problem: err.Error(), Fix: "Run git reviewer from inside a git repository"})
+	}
+
 	r := gr.ContributionCounter{
 		Repo:              repo,
+		Dir:               root,
 		ShowFiles:         *showFiles,
 		Verbose:           *verbose,
 		Since:             *since,
@@ -85,51 +301,426 @@ func main() {
 		OnlyExtensions:    onlyExtensions,
 		IgnoredPaths:      ignoredPaths,
 		OnlyPaths:         onlyPaths,
+		Presets:           presets,
+		Diverse:           *diverse,
+		RecentDays:        *recentDays,
+		IncludeLearners:   *learners,
+		OwnershipAlert:    *alert / 100.0,
+		IncludeLFS:        *includeLFS,
+		InitialImport:     *initialImport,
+		Show:              *show,
+		IncludeAdded:      *includeAdded,
+		DirWeight:         dirWeight,
+		IncludeUntracked:  *includeUntracked,
+		WorkingTree:       *includeUntracked,
+		IgnoreMerges:      *ignoreMerges,
+		PerFile:           *perFile,
+		NoDefaultIgnores:  *noDefaultIgnores,
+		Base:              *base,
+		Language:          lang,
+		MaxFileSize:       *maxFileSize << 20,
+		BlameTimeout:      *blameTimeout,
+		Topics:            *topics,
+		FirstParent:       *firstParent,
+		MaxHistory:        *maxHistory,
+		BlameAt:           *blameAt,
+		AsOf:              *asOf,
+		GitBin:            gitBin,
+		NoExec:            *noExec,
+		Availability:      *availability,
+		WarnTakeovers:     *warnTakeovers,
+		BalanceLoad:       *balanceLoad,
+		SinceFrom:         *sinceFrom,
+		Ranker:            *ranker,
+		Sort:              *sortFlag,
+	}
+	if *maxFileSize == 0 {
+		r.MaxFileSize = -1
+	}
+	if *blameTimeout == 0 {
+		r.BlameTimeout = -1
 	}
 
-	// TODO take mailmap paths from command args
-	var mailmapPaths []string
-	if u, err := user.Current(); err == nil {
-		mailmapPaths = append(mailmapPaths, u.HomeDir+"/.mailmap")
+	// API responses and the accounts of reviewers are cached along with
+	// suggestions
+	var ids *gr.IdentityCache
+	if !*noCache {
+		if dir, err := os.UserCacheDir(); err == nil {
+			r.Cache = gr.NewFileCache(filepath.Join(dir, "git-reviewer"))
+		}
+		r.CacheResults = true
+		ids = loadIdentityCache()
+		defer saveIdentities(ids)
+		if ids != nil {
+			r.OwnerEmails = ids.Emails()
+		}
 	}
-	if cwd, err := os.Getwd(); err == nil {
-		mailmapPaths = append(mailmapPaths, cwd+"/.mailmap")
-		mailmapPaths = append(mailmapPaths, cwd+"/mailmap")
+
+	cfg := &gr.Config{}
+	if repo != nil {
+		if cfg, err = r.LoadConfig(); err != nil {
+			problems = append(problems, gr.ValidationError{Option: "config",
+				Problem: err.Error(),
+				Fix:     fmt.Sprintf("Check the syntax of %s and your git config", gr.ConfigFile)})
+			cfg = &gr.Config{}
+		} else {
+			r.ApplyConfig(cfg)
+			if v, ok := cfg.Get("reviewer.ownershipAlert"); ok && !givenFlags()["ownership-alert"] {
+				if pct, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+					r.OwnershipAlert = pct / 100.0
+				} else {
+					problems = append(problems, gr.ValidationError{Option: "reviewer.ownershipAlert",
+						Problem: fmt.Sprintf("'%s' is not a percentage", v),
+						Fix:     "Set it to a number such as 10, or 0 to turn the alert off"})
+				}
+			}
+			if *tickets {
+				r.Tickets = cfg.TicketProvider()
+				if j, ok := r.Tickets.(*gr.JiraProvider); ok {
+					j.Client = r.HTTPClient()
+				}
+			}
+		}
 	}
-	r.BuildMailmap(mailmapPaths...)
 
-	// Determine if branch is reviewable
-	if behind, err := r.BranchBehind(); behind || err != nil {
+	// The pull request knows which branch the changes are headed for. The gh
+	// command is the pr command for GitHub, where it is usually run from.
+	var (
+		provider gr.ReviewProvider
+		request  *gr.PullRequest
+	)
+	if repo != nil && (command == "pr" || command == "gh") && !*noExec {
+		if provider, err = reviewProvider(&r, cfg, ids, command == "gh"); err != nil {
+			problems = append(problems, gr.ValidationError{Option: command, Problem: err.Error(),
+				Fix: "Point origin to a repository on GitHub, Bitbucket, Gerrit or Azure DevOps"})
+		} else if request, err = provider.PullRequest(currentBranch(root)); err != nil {
+			fmt.Printf(tr("No pull request found, comparing to the default branch: %v\n\n"), err)
+		} else if request != nil {
+			r.Title = request.Title
+			if r.Base == "" {
+				r.Base = pullRequestBase(root, request.Base)
+			}
+		}
+	}
+
+	if repo != nil && *merge != "" {
+		if err := r.ReviewMerge(*merge); err != nil {
+			problems = append(problems, gr.ValidationError{Option: "merge",
+				Problem: err.Error(), Fix: "Pass a commit that has a parent"})
+		}
+	}
+
+	// Validate explains what to do if no base is found
+	if repo != nil && r.Base == "" {
+		if detected, err := r.DetectBase(); err == nil {
+			r.Base = detected
+		}
+	}
+
+	if err := r.Validate(); err != nil {
+		problems = append(problems, err.(gr.ValidationErrors)...)
+	}
+
+	// The configuration is printed even if the settings in it are invalid,
+	// since it shows where they were made, and every problem is reported
+	// after it
+	if command == "config" {
+		printEffective(cfg, *format)
+		if len(problems) > 0 {
+			if *format == "table" {
+				fmt.Println()
+			}
+			reportProblems(problems, *format)
+		}
+		return
+	}
+
+	if len(problems) > 0 {
+		reportProblems(problems, *format)
+		return
+	}
+	if err := r.NormalizeFilters(); err != nil {
+		reportError(*format, "Unable to read path filters: %v\n", err)
+		return
+	}
+
+	// The summary goes to stderr so it doesn't get in the way of scripts
+	// reading suggestions.
+	if *verbose {
+		start := time.Now()
+		r.Summary = &gr.RunSummary{}
+		defer func() { r.Summary.Print(os.Stderr, time.Since(start)) }()
+	}
+
+	if *dumpSignals != "" {
+		r.Signals = &gr.Signals{}
+		defer writeSignals(*dumpSignals, r.Signals)
+	}
+
+	if *exportBundle != "" {
+		r.Bundle = &gr.Bundle{Version: version, Config: cfg.Entries}
+		defer writeBundle(*exportBundle, r.Bundle)
+	}
+
+	if command == "history" || command == "watch" || command == "ownership" ||
+		command == "hook" || command == "conflicts" || len(branches) > 0 {
+		if err := loadIdentities(&r, root, *teams); err != nil {
+			reportError(*format, "Problem reading teams: %v\n", err)
+			return
+		}
+	}
+
+	if command == "history" {
+		history(&r, *format)
+		return
+	}
+
+	if command == "watch" {
+		watch(&r, *interval)
+		return
+	}
+
+	if command == "ownership" {
+		ownership(&r, *format, *top)
+		return
+	}
+
+	if command == "conflicts" {
+		conflicts(&r)
+		return
+	}
+
+	if command == "hook" && action == "install" {
+		path, err := r.InstallPrePushHook()
 		if err != nil {
-			fmt.Printf("There was an error determining branch state: %v\n", err)
+			fmt.Printf(tr("Unable to install the hook: %v\n"), err)
+			os.Exit(1)
+		}
+		fmt.Printf(tr("Installed the pre-push hook in %s\n"), path)
+		return
+	}
+
+	if command == "hook" && action == "uninstall" {
+		path, err := r.UninstallPrePushHook()
+		switch {
+		case err != nil:
+			fmt.Printf(tr("Unable to uninstall the hook: %v\n"), err)
+			os.Exit(1)
+		case path == "":
+			fmt.Println(tr("No pre-push hook to uninstall"))
+		default:
+			fmt.Printf(tr("Removed the pre-push hook from %s\n"), path)
+		}
+		return
+	}
+
+	if command == "hook" {
+		if !prePush(&r, cfg, os.Stdin) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(branches) > 0 {
+		stack(&r, branches)
+		return
+	}
+
+	// Reading the mailmap and teams files doesn't need the repository, so it
+	// overlaps with checking and diffing the branch. Both can be slow on
+	// network filesystems.
+	var (
+		status              gr.BranchStatus
+		files               []string
+		statusErr, filesErr error
+		wg                  sync.WaitGroup
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		status, statusErr = r.BranchStatus()
+	}()
+	go func() {
+		defer wg.Done()
+		files, filesErr = r.FindFiles()
+	}()
+
+	identitiesErr := loadIdentities(&r, root, *teams)
+	wg.Wait()
+
+	if identitiesErr != nil {
+		reportError(*format, "Problem reading teams: %v\n", identitiesErr)
+		return
+	}
+
+	// Notices go to stderr whatever the format, so that only results go to
+	// stdout, where scripts and CI read them
+	notices := os.Stderr
+
+	// Determine if branch is reviewable
+	if status.IsBehind() || statusErr != nil {
+		if statusErr != nil {
+			reportError(*format, "There was an error determining branch state: %v\n", statusErr)
 			return
 		}
 
-		fmt.Println("Current branch is behind master. Merge up!")
-		if *force == false {
+		if *strict && !*force {
+			reportError(*format, "%s. Merge up!\n", status.Err())
 			return
 		}
+		fmt.Fprintf(notices, tr("WARNING: %s. Suggestions may miss the latest changes on %s.\n\n"),
+			status, status.Base)
 	}
 
-	// Find changed files in this branch.
-	files, err := r.FindFiles()
+	// Report problems finding changed files in this branch.
+	if filesErr != nil {
+		reportError(*format, "There was an error finding files: %v\n", filesErr)
+		return
+	}
 
-	if err != nil {
-		fmt.Printf("There was an error finding files: %v\n", err)
+	// Files left out by filters are listed too, so this comes before
+	// checking there are any left
+	if command == "files" {
+		listFiles(&r, *format)
 		return
 	}
 
-	if len(files) == 0 {
-		fmt.Println("No changes on this branch!")
+	// Branches that only add files, or only change files the filters leave
+	// out, have nothing to blame, but default reviewers may cover added files
+	// and sensitive paths require reviewers whatever was blamed
+	suggesting := command == "" && !*packages && !*split && !*actions
+	unblamed := len(r.AddedFiles()) > 0 && (command == "project" ||
+		suggesting && (*format == "table" || *format == "assignments")) ||
+		len(r.SensitiveChanges(files)) > 0 && suggesting && (*format == "table" ||
+			*format == "emails" || *format == "github" || *format == "sarif")
+	if len(files) == 0 && !unblamed {
+		fmt.Fprintln(notices, tr("No changes on this branch!"))
+		// CI still expects a log to upload
+		switch *format {
+		case "sarif":
+			gr.WriteSARIF(os.Stdout, nil, version)
+		case "junit":
+			gr.WriteJUnit(os.Stdout, nil, r.OwnershipAlert)
+		}
 		return
 	}
 
 	if *showFiles {
-		fmt.Println("Reviewers across the following changed files:")
+		fmt.Fprintln(notices, tr("Reviewers across the following changed files:"))
 		for _, file := range files {
-			fmt.Printf("  %s\n", file)
+			fmt.Fprintf(notices, "  %s\n", file)
+		}
+		fmt.Fprintln(notices)
+	}
+
+	if command == "annotate" {
+		if err := r.AnnotateDiff(os.Stdout, files); err != nil {
+			fmt.Printf(tr("There was an error annotating the diff: %v\n"), err)
 		}
-		fmt.Println()
+		return
+	}
+
+	if *format == "emails" {
+		stats, err := r.SuggestReviewers(files)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, tr("There was an error finding reviewers: %v\n"), err)
+			os.Exit(1)
+		}
+		gr.SortStats(stats, r.Sort)
+		for _, s := range stats {
+			fmt.Println(s.Reviewer)
+		}
+		return
+	}
+
+	if *format == "github" || *format == "sarif" {
+		annotations, err := r.Annotations(files)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, tr("There was an error finding annotations: %v\n"), err)
+			os.Exit(1)
+		}
+
+		if *format == "sarif" {
+			gr.WriteSARIF(os.Stdout, annotations, version)
+			return
+		}
+		for _, a := range annotations {
+			fmt.Println(a.WorkflowCommand())
+		}
+		return
+	}
+
+	if *format == "junit" {
+		coverage, err := r.Coverage(files)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, tr("There was an error measuring ownership: %v\n"), err)
+			os.Exit(1)
+		}
+		gr.WriteJUnit(os.Stdout, coverage, r.OwnershipAlert)
+		return
+	}
+
+	if *format == "assignments" {
+		assignments, err := r.Assignments(files)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, tr("There was an error finding reviewers: %v\n"), err)
+			os.Exit(1)
+		}
+		gr.WriteAssignments(os.Stdout, assignments)
+		return
+	}
+
+	if *format == "editor" {
+		owners, err := r.FindHunkOwners(files)
+		if err != nil {
+			fmt.Printf(tr("There was an error finding hunk owners: %v\n"), err)
+			return
+		}
+
+		for _, o := range owners {
+			fmt.Println(o.Format(r.Show))
+		}
+		return
+	}
+
+	if command == "describe" {
+		describe(&r, files)
+		return
+	}
+
+	if command == "query" {
+		query(&r, files, flag.Args())
+		return
+	}
+
+	if command == "project" {
+		project(&r, files, *format, *top)
+		return
+	}
+
+	if command == "pr" || command == "gh" {
+		if r.BalanceLoad {
+			r.OpenReviews = gr.OpenReviewsOf(provider)
+		}
+		suggestPullRequest(&r, files, provider, request, *assign)
+		return
+	}
+
+	if *actions {
+		// Accounts are looked up where origin is hosted, GitHub in a workflow
+		provider, _ := reviewProvider(&r, cfg, ids, true)
+		githubActions(&r, files, provider)
+		return
+	}
+
+	if *packages {
+		byPackage(&r, files)
+		return
+	}
+
+	if *split {
+		splitTests(&r, files)
+		return
 	}
 
 	// Find the best reviewers for these files.
@@ -137,10 +728,10 @@ func main() {
 	if err != nil {
 		switch e := err.(type) {
 		case gr.NoReviewersErr:
-			fmt.Printf("Problem finding reviewers: %s", e.Help())
-			fmt.Println("Run git-reviwer again with the --since argument")
+			fmt.Printf(tr("Problem finding reviewers: %s\n\n"), e.Help())
+			fmt.Println(tr("Run git-reviewer again with the --since argument"))
 		default:
-			fmt.Printf("There was an error finding reviewers: %v\n", err)
+			fmt.Printf(tr("There was an error finding reviewers: %v\n"), err)
 		}
 		return
 	}
@@ -148,21 +739,48 @@ func main() {
 	fmt.Println(reviewers)
 }
 
-// checkDateArg takes a date argument as a YYYY-MM-DD formatted string and
-// ensures it is appropriate for usage in the program. Returns an error
-// if any checks fail, or nil if things look fine.
-func checkDateArg(input string) error {
-	if len(input) == 0 {
-		return errors.New("no input")
+// writeSignals writes the signals collected while suggesting reviewers to
+// 'path' as JSON. Nothing is written if the run stopped before collecting
+// any, such as when the branch has no changes.
+func writeSignals(path string, s *gr.Signals) {
+	if len(s.Paths) == 0 {
+		return
 	}
 
-	r := dateRx.Find([]byte(input))
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err == nil {
+		err = ioutil.WriteFile(path, append(b, '\n'), 0644)
+	}
+	if err != nil {
+		fmt.Printf(tr("Unable to write signals: %v\n"), err)
+	}
+}
 
-	if r == nil {
-		return errors.New("input doesn't match expected format (YYYY-MM-DD)")
+// loadIdentities reads the mailmap files that merge the identities of
+// collaborators and, if 'teams' names a file, the team of each collaborator.
+func loadIdentities(r *gr.ContributionCounter, root, teams string) error {
+	// TODO take mailmap paths from command args
+	var mailmapPaths []string
+	if u, err := user.Current(); err == nil {
+		mailmapPaths = append(mailmapPaths, u.HomeDir+"/.mailmap")
 	}
+	mailmapPaths = append(mailmapPaths, root+"/.mailmap")
+	mailmapPaths = append(mailmapPaths, root+"/mailmap")
+	r.BuildMailmap(mailmapPaths...)
 
-	// TODO Make sure date arg isn't greater than today
+	if teams != "" {
+		return r.BuildTeams(teams)
+	}
 
 	return nil
 }
+
+// contains reports whether a list of strings contains a value.
+func contains(list []string, val string) bool {
+	for _, v := range list {
+		if v == val {
+			return true
+		}
+	}
+	return false
+}
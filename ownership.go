@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	gr "github.com/thedahv/git-reviewer/src"
+)
+
+// ownership prints who owns the lines of the whole repository: the 'top'
+// largest owners, or all of them if 'top' is 0, as a table, JSON or CSV.
+func ownership(r *gr.ContributionCounter, format string, top int) {
+	o, err := r.RepositoryOwnership()
+	if err != nil {
+		if format == "json" {
+			reportError(format, "", err)
+		} else {
+			fmt.Fprintf(os.Stderr, tr("There was an error measuring ownership: %v\n"), err)
+		}
+		os.Exit(1)
+	}
+
+	if top > 0 && len(o.Owners) > top {
+		o.Owners = o.Owners[:top]
+	}
+	gr.SortOwners(o.Owners, r.Sort)
+
+	switch format {
+	case "json":
+		b, _ := json.MarshalIndent(o, "", "  ")
+		fmt.Println(string(b))
+		return
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		w.Write([]string{"author", "lines", "files", "share"})
+		for _, a := range o.Owners {
+			w.Write([]string{a.Author, strconv.Itoa(a.Lines), strconv.Itoa(a.Files),
+				strconv.FormatFloat(a.Share, 'f', 4, 64)})
+		}
+		w.Flush()
+		return
+	}
+
+	fmt.Printf(tr("Ownership of %d lines in %d files at %.7s since %s\n\n"), o.Lines, o.Files,
+		o.Rev, r.Since)
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', 0)
+	fmt.Fprintln(tw, tr("Owner\tShare\tLines\tFiles"))
+	fmt.Fprintln(tw, "-----\t-----\t-----\t-----")
+	for _, a := range o.Owners {
+		fmt.Fprintf(tw, "%s\t%.2f%%\t%d\t%d\n", a.Author, a.Share*100.0, a.Lines, a.Files)
+	}
+	tw.Flush()
+
+	if len(o.Skipped) > 0 {
+		fmt.Print(tr("\nWARNING: these files were too large or too slow to blame:\n"))
+		for _, s := range o.Skipped {
+			fmt.Printf("  %s\n", s)
+		}
+	}
+}
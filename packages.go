@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+
+	gr "github.com/thedahv/git-reviewer/src"
+)
+
+// byPackage suggests reviewers for the changed files of each package they
+// belong to, followed by suggestions for all of the changes. Changes within a
+// single package get the usual suggestions.
+func byPackage(r *gr.ContributionCounter, files []string) {
+	packages, err := r.Packages(files)
+	if err != nil {
+		fmt.Printf(tr("There was an error finding packages: %v\n"), err)
+		return
+	}
+
+	if len(packages) > 1 {
+		for _, pkg := range packages {
+			fmt.Printf(tr("Package %s (%s)\n\n"), pkg.Name(), pluralizeFiles(len(pkg.Files)))
+			suggestFiles(r, pkg.Files)
+		}
+		fmt.Printf(tr("All packages (%s)\n\n"), pluralizeFiles(len(files)))
+	}
+
+	suggestFiles(r, files)
+}
+
+// pluralizeFiles describes a number of files.
+func pluralizeFiles(n int) string {
+	if n == 1 {
+		return tr("1 file")
+	}
+	return fmt.Sprintf(tr("%d files"), n)
+}
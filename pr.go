@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	gr "github.com/thedahv/git-reviewer/src"
+)
+
+// currentBranch names the branch checked out in 'dir', or returns an empty
+// string if HEAD is detached.
+func currentBranch(dir string) string {
+	out, err := git(dir, "symbolic-ref", "--quiet", "--short", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// suggestPullRequest prints suggested reviewers by their account on the
+// provider hosting the repository and, with 'assign', asks them to review the
+// pull request. The author of the pull request is never asked to review it.
+func suggestPullRequest(r *gr.ContributionCounter, files []string, provider gr.ReviewProvider,
+	pr *gr.PullRequest, assign bool) {
+	stats, err := r.SuggestReviewers(files)
+	if err != nil {
+		if e, ok := err.(gr.NoReviewersErr); ok {
+			fmt.Printf(tr("Problem finding reviewers: %s\n\n"), e.Help())
+			fmt.Println(tr("Run git-reviewer again with the --since argument"))
+			return
+		}
+		fmt.Printf(tr("There was an error finding reviewers: %v\n"), err)
+		return
+	}
+
+	var accounts []gr.Account
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', 0)
+	fmt.Fprint(tw, gr.TableHeader(lang))
+	for _, s := range stats {
+		name := s.Reviewer
+
+		if account, err := provider.User(s.Reviewer); err == nil && account.ID != "" {
+			name = "@" + account.Name
+			if pr == nil || account.ID != pr.Author.ID {
+				accounts = append(accounts, account)
+			}
+		}
+		fmt.Fprintf(tw, "%s%s\t%s\n", name, s.Notes(), s.Experience(r.Show))
+	}
+	tw.Flush()
+
+	if !assign {
+		return
+	}
+
+	switch {
+	case pr == nil:
+		fmt.Println(tr("\nNo open pull request to request reviews on."))
+		return
+	case len(accounts) == 0:
+		fmt.Println(tr("\nNone of the reviewers have an account to request reviews from."))
+		return
+	}
+
+	if err := provider.AddReviewers(pr, accounts); err != nil {
+		fmt.Printf(tr("\nUnable to request reviews: %v\n"), err)
+		return
+	}
+
+	names := make([]string, 0, len(accounts))
+	for _, a := range accounts {
+		names = append(names, a.Name)
+	}
+	fmt.Printf(tr("\nRequested reviews from @%s on %s.\n"), strings.Join(names, ", @"), pr.URL)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	gr "github.com/thedahv/git-reviewer/src"
+)
+
+// project prints who will own the changed files in 'files', and their
+// directories, once the branch merges, and how that changes their shares: the
+// 'top' largest owners of each, or all of them if 'top' is 0, as a table, JSON
+// or CSV.
+func project(r *gr.ContributionCounter, files []string, format string, top int) {
+	p, err := r.Projection(files)
+	if err != nil {
+		if format == "json" {
+			reportError(format, "", err)
+		} else {
+			fmt.Fprintf(os.Stderr, tr("There was an error measuring ownership: %v\n"), err)
+		}
+		os.Exit(1)
+	}
+
+	if top > 0 {
+		for _, paths := range [][]gr.PathProjection{p.Directories, p.Files} {
+			for i := range paths {
+				if len(paths[i].Owners) > top {
+					paths[i].Owners = paths[i].Owners[:top]
+				}
+			}
+		}
+	}
+	gr.SortProjection(p, r.Sort)
+
+	switch format {
+	case "json":
+		b, _ := json.MarshalIndent(p, "", "  ")
+		fmt.Println(string(b))
+		return
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		w.Write([]string{"path", "author", "linesBefore", "linesAfter", "before", "after"})
+		for _, paths := range [][]gr.PathProjection{p.Directories, p.Files} {
+			for _, f := range paths {
+				for _, o := range f.Owners {
+					w.Write([]string{f.Path, o.Author, strconv.Itoa(o.LinesBefore),
+						strconv.Itoa(o.LinesAfter), strconv.FormatFloat(o.Before, 'f', 4, 64),
+						strconv.FormatFloat(o.After, 'f', 4, 64)})
+				}
+			}
+		}
+		w.Flush()
+		return
+	}
+
+	fmt.Printf(tr("Projected ownership of %d changed files once the branch merges\n\n"),
+		len(p.Files))
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', 0)
+	fmt.Fprintln(tw, tr("Path\tOwner\tBefore\tAfter\tChange"))
+	fmt.Fprintln(tw, "----\t-----\t------\t-----\t------")
+	for _, paths := range [][]gr.PathProjection{p.Directories, p.Files} {
+		for _, f := range paths {
+			fmt.Fprintf(tw, "%s\t%s\t\t\t\n", f.Path,
+				fmt.Sprintf(tr("%d lines, %+d"), f.LinesAfter, f.LinesAfter-f.LinesBefore))
+			for _, o := range f.Owners {
+				fmt.Fprintf(tw, "\t%s\t%.2f%%\t%.2f%%\t%+.2f%%\n", o.Author, o.Before*100.0,
+					o.After*100.0, o.Change()*100.0)
+			}
+		}
+	}
+	tw.Flush()
+
+	if len(p.Skipped) > 0 {
+		fmt.Print(tr("\nWARNING: these files were too large or too slow to blame:\n"))
+		for _, s := range p.Skipped {
+			fmt.Printf("  %s\n", s)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	gr "github.com/thedahv/git-reviewer/src"
+)
+
+// query answers questions about how reviewers are picked for the changes in
+// 'files', such as "why bob@example.com". The question is taken from 'args'
+// if there is one. Otherwise questions are read from stdin, one per line,
+// until it ends or one of them is "quit". Suggesting reviewers runs once, so
+// the answers are instant.
+func query(r *gr.ContributionCounter, files []string, args []string) {
+	r.Bundle = &gr.Bundle{}
+	if _, err := r.SuggestReviewers(files); err != nil {
+		if _, ok := err.(gr.NoReviewersErr); !ok {
+			fmt.Printf(tr("There was an error finding reviewers: %v\n"), err)
+			os.Exit(1)
+		}
+	}
+
+	q, err := gr.NewQuery(r.Bundle)
+	if err != nil {
+		fmt.Printf(tr("There was an error finding reviewers: %v\n"), err)
+		os.Exit(1)
+	}
+
+	if len(args) > 0 {
+		if !answer(q, strings.Join(args, " ")) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	fmt.Println(tr("Ask about the suggestion, or type help or quit."))
+	scanner := bufio.NewScanner(os.Stdin)
+	for fmt.Print("> "); scanner.Scan(); fmt.Print("> ") {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "quit" || line == "exit" {
+			return
+		}
+		answer(q, line)
+	}
+	fmt.Println()
+}
+
+// answer prints the answer to 'question', reporting whether there was one.
+func answer(q *gr.Query, question string) bool {
+	a, err := q.Run(question)
+	if err != nil {
+		fmt.Println(err)
+		return false
+	}
+	fmt.Print(a)
+	return true
+}
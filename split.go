@@ -0,0 +1,22 @@
+package main
+
+import (
+	"fmt"
+
+	gr "github.com/thedahv/git-reviewer/src"
+)
+
+// splitTests suggests reviewers for changes to production code and to tests
+// separately.
+func splitTests(r *gr.ContributionCounter, files []string) {
+	production, tests := gr.SplitTests(files)
+
+	if len(production) > 0 {
+		fmt.Printf(tr("Production code (%s)\n\n"), pluralizeFiles(len(production)))
+		suggestFiles(r, production)
+	}
+	if len(tests) > 0 {
+		fmt.Printf(tr("Tests (%s)\n\n"), pluralizeFiles(len(tests)))
+		suggestFiles(r, tests)
+	}
+}
@@ -0,0 +1,39 @@
+package gitreviewers
+
+import (
+	"sort"
+)
+
+// unownedFiles lists the changed files in which no active collaborator owns
+// more than the OwnershipAlert share of the lines. Lines committed before
+// Since don't count towards anybody, so a file only touched by people who
+// have since moved on is reported too. These are the "nobody really owns
+// this" situations worth flagging at review time.
+func (r *ContributionCounter) unownedFiles(c *contributions) []string {
+	var unowned []string
+
+	if r.OwnershipAlert <= 0 {
+		return unowned
+	}
+
+	for path, lines := range c.fileLines {
+		if lines == 0 {
+			continue
+		}
+
+		owned := false
+		for _, l := range c.byFile[path] {
+			if float64(l)/float64(lines) > r.OwnershipAlert {
+				owned = true
+				break
+			}
+		}
+
+		if !owned {
+			unowned = append(unowned, path)
+		}
+	}
+	sort.Strings(unowned)
+
+	return unowned
+}
@@ -0,0 +1,28 @@
+package gitreviewers
+
+import (
+	"testing"
+)
+
+func TestUnownedFiles(t *testing.T) {
+	c := newContributions()
+	c.add("src/owned.go", []attribution{
+		{"abe@git-reviewer.com", "2017-01-01"},
+		{"abe@git-reviewer.com", "2017-01-01"},
+	}, 10)
+	c.add("src/stale.go", []attribution{
+		{"abe@git-reviewer.com", "2017-01-01"},
+	}, 20)
+	c.add("src/empty.go", nil, 0)
+
+	r := &ContributionCounter{}
+	if unowned := r.unownedFiles(c); len(unowned) != 0 {
+		t.Errorf("Expected alerts to be disabled by default, got %v\n", unowned)
+	}
+
+	r.OwnershipAlert = 0.1
+	unowned := r.unownedFiles(c)
+	if len(unowned) != 1 || unowned[0] != "src/stale.go" {
+		t.Errorf("Got unowned files %v, expected [src/stale.go]\n", unowned)
+	}
+}
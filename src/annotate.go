@@ -0,0 +1,120 @@
+package gitreviewers
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// maxHunkOwners limits how many owners are listed above each annotated hunk.
+const maxHunkOwners = 2
+
+// AnnotateDiff writes the diff between "master" and HEAD for the given paths
+// to w, with every hunk preceded by a line naming the top owners of the lines
+// it changes. It is an ownership-aware `git diff` that helps authors notify
+// the right people about specific parts of a change.
+func (r *ContributionCounter) AnnotateDiff(w io.Writer, paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+
+	r.defaultSince()
+
+	base, head, err := r.branchTips()
+	if err != nil {
+		return errors.Wrap(err, "unable to resolve branch tips")
+	}
+
+	// Ownership is measured on the exact changed lines, which we get from a diff
+	// without context. Those hunks are then grouped under the hunks of the
+	// regular diff we show.
+	changed, err := r.diffHunks(base, head, paths)
+	if err != nil {
+		return err
+	}
+
+	args := []string{"diff", "--no-color", "--no-ext-diff",
+		"--src-prefix=a/", "--dst-prefix=b/", base, head, "--"}
+	args = append(args, paths...)
+
+	out, err := r.output(args...)
+	if err != nil {
+		return errors.Wrap(err, "unable to execute external git diff command")
+	}
+
+	var path string
+	scn := bufio.NewScanner(bytes.NewReader(out))
+	// Changed lines of minified or generated files can be arbitrarily long
+	scn.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), len(out)+1)
+	for scn.Scan() {
+		line := scn.Text()
+
+		switch {
+		case strings.HasPrefix(line, "diff "):
+			path = ""
+		case strings.HasPrefix(line, "--- "):
+			path = diffPath(strings.TrimPrefix(line, "--- "), "a/")
+		case strings.HasPrefix(line, "@@ "):
+			owners := "new file"
+			if path != "" {
+				display, err := parseHunkHeader(line)
+				if err != nil {
+					return err
+				}
+				display.path = path
+
+				if owners, err = r.displayHunkOwners(display, changed, base); err != nil {
+					return err
+				}
+			}
+			fmt.Fprintf(w, "# owners: %s\n", owners)
+		}
+
+		fmt.Fprintln(w, line)
+	}
+
+	return scn.Err()
+}
+
+// displayHunkOwners totals the attributions of every changed range that falls
+// inside a displayed hunk and describes its top owners.
+func (r *ContributionCounter) displayHunkOwners(display hunk, changed []hunk, rev string) (string, error) {
+	var (
+		counts = make(map[string]int)
+		total  int
+	)
+
+	end := display.baseStart + display.baseCount
+	for _, h := range changed {
+		if h.path != display.path || h.baseStart < display.baseStart || h.baseStart > end {
+			continue
+		}
+
+		c, n, err := r.hunkAttributions(h, rev)
+		if err != nil {
+			return "", err
+		}
+		for author, lines := range c {
+			counts[author] += lines
+		}
+		total += n
+	}
+
+	if len(counts) == 0 {
+		return "no recent owner", nil
+	}
+
+	stats := rankOwners(counts, total)
+
+	var owners []string
+	for i := 0; i < len(stats) && i < maxHunkOwners; i++ {
+		owners = append(owners, fmt.Sprintf("%s (%s)", stats[i].Reviewer,
+			formatExperience(r.Show, stats[i].Percentage, stats[i].Lines, 0)))
+	}
+
+	return strings.Join(owners, ", "), nil
+}
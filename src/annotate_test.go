@@ -0,0 +1,108 @@
+package gitreviewers
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAnnotateDiffWithoutPaths(t *testing.T) {
+	var buf bytes.Buffer
+
+	r := &ContributionCounter{}
+	if err := r.AnnotateDiff(&buf, nil); err != nil {
+		t.Errorf("Unexpected error annotating an empty diff: %v\n", err)
+	}
+
+	if buf.Len() != 0 {
+		t.Errorf("Expected no output without paths, got '%s'\n", buf.String())
+	}
+}
+
+func TestAnnotateDiff(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	write := func(path, content string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, path), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("src/a.go", "package a\n\nvar x = 1\nvar y = 2\n")
+	runGit(t, dir, "commit", "-q", "-a", "--author", "Ben Franklin <ben@git-reviewer.com>",
+		"-m", "Add x and y")
+
+	runGit(t, dir, "checkout", "-q", "-b", "feature")
+	write("src/a.go", "package a\n\nvar x = 10\nvar y = 2\n")
+	write("src/c.go", "package c\n")
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-q", "-m", "Change x, add c")
+
+	repo, _, err := OpenRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := ContributionCounter{Repo: repo, Dir: dir, Since: "2000-01-01", Head: "feature"}
+
+	var buf bytes.Buffer
+	if err := r.AnnotateDiff(&buf, []string{"src/a.go", "src/c.go"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var annotations []string
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.HasPrefix(line, "# owners: ") || strings.HasPrefix(line, "@@ ") {
+			annotations = append(annotations, line)
+		}
+	}
+
+	expected := []string{
+		"# owners: ben@git-reviewer.com (100.00%)",
+		"@@ -1,4 +1,4 @@",
+		"# owners: new file",
+		"@@ -0,0 +1 @@",
+	}
+	if !reflect.DeepEqual(annotations, expected) {
+		t.Errorf("Got annotations %q, expected %q\n", annotations, expected)
+	}
+}
+
+func TestAnnotateDiffLongLines(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	long := strings.Repeat("x", 1<<17)
+	path := filepath.Join(dir, "src", "app.min.js")
+	if err := ioutil.WriteFile(path, []byte(long+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-q", "--author", "Ben Franklin <ben@git-reviewer.com>",
+		"-m", "Add minified app")
+
+	runGit(t, dir, "checkout", "-q", "-b", "feature")
+	if err := ioutil.WriteFile(path, []byte(long+"y\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "commit", "-q", "-a", "-m", "Rebuild app")
+
+	repo, _, err := OpenRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := ContributionCounter{Repo: repo, Dir: dir, Since: "2000-01-01", Head: "feature"}
+
+	var buf bytes.Buffer
+	if err := r.AnnotateDiff(&buf, []string{"src/app.min.js"}); err != nil {
+		t.Fatalf("Unexpected error annotating a long line: %v\n", err)
+	}
+	if !strings.Contains(buf.String(), "# owners: ben@git-reviewer.com (100.00%)\n@@ -1 +1 @@\n") ||
+		!strings.Contains(buf.String(), "\n+"+long+"y\n") {
+		t.Errorf("Expected the hunk of the long line to be annotated and shown whole\n")
+	}
+}
@@ -0,0 +1,214 @@
+package gitreviewers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Rules annotations are raised for.
+const (
+	// RuleUnowned flags changed files nobody active owns more than
+	// OwnershipAlert of.
+	RuleUnowned = "unowned-file"
+	// RuleSensitive flags changed files matching one of SensitiveRules.
+	RuleSensitive = "sensitive-path"
+)
+
+// unownedTitle sums up RuleUnowned findings, in annotations and JUnit reports
+// alike.
+const unownedTitle = "No knowledgeable owner"
+
+// unownedMessage explains why a file has no qualified owner at 'threshold',
+// naming its largest active owner, who owns 'share' of it, if it has one.
+func unownedMessage(threshold float64, owner string, share float64) string {
+	msg := fmt.Sprintf("Nobody active owns more than %.0f%% of this file.", threshold*100)
+	if owner != "" {
+		msg += fmt.Sprintf(" The largest active owner is %s with %.0f%% of its lines.",
+			owner, share*100)
+	}
+	return msg
+}
+
+// Annotation levels, from the most to the least severe.
+const (
+	AnnotationWarning = "warning"
+	AnnotationNotice  = "notice"
+)
+
+// Annotation is a risk spotted in a changed file, meant to be shown inline in
+// code review by a CI system rather than buried in a log.
+type Annotation struct {
+	Path string
+	// Rule is RuleUnowned or RuleSensitive, and Level one of the annotation
+	// levels.
+	Rule    string
+	Level   string
+	Title   string
+	Message string
+	// Contacts are who to ask about the file: its largest active owner, or
+	// the reviewers the sensitive path requires.
+	Contacts []string
+}
+
+// Annotations finds the changed files worth flagging in code review: those in
+// 'paths' nobody active really owns, according to OwnershipAlert, and any
+// file the branch changes matching SensitiveRules, even if it isn't blamed.
+// They are sorted by path.
+func (r *ContributionCounter) Annotations(paths []string) ([]Annotation, error) {
+	var annotations []Annotation
+
+	r.defaultSince()
+
+	if r.OwnershipAlert > 0 {
+		counts, err := r.generateCounts(paths)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, path := range r.unownedFiles(counts) {
+			owner, lines := topOwner(counts.byFile[path])
+			share := float64(lines) / float64(counts.fileLines[path])
+			a := Annotation{Path: path, Rule: RuleUnowned, Level: AnnotationWarning,
+				Title: unownedTitle, Message: unownedMessage(r.OwnershipAlert, owner, share)}
+			if owner != "" {
+				a.Contacts = []string{owner}
+			}
+
+			annotations = append(annotations, a)
+		}
+	}
+
+	for _, path := range r.changedPaths(paths) {
+		for _, rule := range r.SensitiveRules {
+			if !rule.matches(path) {
+				continue
+			}
+
+			annotations = append(annotations, Annotation{Path: path, Rule: RuleSensitive,
+				Level: AnnotationNotice, Title: "Sensitive path",
+				Message: fmt.Sprintf("Matches the '%s' rule, which requires a review from %s.",
+					rule.Name, strings.Join(rule.Reviewers, ", ")),
+				Contacts: rule.Reviewers})
+		}
+	}
+
+	sort.SliceStable(annotations, func(i, j int) bool {
+		return annotations[i].Path < annotations[j].Path
+	})
+
+	return annotations, nil
+}
+
+// topOwner returns the author owning the most lines in 'owners', breaking
+// ties by name.
+func topOwner(owners map[string]int) (string, int) {
+	var (
+		top   string
+		lines int
+	)
+	for author, l := range owners {
+		if l > lines || l == lines && author < top {
+			top, lines = author, l
+		}
+	}
+	return top, lines
+}
+
+// WorkflowCommand formats the annotation as a GitHub Actions workflow
+// command, which shows it on the file in the pull request when printed by a
+// step.
+func (a Annotation) WorkflowCommand() string {
+	return fmt.Sprintf("::%s file=%s,title=%s::%s", a.Level, escapeProperty(a.Path),
+		escapeProperty(a.Title), escapeData(a.Message))
+}
+
+// escapeData escapes the message of a workflow command.
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty escapes a property of a workflow command, such as its file.
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A",
+		",", "%2C").Replace(s)
+}
+
+// sarifLevels maps annotation levels to SARIF result levels.
+var sarifLevels = map[string]string{
+	AnnotationWarning: "warning",
+	AnnotationNotice:  "note",
+}
+
+// sarifRules describes each rule for SARIF consumers such as code scanning.
+var sarifRules = []struct {
+	ID, Name, Description string
+}{
+	{RuleUnowned, "UnownedFile", "Changed file nobody active knows well"},
+	{RuleSensitive, "SensitivePath", "Changed file matching a sensitive path rule"},
+}
+
+// WriteSARIF writes 'annotations' to 'w' as a SARIF 2.1.0 log, which CI
+// systems such as GitHub code scanning attach to pull requests. 'version' is
+// the version of git-reviewer reported as the tool.
+func WriteSARIF(w io.Writer, annotations []Annotation, version string) error {
+	type message struct {
+		Text string `json:"text"`
+	}
+	type rule struct {
+		ID               string  `json:"id"`
+		Name             string  `json:"name"`
+		ShortDescription message `json:"shortDescription"`
+	}
+	type location struct {
+		PhysicalLocation struct {
+			ArtifactLocation struct {
+				URI string `json:"uri"`
+			} `json:"artifactLocation"`
+		} `json:"physicalLocation"`
+	}
+	type result struct {
+		RuleID    string     `json:"ruleId"`
+		Level     string     `json:"level"`
+		Message   message    `json:"message"`
+		Locations []location `json:"locations"`
+	}
+
+	rules := make([]rule, 0, len(sarifRules))
+	for _, r := range sarifRules {
+		rules = append(rules, rule{ID: r.ID, Name: r.Name,
+			ShortDescription: message{r.Description}})
+	}
+
+	results := make([]result, 0, len(annotations))
+	for _, a := range annotations {
+		var loc location
+		loc.PhysicalLocation.ArtifactLocation.URI = a.Path
+		results = append(results, result{RuleID: a.Rule, Level: sarifLevels[a.Level],
+			Message: message{a.Title + ": " + a.Message}, Locations: []location{loc}})
+	}
+
+	log := map[string]interface{}{
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"version": "2.1.0",
+		"runs": []interface{}{
+			map[string]interface{}{
+				"tool": map[string]interface{}{
+					"driver": map[string]interface{}{
+						"name":           "git-reviewer",
+						"version":        version,
+						"informationUri": "https://github.com/TheDahv/git-reviewer",
+						"rules":          rules,
+					},
+				},
+				"results": results,
+			},
+		},
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(log)
+}
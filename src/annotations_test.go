@@ -0,0 +1,152 @@
+package gitreviewers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAnnotations(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	repo, _, err := OpenRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every line was committed before Since, so nobody active owns the file
+	r := ContributionCounter{Repo: repo, Dir: dir, Since: "2100-01-01", OwnershipAlert: 0.1,
+		SensitiveRules: []SensitiveRule{
+			{Name: "security", Paths: []string{"src"}, Reviewers: []string{"sec@git-reviewer.com"}},
+			{Name: "docs", Paths: []string{"docs"}, Reviewers: []string{"doc@git-reviewer.com"}},
+		}}
+
+	got, err := r.Annotations([]string{"src/a.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Annotation{
+		{Path: "src/a.go", Rule: RuleUnowned, Level: AnnotationWarning,
+			Title: "No knowledgeable owner", Message: "Nobody active owns more than 10% of this file."},
+		{Path: "src/a.go", Rule: RuleSensitive, Level: AnnotationNotice, Title: "Sensitive path",
+			Message:  "Matches the 'security' rule, which requires a review from sec@git-reviewer.com.",
+			Contacts: []string{"sec@git-reviewer.com"}},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Got %+v, expected %+v\n", got, expected)
+	}
+}
+
+func TestAnnotationsUnblamedFiles(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	// The branch adds a sensitive file, which has nothing to blame
+	runGit(t, dir, "checkout", "-q", "-b", "feature")
+	if err := os.MkdirAll(filepath.Join(dir, "docs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "docs", "guide.md"), []byte("# Guide\n"),
+		0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-q", "-m", "Add a guide")
+
+	repo, _, err := OpenRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := ContributionCounter{Repo: repo, Dir: dir, Since: "2000-01-01",
+		SensitiveRules: []SensitiveRule{
+			{Name: "docs", Paths: []string{"docs"}, Reviewers: []string{"doc@git-reviewer.com"}},
+		}}
+
+	paths, err := r.FindFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := r.Annotations(paths)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Annotation{
+		{Path: "docs/guide.md", Rule: RuleSensitive, Level: AnnotationNotice, Title: "Sensitive path",
+			Message:  "Matches the 'docs' rule, which requires a review from doc@git-reviewer.com.",
+			Contacts: []string{"doc@git-reviewer.com"}},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Got %+v, expected %+v\n", got, expected)
+	}
+}
+
+func TestWorkflowCommand(t *testing.T) {
+	a := Annotation{Path: "src/a,b:c.go", Level: AnnotationWarning, Title: "100% owned",
+		Message: "Line one\nLine two"}
+
+	expected := "::warning file=src/a%2Cb%3Ac.go,title=100%25 owned::Line one%0ALine two"
+	if got := a.WorkflowCommand(); got != expected {
+		t.Errorf("Got '%s', expected '%s'\n", got, expected)
+	}
+}
+
+func TestWriteSARIF(t *testing.T) {
+	annotations := []Annotation{
+		{Path: "src/a.go", Rule: RuleSensitive, Level: AnnotationNotice, Title: "Sensitive path",
+			Message: "Matches the 'security' rule."},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, annotations, "0.0.5"); err != nil {
+		t.Fatal(err)
+	}
+
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Results []struct {
+				RuleID  string `json:"ruleId"`
+				Level   string `json:"level"`
+				Message struct {
+					Text string `json:"text"`
+				} `json:"message"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+
+	if log.Version != "2.1.0" || len(log.Runs) != 1 || len(log.Runs[0].Results) != 1 {
+		t.Fatalf("Got %s, expected a single run with a single result\n", buf.String())
+	}
+	res := log.Runs[0].Results[0]
+	if res.RuleID != RuleSensitive || res.Level != "note" ||
+		res.Message.Text != "Sensitive path: Matches the 'security' rule." ||
+		len(res.Locations) != 1 || res.Locations[0].PhysicalLocation.ArtifactLocation.URI != "src/a.go" {
+		t.Errorf("Got %+v, expected a note on src/a.go\n", res)
+	}
+
+	// An empty log still lists results, so uploads don't fail
+	buf.Reset()
+	if err := WriteSARIF(&buf, nil, "0.0.5"); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte(`"results": []`)) {
+		t.Errorf("Got %s, expected an empty list of results\n", buf.String())
+	}
+}
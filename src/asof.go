@@ -0,0 +1,83 @@
+package gitreviewers
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// asOfPoint is AsOf resolved to the commit ownership is measured at and the
+// day (YYYY-MM-DD) it stands for.
+type asOfPoint struct {
+	rev  string
+	date string
+}
+
+// asOf resolves AsOf, either a revision or a YYYY-MM-DD date, once per run. A
+// revision stands for the day it was committed, and a date for the last
+// commit of the base revision's history on or before it. It is nil if AsOf
+// isn't set.
+func (r *ContributionCounter) asOf() (*asOfPoint, error) {
+	if r.AsOf == "" || r.asOfPoint != nil {
+		return r.asOfPoint, nil
+	}
+
+	if _, err := time.Parse("2006-01-02", r.AsOf); err == nil {
+		out, err := r.output("rev-list", "-1", "--before="+r.AsOf+" 23:59:59", r.baseRev())
+		rev := strings.TrimSpace(string(out))
+		if err != nil || rev == "" {
+			return nil, errors.Errorf("no commit on '%s' on or before %s", r.baseRev(), r.AsOf)
+		}
+		r.asOfPoint = &asOfPoint{rev: rev, date: r.AsOf}
+		return r.asOfPoint, nil
+	}
+
+	hash, err := r.resolve(r.AsOf)
+	if err != nil {
+		return nil, err
+	}
+	out, err := r.output("show", "-s", "--format=%cd", "--date=short", hash.String())
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read the date of '%s'", r.AsOf)
+	}
+	r.asOfPoint = &asOfPoint{rev: hash.String(), date: strings.TrimSpace(string(out))}
+
+	return r.asOfPoint, nil
+}
+
+// now is the moment ownership is measured at: the day of AsOf, or the
+// current time. It is resolved by defaultSince, which every entry point calls
+// before blaming anything concurrently.
+func (r *ContributionCounter) now() time.Time {
+	if p, err := r.asOf(); err == nil && p != nil {
+		if t, err := time.Parse("2006-01-02", p.date); err == nil {
+			return t
+		}
+	}
+	return time.Now()
+}
+
+// historyRev names the revision whose history experience is read from: the
+// AsOf commit, or the base revision.
+func (r *ContributionCounter) historyRev() string {
+	if p, err := r.asOf(); err == nil && p != nil {
+		return p.rev
+	}
+	return r.baseRev()
+}
+
+// counted reports whether a line or commit made on 'date' (YYYY-MM-DD) counts
+// towards ownership: on or after Since and, with AsOf, on or before its day.
+// r.Since is a string, not a date. However, since the format is just a
+// "YYYY-MM-DD" string, we can rely on ASCII sorting and just compare the
+// strings.
+func (r *ContributionCounter) counted(date string) bool {
+	if r.Since > date {
+		return false
+	}
+	if p := r.asOfPoint; p != nil && date > p.date {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,88 @@
+package gitreviewers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestAsOf(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "src", "a.go"),
+		[]byte("package a\n\nvar x = 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "commit", "-q", "-a", "--author", "Ben Franklin <ben@git-reviewer.com>",
+		"-m", "Add x")
+
+	repo, _, err := OpenRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		AsOf     string
+		Expected []string
+	}{
+		{"", []string{"abe@git-reviewer.com", "ben@git-reviewer.com"}},
+		{"master~1", []string{"abe@git-reviewer.com"}},
+		{time.Now().Format("2006-01-02"), []string{"abe@git-reviewer.com", "ben@git-reviewer.com"}},
+	}
+
+	for _, c := range cases {
+		r := ContributionCounter{Repo: repo, Dir: dir, Since: "2000-01-01", AsOf: c.AsOf}
+
+		counts, err := r.generateCounts([]string{"src/a.go"})
+		if err != nil {
+			t.Fatalf("Unexpected error blaming as of '%s': %v\n", c.AsOf, err)
+		}
+
+		var authors []string
+		for author := range counts.byAuthor {
+			authors = append(authors, author)
+		}
+		sort.Strings(authors)
+
+		if !reflect.DeepEqual(authors, c.Expected) {
+			t.Errorf("Got %v, expected %v as of '%s'\n", authors, c.Expected, c.AsOf)
+		}
+	}
+
+	r := ContributionCounter{Repo: repo, Dir: dir, AsOf: "2000-01-01"}
+	if _, err := r.asOf(); err == nil {
+		t.Errorf("Expected an error for a date before the first commit\n")
+	}
+
+	r = ContributionCounter{Repo: repo, Dir: dir, AsOf: "master~1"}
+	r.defaultSince()
+	if expected := time.Now().AddDate(0, -6, 0).Format("2006-01-02"); r.Since != expected {
+		t.Errorf("Got since %s, expected %s\n", r.Since, expected)
+	}
+}
+
+func TestCounted(t *testing.T) {
+	r := ContributionCounter{Since: "2017-01-01",
+		asOfPoint: &asOfPoint{rev: "abc", date: "2017-06-01"}}
+
+	cases := []struct {
+		date     string
+		expected bool
+	}{
+		{"2016-12-31", false},
+		{"2017-01-01", true},
+		{"2017-06-01", true},
+		{"2017-06-02", false},
+	}
+
+	for _, c := range cases {
+		if got := r.counted(c.date); got != c.expected {
+			t.Errorf("Got %t, expected %t for %s\n", got, c.expected, c.date)
+		}
+	}
+}
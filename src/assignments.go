@@ -0,0 +1,141 @@
+package gitreviewers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Assignment is the part of the changes a suggested reviewer is asked to
+// focus on.
+type Assignment struct {
+	Reviewer string
+	Files    []AssignedFile
+}
+
+// AssignedFile is a changed file assigned to a reviewer, who owns Share of
+// its lines. Files the reviewer owns none of, such as added files, have no
+// Share.
+type AssignedFile struct {
+	Path  string
+	Share float64
+}
+
+// Assignments proposes how the reviewers SuggestReviewers picks for 'paths'
+// could split the review: each changed file, including added ones, goes to
+// the suggested reviewer owning the most of it, as long as nobody ends up
+// with more than their fair share of files. Files nobody suggested owns, and
+// those left over by reviewers who are full, go to whoever has the fewest.
+// Reviewers are listed like SortStats orders them, leaving out those with
+// nothing assigned.
+func (r *ContributionCounter) Assignments(paths []string) ([]Assignment, error) {
+	if len(paths) == 0 && len(r.added) == 0 {
+		return nil, &NoChangesError{Base: r.baseRev(), Head: r.headRev()}
+	}
+
+	r.defaultSince()
+
+	topN, counts, err := r.suggestions(paths)
+	if err != nil {
+		return nil, err
+	}
+	SortStats(topN, r.Sort)
+
+	reviewers := make([]string, 0, len(topN))
+	for _, s := range topN {
+		reviewers = append(reviewers, s.Reviewer)
+	}
+
+	// Files standing in for added ones weren't changed, so the added files
+	// are assigned instead
+	var changed []string
+	for _, p := range paths {
+		if !r.standIns[p] {
+			changed = append(changed, p)
+		}
+	}
+	changed = append(changed, r.added...)
+
+	return assignFiles(reviewers, changed, counts), nil
+}
+
+// assignFiles splits 'paths' among 'reviewers', listed best match first, see
+// Assignments. The largest files are assigned first, so they are the ones
+// that go to their owners when reviewers fill up.
+func assignFiles(reviewers, paths []string, counts *contributions) []Assignment {
+	assignments := make([]Assignment, len(reviewers))
+	for i, reviewer := range reviewers {
+		assignments[i].Reviewer = reviewer
+	}
+
+	files := append([]string{}, paths...)
+	sort.SliceStable(files, func(i, j int) bool {
+		if counts.fileLines[files[i]] != counts.fileLines[files[j]] {
+			return counts.fileLines[files[i]] > counts.fileLines[files[j]]
+		}
+		return files[i] < files[j]
+	})
+
+	// Nobody takes more than their fair share, rounded up
+	capacity := (len(files) + len(reviewers) - 1) / len(reviewers)
+
+	for _, path := range files {
+		owner, fewest := -1, -1
+		for i, reviewer := range reviewers {
+			if len(assignments[i].Files) >= capacity {
+				continue
+			}
+			if lines := counts.byFile[path][reviewer]; lines > 0 &&
+				(owner < 0 || lines > counts.byFile[path][reviewers[owner]]) {
+				owner = i
+			}
+			if fewest < 0 || len(assignments[i].Files) < len(assignments[fewest].Files) {
+				fewest = i
+			}
+		}
+
+		f := AssignedFile{Path: path}
+		if owner < 0 {
+			owner = fewest
+		} else {
+			f.Share = float64(counts.byFile[path][reviewers[owner]]) /
+				float64(counts.fileLines[path])
+		}
+		assignments[owner].Files = append(assignments[owner].Files, f)
+	}
+
+	var assigned []Assignment
+	for _, a := range assignments {
+		if len(a.Files) == 0 {
+			continue
+		}
+		sort.Slice(a.Files, func(i, j int) bool {
+			return a.Files[i].Path < a.Files[j].Path
+		})
+		assigned = append(assigned, a)
+	}
+
+	return assigned
+}
+
+// WriteAssignments writes 'assignments' to 'w' as Markdown, ready to paste
+// into a pull request comment asking each reviewer to focus on their files.
+func WriteAssignments(w io.Writer, assignments []Assignment) error {
+	var buf bytes.Buffer
+
+	fmt.Fprintln(&buf, "Proposed review split, so each of you can focus on the files you know best:")
+	for _, a := range assignments {
+		fmt.Fprintf(&buf, "\n**%s**\n", a.Reviewer)
+		for _, f := range a.Files {
+			fmt.Fprintf(&buf, "- `%s`", f.Path)
+			if f.Share > 0 {
+				fmt.Fprintf(&buf, " (owns %.0f%%)", f.Share*100)
+			}
+			fmt.Fprintln(&buf)
+		}
+	}
+
+	_, err := buf.WriteTo(w)
+	return err
+}
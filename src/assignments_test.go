@@ -0,0 +1,60 @@
+package gitreviewers
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestAssignFiles(t *testing.T) {
+	counts := newContributions()
+	counts.add("src/a.go", []attribution{{author: "abe"}, {author: "abe"}, {author: "ben"}}, 3)
+	counts.add("src/b.go", []attribution{{author: "abe"}, {author: "ben"}}, 2)
+	counts.add("src/c.go", []attribution{{author: "abe"}}, 4)
+
+	cases := []struct {
+		reviewers []string
+		paths     []string
+		expected  []Assignment
+	}{
+		// Abe owns the most of every file, but only takes half of them. The
+		// largest files are his, and Ben gets what's left.
+		{[]string{"abe", "ben"}, []string{"src/a.go", "src/b.go", "src/c.go", "src/new.go"},
+			[]Assignment{
+				{"abe", []AssignedFile{{"src/a.go", 2.0 / 3}, {"src/c.go", 0.25}}},
+				{"ben", []AssignedFile{{"src/b.go", 0.5}, {"src/new.go", 0}}},
+			}},
+		// Files nobody suggested owns go to whoever has the fewest, and
+		// reviewers without files are left out
+		{[]string{"cal", "ben", "dee"}, []string{"src/b.go", "src/c.go"},
+			[]Assignment{
+				{"cal", []AssignedFile{{"src/c.go", 0}}},
+				{"ben", []AssignedFile{{"src/b.go", 0.5}}},
+			}},
+	}
+
+	for _, c := range cases {
+		if actual := assignFiles(c.reviewers, c.paths, counts); !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("Got %+v for %v, expected %+v\n", actual, c.reviewers, c.expected)
+		}
+	}
+}
+
+func TestWriteAssignments(t *testing.T) {
+	assignments := []Assignment{
+		{"abe@git-reviewer.com", []AssignedFile{{"src/a.go", 0.6}, {"src/new.go", 0}}},
+		{"ben@git-reviewer.com", []AssignedFile{{"docs/b.md", 1}}},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteAssignments(&buf, assignments); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "Proposed review split, so each of you can focus on the files you know best:\n" +
+		"\n**abe@git-reviewer.com**\n- `src/a.go` (owns 60%)\n- `src/new.go`\n" +
+		"\n**ben@git-reviewer.com**\n- `docs/b.md` (owns 100%)\n"
+	if got := buf.String(); got != expected {
+		t.Errorf("Got %s, expected %s\n", got, expected)
+	}
+}
@@ -0,0 +1,100 @@
+package gitreviewers
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// workdayStart and workdayEnd are the hours of the working day, in the
+	// local time of each person.
+	workdayStart = 9
+	workdayEnd   = 17
+	// availabilityWeight is the boost given to a candidate whose working
+	// hours are the same as the author's, and a share of it to those whose
+	// hours partly overlap. It is kept small so it only breaks near ties: a
+	// candidate owning 5% more of the changes always ranks ahead.
+	availabilityWeight = 0.05
+)
+
+// boostAvailable boosts the candidates whose working hours overlap those of
+// the author of the head commit, see Availability.
+func (r *ContributionCounter) boostAvailable(candidates Stats) error {
+	defer r.Summary.stage("availability", time.Now())
+
+	h, err := r.resolve(r.headRev())
+	if err != nil {
+		return errors.Wrap(err, "issue resolving head revision")
+	}
+	c, err := r.Repo.CommitObject(h)
+	if err != nil {
+		return errors.Wrap(err, "issue opening head commit")
+	}
+
+	author := reviewerKey(c.Author.Email, r.Mailmap)
+	zone := c.Author.When.Location()
+	if loc, ok := r.timezone(author); ok {
+		zone = loc
+	}
+
+	now := r.now()
+	for _, s := range candidates {
+		loc, ok := r.timezone(s.Reviewer)
+		if !ok || s.Reviewer == author {
+			continue
+		}
+
+		s.Overlap = workdayOverlap(now, zone, loc)
+		s.Boost += availabilityWeight * float64(s.Overlap) / (workdayEnd - workdayStart)
+	}
+
+	return nil
+}
+
+// timezone looks up the time zone of 'email' in Timezones.
+func (r *ContributionCounter) timezone(email string) (*time.Location, bool) {
+	name, ok := r.Timezones[strings.ToLower(email)]
+	if !ok {
+		return nil, false
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		r.logf("Ignoring unknown time zone '%s' of %s\n", name, email)
+		return nil, false
+	}
+	return loc, true
+}
+
+// workdayOverlap counts the hours the working day of someone in 'a' on the
+// day of 'now' shares with the working days of someone in 'b' around it.
+func workdayOverlap(now time.Time, a, b *time.Location) int {
+	workday := func(day time.Time, loc *time.Location) (time.Time, time.Time) {
+		y, m, d := day.Date()
+		return time.Date(y, m, d, workdayStart, 0, 0, 0, loc),
+			time.Date(y, m, d, workdayEnd, 0, 0, 0, loc)
+	}
+
+	day := now.In(a)
+	start, end := workday(day, a)
+
+	var best time.Duration
+	for _, days := range []int{-1, 0, 1} {
+		otherStart, otherEnd := workday(day.AddDate(0, 0, days), b)
+
+		from, to := start, end
+		if otherStart.After(from) {
+			from = otherStart
+		}
+		if otherEnd.Before(to) {
+			to = otherEnd
+		}
+		if overlap := to.Sub(from); overlap > best {
+			best = overlap
+		}
+	}
+
+	return int(best / time.Hour)
+}
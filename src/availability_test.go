@@ -0,0 +1,81 @@
+package gitreviewers
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestWorkdayOverlap(t *testing.T) {
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		a, b     string
+		expected int
+	}{
+		{"UTC", "UTC", 8},
+		{"Europe/Paris", "Europe/Berlin", 8},
+		{"Europe/Paris", "America/New_York", 2},
+		{"America/New_York", "Europe/Paris", 2},
+		// Late in Los Angeles is early the next day in Tokyo
+		{"America/Los_Angeles", "Asia/Tokyo", 1},
+		{"Asia/Kolkata", "UTC", 2},
+		{"Europe/London", "Pacific/Auckland", 0},
+	}
+
+	for _, c := range cases {
+		a, err := time.LoadLocation(c.a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := time.LoadLocation(c.b)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if actual := workdayOverlap(now, a, b); actual != c.expected {
+			t.Errorf("Got %d hours between %s and %s, expected %d\n", actual, c.a, c.b,
+				c.expected)
+		}
+	}
+}
+
+func TestBoostAvailable(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	repo, _, err := OpenRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := ContributionCounter{Repo: repo, Dir: dir, Timezones: map[string]string{
+		"abe@git-reviewer.com":    "Europe/Paris",
+		"ben@git-reviewer.com":    "Europe/Berlin",
+		"george@git-reviewer.com": "America/Los_Angeles",
+	}}
+	candidates := Stats{
+		{Reviewer: "george@git-reviewer.com", Percentage: 0.42},
+		{Reviewer: "ben@git-reviewer.com", Percentage: 0.40},
+		{Reviewer: "tom@git-reviewer.com", Percentage: 0.10},
+		{Reviewer: "abe@git-reviewer.com", Percentage: 0.08},
+	}
+	if err := r.boostAvailable(candidates); err != nil {
+		t.Fatalf("Unexpected error boosting: %v\n", err)
+	}
+
+	// Ben overtakes George, who is asleep, but the boost is never more than
+	// a few points and nobody else's changes
+	ben, george := candidates[1], candidates[0]
+	if ben.Overlap != 8 || ben.Boost != availabilityWeight {
+		t.Errorf("Got %d hours and a %f boost for Ben, expected 8 and %f\n", ben.Overlap,
+			ben.Boost, availabilityWeight)
+	}
+	if george.Overlap > 1 || george.Percentage+george.Boost > ben.Percentage+ben.Boost {
+		t.Errorf("Expected Ben to rank ahead of George, got %+v and %+v\n", ben, george)
+	}
+	if candidates[2].Boost != 0 || candidates[3].Boost != 0 {
+		t.Errorf("Expected no boost for people without a time zone or the author, got %+v\n",
+			candidates)
+	}
+}
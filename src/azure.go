@@ -0,0 +1,165 @@
+package gitreviewers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// azureAPIVersion is the version of the Azure DevOps REST API requests ask for.
+const azureAPIVersion = "7.0"
+
+// azure builds the provider for an Azure DevOps remote, in the organization
+// and project its URL names, or in the collection at reviewer.azureUrl for
+// Azure DevOps Server. Requests are authenticated with the personal access
+// token in AZURE_DEVOPS_EXT_PAT, as for the Azure CLI.
+func azure(c *Config, remote Remote) (*AzureDevOps, error) {
+	// Remotes look like dev.azure.com/org/project/_git/repo, or
+	// ssh.dev.azure.com:v3/org/project/repo over SSH
+	parts := strings.Split(strings.TrimSuffix(remote.Owner, "/_git"), "/")
+	ssh := parts[0] == "v3"
+	if ssh {
+		parts = parts[1:]
+	}
+
+	a := &AzureDevOps{Project: parts[len(parts)-1], Repo: remote.Repo,
+		Token: os.Getenv("AZURE_DEVOPS_EXT_PAT")}
+
+	var org string
+	switch u, _ := c.Get("reviewer.azureUrl"); {
+	case u != "":
+		a.URL = strings.TrimSuffix(u, "/")
+		a.IdentityURL = a.URL
+	case strings.HasSuffix(remote.Host, ".visualstudio.com") && !ssh:
+		org = strings.TrimSuffix(remote.Host, ".visualstudio.com")
+	case len(parts) == 2:
+		org = parts[0]
+	default:
+		return nil, errors.Errorf("unable to tell the organization and project of %s/%s",
+			remote.Owner, remote.Repo)
+	}
+	if org != "" {
+		a.URL = "https://dev.azure.com/" + org
+		a.IdentityURL = "https://vssps.dev.azure.com/" + org
+	}
+
+	return a, nil
+}
+
+// AzureDevOps is the ReviewProvider of repositories on Azure DevOps Services
+// or Server.
+type AzureDevOps struct {
+	// URL is the address of the organization or collection, such as
+	// "https://dev.azure.com/org", and IdentityURL that of the service
+	// looking up users, which differs from URL on Azure DevOps Services.
+	URL         string
+	IdentityURL string
+	Project     string
+	Repo        string
+	// Token is a personal access token.
+	Token  string
+	Client *http.Client
+}
+
+func (a *AzureDevOps) api(base string) apiClient {
+	return apiClient{URL: base, Token: a.Token, Client: a.Client, Basic: true}
+}
+
+func (a *AzureDevOps) repoPath() string {
+	return fmt.Sprintf("/%s/_apis/git/repositories/%s", url.PathEscape(a.Project),
+		url.PathEscape(a.Repo))
+}
+
+// azureIdentity holds the fields of an Azure DevOps user we use.
+type azureIdentity struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"displayName"`
+}
+
+// User finds the only identity with 'email'.
+func (a *AzureDevOps) User(email string) (Account, error) {
+	path := fmt.Sprintf("/_apis/identities?searchFilter=General&filterValue=%s&api-version=%s",
+		url.QueryEscape(email), azureAPIVersion)
+
+	var found struct {
+		Value []struct {
+			ID          string `json:"id"`
+			DisplayName string `json:"providerDisplayName"`
+		} `json:"value"`
+	}
+	err := a.api(a.IdentityURL).do("GET", path, nil, &found)
+	switch {
+	case err == errAPINotFound || err == nil && len(found.Value) != 1:
+		return Account{}, nil
+	case err != nil:
+		return Account{}, errors.Wrapf(err, "unable to look up %s on Azure DevOps", email)
+	}
+
+	return Account{ID: found.Value[0].ID, Name: found.Value[0].DisplayName}, nil
+}
+
+// PullRequest finds the active pull request from 'branch'.
+func (a *AzureDevOps) PullRequest(branch string) (*PullRequest, error) {
+	path := fmt.Sprintf("%s/pullrequests?searchCriteria.sourceRefName=%s"+
+		"&searchCriteria.status=active&api-version=%s", a.repoPath(),
+		url.QueryEscape("refs/heads/"+branch), azureAPIVersion)
+
+	var found struct {
+		Value []struct {
+			ID            int           `json:"pullRequestId"`
+			Title         string        `json:"title"`
+			CreatedBy     azureIdentity `json:"createdBy"`
+			TargetRefName string        `json:"targetRefName"`
+			Repository    struct {
+				WebURL string `json:"webUrl"`
+			} `json:"repository"`
+		} `json:"value"`
+	}
+	if err := a.api(a.URL).do("GET", path, nil, &found); err != nil {
+		return nil, errors.Wrap(err, "unable to find pull request on Azure DevOps")
+	}
+	if len(found.Value) == 0 {
+		return nil, nil
+	}
+
+	v := found.Value[0]
+	return &PullRequest{ID: fmt.Sprint(v.ID), Title: v.Title,
+		Author: Account{ID: v.CreatedBy.ID, Name: v.CreatedBy.DisplayName},
+		Base:   strings.TrimPrefix(v.TargetRefName, "refs/heads/"),
+		URL:    fmt.Sprintf("%s/pullrequest/%d", v.Repository.WebURL, v.ID)}, nil
+}
+
+// AddReviewers adds 'accounts' to the reviewers of 'pr', without a vote.
+func (a *AzureDevOps) AddReviewers(pr *PullRequest, accounts []Account) error {
+	for _, account := range accounts {
+		path := fmt.Sprintf("%s/pullrequests/%s/reviewers/%s?api-version=%s", a.repoPath(),
+			pr.ID, url.PathEscape(account.ID), azureAPIVersion)
+		in := struct {
+			Vote int `json:"vote"`
+		}{0}
+		if err := a.api(a.URL).do("PUT", path, in, nil); err != nil {
+			return errors.Wrapf(err, "unable to add %s as a reviewer", account.Name)
+		}
+	}
+	return nil
+}
+
+// OpenReviews counts the active pull requests 'account' is a reviewer of, in
+// every repository of the organization or collection.
+func (a *AzureDevOps) OpenReviews(account Account) (int, error) {
+	path := fmt.Sprintf("/_apis/git/pullrequests?searchCriteria.reviewerId=%s"+
+		"&searchCriteria.status=active&$top=1000&api-version=%s", url.QueryEscape(account.ID),
+		azureAPIVersion)
+
+	var found struct {
+		Count int `json:"count"`
+	}
+	if err := a.api(a.URL).do("GET", path, nil, &found); err != nil {
+		return 0, errors.Wrapf(err, "unable to count the open reviews of %s", account.Name)
+	}
+	return found.Count, nil
+}
@@ -0,0 +1,128 @@
+package gitreviewers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestAzureDevOps(t *testing.T) {
+	var added []string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if user, token, ok := req.BasicAuth(); !ok || user != "" || token != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch req.Method + " " + req.URL.Path {
+		case "GET /identities/_apis/identities":
+			if req.URL.Query().Get("filterValue") != "abe@git-reviewer.com" {
+				fmt.Fprint(w, `{"count": 0, "value": []}`)
+				return
+			}
+			fmt.Fprint(w, `{"count": 1, "value": [{"id": "a1", "providerDisplayName": "Abe"}]}`)
+		case "GET /Web Site/_apis/git/repositories/app/pullrequests":
+			if req.URL.Query().Get("searchCriteria.sourceRefName") != "refs/heads/feature" {
+				fmt.Fprint(w, `{"value": []}`)
+				return
+			}
+			fmt.Fprint(w, `{"value": [{"pullRequestId": 9, "title": "Add a feature",
+				"createdBy": {"id": "c3", "displayName": "Carl"}, "targetRefName": "refs/heads/main",
+				"repository": {"webUrl": "https://dev.azure.com/team/site/_git/app"}}]}`)
+		case "PUT /Web Site/_apis/git/repositories/app/pullrequests/9/reviewers/a1",
+			"PUT /Web Site/_apis/git/repositories/app/pullrequests/9/reviewers/b2":
+			added = append(added, req.URL.Path[len(req.URL.Path)-2:])
+			fmt.Fprint(w, `{}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	a := &AzureDevOps{URL: srv.URL, IdentityURL: srv.URL + "/identities", Project: "Web Site",
+		Repo: "app", Token: "secret"}
+
+	if got, err := a.User("abe@git-reviewer.com"); err != nil || got != (Account{ID: "a1", Name: "Abe"}) {
+		t.Errorf("Got %+v (error: %v), expected Abe\n", got, err)
+	}
+	if got, err := a.User("ben@git-reviewer.com"); err != nil || got != (Account{}) {
+		t.Errorf("Got %+v (error: %v), expected no account\n", got, err)
+	}
+
+	pr, err := a.PullRequest("feature")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &PullRequest{ID: "9", Title: "Add a feature", Author: Account{ID: "c3", Name: "Carl"},
+		Base: "main", URL: "https://dev.azure.com/team/site/_git/app/pullrequest/9"}
+	if !reflect.DeepEqual(pr, expected) {
+		t.Errorf("Got %+v, expected %+v\n", pr, expected)
+	}
+
+	if pr, err := a.PullRequest("other"); err != nil || pr != nil {
+		t.Errorf("Got %+v (error: %v), expected no pull request\n", pr, err)
+	}
+
+	if err := a.AddReviewers(expected, []Account{{ID: "a1"}, {ID: "b2"}}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(added, []string{"a1", "b2"}) {
+		t.Errorf("Got %v, expected a1 and b2 added as reviewers\n", added)
+	}
+
+	a.Token = "wrong"
+	if _, err := a.PullRequest("feature"); err == nil {
+		t.Error("Expected an error with the wrong token")
+	}
+}
+
+func TestAzureRemotes(t *testing.T) {
+	tests := []struct {
+		remote   Remote
+		config   []ConfigEntry
+		expected AzureDevOps
+		fails    bool
+	}{
+		{remote: Remote{"dev.azure.com", "team/site/_git", "app", ProviderAzure},
+			expected: AzureDevOps{URL: "https://dev.azure.com/team",
+				IdentityURL: "https://vssps.dev.azure.com/team", Project: "site", Repo: "app"}},
+		{remote: Remote{"ssh.dev.azure.com", "v3/team/site", "app", ProviderAzure},
+			expected: AzureDevOps{URL: "https://dev.azure.com/team",
+				IdentityURL: "https://vssps.dev.azure.com/team", Project: "site", Repo: "app"}},
+		{remote: Remote{"team.visualstudio.com", "site/_git", "app", ProviderAzure},
+			expected: AzureDevOps{URL: "https://dev.azure.com/team",
+				IdentityURL: "https://vssps.dev.azure.com/team", Project: "site", Repo: "app"}},
+		{remote: Remote{"tfs.example.com", "tfs/DefaultCollection/site/_git", "app", ProviderAzure},
+			config: []ConfigEntry{{Key: "reviewer.azureurl", Value: "https://tfs.example.com/tfs/DefaultCollection/"}},
+			expected: AzureDevOps{URL: "https://tfs.example.com/tfs/DefaultCollection",
+				IdentityURL: "https://tfs.example.com/tfs/DefaultCollection", Project: "site", Repo: "app"}},
+		{remote: Remote{"tfs.example.com", "tfs/DefaultCollection/site/_git", "app", ProviderAzure},
+			fails: true},
+	}
+
+	r := &ContributionCounter{}
+	for _, tt := range tests {
+		p, err := r.ReviewProvider(&Config{Entries: tt.config}, tt.remote)
+		if tt.fails {
+			if err == nil {
+				t.Errorf("Expected an error for %+v, got %+v\n", tt.remote, p)
+			}
+			continue
+		}
+		a, ok := p.(*AzureDevOps)
+		if err != nil || !ok {
+			t.Errorf("Got %+v (error: %v), expected Azure DevOps for %+v\n", p, err, tt.remote)
+			continue
+		}
+		if a.Client == nil {
+			t.Errorf("Expected %+v to call the API with the shared client\n", tt.remote)
+		}
+		a.Client = nil
+		if *a != tt.expected {
+			t.Errorf("Got %+v, expected %+v\n", *a, tt.expected)
+		}
+	}
+}
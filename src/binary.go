@@ -0,0 +1,25 @@
+package gitreviewers
+
+import (
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// binaryPaths finds which of 'paths' hold binary content in 'tree', whatever
+// their extension. Blaming them attributes meaningless "lines" split at
+// random newline bytes. Like git, a file is binary if a null byte shows up
+// in its first 8000 bytes. Paths missing from 'tree' are left alone.
+func binaryPaths(tree *object.Tree, paths []string) map[string]bool {
+	binary := make(map[string]bool)
+
+	for _, p := range paths {
+		f, err := tree.File(p)
+		if err != nil {
+			continue
+		}
+		if isBinary, err := f.IsBinary(); err == nil && isBinary {
+			binary[p] = true
+		}
+	}
+
+	return binary
+}
@@ -0,0 +1,47 @@
+package gitreviewers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindFilesSkipsBinaries(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	write := func(name, content string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// An extension no filter knows about
+	write("src/model.weights", "\x00\x01\x02\nline\n")
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-q", "-m", "Add weights")
+
+	runGit(t, dir, "checkout", "-q", "-b", "feature")
+	write("src/model.weights", "\x00\x01\x03\nline\n")
+	write("src/a.go", "package a\n\nvar a = 1\n")
+	runGit(t, dir, "commit", "-q", "-a", "-m", "Retrain")
+
+	repo, _, err := OpenRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := ContributionCounter{Repo: repo, Dir: dir, Head: "feature", Summary: &RunSummary{}}
+	files, err := r.FindFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 1 || files[0] != "src/a.go" {
+		t.Errorf("Got %v, expected [src/a.go]\n", files)
+	}
+	if reason := r.Summary.Filtered["src/model.weights"]; reason != "binary" {
+		t.Errorf("Got '%s', expected the weights to be filtered as binary\n", reason)
+	}
+}
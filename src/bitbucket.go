@@ -0,0 +1,296 @@
+package gitreviewers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// BitbucketCloudAPI is the address of the Bitbucket Cloud REST API.
+const BitbucketCloudAPI = "https://api.bitbucket.org/2.0"
+
+// bitbucket builds the provider for a Bitbucket remote: Bitbucket Cloud for
+// bitbucket.org, and Bitbucket Server or Data Center at reviewer.bitbucketUrl,
+// or the remote's host, otherwise. Requests are authenticated as
+// reviewer.bitbucketUser with the app password in BITBUCKET_TOKEN, or with
+// BITBUCKET_TOKEN as an access token if there is no user.
+func (r *ContributionCounter) bitbucket(c *Config, remote Remote) ReviewProvider {
+	user, _ := c.Get("reviewer.bitbucketUser")
+	token := os.Getenv("BITBUCKET_TOKEN")
+
+	if remote.Host == "bitbucket.org" {
+		return &BitbucketCloud{URL: BitbucketCloudAPI, Workspace: remote.Owner,
+			Repo: remote.Repo, Username: user, Token: token, Client: r.HTTPClient(),
+			commitBy: r.lastCommitBy}
+	}
+
+	u, _ := c.Get("reviewer.bitbucketUrl")
+	if u == "" {
+		u = "https://" + remote.Host
+	}
+	// HTTP remotes of Bitbucket Server live under /scm
+	project := strings.TrimPrefix(remote.Owner, "scm/")
+
+	return &BitbucketServer{URL: strings.TrimSuffix(u, "/"), Project: project,
+		Repo: remote.Repo, Username: user, Token: token, Client: r.HTTPClient()}
+}
+
+// BitbucketCloud is the ReviewProvider of repositories on bitbucket.org.
+type BitbucketCloud struct {
+	// URL is the address of the API, BitbucketCloudAPI unless testing.
+	URL       string
+	Workspace string
+	Repo      string
+	// Username and Token authenticate with an app password, or Token alone
+	// with an access token.
+	Username string
+	Token    string
+	Client   *http.Client
+
+	// commitBy finds a commit by an email. Bitbucket Cloud doesn't look
+	// users up by email, but knows who authored each commit.
+	commitBy func(email string) (string, error)
+}
+
+func (b *BitbucketCloud) api() apiClient {
+	return apiClient{URL: b.URL, User: b.Username, Token: b.Token, Client: b.Client}
+}
+
+func (b *BitbucketCloud) repoPath() string {
+	return fmt.Sprintf("/repositories/%s/%s", url.PathEscape(b.Workspace), url.PathEscape(b.Repo))
+}
+
+// bitbucketCloudUser holds the fields of a Bitbucket Cloud user we use.
+type bitbucketCloudUser struct {
+	UUID     string `json:"uuid"`
+	Nickname string `json:"nickname"`
+}
+
+// User finds the account linked to the latest commit made with 'email'.
+func (b *BitbucketCloud) User(email string) (Account, error) {
+	if b.commitBy == nil {
+		return Account{}, nil
+	}
+	commit, err := b.commitBy(email)
+	if err != nil || commit == "" {
+		return Account{}, err
+	}
+
+	var c struct {
+		Author struct {
+			User *bitbucketCloudUser `json:"user"`
+		} `json:"author"`
+	}
+	err = b.api().do("GET", b.repoPath()+"/commit/"+commit, nil, &c)
+	switch {
+	case err == errAPINotFound || err == nil && c.Author.User == nil:
+		return Account{}, nil
+	case err != nil:
+		return Account{}, errors.Wrapf(err, "unable to look up %s on Bitbucket", email)
+	}
+
+	return Account{ID: c.Author.User.UUID, Name: c.Author.User.Nickname}, nil
+}
+
+// PullRequest finds the open pull request from 'branch'.
+func (b *BitbucketCloud) PullRequest(branch string) (*PullRequest, error) {
+	q := url.QueryEscape(fmt.Sprintf(`source.branch.name="%s" AND state="OPEN"`, branch))
+
+	var page struct {
+		Values []struct {
+			ID          int                `json:"id"`
+			Title       string             `json:"title"`
+			Author      bitbucketCloudUser `json:"author"`
+			Destination struct {
+				Branch struct {
+					Name string `json:"name"`
+				} `json:"branch"`
+			} `json:"destination"`
+			Links struct {
+				HTML struct {
+					Href string `json:"href"`
+				} `json:"html"`
+			} `json:"links"`
+		} `json:"values"`
+	}
+	if err := b.api().do("GET", b.repoPath()+"/pullrequests?q="+q, nil, &page); err != nil {
+		return nil, errors.Wrap(err, "unable to find pull request on Bitbucket")
+	}
+	if len(page.Values) == 0 {
+		return nil, nil
+	}
+
+	v := page.Values[0]
+	return &PullRequest{ID: strconv.Itoa(v.ID), Title: v.Title,
+		Author: Account{ID: v.Author.UUID, Name: v.Author.Nickname},
+		Base:   v.Destination.Branch.Name, URL: v.Links.HTML.Href}, nil
+}
+
+// AddReviewers adds 'accounts' to the reviewers of 'pr'. Bitbucket Cloud
+// replaces the whole list of reviewers, so the current ones are sent too.
+func (b *BitbucketCloud) AddReviewers(pr *PullRequest, accounts []Account) error {
+	type reviewer struct {
+		UUID string `json:"uuid"`
+	}
+	var current struct {
+		Title     string     `json:"title"`
+		Reviewers []reviewer `json:"reviewers"`
+	}
+
+	path := b.repoPath() + "/pullrequests/" + pr.ID
+	if err := b.api().do("GET", path, nil, &current); err != nil {
+		return errors.Wrap(err, "unable to read pull request from Bitbucket")
+	}
+
+	reviewers := current.Reviewers
+	for _, a := range accounts {
+		reviewers = append(reviewers, reviewer{a.ID})
+	}
+
+	update := map[string]interface{}{"title": current.Title, "reviewers": reviewers}
+	return errors.Wrap(b.api().do("PUT", path, update, nil), "unable to add reviewers on Bitbucket")
+}
+
+// OpenReviews counts the open pull requests of the repository 'account' is a
+// reviewer of. Bitbucket Cloud only searches pull requests one repository at
+// a time, and lists them a page at a time.
+func (b *BitbucketCloud) OpenReviews(account Account) (int, error) {
+	q := url.QueryEscape(fmt.Sprintf(`reviewers.uuid="%s" AND state="OPEN"`, account.ID))
+
+	var open int
+	for path := b.repoPath() + "/pullrequests?pagelen=50&q=" + q; path != ""; {
+		var page struct {
+			Values []struct{} `json:"values"`
+			// Next is the address of the next page, if there is one
+			Next string `json:"next"`
+		}
+		if err := b.api().do("GET", path, nil, &page); err != nil {
+			return 0, errors.Wrapf(err, "unable to count the open reviews of %s", account.Name)
+		}
+		open += len(page.Values)
+
+		if page.Next != "" && !strings.HasPrefix(page.Next, b.URL) {
+			return 0, errors.Errorf("unexpected next page %s", page.Next)
+		}
+		path = strings.TrimPrefix(page.Next, b.URL)
+	}
+	return open, nil
+}
+
+// BitbucketServer is the ReviewProvider of repositories on Bitbucket Server
+// or Data Center.
+type BitbucketServer struct {
+	// URL is the address of the instance, such as
+	// "https://bitbucket.example.com".
+	URL     string
+	Project string
+	Repo    string
+	// Username and Token authenticate with a password, or Token alone with a
+	// personal access token.
+	Username string
+	Token    string
+	Client   *http.Client
+}
+
+func (b *BitbucketServer) api() apiClient {
+	return apiClient{URL: b.URL + "/rest/api/1.0", User: b.Username, Token: b.Token, Client: b.Client}
+}
+
+func (b *BitbucketServer) repoPath() string {
+	return fmt.Sprintf("/projects/%s/repos/%s", url.PathEscape(b.Project), url.PathEscape(b.Repo))
+}
+
+// User finds the user whose email address is 'email'.
+func (b *BitbucketServer) User(email string) (Account, error) {
+	var page struct {
+		Values []struct {
+			Name         string `json:"name"`
+			EmailAddress string `json:"emailAddress"`
+		} `json:"values"`
+	}
+	if err := b.api().do("GET", "/users?filter="+url.QueryEscape(email), nil, &page); err != nil {
+		return Account{}, errors.Wrapf(err, "unable to look up %s on Bitbucket", email)
+	}
+
+	for _, u := range page.Values {
+		if strings.EqualFold(u.EmailAddress, email) {
+			return Account{ID: u.Name, Name: u.Name}, nil
+		}
+	}
+	return Account{}, nil
+}
+
+// PullRequest finds the open pull request from 'branch'.
+func (b *BitbucketServer) PullRequest(branch string) (*PullRequest, error) {
+	var page struct {
+		Values []struct {
+			ID     int    `json:"id"`
+			Title  string `json:"title"`
+			Author struct {
+				User struct {
+					Name string `json:"name"`
+				} `json:"user"`
+			} `json:"author"`
+			ToRef struct {
+				DisplayID string `json:"displayId"`
+			} `json:"toRef"`
+			Links struct {
+				Self []struct {
+					Href string `json:"href"`
+				} `json:"self"`
+			} `json:"links"`
+		} `json:"values"`
+	}
+
+	path := b.repoPath() + "/pull-requests?state=OPEN&direction=OUTGOING&at=" +
+		url.QueryEscape("refs/heads/"+branch)
+	if err := b.api().do("GET", path, nil, &page); err != nil {
+		return nil, errors.Wrap(err, "unable to find pull request on Bitbucket")
+	}
+	if len(page.Values) == 0 {
+		return nil, nil
+	}
+
+	v := page.Values[0]
+	pr := &PullRequest{ID: strconv.Itoa(v.ID), Title: v.Title,
+		Author: Account{ID: v.Author.User.Name, Name: v.Author.User.Name},
+		Base:   v.ToRef.DisplayID}
+	if len(v.Links.Self) > 0 {
+		pr.URL = v.Links.Self[0].Href
+	}
+	return pr, nil
+}
+
+// AddReviewers adds each of 'accounts' as a reviewer of 'pr'.
+func (b *BitbucketServer) AddReviewers(pr *PullRequest, accounts []Account) error {
+	path := b.repoPath() + "/pull-requests/" + pr.ID + "/participants"
+	for _, a := range accounts {
+		participant := map[string]interface{}{
+			"user": map[string]string{"name": a.ID},
+			"role": "REVIEWER",
+		}
+		if err := b.api().do("POST", path, participant, nil); err != nil {
+			return errors.Wrapf(err, "unable to add %s as a reviewer on Bitbucket", a.Name)
+		}
+	}
+	return nil
+}
+
+// OpenReviews counts the open pull requests of the repository 'account' is a
+// reviewer of, up to a thousand.
+func (b *BitbucketServer) OpenReviews(account Account) (int, error) {
+	var page struct {
+		Size int `json:"size"`
+	}
+	path := b.repoPath() + "/pull-requests?state=OPEN&role.1=REVIEWER&limit=1000&username.1=" +
+		url.QueryEscape(account.ID)
+	if err := b.api().do("GET", path, nil, &page); err != nil {
+		return 0, errors.Wrapf(err, "unable to count the open reviews of %s", account.Name)
+	}
+	return page.Size, nil
+}
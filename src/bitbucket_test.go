@@ -0,0 +1,205 @@
+package gitreviewers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestBitbucketCloud(t *testing.T) {
+	var updated map[string]interface{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if user, token, _ := req.BasicAuth(); user != "me" || token != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch req.Method + " " + req.URL.Path {
+		case "GET /repositories/team/app/commit/abc":
+			fmt.Fprint(w, `{"author": {"raw": "Abe <abe@git-reviewer.com>",
+				"user": {"uuid": "{abe}", "nickname": "abe"}}}`)
+		case "GET /repositories/team/app/commit/def":
+			fmt.Fprint(w, `{"author": {"raw": "Ben <ben@git-reviewer.com>"}}`)
+		case "GET /repositories/team/app/pullrequests":
+			if q := req.URL.Query().Get("q"); q == `reviewers.uuid="{abe}" AND state="OPEN"` {
+				if req.URL.Query().Get("page") == "2" {
+					fmt.Fprint(w, `{"values": [{"id": 3}]}`)
+					return
+				}
+				fmt.Fprintf(w, `{"values": [{"id": 1}, {"id": 2}], "next": "%s%s?page=2&q=%s"}`,
+					"http://"+req.Host, req.URL.Path, url.QueryEscape(q))
+				return
+			}
+			if q := req.URL.Query().Get("q"); q != `source.branch.name="feature" AND state="OPEN"` {
+				fmt.Fprint(w, `{"values": []}`)
+				return
+			}
+			fmt.Fprint(w, `{"values": [{"id": 7, "title": "Add a feature",
+				"author": {"uuid": "{carl}", "nickname": "carl"},
+				"destination": {"branch": {"name": "main"}},
+				"links": {"html": {"href": "https://bitbucket.org/team/app/pull-requests/7"}}}]}`)
+		case "GET /repositories/team/app/pullrequests/7":
+			fmt.Fprint(w, `{"title": "Add a feature", "reviewers": [{"uuid": "{dan}"}]}`)
+		case "PUT /repositories/team/app/pullrequests/7":
+			json.NewDecoder(req.Body).Decode(&updated)
+			fmt.Fprint(w, `{}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	commits := map[string]string{"abe@git-reviewer.com": "abc", "ben@git-reviewer.com": "def"}
+	b := &BitbucketCloud{URL: srv.URL, Workspace: "team", Repo: "app", Username: "me",
+		Token: "secret", commitBy: func(email string) (string, error) {
+			return commits[email], nil
+		}}
+
+	users := []struct {
+		email    string
+		expected Account
+	}{
+		{"abe@git-reviewer.com", Account{ID: "{abe}", Name: "abe"}},
+		{"ben@git-reviewer.com", Account{}},
+		{"carl@git-reviewer.com", Account{}},
+	}
+	for _, u := range users {
+		got, err := b.User(u.email)
+		if err != nil {
+			t.Fatalf("Unexpected error looking up %s: %v\n", u.email, err)
+		}
+		if got != u.expected {
+			t.Errorf("Got %+v, expected %+v for %s\n", got, u.expected, u.email)
+		}
+	}
+
+	pr, err := b.PullRequest("feature")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &PullRequest{ID: "7", Title: "Add a feature", Author: Account{ID: "{carl}", Name: "carl"},
+		Base: "main", URL: "https://bitbucket.org/team/app/pull-requests/7"}
+	if !reflect.DeepEqual(pr, expected) {
+		t.Errorf("Got %+v, expected %+v\n", pr, expected)
+	}
+
+	if pr, err := b.PullRequest("other"); err != nil || pr != nil {
+		t.Errorf("Got %+v (error: %v), expected no pull request\n", pr, err)
+	}
+
+	if err := b.AddReviewers(expected, []Account{{ID: "{abe}", Name: "abe"}}); err != nil {
+		t.Fatal(err)
+	}
+	reviewers := fmt.Sprint(updated["reviewers"])
+	if reviewers != "[map[uuid:{dan}] map[uuid:{abe}]]" || updated["title"] != "Add a feature" {
+		t.Errorf("Got update %v, expected the current and new reviewers\n", updated)
+	}
+
+	if n, err := b.OpenReviews(Account{ID: "{abe}", Name: "abe"}); err != nil || n != 3 {
+		t.Errorf("Got %d (error: %v), expected 3 open reviews for abe over two pages\n", n, err)
+	}
+}
+
+func TestBitbucketServer(t *testing.T) {
+	var added []string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch req.Method + " " + req.URL.Path {
+		case "GET /rest/api/1.0/users":
+			fmt.Fprint(w, `{"values": [{"name": "abe2", "emailAddress": "abe@other.com"},
+				{"name": "abe", "emailAddress": "Abe@git-reviewer.com"}]}`)
+		case "GET /rest/api/1.0/projects/APP/repos/app/pull-requests":
+			if req.URL.Query().Get("at") != "refs/heads/feature" {
+				fmt.Fprint(w, `{"values": []}`)
+				return
+			}
+			fmt.Fprint(w, `{"values": [{"id": 3, "title": "Add a feature",
+				"author": {"user": {"name": "carl"}}, "toRef": {"displayId": "master"},
+				"links": {"self": [{"href": "https://git.example.com/pr/3"}]}}]}`)
+		case "POST /rest/api/1.0/projects/APP/repos/app/pull-requests/3/participants":
+			var p struct {
+				User struct {
+					Name string `json:"name"`
+				} `json:"user"`
+				Role string `json:"role"`
+			}
+			json.NewDecoder(req.Body).Decode(&p)
+			added = append(added, p.User.Name+":"+p.Role)
+			fmt.Fprint(w, `{}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	b := &BitbucketServer{URL: srv.URL, Project: "APP", Repo: "app", Token: "secret"}
+
+	if got, err := b.User("abe@git-reviewer.com"); err != nil || got != (Account{ID: "abe", Name: "abe"}) {
+		t.Errorf("Got %+v (error: %v), expected abe\n", got, err)
+	}
+
+	pr, err := b.PullRequest("feature")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &PullRequest{ID: "3", Title: "Add a feature", Author: Account{ID: "carl", Name: "carl"},
+		Base: "master", URL: "https://git.example.com/pr/3"}
+	if !reflect.DeepEqual(pr, expected) {
+		t.Errorf("Got %+v, expected %+v\n", pr, expected)
+	}
+
+	if err := b.AddReviewers(pr, []Account{{ID: "abe"}, {ID: "ben"}}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(added, []string{"abe:REVIEWER", "ben:REVIEWER"}) {
+		t.Errorf("Got %v, expected abe and ben added as reviewers\n", added)
+	}
+
+	b.Token = "wrong"
+	if _, err := b.PullRequest("feature"); err == nil {
+		t.Error("Expected an error with the wrong token")
+	}
+}
+
+func TestReviewProvider(t *testing.T) {
+	r := &ContributionCounter{}
+	c := &Config{Entries: []ConfigEntry{
+		{Key: "reviewer.bitbucketuser", Value: "me"},
+	}}
+
+	p, err := r.ReviewProvider(c, Remote{Host: "bitbucket.org", Owner: "team", Repo: "app",
+		Provider: ProviderBitbucket})
+	if cloud, ok := p.(*BitbucketCloud); err != nil || !ok || cloud.Workspace != "team" ||
+		cloud.Username != "me" {
+		t.Errorf("Got %+v (error: %v), expected Bitbucket Cloud for team/app\n", p, err)
+	}
+
+	p, err = r.ReviewProvider(c, Remote{Host: "git.example.com", Owner: "scm/APP", Repo: "app",
+		Provider: ProviderBitbucket})
+	if server, ok := p.(*BitbucketServer); err != nil || !ok || server.Project != "APP" ||
+		server.URL != "https://git.example.com" {
+		t.Errorf("Got %+v (error: %v), expected Bitbucket Server for APP/app\n", p, err)
+	}
+
+	p, err = r.ReviewProvider(c, Remote{Host: "ghe.example.com", Owner: "team", Repo: "app",
+		Provider: ProviderGitHub})
+	if gh, ok := p.(*GitHub); err != nil || !ok || gh.URL != "https://ghe.example.com/api/v3" {
+		t.Errorf("Got %+v (error: %v), expected GitHub Enterprise for team/app\n", p, err)
+	}
+
+	for _, provider := range []string{ProviderGitLab, ""} {
+		if _, err := r.ReviewProvider(c, Remote{Host: "example.com", Provider: provider}); err == nil {
+			t.Errorf("Expected an error for provider '%s'\n", provider)
+		}
+	}
+}
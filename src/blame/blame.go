@@ -0,0 +1,250 @@
+// Package blame runs git blame and parses its output into one attribution per
+// line, with everything git knows about the commit each line comes from.
+//
+// It reads the --porcelain format, which is meant for programs, so it doesn't
+// depend on the human-oriented formats and their padding. The commit details
+// git only gives for the first line of each commit are carried over to the
+// other lines, and the --line-porcelain format, which repeats them, is read
+// too.
+package blame
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/thedahv/git-reviewer/src/gitcmd"
+)
+
+// uncommittedRev is the commit git blames lines that are not committed yet on.
+const uncommittedRev = "0000000000000000000000000000000000000000"
+
+// Options tunes how git blame runs.
+type Options struct {
+	// Lines limits blame to ranges in any form -L accepts, such as "10,20".
+	Lines []string
+	// IgnoreRevs are commits whose changes are blamed on the commits before
+	// them, such as reformatting commits.
+	IgnoreRevs []string
+	// FirstParent follows only the first parent of merge commits.
+	FirstParent bool
+	// Since stops following history at this date, in any form git accepts,
+	// such as "2017-06-01". Older lines are left on the commit blame stopped
+	// at, as boundary lines.
+	Since string
+	// Git is the git binary to run, "git" if empty.
+	Git string
+}
+
+// LineAttribution describes who last changed a line of the file, and when.
+type LineAttribution struct {
+	// Commit is the full SHA of the commit the line comes from.
+	Commit string
+	// Line is the number of the line in the blamed revision of the file, and
+	// OriginalLine and OriginalPath where it was in Commit.
+	Line         int
+	OriginalLine int
+	OriginalPath string
+
+	Author         string
+	AuthorEmail    string
+	AuthorTime     time.Time
+	Committer      string
+	CommitterEmail string
+	CommitterTime  time.Time
+
+	// Boundary is set for lines of a boundary commit, such as the root commit
+	// or the edge of a shallow clone.
+	Boundary bool
+	// Content is the line of the file, without its line ending.
+	Content string
+}
+
+// Uncommitted reports whether the line is a local change that has not been
+// committed yet.
+func (l LineAttribution) Uncommitted() bool {
+	return l.Commit == uncommittedRev
+}
+
+// Args are the arguments of the git command blaming 'path' at 'rev' with
+// 'opts', for programs that run git their own way and Parse its output. Git
+// isn't one of them.
+func Args(rev, path string, opts Options) []string {
+	args := []string{"blame", "--porcelain"}
+	for _, l := range opts.Lines {
+		args = append(args, "-L", l)
+	}
+	for _, r := range opts.IgnoreRevs {
+		args = append(args, "--ignore-rev", r)
+	}
+	if opts.FirstParent {
+		args = append(args, "--first-parent")
+	}
+	if opts.Since != "" {
+		args = append(args, "--since="+opts.Since)
+	}
+	if rev != "" {
+		args = append(args, rev)
+	}
+	return append(args, "--", path)
+}
+
+// Run blames 'path' at 'rev' in the repository at 'repoDir' and returns the
+// attribution of each line in order. An empty 'rev' blames the working tree.
+func Run(ctx context.Context, repoDir, rev, path string, opts Options) ([]LineAttribution, error) {
+	cmd := gitcmd.Runner{Git: opts.Git, Dir: repoDir}.Command(ctx, Args(rev, path, opts)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, errors.Wrapf(ctx.Err(), "git blame of %s stopped", path)
+		}
+		return nil, errors.Wrapf(err, "git blame of %s failed: %s", path,
+			strings.TrimSpace(stderr.String()))
+	}
+
+	return Parse(bytes.NewReader(out))
+}
+
+// Parse reads the output of git blame --porcelain or --line-porcelain.
+func Parse(r io.Reader) ([]LineAttribution, error) {
+	var (
+		lines []LineAttribution
+		line  LineAttribution
+		err   error
+		n     int
+		// commits holds the details of each commit seen so far, which
+		// --porcelain only gives once
+		commits = make(map[string]LineAttribution)
+	)
+
+	scn := bufio.NewScanner(r)
+	// Lines of minified or generated files can be arbitrarily long
+	scn.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), 1<<30)
+
+	header := true
+	for scn.Scan() {
+		n++
+		text := scn.Text()
+
+		// The content ends the entry of each line, after a tab
+		if strings.HasPrefix(text, "\t") {
+			if header {
+				return nil, errors.Errorf("line %d: content before the commit line", n)
+			}
+			line.Content = text[1:]
+			lines = append(lines, line)
+			commits[line.Commit] = line
+			line, header = LineAttribution{}, true
+			continue
+		}
+
+		if header {
+			start, err := parseCommitLine(text)
+			if err != nil {
+				return nil, errors.Wrapf(err, "line %d", n)
+			}
+			line = commits[start.Commit]
+			line.Commit, line.Line, line.OriginalLine = start.Commit, start.Line, start.OriginalLine
+			header = false
+			continue
+		}
+
+		key, value := text, ""
+		if i := strings.IndexByte(text, ' '); i >= 0 {
+			key, value = text[:i], text[i+1:]
+		}
+
+		switch key {
+		case "author":
+			line.Author = value
+		case "author-mail":
+			line.AuthorEmail = strings.TrimSuffix(strings.TrimPrefix(value, "<"), ">")
+		case "author-time":
+			line.AuthorTime, err = parseTime(value, line.AuthorTime)
+		case "author-tz":
+			line.AuthorTime, err = inZone(line.AuthorTime, value)
+		case "committer":
+			line.Committer = value
+		case "committer-mail":
+			line.CommitterEmail = strings.TrimSuffix(strings.TrimPrefix(value, "<"), ">")
+		case "committer-time":
+			line.CommitterTime, err = parseTime(value, line.CommitterTime)
+		case "committer-tz":
+			line.CommitterTime, err = inZone(line.CommitterTime, value)
+		case "boundary":
+			line.Boundary = true
+		case "filename":
+			line.OriginalPath = value
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d: unable to read %s", n, key)
+		}
+	}
+	if err := scn.Err(); err != nil {
+		return nil, errors.Wrap(err, "unable to read blame output")
+	}
+	if !header {
+		return nil, errors.New("blame output ends before the content of the last line")
+	}
+
+	return lines, nil
+}
+
+// parseCommitLine reads the line starting the entry of each line:
+// "<sha> <original line> <final line> [<lines in group>]".
+func parseCommitLine(text string) (LineAttribution, error) {
+	fields := strings.Fields(text)
+	if len(fields) < 3 || len(fields[0]) < 40 {
+		return LineAttribution{}, errors.Errorf("'%s' is not a commit line", text)
+	}
+
+	original, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return LineAttribution{}, errors.Errorf("'%s' is not a line number", fields[1])
+	}
+	final, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return LineAttribution{}, errors.Errorf("'%s' is not a line number", fields[2])
+	}
+
+	return LineAttribution{Commit: fields[0], OriginalLine: original, Line: final}, nil
+}
+
+// parseTime reads a Unix timestamp, keeping the time zone of 't' if it was
+// read first.
+func parseTime(value string, t time.Time) (time.Time, error) {
+	secs, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return t, err
+	}
+	return time.Unix(secs, 0).In(t.Location()), nil
+}
+
+// inZone moves 't' to the time zone of a "+0200" style offset.
+func inZone(t time.Time, tz string) (time.Time, error) {
+	if len(tz) != 5 || tz[0] != '+' && tz[0] != '-' {
+		return t, errors.Errorf("'%s' is not a time zone", tz)
+	}
+	hours, err := strconv.Atoi(tz[1:3])
+	if err != nil {
+		return t, err
+	}
+	minutes, err := strconv.Atoi(tz[3:])
+	if err != nil {
+		return t, err
+	}
+
+	offset := hours*3600 + minutes*60
+	if tz[0] == '-' {
+		offset = -offset
+	}
+	return t.In(time.FixedZone(tz, offset)), nil
+}
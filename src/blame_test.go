@@ -0,0 +1,59 @@
+package gitreviewers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBlameAttributionsInitialImport(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	// The root commit is a boundary, so only this line is a regular commit
+	if err := ioutil.WriteFile(filepath.Join(dir, "src", "a.go"),
+		[]byte("package a\n\nvar b = 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "commit", "-q", "-a", "-m", "Add b")
+
+	for _, initialImport := range []bool{false, true} {
+		r := ContributionCounter{Dir: dir, Since: "2000-01-01", InitialImport: initialImport}
+
+		attributions, lines, err := r.blameAttributions("src/a.go", "master")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if lines != 3 {
+			t.Errorf("Got %d lines, expected %d\n", lines, 3)
+		}
+
+		expected := "abe@git-reviewer.com"
+		if initialImport {
+			expected = initialImportAuthor
+		}
+		if len(attributions) != 3 || attributions[0].author != expected ||
+			attributions[2].author != "abe@git-reviewer.com" {
+			t.Errorf("Got %v, expected the first line credited to '%s'\n",
+				attributions, expected)
+		}
+	}
+}
+
+func TestContributionsSkipInitialImport(t *testing.T) {
+	c := newContributions()
+	c.add("a.go", []attribution{
+		{author: initialImportAuthor, date: "2017-01-01"},
+		{author: initialImportAuthor, date: "2017-01-01"},
+		{author: "abe@git-reviewer.com", date: "2017-02-01"},
+	}, 3)
+
+	if _, ok := c.byAuthor[initialImportAuthor]; ok {
+		t.Errorf("Expected '%s' not to be a candidate\n", initialImportAuthor)
+	}
+	if c.total != 3 {
+		t.Errorf("Got total %d, expected %d\n", c.total, 3)
+	}
+}
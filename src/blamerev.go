@@ -0,0 +1,122 @@
+package gitreviewers
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	gogit "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// Revisions changed files can be blamed at, set through
+// ContributionCounter.BlameAt.
+const (
+	// BlameAtBase measures the experience people had with the code before
+	// the changes, which is the default.
+	BlameAtBase = "base"
+	// BlameAtHead measures ownership of the code as it will be after the
+	// changes, crediting related work merged into the branch but not the
+	// commits made on the branch itself, whose author is the one asking for
+	// a review.
+	BlameAtHead = "head"
+	// BlameAtMergeBase measures ownership of the code as it was when the
+	// branch started, leaving out later work on the base branch.
+	BlameAtMergeBase = "merge-base"
+)
+
+// BlameAtOptions lists the valid values of ContributionCounter.BlameAt.
+var BlameAtOptions = []string{BlameAtBase, BlameAtHead, BlameAtMergeBase}
+
+// blameRev resolves the revision changed files are blamed at, picked by
+// AsOf or BlameAt.
+func (r *ContributionCounter) blameRev() (plumbing.Hash, error) {
+	if r.AsOf != "" {
+		p, err := r.asOf()
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+		return plumbing.NewHash(p.rev), nil
+	}
+
+	switch r.BlameAt {
+	case BlameAtHead:
+		return r.resolve(r.headRev())
+	case BlameAtMergeBase:
+		mergeBase, err := r.mergeBase(r.baseRev(), r.headRev())
+		if err != nil {
+			return plumbing.ZeroHash, errors.Errorf("'%s' and '%s' have no common ancestor",
+				r.baseRev(), r.headRev())
+		}
+		return plumbing.NewHash(mergeBase), nil
+	default:
+		return r.resolve(r.baseRev())
+	}
+}
+
+// branchCommits lists the commits made on the branch itself, sorted: those
+// on the first-parent line of the head revision that the base revision
+// doesn't have. Work merged into the branch from elsewhere isn't among them.
+func (r *ContributionCounter) branchCommits() ([]string, error) {
+	if r.NoExec {
+		return r.branchCommitsGoGit()
+	}
+
+	out, err := r.output("rev-list", "--first-parent", r.headRev(), "^"+r.baseRev(), "--")
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list the commits of the branch")
+	}
+	commits := strings.Fields(string(out))
+	sort.Strings(commits)
+	return commits, nil
+}
+
+// branchCommitsGoGit is branchCommits without git.
+func (r *ContributionCounter) branchCommitsGoGit() ([]string, error) {
+	base, err := r.resolve(r.baseRev())
+	if err != nil {
+		return nil, err
+	}
+	iter, err := r.Repo.Log(&gogit.LogOptions{From: base})
+	if err != nil {
+		return nil, errors.Wrapf(err, "issue reading the history of %s", r.baseRev())
+	}
+	inBase := make(map[plumbing.Hash]bool)
+	err = iter.ForEach(func(c *object.Commit) error {
+		inBase[c.Hash] = true
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "issue reading the history of %s", r.baseRev())
+	}
+
+	h, err := r.resolve(r.headRev())
+	if err != nil {
+		return nil, err
+	}
+	var commits []string
+	for !inBase[h] {
+		c, err := r.Repo.CommitObject(h)
+		if err != nil {
+			return nil, errors.Wrapf(err, "issue opening commit %s", h)
+		}
+		commits = append(commits, h.String())
+		if len(c.ParentHashes) == 0 {
+			break
+		}
+		h = c.ParentHashes[0]
+	}
+	sort.Strings(commits)
+	return commits, nil
+}
+
+// skipped reports whether lines from the commit 'rev', possibly abbreviated,
+// credit nobody because it is one of skipRevs.
+func (r *ContributionCounter) skipped(rev string) bool {
+	if rev == "" || len(r.skipRevs) == 0 {
+		return false
+	}
+	i := sort.SearchStrings(r.skipRevs, rev)
+	return i < len(r.skipRevs) && strings.HasPrefix(r.skipRevs[i], rev)
+}
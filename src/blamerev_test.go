@@ -0,0 +1,92 @@
+package gitreviewers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestBlameAt(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	write := func(name, content string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, "src", name),
+			[]byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("b.go", "package a\n")
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-q", "-m", "Add b")
+
+	// The branch deletes b.go, which only base has left to blame
+	runGit(t, dir, "checkout", "-q", "-b", "feature")
+	write("a.go", "package a\n\nvar y = 2\n")
+	runGit(t, dir, "rm", "-q", "src/b.go")
+	runGit(t, dir, "commit", "-q", "-a", "--author", "Carl Sagan <carl@git-reviewer.com>",
+		"-m", "Add y")
+
+	runGit(t, dir, "checkout", "-q", "master")
+	write("a.go", "package a\n\nvar x = 1\n")
+	runGit(t, dir, "commit", "-q", "-a", "--author", "Ben Franklin <ben@git-reviewer.com>",
+		"-m", "Add x")
+
+	repo, _, err := OpenRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		BlameAt  string
+		Expected []string
+	}{
+		{"", []string{"abe@git-reviewer.com", "ben@git-reviewer.com"}},
+		{BlameAtBase, []string{"abe@git-reviewer.com", "ben@git-reviewer.com"}},
+		// Carl's own lines are left out of the suggestions for the branch
+		{BlameAtHead, []string{"abe@git-reviewer.com"}},
+		{BlameAtMergeBase, []string{"abe@git-reviewer.com"}},
+	}
+
+	for _, c := range cases {
+		r := ContributionCounter{Repo: repo, Dir: dir, Since: "2000-01-01",
+			Head: "feature", BlameAt: c.BlameAt}
+
+		counts, err := r.generateCounts([]string{"src/a.go", "src/b.go"})
+		if err != nil {
+			t.Fatalf("Unexpected error blaming at '%s': %v\n", c.BlameAt, err)
+		}
+
+		var authors []string
+		for author := range counts.byAuthor {
+			authors = append(authors, author)
+		}
+		sort.Strings(authors)
+
+		if !reflect.DeepEqual(authors, c.Expected) {
+			t.Errorf("Got %v, expected %v blaming at '%s'\n", authors, c.Expected, c.BlameAt)
+		}
+		if len(counts.byFile) != 2 {
+			t.Errorf("Got %d files blamed at '%s', expected 2\n", len(counts.byFile), c.BlameAt)
+		}
+
+		// They still count once the branch merges
+		if c.BlameAt != BlameAtHead {
+			continue
+		}
+		if lines := counts.fileLines["src/a.go"]; lines != 3 {
+			t.Errorf("Got %d lines in src/a.go at head, expected 3\n", lines)
+		}
+		after, err := r.countsAt(BlameAtHead, []string{"src/a.go"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := after.byAuthor["carl@git-reviewer.com"]; !ok {
+			t.Error("Expected carl to own lines of src/a.go once the branch merges")
+		}
+	}
+}
@@ -0,0 +1,341 @@
+package gitreviewers
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Bundle captures everything a suggestion is computed from, so it can be
+// reproduced offline without the repository, for example to find out why a
+// reviewer was picked on a private repository. Set
+// ContributionCounter.Bundle to a new Bundle to capture one while finding
+// reviewers, save it with Write and recompute the suggestion with Replay.
+type Bundle struct {
+	// Version is the version of the program that captured the bundle, which
+	// the caller fills in.
+	Version string `json:"version"`
+	// Base and Head are the commits that were compared, and Since and Now
+	// (YYYY-MM-DD) the window ownership was measured in.
+	Base  string `json:"base"`
+	Head  string `json:"head"`
+	Since string `json:"since"`
+	Now   string `json:"now"`
+	// Paths lists the changed files that were blamed, and Added the added
+	// files default reviewers may cover.
+	Paths   []string      `json:"paths"`
+	Added   []string      `json:"added,omitempty"`
+	Options BundleOptions `json:"options"`
+	// Teams maps collaborators to their team, after the mailmap was applied.
+	Teams map[string]string `json:"teams,omitempty"`
+
+	// Files and Authors hold the blamed lines and the other signals of every
+	// candidate. Owners holds, for each OWNERS file nearest to a changed
+	// file, the owners it lists.
+	Files   []BundleFile        `json:"-"`
+	Authors []BundleAuthor      `json:"-"`
+	Owners  map[string][]string `json:"-"`
+	// Config holds the settings that were read, which the caller fills in.
+	Config []ConfigEntry `json:"-"`
+}
+
+// BundleOptions are the options of the counter that change which reviewers
+// are picked out of the captured signals.
+type BundleOptions struct {
+	PerFile         bool    `json:"perFile"`
+	Diverse         bool    `json:"diverse"`
+	RecentDays      int     `json:"recentDays"`
+	IncludeLearners bool    `json:"includeLearners"`
+	OwnershipAlert  float64 `json:"ownershipAlert"`
+	Show            string  `json:"show"`
+	Language        string  `json:"language"`
+	OwnersPolicy    string  `json:"owners"`
+	Sort            string  `json:"sort,omitempty"`
+}
+
+// BundleFile holds the blamed lines of one changed file.
+type BundleFile struct {
+	Path string `json:"path"`
+	// Lines counts every line of the file and Blamed the lines counted
+	// towards ownership, including lines of initial imports.
+	Lines  int `json:"lines"`
+	Blamed int `json:"blamed"`
+	// Authors counts the lines each author owns.
+	Authors map[string]int `json:"authors,omitempty"`
+	// Skipped says why the file was left out, if it was.
+	Skipped string `json:"skipped,omitempty"`
+	// Weight is how much its lines count, if not fully, because it stands
+	// in for an added file, see DirWeight.
+	Weight *float64 `json:"weight,omitempty"`
+}
+
+// BundleAuthor holds what is known of a candidate besides the lines they own.
+type BundleAuthor struct {
+	Author string `json:"author"`
+	// LastTouched is the most recent day one of their lines was committed,
+	// and Quarters counts their lines by quarter, see quarterOf.
+	LastTouched string      `json:"lastTouched,omitempty"`
+	Quarters    map[int]int `json:"quarters,omitempty"`
+	// Boost, Score, Topics, Tickets, Owners and Overlap are the values of
+	// the Stat fields of the same name once every signal was looked up.
+	Boost   float64  `json:"boost,omitempty"`
+	Score   float64  `json:"score,omitempty"`
+	Topics  []string `json:"topics,omitempty"`
+	Tickets []string `json:"tickets,omitempty"`
+	Owners  []string `json:"owners,omitempty"`
+	Overlap int      `json:"overlap,omitempty"`
+}
+
+// Names of the files in a bundle archive.
+const (
+	bundleManifest     = "manifest.json"
+	bundleAttributions = "attributions.json"
+	bundleConfig       = "config.json"
+)
+
+// bundleSignals is the content of bundleAttributions.
+type bundleSignals struct {
+	Files   []BundleFile        `json:"files"`
+	Authors []BundleAuthor      `json:"authors"`
+	Owners  map[string][]string `json:"owners,omitempty"`
+}
+
+// captureBundle fills Bundle with the signals the candidates in 'final' were
+// ranked from.
+func (r *ContributionCounter) captureBundle(counts *contributions, final Stats,
+	paths []string, owners *ownership) {
+	b := r.Bundle
+	b.Base, b.Head, _ = r.branchTips()
+	b.Since, b.Now = r.Since, r.now().Format("2006-01-02")
+	b.Paths = append([]string{}, paths...)
+	b.Added = append([]string{}, r.added...)
+	b.Teams = r.Teams
+	b.Options = BundleOptions{
+		PerFile:         r.PerFile,
+		Diverse:         r.Diverse,
+		RecentDays:      r.RecentDays,
+		IncludeLearners: r.IncludeLearners,
+		OwnershipAlert:  r.OwnershipAlert,
+		Show:            r.Show,
+		Language:        r.Language,
+		OwnersPolicy:    r.ownersPolicy(),
+		Sort:            r.Sort,
+	}
+
+	b.Files = nil
+	for _, path := range paths {
+		b.Files = append(b.Files, BundleFile{
+			Path:    path,
+			Lines:   counts.fileLines[path],
+			Blamed:  counts.fileTotal[path],
+			Authors: counts.byFile[path],
+			Skipped: counts.skipped[path],
+		})
+		if w, ok := counts.weights[path]; ok {
+			b.Files[len(b.Files)-1].Weight = &w
+		}
+	}
+
+	b.Authors = nil
+	for _, s := range final {
+		b.Authors = append(b.Authors, BundleAuthor{
+			Author:      s.Reviewer,
+			LastTouched: counts.lastTouched[s.Reviewer],
+			Quarters:    counts.byQuarter[s.Reviewer],
+			Boost:       s.Boost,
+			Score:       s.Score,
+			Topics:      s.Topics,
+			Tickets:     s.Tickets,
+			Owners:      s.Owners,
+			Overlap:     s.Overlap,
+		})
+	}
+	sort.Slice(b.Authors, func(i, j int) bool {
+		return b.Authors[i].Author < b.Authors[j].Author
+	})
+
+	b.Owners = nil
+	if owners != nil {
+		b.Owners = owners.nearest
+	}
+}
+
+// contributions rebuilds the blame counts of the bundle.
+func (b *Bundle) contributions() *contributions {
+	c := newContributions()
+	for _, f := range b.Files {
+		c.byFile[f.Path] = make(map[string]int)
+		c.fileLines[f.Path] = f.Lines
+		c.fileTotal[f.Path] = f.Blamed
+		c.total += f.Blamed
+		if f.Skipped != "" {
+			c.skipped[f.Path] = f.Skipped
+		}
+		if f.Weight != nil {
+			c.weights[f.Path] = *f.Weight
+		}
+		for author, lines := range f.Authors {
+			c.byFile[f.Path][author] = lines
+			c.byAuthor[author] += lines
+		}
+	}
+
+	for _, a := range b.Authors {
+		if a.LastTouched != "" {
+			c.lastTouched[a.Author] = a.LastTouched
+		}
+		if a.Quarters != nil {
+			c.byQuarter[a.Author] = a.Quarters
+		}
+	}
+
+	return c
+}
+
+// Replay recomputes the suggestion captured in 'b' with the options and
+// settings it was made with, and formats it like FindReviewers does. It
+// needs no repository: signals that come from history, such as topics,
+// tickets, OWNERS files and learned scores, are taken from the bundle as they
+// were.
+func Replay(b *Bundle) (string, error) {
+	r, counts, _, topN, err := b.replay()
+	if err != nil {
+		return "", err
+	}
+
+	return r.formatSuggestions(topN, counts), nil
+}
+
+// replay picks reviewers out of the signals of the bundle like Replay does,
+// returning the counter it set up, the blame counts, every candidate and
+// the suggested reviewers.
+func (b *Bundle) replay() (*ContributionCounter, *contributions, Stats, Stats, error) {
+	r := &ContributionCounter{
+		Since:           b.Since,
+		Teams:           b.Teams,
+		PerFile:         b.Options.PerFile,
+		Diverse:         b.Options.Diverse,
+		RecentDays:      b.Options.RecentDays,
+		IncludeLearners: b.Options.IncludeLearners,
+		OwnershipAlert:  b.Options.OwnershipAlert,
+		Show:            b.Options.Show,
+		Language:        b.Options.Language,
+		Sort:            b.Options.Sort,
+		added:           b.Added,
+	}
+	r.ApplyConfig(&Config{Entries: b.Config})
+	r.OwnersPolicy = b.Options.OwnersPolicy
+
+	// Ownership is measured at the day the bundle was captured rather than
+	// today, as if AsOf had been given
+	r.AsOf = b.Now
+	r.asOfPoint = &asOfPoint{rev: b.Base, date: b.Now}
+
+	counts := b.contributions()
+	final := r.candidates(counts)
+	for _, a := range b.Authors {
+		if s := findStat(final, a.Author); s != nil {
+			s.Boost, s.Score = a.Boost, a.Score
+			s.Topics, s.Tickets, s.Owners = a.Topics, a.Tickets, a.Owners
+			s.Overlap = a.Overlap
+		}
+	}
+
+	var owners *ownership
+	if b.Owners != nil {
+		owners = &ownership{nearest: b.Owners}
+	}
+
+	topN, err := r.pick(final, counts, b.Paths, owners)
+	return r, counts, final, topN, err
+}
+
+// Write saves the bundle as a gzipped tar archive holding manifest.json, with
+// the revisions and options, attributions.json, with the signals, and
+// config.json, with the settings.
+func (b *Bundle) Write(w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	members := []struct {
+		name string
+		v    interface{}
+	}{
+		{bundleManifest, b},
+		{bundleAttributions, bundleSignals{Files: b.Files, Authors: b.Authors, Owners: b.Owners}},
+		{bundleConfig, b.Config},
+	}
+	for _, m := range members {
+		content, err := json.MarshalIndent(m.v, "", "  ")
+		if err != nil {
+			return errors.Wrapf(err, "unable to encode %s", m.name)
+		}
+		content = append(content, '\n')
+
+		hdr := &tar.Header{Name: m.name, Mode: 0644, Size: int64(len(content)),
+			ModTime: time.Now()}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return errors.Wrapf(err, "unable to write %s", m.name)
+		}
+		if _, err := tw.Write(content); err != nil {
+			return errors.Wrapf(err, "unable to write %s", m.name)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return errors.Wrap(err, "unable to write bundle")
+	}
+	return gz.Close()
+}
+
+// ReadBundle reads a bundle saved with Write.
+func ReadBundle(rd io.Reader) (*Bundle, error) {
+	gz, err := gzip.NewReader(rd)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read bundle")
+	}
+	defer gz.Close()
+
+	members := make(map[string][]byte)
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to read bundle")
+		}
+		if members[hdr.Name], err = ioutil.ReadAll(tr); err != nil {
+			return nil, errors.Wrapf(err, "unable to read %s", hdr.Name)
+		}
+	}
+
+	b := &Bundle{}
+	var signals bundleSignals
+	for _, m := range []struct {
+		name string
+		v    interface{}
+	}{
+		{bundleManifest, b},
+		{bundleAttributions, &signals},
+		{bundleConfig, &b.Config},
+	} {
+		content, ok := members[m.name]
+		if !ok {
+			return nil, errors.Errorf("bundle has no %s", m.name)
+		}
+		if err := json.Unmarshal(content, m.v); err != nil {
+			return nil, errors.Wrapf(err, "unable to decode %s", m.name)
+		}
+	}
+	b.Files, b.Authors, b.Owners = signals.Files, signals.Authors, signals.Owners
+
+	return b, nil
+}
@@ -0,0 +1,75 @@
+package gitreviewers
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func testBundle() *Bundle {
+	return &Bundle{
+		Version: "0.0.5",
+		Base:    "1111111111111111111111111111111111111111",
+		Head:    "2222222222222222222222222222222222222222",
+		Since:   "2017-01-01",
+		Now:     "2017-06-01",
+		Paths:   []string{"src/a.go", "src/b.go"},
+		Options: BundleOptions{Show: ShowPercent, Language: LanguageEnglish},
+		Files: []BundleFile{
+			{Path: "src/a.go", Lines: 10, Blamed: 10,
+				Authors: map[string]int{"abe@git-reviewer.com": 6, "ben@git-reviewer.com": 4}},
+			{Path: "src/b.go", Lines: 10, Blamed: 10,
+				Authors: map[string]int{"abe@git-reviewer.com": 2, "tom@git-reviewer.com": 8}},
+		},
+		Authors: []BundleAuthor{
+			{Author: "abe@git-reviewer.com", LastTouched: "2017-05-01"},
+			{Author: "ben@git-reviewer.com", LastTouched: "2017-05-01", Boost: 0.5,
+				Topics: []string{"cache"}},
+			{Author: "tom@git-reviewer.com", LastTouched: "2017-02-01"},
+		},
+		Config: []ConfigEntry{{Key: "reviewer.src.sensitivepath", Value: "src/b.go",
+			Origin: "file:.gitreviewer"}, {Key: "reviewer.src.mandatoryreviewer",
+			Value: "sec@git-reviewer.com", Origin: "file:.gitreviewer"}},
+	}
+}
+
+func TestBundleRoundTrip(t *testing.T) {
+	expected := testBundle()
+
+	var buf bytes.Buffer
+	if err := expected.Write(&buf); err != nil {
+		t.Fatalf("Unexpected error writing bundle: %v\n", err)
+	}
+
+	actual, err := ReadBundle(&buf)
+	if err != nil {
+		t.Fatalf("Unexpected error reading bundle: %v\n", err)
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %+v, expected %+v\n", actual, expected)
+	}
+
+	if _, err := ReadBundle(bytes.NewReader([]byte("not a bundle"))); err == nil {
+		t.Error("Expected an error reading something that isn't a bundle")
+	}
+}
+
+func TestReplay(t *testing.T) {
+	actual, err := Replay(testBundle())
+	if err != nil {
+		t.Fatalf("Unexpected error replaying bundle: %v\n", err)
+	}
+
+	// Ben's boost from topics ranks him first, and the sensitive path adds
+	// its mandatory reviewer
+	expected := "Reviewer\t\t\t\tExperience\n" +
+		"--------\t\t\t\t----------\n" +
+		"ben@git-reviewer.com (topics: cache)\t20.00%\n" +
+		"abe@git-reviewer.com\t\t\t40.00%\n" +
+		"tom@git-reviewer.com\t\t\t40.00%\n" +
+		"sec@git-reviewer.com (mandatory: src)\t0.00%\n"
+	if actual != expected {
+		t.Errorf("Got %q, expected %q\n", actual, expected)
+	}
+}
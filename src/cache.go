@@ -0,0 +1,154 @@
+package gitreviewers
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// suggestionKey computes the cache key for a set of reviewer suggestions. The
+// key combines the tip of the base branch, the tip of the branch under review,
+// and a hash of every option that could change the outcome of the
+// calculation, so any new commit or different flag produces a new entry.
+func (r *ContributionCounter) suggestionKey(base, head string, paths []string) string {
+	sorted := append([]string{}, paths...)
+	sort.Strings(sorted)
+
+	h := sha1.New()
+	fmt.Fprintf(h, "since:%s\n", r.Since)
+	fmt.Fprintf(h, "ignored-ext:%s\n", strings.Join(r.IgnoredExtensions, ","))
+	fmt.Fprintf(h, "only-ext:%s\n", strings.Join(r.OnlyExtensions, ","))
+	fmt.Fprintf(h, "ignored-paths:%s\n", strings.Join(r.IgnoredPaths, ","))
+	fmt.Fprintf(h, "only-paths:%s\n", strings.Join(r.OnlyPaths, ","))
+	fmt.Fprintf(h, "paths:%s\n", strings.Join(sorted, ","))
+	fmt.Fprintf(h, "added:%s\n", strings.Join(r.added, ","))
+	fmt.Fprintf(h, "diverse:%t\n", r.Diverse)
+	fmt.Fprintf(h, "recent-days:%d\n", r.RecentDays)
+	fmt.Fprintf(h, "learners:%t\n", r.IncludeLearners)
+	fmt.Fprintf(h, "ownership-alert:%f\n", r.OwnershipAlert)
+	fmt.Fprintf(h, "include-lfs:%t\n", r.IncludeLFS)
+	fmt.Fprintf(h, "initial-import:%t\n", r.InitialImport)
+	fmt.Fprintf(h, "show:%s\n", r.Show)
+	fmt.Fprintf(h, "include-added:%t\n", r.IncludeAdded)
+	fmt.Fprintf(h, "dir-weight:%f\n", r.dirWeight())
+	fmt.Fprintf(h, "ignore-merges:%t\n", r.IgnoreMerges)
+	fmt.Fprintf(h, "first-parent:%t\n", r.FirstParent)
+	fmt.Fprintf(h, "max-history:%s\n", r.historyStart)
+	fmt.Fprintf(h, "blame-at:%s\n", r.BlameAt)
+	fmt.Fprintf(h, "warn-takeovers:%t\n", r.WarnTakeovers)
+	fmt.Fprintf(h, "owners:%s\n", r.ownersPolicy())
+	if r.ownersPolicy() != OwnersOff {
+		fmt.Fprintf(h, "owner-emails:%v\n", r.OwnerEmails)
+	}
+	if p, err := r.asOf(); err == nil && p != nil {
+		fmt.Fprintf(h, "as-of:%s:%s\n", p.rev, p.date)
+	}
+	fmt.Fprintf(h, "exclude-lines:%s:%s\n", strings.Join(r.ExcludeLines, ","),
+		strings.Join(r.ExcludeLinePatterns, "\x00"))
+	fmt.Fprintf(h, "per-file:%t\n", r.PerFile)
+	fmt.Fprintf(h, "ranker:%s\n", r.Ranker)
+	fmt.Fprintf(h, "sort:%s\n", r.Sort)
+	fmt.Fprintf(h, "infra:%s:%s\n", strings.Join(r.InfraReviewers, ","),
+		strings.Join(r.InfraPaths, ","))
+	fmt.Fprintf(h, "language:%s\n", r.Language)
+	if r.Verbose {
+		// The trend moves on every quarter
+		quarter, _ := quarterOf(r.now().Format("2006-01-02"))
+		fmt.Fprintf(h, "trend:%d\n", quarter)
+	}
+	fmt.Fprintf(h, "max-file-size:%d\n", r.maxFileSize())
+	fmt.Fprintf(h, "topics:%t:%s\n", r.Topics, r.Title)
+	fmt.Fprintf(h, "tickets:%T:%v\n", r.Tickets, r.ticketPattern())
+	fmt.Fprintf(h, "availability:%t:%v\n", r.Availability, r.Timezones)
+
+	teams := make([]string, 0, len(r.Teams))
+	for email, team := range r.Teams {
+		teams = append(teams, email+"="+team)
+	}
+	sort.Strings(teams)
+	fmt.Fprintf(h, "teams:%s\n", strings.Join(teams, ","))
+
+	for _, rule := range r.SensitiveRules {
+		fmt.Fprintf(h, "sensitive:%s:%s:%s\n", rule.Name, strings.Join(rule.Paths, ","),
+			strings.Join(rule.Reviewers, ","))
+	}
+	for _, rule := range r.DefaultRules {
+		fmt.Fprintf(h, "default:%s:%s:%s\n", rule.Name, strings.Join(rule.Paths, ","),
+			strings.Join(rule.Reviewers, ","))
+	}
+
+	mmKeys := make([]string, 0, len(r.Mailmap))
+	for k, v := range r.Mailmap {
+		mmKeys = append(mmKeys, k+"="+v)
+	}
+	sort.Strings(mmKeys)
+	fmt.Fprintf(h, "mailmap:%s\n", strings.Join(mmKeys, ","))
+
+	return fmt.Sprintf("%s-%s-%x", base, head, h.Sum(nil))
+}
+
+// cache returns the cache results are stored in: Cache if it is set, a
+// FileCache in CacheDir otherwise, or nil if caching is disabled.
+func (r *ContributionCounter) cache() Cache {
+	if r.Cache != nil {
+		return r.Cache
+	}
+	if r.CacheDir != "" {
+		return NewFileCache(r.CacheDir)
+	}
+	return nil
+}
+
+// cacheResults reports whether suggestions are cached, see CacheResults.
+func (r *ContributionCounter) cacheResults() bool {
+	return r.CacheResults && r.cache() != nil
+}
+
+// readCachedSuggestion looks up a previously computed suggestion in the
+// cache. It reports false if caching is disabled or nothing was stored under
+// the key.
+func (r *ContributionCounter) readCachedSuggestion(key string) (string, bool) {
+	c := r.cache()
+	if c == nil {
+		return "", false
+	}
+
+	b, ok, err := c.Get("suggestions/" + key)
+	if err != nil {
+		r.logf("Unable to read cached suggestions: %v\n", err)
+	}
+	if !ok {
+		return "", false
+	}
+
+	return string(b), true
+}
+
+// writeCachedSuggestion stores a computed suggestion in the cache. Caching is
+// an optimization, so failures are only reported in verbose mode.
+func (r *ContributionCounter) writeCachedSuggestion(key, val string) {
+	c := r.cache()
+	if c == nil {
+		return
+	}
+
+	if err := c.Set("suggestions/"+key, []byte(val), suggestionTTL); err != nil {
+		r.logf("Unable to cache suggestions: %v\n", err)
+	}
+}
+
+// branchTips resolves the commit hashes of the base and head revisions.
+func (r *ContributionCounter) branchTips() (string, string, error) {
+	m, err := r.resolve(r.baseRev())
+	if err != nil {
+		return "", "", err
+	}
+
+	h, err := r.resolve(r.headRev())
+	if err != nil {
+		return "", "", err
+	}
+
+	return m.String(), h.String(), nil
+}
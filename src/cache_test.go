@@ -0,0 +1,172 @@
+package gitreviewers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSuggestionKey(t *testing.T) {
+	r := &ContributionCounter{Since: "2017-01-01"}
+	paths := []string{"b.go", "a.go"}
+
+	key := r.suggestionKey("base", "head", paths)
+	if again := r.suggestionKey("base", "head", []string{"a.go", "b.go"}); again != key {
+		t.Errorf("Expected path order not to change the key, got '%s' and '%s'\n",
+			key, again)
+	}
+
+	if k := r.suggestionKey("base", "newhead", paths); k == key {
+		t.Error("Expected a new head commit to change the key")
+	}
+
+	if k := r.suggestionKey("newbase", "head", paths); k == key {
+		t.Error("Expected a new base commit to change the key")
+	}
+
+	r.OnlyExtensions = []string{"go"}
+	if k := r.suggestionKey("base", "head", paths); k == key {
+		t.Error("Expected different options to change the key")
+	}
+}
+
+func TestCachedSuggestionRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "git-reviewer-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	r := &ContributionCounter{CacheDir: dir}
+	if _, ok := r.readCachedSuggestion("missing"); ok {
+		t.Error("Expected a miss for a key that was never written")
+	}
+
+	r.writeCachedSuggestion("key", "reviewers")
+	if val, ok := r.readCachedSuggestion("key"); !ok || val != "reviewers" {
+		t.Errorf("Got '%s' (found: %t), expected 'reviewers'\n", val, ok)
+	}
+
+	r.CacheDir = ""
+	if _, ok := r.readCachedSuggestion("key"); ok {
+		t.Error("Expected caching to be disabled without a cache directory")
+	}
+}
+
+func TestCaches(t *testing.T) {
+	dir, err := ioutil.TempDir("", "git-reviewer-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	caches := map[string]Cache{
+		"file":   NewFileCache(dir),
+		"memory": NewMemoryCache(),
+	}
+
+	for name, c := range caches {
+		if _, ok, err := c.Get("suggestions/missing"); ok || err != nil {
+			t.Errorf("%s: got a hit (error: %v), expected a miss\n", name, err)
+		}
+
+		if err := c.Set("suggestions/key", []byte("reviewers"), 0); err != nil {
+			t.Fatal(err)
+		}
+		if val, ok, err := c.Get("suggestions/key"); !ok || err != nil ||
+			string(val) != "reviewers" {
+			t.Errorf("%s: got '%s' (found: %t, error: %v), expected 'reviewers'\n",
+				name, val, ok, err)
+		}
+
+		if err := c.Set("suggestions/old", []byte("reviewers"), time.Nanosecond); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+		if _, ok, _ := c.Get("suggestions/old"); ok {
+			t.Errorf("%s: expected an expired entry to be a miss\n", name)
+		}
+	}
+
+	// Entries written before values carried an expiry are misses
+	if err := ioutil.WriteFile(filepath.Join(dir, "suggestions", "legacy"),
+		[]byte("reviewers"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := caches["file"].Get("suggestions/legacy"); ok {
+		t.Error("Expected an entry without an expiry line to be a miss")
+	}
+}
+
+func TestFileCacheWritable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "git-reviewer-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := NewFileCache(filepath.Join(dir, "new")).Writable(); err != nil {
+		t.Errorf("Unexpected error: %v\n", err)
+	}
+	if files, _ := ioutil.ReadDir(filepath.Join(dir, "new")); len(files) != 0 {
+		t.Errorf("Got %d files, expected the probe to be removed\n", len(files))
+	}
+
+	// A file stands where the directory should be
+	if err := ioutil.WriteFile(filepath.Join(dir, "file"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewFileCache(filepath.Join(dir, "file", "cache")).Writable(); err == nil {
+		t.Error("Expected an error creating a directory under a file")
+	}
+}
+
+func TestCachedSuggestionInCache(t *testing.T) {
+	c := NewMemoryCache()
+	r := &ContributionCounter{Cache: c, CacheDir: "/nonexistent"}
+
+	r.writeCachedSuggestion("key", "reviewers")
+	if val, ok, _ := c.Get("suggestions/key"); !ok || string(val) != "reviewers" {
+		t.Errorf("Got '%s' (found: %t), expected Cache to win over CacheDir\n", val, ok)
+	}
+}
+
+func TestFindReviewersCachesOnlyWhenAsked(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	runGit(t, dir, "checkout", "-q", "-b", "feature")
+	if err := ioutil.WriteFile(filepath.Join(dir, "src", "a.go"),
+		[]byte("package a\n\nvar x = 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "commit", "-q", "-a", "--author", "Ben Franklin <ben@git-reviewer.com>",
+		"-m", "Add x")
+
+	repo, _, err := OpenRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, cacheResults := range []bool{false, true} {
+		r := &ContributionCounter{Repo: repo, Dir: dir, Since: "2000-01-01", Head: "feature",
+			Cache: NewMemoryCache(), CacheResults: cacheResults}
+		paths, err := r.FindFiles()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.FindReviewers(paths); err != nil {
+			t.Fatal(err)
+		}
+
+		base, head, err := r.branchTips()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := r.readCachedSuggestion(r.suggestionKey(base, head, paths)); ok != cacheResults {
+			t.Errorf("Got cached: %t with CacheResults %t, expected the same\n", ok, cacheResults)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package gitreviewers
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// suggestionTTL is how long suggestions stay cached. Keys already change with
+// every new commit, so this only keeps stale entries from piling up.
+const suggestionTTL = 30 * 24 * time.Hour
+
+// Cache stores results that are expensive to compute, such as reviewer
+// suggestions, so running again with the same commits and options is instant.
+// Keys are slash-separated, like "suggestions/<hash>". A TTL of 0 keeps a value
+// until it is overwritten.
+//
+// FileCache and MemoryCache are built in. Shared deployments can plug in a
+// store such as Redis by implementing the interface.
+type Cache interface {
+	// Get returns the value stored under 'key', or false if there is none or
+	// it expired.
+	Get(key string) ([]byte, bool, error)
+	// Set stores 'val' under 'key' for 'ttl'.
+	Set(key string, val []byte, ttl time.Duration) error
+}
+
+// FileCache stores each value in a file under Dir, preceded by a line holding
+// its expiry time. Files are replaced at once, so concurrent runs never read
+// a value that is only partly written; the last one to write a key wins.
+type FileCache struct {
+	Dir string
+}
+
+// NewFileCache builds a cache that stores values in 'dir'. The directory is
+// created on the first Set.
+func NewFileCache(dir string) *FileCache {
+	return &FileCache{Dir: dir}
+}
+
+// Get reads the value stored under 'key'. Files that are missing, expired or
+// written by an older version without an expiry line are all misses.
+func (c *FileCache) Get(key string) ([]byte, bool, error) {
+	b, err := ioutil.ReadFile(c.path(key))
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, errors.Wrap(err, "unable to read cache file")
+	}
+
+	nl := bytes.IndexByte(b, '\n')
+	if nl < 0 {
+		return nil, false, nil
+	}
+	expires, err := strconv.ParseInt(string(b[:nl]), 10, 64)
+	if err != nil {
+		return nil, false, nil
+	}
+	if expires != 0 && time.Now().UnixNano() >= expires {
+		return nil, false, nil
+	}
+
+	return b[nl+1:], true, nil
+}
+
+// Set writes 'val' to the file for 'key', replacing it at once.
+func (c *FileCache) Set(key string, val []byte, ttl time.Duration) error {
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrap(err, "unable to create cache directory")
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%d\n", expiry(ttl))
+	buf.Write(val)
+
+	return errors.Wrap(writeFileAtomic(path, buf.Bytes(), 0644),
+		"unable to write cache file")
+}
+
+// Writable checks that values can be written to Dir, creating it if needed.
+func (c *FileCache) Writable() error {
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return errors.Wrap(err, "unable to create cache directory")
+	}
+
+	f, err := ioutil.TempFile(c.Dir, ".probe")
+	if err != nil {
+		return errors.Wrap(err, "unable to write to cache directory")
+	}
+	f.Close()
+
+	return errors.Wrap(os.Remove(f.Name()), "unable to clean up cache directory")
+}
+
+func (c *FileCache) path(key string) string {
+	return filepath.Join(c.Dir, filepath.FromSlash(key))
+}
+
+// MemoryCache keeps values in memory, which suits long-running processes and
+// tests. It is safe for concurrent use.
+type MemoryCache struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+}
+
+type memoryEntry struct {
+	val     []byte
+	expires int64
+}
+
+// NewMemoryCache builds an empty in-memory cache.
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: make(map[string]memoryEntry)}
+}
+
+// Get returns the value stored under 'key', dropping it if it expired.
+func (c *MemoryCache) Get(key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if e.expires != 0 && time.Now().UnixNano() >= e.expires {
+		delete(c.entries, key)
+		return nil, false, nil
+	}
+
+	return e.val, true, nil
+}
+
+// Set stores a copy of 'val' under 'key'.
+func (c *MemoryCache) Set(key string, val []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = memoryEntry{
+		val:     append([]byte{}, val...),
+		expires: expiry(ttl),
+	}
+
+	return nil
+}
+
+// expiry turns a TTL into a Unix time in nanoseconds, or 0 for values that
+// never expire.
+func expiry(ttl time.Duration) int64 {
+	if ttl <= 0 {
+		return 0
+	}
+	return time.Now().Add(ttl).UnixNano()
+}
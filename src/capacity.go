@@ -0,0 +1,58 @@
+package gitreviewers
+
+import "strings"
+
+// capacity looks up how many reviews 'email' takes on at a time in
+// Capacities.
+func (r *ContributionCounter) capacity(email string) (int, bool) {
+	n, ok := r.Capacities[strings.ToLower(email)]
+	return n, ok
+}
+
+// atCapacity reports whether 'email' has as many open reviews as their
+// capacity, or more. People without a capacity, or whose open reviews can't
+// be counted, never are.
+func (r *ContributionCounter) atCapacity(email string) bool {
+	limit, ok := r.capacity(email)
+	if !ok || r.OpenReviews == nil {
+		return false
+	}
+
+	open, err := r.OpenReviews(email)
+	if err != nil {
+		r.logf("Unable to count the open reviews of %s: %v\n", email, err)
+		return false
+	}
+	return open >= limit
+}
+
+// balanceLoad leaves the candidates at capacity out of 'candidates', see
+// BalanceLoad. Candidates who make the top of the list in place of someone at
+// capacity note who they stand in for. Counting open reviews asks the
+// provider, so it stops once the top of the list is filled, and candidates
+// below it are kept unchecked.
+func (r *ContributionCounter) balanceLoad(candidates Stats) Stats {
+	var (
+		kept Stats
+		// full lists who would have been suggested but are at capacity, in
+		// the order they are replaced
+		full []string
+	)
+	for i, c := range chooseTopN(len(candidates), candidates) {
+		if len(kept) < maxReviewers && r.atCapacity(c.Reviewer) {
+			if i < maxReviewers {
+				full = append(full, c.Reviewer)
+			}
+			continue
+		}
+
+		if i >= maxReviewers && len(kept) < maxReviewers && len(full) > 0 {
+			substitute := *c
+			substitute.Substitutes, full = full[0], full[1:]
+			c = &substitute
+		}
+		kept = append(kept, c)
+	}
+
+	return kept
+}
@@ -0,0 +1,118 @@
+package gitreviewers
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestBalanceLoad(t *testing.T) {
+	c := testContributions()
+
+	open := map[string]int{"abe@git-reviewer.com": 2, "george@git-reviewer.com": 9,
+		"ben@git-reviewer.com": 0}
+	r := &ContributionCounter{BalanceLoad: true}
+	r.ApplyConfig(&Config{Entries: []ConfigEntry{
+		{"reviewer.capacity", "ABE@git-reviewer.com=2, ben@git-reviewer.com=1", "file:.gitreviewer"},
+		{"reviewer.capacity", "tom@git-reviewer.com=lots", "file:.gitreviewer"},
+	}})
+	r.OpenReviews = func(email string) (int, error) {
+		if email == "tom@git-reviewer.com" {
+			t.Errorf("Expected open reviews not to be counted for %s without a capacity\n", email)
+		}
+		return open[email], nil
+	}
+
+	capacities := map[string]int{"abe@git-reviewer.com": 2, "ben@git-reviewer.com": 1}
+	if !reflect.DeepEqual(r.Capacities, capacities) {
+		t.Errorf("Got capacities %v, expected %v\n", r.Capacities, capacities)
+	}
+	err := r.Validate()
+	if errs, ok := err.(ValidationErrors); !ok || len(errs) != 1 ||
+		errs[0].Problem != "'tom@git-reviewer.com=lots' is not an email and a number of reviews" {
+		t.Errorf("Got %v, expected a problem with tom's capacity\n", err)
+	}
+
+	// Abe is at capacity, and George has no cap however many reviews they have
+	top := r.selectReviewers(statsFor(c), c)
+	var actual []string
+	for _, s := range top {
+		actual = append(actual, s.Reviewer+s.Notes())
+	}
+	expected := []string{"george@git-reviewer.com", "ben@git-reviewer.com",
+		"tom@git-reviewer.com (instead of abe@git-reviewer.com, at capacity)"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %v, expected %v\n", actual, expected)
+	}
+
+	// Without counts, nobody is left out
+	r.OpenReviews = func(email string) (int, error) {
+		return 0, errors.New("offline")
+	}
+	if top := r.selectReviewers(statsFor(c), c); top[0].Reviewer != "abe@git-reviewer.com" ||
+		top[0].Substitutes != "" {
+		t.Errorf("Got %+v, expected abe@git-reviewer.com first when reviews can't be counted\n", top[0])
+	}
+
+	// Once the top of the list is filled, nobody else is counted
+	var counted []string
+	r.Capacities["tom@git-reviewer.com"] = 5
+	r.Capacities["george@git-reviewer.com"] = 5
+	r.OpenReviews = func(email string) (int, error) {
+		counted = append(counted, email)
+		return 0, nil
+	}
+	r.selectReviewers(statsFor(c), c)
+	expected = []string{"abe@git-reviewer.com", "george@git-reviewer.com", "ben@git-reviewer.com"}
+	if !reflect.DeepEqual(counted, expected) {
+		t.Errorf("Got open reviews counted for %v, expected %v\n", counted, expected)
+	}
+}
+
+// loadProvider is a ReviewProvider counting open reviews from a map.
+type loadProvider struct {
+	accounts map[string]Account
+	open     map[string]int
+}
+
+func (p loadProvider) User(email string) (Account, error) {
+	return p.accounts[email], nil
+}
+
+func (p loadProvider) PullRequest(branch string) (*PullRequest, error) {
+	return nil, nil
+}
+
+func (p loadProvider) AddReviewers(pr *PullRequest, accounts []Account) error {
+	return nil
+}
+
+func (p loadProvider) OpenReviews(account Account) (int, error) {
+	return p.open[account.ID], nil
+}
+
+func TestOpenReviewsOf(t *testing.T) {
+	p := loadProvider{accounts: map[string]Account{"abe@git-reviewer.com": {ID: "1", Name: "abe"}},
+		open: map[string]int{"1": 4}}
+
+	ids, err := LoadIdentityCache("", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, provider := range []ReviewProvider{p, CachedProvider(p, ids, "git.example.com")} {
+		count := OpenReviewsOf(provider)
+		if count == nil {
+			t.Fatalf("Expected %T to count open reviews\n", provider)
+		}
+		if n, err := count("abe@git-reviewer.com"); err != nil || n != 4 {
+			t.Errorf("Got %d (error: %v), expected 4 open reviews for abe\n", n, err)
+		}
+		if n, err := count("ben@git-reviewer.com"); err != nil || n != 0 {
+			t.Errorf("Got %d (error: %v), expected none for ben, who has no account\n", n, err)
+		}
+	}
+
+	if count := OpenReviewsOf(&Gerrit{}); count == nil {
+		t.Error("Expected Gerrit to count open reviews")
+	}
+}
@@ -0,0 +1,129 @@
+package gitreviewers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/utils/diff"
+)
+
+// Kinds of changes a ChangedFile can have.
+const (
+	ChangeAdded    = "added"
+	ChangeModified = "modified"
+	ChangeDeleted  = "deleted"
+	ChangeRenamed  = "renamed"
+)
+
+// ChangedFile describes a file changed on the branch, to tell which files
+// suggestions are made from and why the others are left out.
+type ChangedFile struct {
+	Path string `json:"path"`
+	// From is the name the file had in the base revision, if it was renamed.
+	From string `json:"from,omitempty"`
+	// Change is ChangeAdded, ChangeModified, ChangeDeleted or ChangeRenamed.
+	Change string `json:"change"`
+	// Added and Removed count the lines added and removed, which aren't
+	// counted for binary files.
+	Added   int  `json:"added"`
+	Removed int  `json:"removed"`
+	Binary  bool `json:"binary,omitempty"`
+	// Excluded is why the file is left out of suggestions, such as
+	// "extension", "path", "moved", "lfs" or "binary", or "added" for added
+	// files with no history to blame. It is empty if the file is considered.
+	Excluded string `json:"excluded,omitempty"`
+	// Relatives are the existing files considered in place of an added file,
+	// see IncludeAdded.
+	Relatives []string `json:"relatives,omitempty"`
+}
+
+// ChangedFiles lists every file the last call to FindFiles found changed,
+// including those it left out, with how it changed and why it is left out if
+// it is. Files are sorted by path.
+func (r *ContributionCounter) ChangedFiles() ([]ChangedFile, error) {
+	var (
+		base, head *object.Tree
+		rg         runGuard
+	)
+	rg.maybeRunMany(
+		func() { base, rg.err = r.treeAt(r.baseRev()) },
+		func() { head, rg.err = r.treeAt(r.headRev()) },
+	)
+	if rg.err != nil {
+		return nil, rg.err
+	}
+
+	files := append([]ChangedFile{}, r.changes...)
+	for i := range files {
+		f := &files[i]
+
+		from := f.From
+		if from == "" {
+			from = f.Path
+		}
+		// Files missing on either side are compared with nothing
+		before, _ := treeFile(base, from)
+		after, _ := treeFile(head, f.Path)
+		// Uncommitted changes count too with WorkingTree
+		if r.WorkingTree {
+			b, err := ioutil.ReadFile(filepath.Join(r.Dir, f.Path))
+			if err != nil && !os.IsNotExist(err) {
+				return nil, errors.Wrapf(err, "unable to read %s", f.Path)
+			}
+			after = string(b)
+		}
+
+		if isBinaryContent(before) || isBinaryContent(after) {
+			f.Binary = true
+			continue
+		}
+		f.Added, f.Removed = countChangedLines(before, after)
+	}
+
+	return files, nil
+}
+
+// treeAt opens the tree of the commit 'rev' names.
+func (r *ContributionCounter) treeAt(rev string) (*object.Tree, error) {
+	h, err := r.resolve(rev)
+	if err != nil {
+		return nil, errors.Wrapf(err, "issue resolving %s", rev)
+	}
+	c, err := r.Repo.CommitObject(h)
+	if err != nil {
+		return nil, errors.Wrapf(err, "issue opening commit %s", rev)
+	}
+	return c.Tree()
+}
+
+// isBinaryContent checks for a null byte in the first 8000 bytes, like git.
+func isBinaryContent(content string) bool {
+	if len(content) > 8000 {
+		content = content[:8000]
+	}
+	return strings.IndexByte(content, 0) >= 0
+}
+
+// countChangedLines counts the lines added and removed to turn 'before' into
+// 'after'.
+func countChangedLines(before, after string) (added, removed int) {
+	for _, d := range diff.Do(before, after) {
+		lines := strings.Count(d.Text, "\n")
+		if !strings.HasSuffix(d.Text, "\n") && d.Text != "" {
+			lines++
+		}
+
+		switch d.Type {
+		case diffmatchpatch.DiffInsert:
+			added += lines
+		case diffmatchpatch.DiffDelete:
+			removed += lines
+		}
+	}
+	return added, removed
+}
@@ -0,0 +1,134 @@
+package gitreviewers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCountChangedLines(t *testing.T) {
+	cases := []struct {
+		before, after  string
+		added, removed int
+	}{
+		{"", "a\nb\n", 2, 0},
+		{"a\nb\n", "", 0, 2},
+		{"a\nb\nc\n", "a\nB\nc\nd", 2, 1},
+		{"a\n", "a\n", 0, 0},
+	}
+
+	for _, c := range cases {
+		if added, removed := countChangedLines(c.before, c.after); added != c.added ||
+			removed != c.removed {
+			t.Errorf("Got +%d -%d for %q to %q, expected +%d -%d\n", added, removed,
+				c.before, c.after, c.added, c.removed)
+		}
+	}
+}
+
+func TestChangedFiles(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	write := func(name, content string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("src/b.go", "package a\n\nfunc b() {}\n")
+	write("src/old.go", "package a\n\nfunc old() {}\n")
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-q", "-m", "Add b and old")
+
+	runGit(t, dir, "checkout", "-q", "-b", "feature")
+	write("src/a.go", "package a\n\nvar x = 1\n")
+	write("data.json", "{}\n")
+	write("src/new.go", "package a\n\nfunc new() {}\n")
+	runGit(t, dir, "rm", "-q", "src/b.go")
+	runGit(t, dir, "mv", "src/old.go", "src/older.go")
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-q", "-m", "Change everything",
+		"--author", "Ben Franklin <ben@git-reviewer.com>")
+
+	repo, _, err := OpenRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		includeAdded bool
+		expected     []ChangedFile
+	}{
+		// Added files have nothing to blame, unless their relatives stand in
+		{false, []ChangedFile{
+			{Path: "data.json", Change: ChangeAdded, Added: 1, Excluded: "extension"},
+			{Path: "src/a.go", Change: ChangeModified, Added: 2},
+			{Path: "src/b.go", Change: ChangeDeleted, Removed: 3},
+			{Path: "src/new.go", Change: ChangeAdded, Added: 3, Excluded: "added"},
+			{Path: "src/older.go", From: "src/old.go", Change: ChangeRenamed},
+		}},
+		{true, []ChangedFile{
+			{Path: "data.json", Change: ChangeAdded, Added: 1, Excluded: "extension"},
+			{Path: "src/a.go", Change: ChangeModified, Added: 2},
+			{Path: "src/b.go", Change: ChangeDeleted, Removed: 3},
+			{Path: "src/new.go", Change: ChangeAdded, Added: 3,
+				Relatives: []string{"src/a.go", "src/b.go"}},
+			{Path: "src/older.go", From: "src/old.go", Change: ChangeRenamed},
+		}},
+	}
+
+	for _, c := range cases {
+		r := ContributionCounter{Repo: repo, Dir: dir, Since: "2000-01-01",
+			IncludeAdded: c.includeAdded}
+		if _, err := r.FindFiles(); err != nil {
+			t.Fatal(err)
+		}
+
+		files, err := r.ChangedFiles()
+		if err != nil {
+			t.Fatalf("Unexpected error listing changed files: %v\n", err)
+		}
+		if !reflect.DeepEqual(files, c.expected) {
+			t.Errorf("Got %+v with IncludeAdded %t, expected %+v\n", files, c.includeAdded,
+				c.expected)
+		}
+	}
+}
+
+func TestChangedFilesWorkingTree(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "src", "a.go"),
+		[]byte("package a\n\nvar x = 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "src", "a_test.go"),
+		[]byte("package a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, _, err := OpenRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := ContributionCounter{Repo: repo, Dir: dir, Since: "2000-01-01", WorkingTree: true,
+		IncludeUntracked: true}
+	if _, err := r.FindFiles(); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := r.ChangedFiles()
+	if err != nil {
+		t.Fatalf("Unexpected error listing changed files: %v\n", err)
+	}
+	expected := []ChangedFile{
+		{Path: "src/a.go", Change: ChangeModified, Added: 2},
+		{Path: "src/a_test.go", Change: ChangeAdded, Added: 1, Relatives: []string{"src/a.go"}},
+	}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("Got %+v, expected %+v\n", files, expected)
+	}
+}
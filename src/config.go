@@ -0,0 +1,308 @@
+package gitreviewers
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ConfigFile is the file at the root of a repository that holds settings
+// shared by everyone working on it. It uses git config syntax, with settings
+// under a [reviewer] section:
+//
+//	[reviewer]
+//		defaultIgnoreExtension = svg, nock
+//
+// The same settings can be made in any git config file, such as
+// ~/.gitconfig, which take precedence over the repository's file.
+const ConfigFile = ".gitreviewer"
+
+// configSection is the git config section settings are read from.
+const configSection = "reviewer."
+
+// ConfigEntry is a single setting along with where it was read from.
+type ConfigEntry struct {
+	// Key is the lower-case name of the setting, such as
+	// "reviewer.defaultignoreextension".
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	// Origin names the file the setting came from, like git config
+	// --show-origin does.
+	Origin string `json:"origin"`
+}
+
+// Config holds settings read from configuration files, in the order they
+// were read so later entries override earlier ones.
+type Config struct {
+	Entries []ConfigEntry
+}
+
+// LoadConfig reads the settings for the repository in Dir: first ConfigFile
+// at its root, then the system, global and repository git config files.
+//
+// With NoExec, only ConfigFile and the repository's config file are read,
+// with go-git.
+func (r *ContributionCounter) LoadConfig() (*Config, error) {
+	if r.NoExec {
+		return r.loadConfigGoGit()
+	}
+
+	c := &Config{}
+
+	file := filepath.Join(r.Dir, ConfigFile)
+	if _, err := os.Stat(file); err == nil {
+		out, err := r.output("config", "--file", file, "--show-origin", "-z", "--list")
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read %s", ConfigFile)
+		}
+		c.Entries = append(c.Entries, parseConfig(out)...)
+	}
+
+	out, err := r.output("config", "--show-origin", "-z", "--list")
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read git config")
+	}
+	c.Entries = append(c.Entries, parseConfig(out)...)
+
+	return c, nil
+}
+
+// parseConfig reads the settings in the reviewer section out of the output
+// of `git config --show-origin -z --list`, where each entry is an origin and
+// a key, optionally followed by a newline and a value, each ending in a NUL.
+func parseConfig(out []byte) []ConfigEntry {
+	var entries []ConfigEntry
+
+	fields := bytes.Split(out, []byte{0})
+	for i := 0; i+1 < len(fields); i += 2 {
+		origin, kv := string(fields[i]), string(fields[i+1])
+
+		key, value := kv, "true"
+		if nl := strings.IndexByte(kv, '\n'); nl >= 0 {
+			key, value = kv[:nl], kv[nl+1:]
+		}
+
+		if strings.HasPrefix(key, configSection) {
+			entries = append(entries, ConfigEntry{Key: key, Value: value, Origin: origin})
+		}
+	}
+
+	return entries
+}
+
+// Get returns the last value of a setting, such as "reviewer.since".
+func (c *Config) Get(key string) (string, bool) {
+	values := c.GetAll(key)
+	if len(values) == 0 {
+		return "", false
+	}
+	return values[len(values)-1], true
+}
+
+// GetAll returns every value of a multi-valued setting. An empty value
+// clears the values read before it, so a repository's list can be reset in a
+// personal config file. The result is empty rather than nil if the setting
+// was cleared and never set again.
+func (c *Config) GetAll(key string) []string {
+	if c == nil {
+		return nil
+	}
+
+	var values []string
+	for _, e := range c.Entries {
+		if !strings.EqualFold(e.Key, key) {
+			continue
+		}
+
+		if e.Value == "" {
+			values = []string{}
+			continue
+		}
+		values = append(values, e.Value)
+	}
+
+	return values
+}
+
+// GetBool reads a boolean setting the way git does, where "true", "yes", "on"
+// and "1" are true. Settings that aren't set are false.
+func (c *Config) GetBool(key string) bool {
+	v, _ := c.Get(key)
+	switch strings.ToLower(v) {
+	case "true", "yes", "on", "1":
+		return true
+	}
+	return false
+}
+
+// ApplyConfig copies the settings in 'c' to the counter. Settings the
+// command line also has flags for are left to the caller, which knows
+// whether a flag was given.
+func (r *ContributionCounter) ApplyConfig(c *Config) {
+	if exts := c.GetAll("reviewer.defaultIgnoreExtension"); exts != nil {
+		r.DefaultIgnoredExtensions = []string{}
+		for _, v := range exts {
+			r.DefaultIgnoredExtensions = append(r.DefaultIgnoredExtensions,
+				splitList(v)...)
+		}
+	}
+
+	r.SensitiveRules = append(r.SensitiveRules, c.sensitiveRules()...)
+	r.DefaultRules = append(r.DefaultRules, c.defaultRules()...)
+	r.CustomPresets = append(r.CustomPresets, c.customPresets()...)
+
+	for _, v := range c.GetAll("reviewer.infraReviewer") {
+		r.InfraReviewers = append(r.InfraReviewers, splitList(v)...)
+	}
+	for _, v := range c.GetAll("reviewer.infraPath") {
+		r.InfraPaths = append(r.InfraPaths, splitList(v)...)
+	}
+
+	for _, v := range c.GetAll("reviewer.excludeLines") {
+		r.ExcludeLines = append(r.ExcludeLines, splitList(v)...)
+	}
+	if v, ok := c.Get("reviewer.owners"); ok {
+		r.OwnersPolicy = strings.ToLower(v)
+	}
+	r.ExcludeLinePatterns = append(r.ExcludeLinePatterns, c.GetAll("reviewer.excludeLinePattern")...)
+
+	for _, v := range c.GetAll("reviewer.timezone") {
+		for _, mapping := range splitList(v) {
+			parts := strings.SplitN(mapping, "=", 2)
+			if len(parts) != 2 {
+				continue
+			}
+			if r.Timezones == nil {
+				r.Timezones = make(map[string]string)
+			}
+			r.Timezones[strings.ToLower(parts[0])] = parts[1]
+		}
+	}
+
+	for _, v := range c.GetAll("reviewer.capacity") {
+		for _, mapping := range splitList(v) {
+			parts := strings.SplitN(mapping, "=", 2)
+			if len(parts) != 2 {
+				r.badCapacities = append(r.badCapacities, mapping)
+				continue
+			}
+			n, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+			if err != nil || n < 0 {
+				r.badCapacities = append(r.badCapacities, mapping)
+				continue
+			}
+			if r.Capacities == nil {
+				r.Capacities = make(map[string]int)
+			}
+			r.Capacities[strings.ToLower(parts[0])] = n
+		}
+	}
+
+	for _, v := range c.GetAll("reviewer.providerHost") {
+		for _, mapping := range splitList(v) {
+			parts := strings.SplitN(mapping, "=", 2)
+			if len(parts) != 2 {
+				continue
+			}
+			if r.ProviderHosts == nil {
+				r.ProviderHosts = make(map[string]string)
+			}
+			r.ProviderHosts[strings.ToLower(parts[0])] = strings.ToLower(parts[1])
+		}
+	}
+}
+
+// splitList splits a setting listing several values separated by commas or
+// spaces, like the filter flags.
+func splitList(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+}
+
+// Setting is one value of a setting in effect and where it came from.
+// Multi-valued settings have a Setting for each of their values.
+type Setting struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// SourceDefault is the Source of settings nobody set.
+const SourceDefault = "default"
+
+// multiValued reports whether every value of the setting 'key' is used
+// rather than only the last one.
+func multiValued(key string) bool {
+	switch strings.ToLower(key) {
+	case "reviewer.defaultignoreextension", "reviewer.excludelines",
+		"reviewer.excludelinepattern", "reviewer.providerhost", "reviewer.infrareviewer",
+		"reviewer.infrapath", "reviewer.timezone",
+		"reviewer.capacity":
+		return true
+	}
+	for _, suffix := range []string{".sensitivepath", ".mandatoryreviewer", ".defaultpath",
+		".defaultreviewer", ".presetignoreextension", ".presetignorepath",
+		".presetonlyextension", ".presetonlypath"} {
+		if strings.HasSuffix(key, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// configDefaults are the values settings take when they aren't set.
+var configDefaults = []Setting{
+	{Key: "reviewer.defaultignoreextension", Value: strings.Join(defaultIgnoreExt, ", ")},
+	{Key: "reviewer.owners", Value: OwnersOff},
+	{Key: "reviewer.ownershipalert", Value: "10"},
+	{Key: "reviewer.prepushblock", Value: "false"},
+}
+
+// Effective lists the settings in effect, sorted by key, after later entries
+// override earlier ones and the defaults. Multi-valued settings keep every
+// value since they were last cleared, or the empty value that cleared them.
+func (c *Config) Effective() []Setting {
+	values := make(map[string][]Setting)
+	for _, d := range configDefaults {
+		d.Source = SourceDefault
+		values[d.Key] = []Setting{d}
+	}
+
+	var entries []ConfigEntry
+	if c != nil {
+		entries = c.Entries
+	}
+	for _, e := range entries {
+		key := strings.ToLower(e.Key)
+		s := Setting{Key: key, Value: e.Value, Source: e.Origin}
+
+		current := values[key]
+		switch {
+		case !multiValued(key):
+			values[key] = []Setting{s}
+		case e.Value == "" || len(current) == 1 &&
+			(current[0].Source == SourceDefault || current[0].Value == ""):
+			values[key] = []Setting{s}
+		default:
+			values[key] = append(current, s)
+		}
+	}
+
+	var keys []string
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var settings []Setting
+	for _, k := range keys {
+		settings = append(settings, values[k]...)
+	}
+	return settings
+}
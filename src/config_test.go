@@ -0,0 +1,106 @@
+package gitreviewers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseConfig(t *testing.T) {
+	out := []byte("file:.gitreviewer\x00reviewer.defaultignoreextension\nsvg, nock\x00" +
+		"file:.git/config\x00core.bare\nfalse\x00" +
+		"file:.git/config\x00reviewer.flag\x00" +
+		"file:.git/config\x00reviewer.defaultignoreextension\n\x00")
+
+	expected := []ConfigEntry{
+		{"reviewer.defaultignoreextension", "svg, nock", "file:.gitreviewer"},
+		{"reviewer.flag", "true", "file:.git/config"},
+		{"reviewer.defaultignoreextension", "", "file:.git/config"},
+	}
+
+	if got := parseConfig(out); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Got '%v', expected '%v'\n", got, expected)
+	}
+}
+
+func TestConfigGetAll(t *testing.T) {
+	tests := []struct {
+		entries  []string
+		expected []string
+	}{
+		{nil, nil},
+		{[]string{"svg", "xml"}, []string{"svg", "xml"}},
+		{[]string{"svg", "", "xml"}, []string{"xml"}},
+		{[]string{"svg", ""}, []string{}},
+	}
+
+	for _, tt := range tests {
+		c := &Config{}
+		for _, v := range tt.entries {
+			c.Entries = append(c.Entries, ConfigEntry{Key: "reviewer.list", Value: v})
+		}
+
+		if got := c.GetAll("reviewer.List"); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("Got '%#v', expected '%#v' for %v\n", got, tt.expected, tt.entries)
+		}
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, ConfigFile),
+		[]byte("[reviewer]\n\tdefaultIgnoreExtension = svg, nock\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "config", "--add", "reviewer.defaultIgnoreExtension", "")
+	runGit(t, dir, "config", "--add", "reviewer.defaultIgnoreExtension", "lock")
+
+	r := ContributionCounter{Dir: dir}
+	c, err := r.LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The repository's git config comes last and resets the list
+	r.ApplyConfig(c)
+	if expected := []string{"lock"}; !reflect.DeepEqual(r.DefaultIgnoredExtensions, expected) {
+		t.Errorf("Got '%v', expected '%v'\n", r.DefaultIgnoredExtensions, expected)
+	}
+	if considerExt("a.lock", &r) || !considerExt("a.json", &r) {
+		t.Error("Expected only lock files to be ignored")
+	}
+}
+
+func TestConfigEffective(t *testing.T) {
+	c := &Config{Entries: []ConfigEntry{
+		{"reviewer.owners", "off", "file:.gitreviewer"},
+		{"reviewer.providerhost", "git.example.com=gitlab", "file:.gitreviewer"},
+		{"reviewer.defaultignoreextension", "lock", "file:.gitreviewer"},
+		{"reviewer.owners", "required", "file:.git/config"},
+		{"reviewer.providerhost", "code.example.com=gerrit", "file:.git/config"},
+		{"reviewer.excludelines", "comments", "file:.gitreviewer"},
+		{"reviewer.excludelines", "", "file:.git/config"},
+	}}
+
+	expected := []Setting{
+		{"reviewer.defaultignoreextension", "lock", "file:.gitreviewer"},
+		{"reviewer.excludelines", "", "file:.git/config"},
+		{"reviewer.owners", "required", "file:.git/config"},
+		{"reviewer.ownershipalert", "10", SourceDefault},
+		{"reviewer.prepushblock", "false", SourceDefault},
+		{"reviewer.providerhost", "git.example.com=gitlab", "file:.gitreviewer"},
+		{"reviewer.providerhost", "code.example.com=gerrit", "file:.git/config"},
+	}
+
+	if got := c.Effective(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Got '%v', expected '%v'\n", got, expected)
+	}
+
+	if got := (&Config{}).Effective(); len(got) != len(configDefaults) {
+		t.Errorf("Got %d settings, expected the %d defaults\n", len(got), len(configDefaults))
+	}
+}
@@ -0,0 +1,273 @@
+package gitreviewers
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Operations that stop on conflicts, see Conflicts.
+const (
+	OperationMerge      = "merge"
+	OperationRebase     = "rebase"
+	OperationCherryPick = "cherry-pick"
+	OperationRevert     = "revert"
+)
+
+// Conflicts describes a merge, rebase, cherry-pick or revert that stopped on
+// conflicts and who owns the other side of each conflicting hunk: the changes
+// being reconciled with yours, and so the people worth consulting to resolve
+// them.
+type Conflicts struct {
+	// Operation is one of the Operation constants.
+	Operation string
+	// Other is the commit the other side of the conflicts comes from. When
+	// rebasing it is HEAD, the branch being rebased onto; otherwise it is the
+	// commit being merged, picked or reverted.
+	Other string
+	// Owners has one entry per conflicting hunk, sorted by path and line. Line
+	// is where the conflict markers start in the working tree and BaseLine and
+	// BaseLines locate the other side of the hunk in Other.
+	Owners []HunkOwner
+}
+
+// conflict is a region of a conflicted file between "<<<<<<<" and ">>>>>>>"
+// markers.
+type conflict struct {
+	// line is the line of the opening marker.
+	line   int
+	ours   []string
+	theirs []string
+}
+
+// Markers git writes around conflicts, followed by a label.
+const (
+	markerOurs   = "<<<<<<<"
+	markerBase   = "|||||||"
+	markerSplit  = "======="
+	markerTheirs = ">>>>>>>"
+)
+
+// FindConflictOwners finds the operation in progress in the repository and the
+// top owner of the other side of each conflicting hunk. Ownership is measured
+// in the commit the other side comes from, since its history explains why the
+// lines conflict with yours. It returns an error if no merge, rebase,
+// cherry-pick or revert is in progress, and no owners if it has no conflicts
+// left.
+func (r *ContributionCounter) FindConflictOwners() (*Conflicts, error) {
+	op, other, err := r.conflictState()
+	if err != nil {
+		return nil, err
+	}
+
+	r.defaultSince()
+
+	hash, err := r.resolve(other)
+	if err != nil {
+		return nil, err
+	}
+	rev := hash.String()
+	result := &Conflicts{Operation: op, Other: rev}
+
+	out, err := r.output("rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to find the working tree")
+	}
+	root := strings.TrimSpace(string(out))
+
+	out, err = r.output("diff", "--name-only", "-z", "--diff-filter=U")
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list conflicted files")
+	}
+
+	for _, path := range strings.Split(string(out), "\x00") {
+		if path == "" {
+			continue
+		}
+
+		owners, err := r.fileConflictOwners(op, rev, root, path)
+		if err != nil {
+			return nil, err
+		}
+		result.Owners = append(result.Owners, owners...)
+	}
+
+	sort.Slice(result.Owners, func(i, j int) bool {
+		if result.Owners[i].Path != result.Owners[j].Path {
+			return result.Owners[i].Path < result.Owners[j].Path
+		}
+		return result.Owners[i].Line < result.Owners[j].Line
+	})
+
+	return result, nil
+}
+
+// conflictState finds the operation in progress from the state files git
+// leaves in the repository, and names the revision the other side of its
+// conflicts comes from.
+func (r *ContributionCounter) conflictState() (string, string, error) {
+	states := []struct {
+		file, op, other string
+	}{
+		{"rebase-merge", OperationRebase, "HEAD"},
+		{"rebase-apply", OperationRebase, "HEAD"},
+		{"MERGE_HEAD", OperationMerge, "MERGE_HEAD"},
+		{"CHERRY_PICK_HEAD", OperationCherryPick, "CHERRY_PICK_HEAD"},
+		// Reverting applies the reverse of the commit, so the other side
+		// comes from its parent
+		{"REVERT_HEAD", OperationRevert, "REVERT_HEAD^"},
+	}
+
+	for _, s := range states {
+		out, err := r.output("rev-parse", "--git-path", s.file)
+		if err != nil {
+			return "", "", errors.Wrap(err, "unable to find the repository state")
+		}
+
+		path := strings.TrimSpace(string(out))
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(r.Dir, path)
+		}
+		if _, err := os.Stat(path); err == nil {
+			return s.op, s.other, nil
+		}
+	}
+
+	return "", "", errors.New("no merge, rebase, cherry-pick or revert in progress")
+}
+
+// fileConflictOwners finds the owners of the other side of each conflict in
+// a file of the working tree.
+func (r *ContributionCounter) fileConflictOwners(op, rev, root, path string) ([]HunkOwner, error) {
+	content, err := ioutil.ReadFile(filepath.Join(root, filepath.FromSlash(path)))
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read %s", path)
+	}
+
+	// The other side of a file that was renamed or deleted there can't be
+	// found by its path, so nobody is credited for it.
+	original, _ := r.output("show", rev+":"+path)
+	lines := splitLines(original)
+
+	var (
+		owners []HunkOwner
+		from   int
+	)
+	for _, c := range parseConflicts(content) {
+		// While rebasing, "ours" is the branch being rebased onto and
+		// "theirs" the commit being replayed
+		side := c.theirs
+		if op == OperationRebase {
+			side = c.ours
+		}
+
+		start := locateLines(lines, side, from)
+		if start == 0 {
+			// The other side removed the lines or can't be found, which
+			// blame can't attribute to anyone
+			owners = append(owners, HunkOwner{Path: path, Line: c.line})
+			continue
+		}
+		from = start - 1 + len(side)
+
+		h := hunk{path: path, baseStart: start, baseCount: len(side), headStart: c.line}
+		owner, err := r.hunkOwner(h, rev)
+		if err != nil {
+			return nil, err
+		}
+		owners = append(owners, owner)
+	}
+
+	return owners, nil
+}
+
+// parseConflicts finds the conflicts marked in the content of a file. Both
+// the default style and the diff3 style, which adds the common ancestor
+// between "|||||||" and "=======", are understood.
+func parseConflicts(content []byte) []conflict {
+	const (
+		outside = iota
+		inOurs
+		inBase
+		inTheirs
+	)
+
+	var (
+		conflicts []conflict
+		current   conflict
+		state     = outside
+		n         int
+	)
+
+	scn := bufio.NewScanner(bytes.NewReader(content))
+	scn.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), len(content)+1)
+	for scn.Scan() {
+		line := scn.Text()
+		n++
+
+		switch {
+		case state == outside && isMarker(line, markerOurs):
+			current, state = conflict{line: n}, inOurs
+		case state == inOurs && isMarker(line, markerBase):
+			state = inBase
+		case (state == inOurs || state == inBase) && isMarker(line, markerSplit):
+			state = inTheirs
+		case state == inTheirs && isMarker(line, markerTheirs):
+			conflicts, state = append(conflicts, current), outside
+		case state == inOurs:
+			current.ours = append(current.ours, line)
+		case state == inTheirs:
+			current.theirs = append(current.theirs, line)
+		}
+	}
+
+	return conflicts
+}
+
+// isMarker reports whether 'line' is a conflict marker, which is followed by
+// a space and a label or nothing at all.
+func isMarker(line, marker string) bool {
+	return line == marker || strings.HasPrefix(line, marker+" ")
+}
+
+// locateLines finds where 'want' appears in 'lines' at or after index 'from'
+// and returns its 1-based line number, or 0 if it doesn't appear.
+func locateLines(lines, want []string, from int) int {
+	if len(want) == 0 {
+		return 0
+	}
+
+	for i := from; i+len(want) <= len(lines); i++ {
+		match := true
+		for j := range want {
+			if lines[i+j] != want[j] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return i + 1
+		}
+	}
+
+	return 0
+}
+
+// splitLines splits file content into lines without their line endings.
+func splitLines(content []byte) []string {
+	var lines []string
+
+	scn := bufio.NewScanner(bytes.NewReader(content))
+	scn.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), len(content)+1)
+	for scn.Scan() {
+		lines = append(lines, scn.Text())
+	}
+
+	return lines
+}
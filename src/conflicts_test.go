@@ -0,0 +1,115 @@
+package gitreviewers
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var conflictcontent = `package a
+
+<<<<<<< HEAD
+var x = 1
+=======
+var x = 2
+var y = 3
+>>>>>>> feature
+
+func f() {}
+<<<<<<< HEAD
+var z = 1
+||||||| merged common ancestors
+var z = 0
+=======
+>>>>>>> feature
+`
+
+func TestParseConflicts(t *testing.T) {
+	expected := []conflict{
+		{line: 3, ours: []string{"var x = 1"}, theirs: []string{"var x = 2", "var y = 3"}},
+		{line: 11, ours: []string{"var z = 1"}},
+	}
+
+	actual := parseConflicts([]byte(conflictcontent))
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %+v, expected %+v\n", actual, expected)
+	}
+}
+
+func TestLocateLines(t *testing.T) {
+	lines := []string{"a", "b", "c", "a", "b"}
+
+	cases := []struct {
+		Want     []string
+		From     int
+		Expected int
+	}{
+		{[]string{"a", "b"}, 0, 1},
+		{[]string{"a", "b"}, 1, 4},
+		{[]string{"b", "c"}, 0, 2},
+		{[]string{"c", "d"}, 0, 0},
+		{nil, 0, 0},
+	}
+
+	for _, c := range cases {
+		if actual := locateLines(lines, c.Want, c.From); actual != c.Expected {
+			t.Errorf("Got %d, expected %d for %v from %d\n", actual, c.Expected,
+				c.Want, c.From)
+		}
+	}
+}
+
+func TestFindConflictOwners(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	write := func(content string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, "src", "a.go"),
+			[]byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := ContributionCounter{Dir: dir}
+	if _, err := r.FindConflictOwners(); err == nil {
+		t.Error("Expected an error without a merge in progress")
+	}
+
+	write("package a\n\nvar x = 0\n")
+	runGit(t, dir, "commit", "-q", "-a", "-m", "Add x")
+
+	runGit(t, dir, "checkout", "-q", "-b", "feature")
+	write("package a\n\nvar x = 2\n")
+	runGit(t, dir, "commit", "-q", "-a", "--author", "Ben Franklin <ben@git-reviewer.com>",
+		"-m", "Set x to 2")
+
+	runGit(t, dir, "checkout", "-q", "master")
+	write("package a\n\nvar x = 1\n")
+	runGit(t, dir, "commit", "-q", "-a", "-m", "Set x to 1")
+
+	cmd := exec.Command("git", "merge", "-q", "feature")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_COMMITTER_NAME=Abraham Lincoln", "GIT_COMMITTER_EMAIL=abe@git-reviewer.com")
+	if out, err := cmd.CombinedOutput(); err == nil {
+		t.Fatalf("Expected the merge to conflict\n%s", out)
+	}
+
+	conflicts, err := r.FindConflictOwners()
+	if err != nil {
+		t.Fatalf("Unexpected error finding conflict owners: %v\n", err)
+	}
+
+	if conflicts.Operation != OperationMerge {
+		t.Errorf("Got operation %s, expected %s\n", conflicts.Operation, OperationMerge)
+	}
+
+	expected := []HunkOwner{{Path: "src/a.go", Line: 3, BaseLine: 3, BaseLines: 1,
+		Reviewer: "ben@git-reviewer.com", Percentage: 1, Lines: 1}}
+	if !reflect.DeepEqual(conflicts.Owners, expected) {
+		t.Errorf("Got %+v, expected %+v\n", conflicts.Owners, expected)
+	}
+}
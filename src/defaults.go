@@ -0,0 +1,116 @@
+package gitreviewers
+
+import "strings"
+
+// DefaultRule names reviewers to fall back on for changed files nobody owns,
+// such as files in a brand new directory or with no history since Since.
+type DefaultRule struct {
+	// Name describes the rule in the output, such as "docs".
+	Name string
+	// Paths are glob patterns like those of SensitiveRule.
+	Paths []string
+	// Reviewers are suggested for changed files matching Paths that no one
+	// owns a line of.
+	Reviewers []string
+}
+
+// matches reports whether any of the rule's patterns matches 'path'.
+func (d DefaultRule) matches(path string) bool {
+	return SensitiveRule{Paths: d.Paths}.matches(path)
+}
+
+// subsections lists the names of the [reviewer "<name>"] sections in the
+// order they first appear.
+func (c *Config) subsections() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, e := range c.Entries {
+		parts := strings.Split(e.Key, ".")
+		if len(parts) < 3 {
+			continue
+		}
+
+		name := strings.Join(parts[1:len(parts)-1], ".")
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// defaultRules reads rules out of [reviewer "<name>"] sections with
+// 'defaultPath' and 'defaultReviewer' settings, where the name of the section
+// names the rule. Sections without both are left out.
+func (c *Config) defaultRules() []DefaultRule {
+	var rules []DefaultRule
+	for _, name := range c.subsections() {
+		rule := DefaultRule{Name: name}
+		for _, v := range c.GetAll(configSection + name + ".defaultPath") {
+			rule.Paths = append(rule.Paths, splitList(v)...)
+		}
+		for _, v := range c.GetAll(configSection + name + ".defaultReviewer") {
+			rule.Reviewers = append(rule.Reviewers, splitList(v)...)
+		}
+
+		if len(rule.Paths) > 0 && len(rule.Reviewers) > 0 {
+			rules = append(rules, rule)
+		}
+	}
+
+	return rules
+}
+
+// unowned lists the 'paths' no author owns a line of in 'counts'.
+func (c *contributions) unowned(paths []string) []string {
+	var unowned []string
+	for _, path := range paths {
+		owned := false
+		for _, lines := range c.byFile[path] {
+			if lines > 0 {
+				owned = true
+				break
+			}
+		}
+		if !owned {
+			unowned = append(unowned, path)
+		}
+	}
+
+	return unowned
+}
+
+// addDefaults adds the reviewers of every DefaultRule matching a changed file
+// nobody owns, or a file added on the branch, to the suggestions in 'top', so
+// new areas of the code still get reviewers. Reviewers that weren't suggested keep whatever experience they
+// have in 'all'.
+func (r *ContributionCounter) addDefaults(top, all Stats, counts *contributions, paths []string) Stats {
+	if len(r.DefaultRules) == 0 {
+		return top
+	}
+
+	unowned := append(counts.unowned(paths), r.added...)
+	for _, rule := range r.DefaultRules {
+		matched := false
+		for _, path := range unowned {
+			if rule.matches(path) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		var owners Stats
+		top, owners = r.mergeOwners(top, all, rule.Reviewers)
+		for _, stat := range owners {
+			if !containsString(stat.Defaults, rule.Name) {
+				stat.Defaults = append(stat.Defaults, rule.Name)
+			}
+		}
+	}
+
+	return top
+}
@@ -0,0 +1,62 @@
+package gitreviewers
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestDefaultRules(t *testing.T) {
+	c := &Config{Entries: []ConfigEntry{
+		{Key: "reviewer.docs.defaultpath", Value: "docs/, *.md"},
+		{Key: "reviewer.docs.defaultreviewer", Value: "writers@git-reviewer.com"},
+		{Key: "reviewer.security.sensitivepath", Value: "auth/**"},
+		{Key: "reviewer.security.mandatoryreviewer", Value: "sec@git-reviewer.com"},
+		{Key: "reviewer.incomplete.defaultpath", Value: "db/**"},
+	}}
+
+	expected := []DefaultRule{{
+		Name:      "docs",
+		Paths:     []string{"docs/", "*.md"},
+		Reviewers: []string{"writers@git-reviewer.com"},
+	}}
+
+	if got := c.defaultRules(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Got '%+v', expected '%+v'\n", got, expected)
+	}
+}
+
+func TestAddDefaults(t *testing.T) {
+	r := ContributionCounter{DefaultRules: []DefaultRule{
+		{"docs", []string{"docs/**"}, []string{"writers@git-reviewer.com"}},
+		{"api", []string{"api/**"}, []string{"abe@git-reviewer.com"}},
+		{"web", []string{"web/**"}, []string{"george@git-reviewer.com"}},
+	}, added: []string{"docs/new.md"}}
+
+	counts := newContributions()
+	counts.add("api/handler.go", []attribution{{"abe@git-reviewer.com", "2017-01-02"}}, 1)
+	counts.add("web/app.js", nil, 10)
+
+	all := Stats{{Reviewer: "abe@git-reviewer.com", Percentage: 1}}
+	top := Stats{all[0]}
+
+	got := r.addDefaults(top, all, counts, []string{"api/handler.go", "web/app.js"})
+
+	var summary []string
+	for _, s := range got {
+		summary = append(summary, fmt.Sprintf("%s %.1f %v", s.Reviewer, s.Percentage, s.Defaults))
+	}
+	expected := []string{
+		"abe@git-reviewer.com 1.0 []",
+		"writers@git-reviewer.com 0.0 [docs]",
+		"george@git-reviewer.com 0.0 [web]",
+	}
+
+	if !reflect.DeepEqual(summary, expected) {
+		t.Errorf("Got '%v', expected '%v'\n", summary, expected)
+	}
+
+	if got := (&ContributionCounter{}).addDefaults(nil, nil, counts, []string{"docs/a.md"}); got != nil {
+		t.Errorf("Got %v, expected no reviewers without rules\n", got)
+	}
+}
@@ -0,0 +1,219 @@
+package gitreviewers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Ways experience can be displayed, set through ContributionCounter.Show.
+const (
+	// ShowPercent displays the share of lines owned, which is the default.
+	ShowPercent = "percent"
+	// ShowCounts displays the number of lines owned and files touched.
+	ShowCounts = "counts"
+	// ShowBoth displays the share of lines followed by the counts.
+	ShowBoth = "both"
+)
+
+// ShowOptions lists the valid values of ContributionCounter.Show.
+var ShowOptions = []string{ShowPercent, ShowCounts, ShowBoth}
+
+// Orders reviewers and owners can be listed in, set through
+// ContributionCounter.Sort. Without one, suggestions are listed in the order
+// they were picked.
+const (
+	// SortPercentage lists the largest share of lines first.
+	SortPercentage = "percentage"
+	// SortRecency lists whoever committed one of their lines most recently
+	// first.
+	SortRecency = "recency"
+	// SortName lists reviewers alphabetically.
+	SortName = "name"
+	// SortLines lists the most lines owned first.
+	SortLines = "lines"
+)
+
+// SortOptions lists the valid values of ContributionCounter.Sort.
+var SortOptions = []string{SortPercentage, SortRecency, SortName, SortLines}
+
+// sortEntry holds what reviewers and owners are sorted on.
+type sortEntry struct {
+	name        string
+	percentage  float64
+	lines       int
+	lastTouched string
+}
+
+// lessBy reports whether 'a' comes before 'b' when sorting by 'by'. Ties are
+// broken by share of lines, then lines, then name, so the order is the same
+// from one run to the next.
+func lessBy(by string, a, b sortEntry) bool {
+	switch {
+	case by == SortRecency && a.lastTouched != b.lastTouched:
+		return a.lastTouched > b.lastTouched
+	case by == SortName && a.name != b.name:
+		return a.name < b.name
+	case by == SortLines && a.lines != b.lines:
+		return a.lines > b.lines
+	case a.percentage != b.percentage:
+		return a.percentage > b.percentage
+	case a.lines != b.lines:
+		return a.lines > b.lines
+	default:
+		return a.name < b.name
+	}
+}
+
+// SortStats orders reviewers by one of SortOptions. An empty 'by' leaves them
+// in the order they were picked.
+func SortStats(stats Stats, by string) {
+	if by == "" {
+		return
+	}
+
+	entry := func(s *Stat) sortEntry {
+		return sortEntry{s.Reviewer, s.Percentage, s.Lines, s.LastTouched}
+	}
+	sort.SliceStable(stats, func(i, j int) bool {
+		return lessBy(by, entry(stats[i]), entry(stats[j]))
+	})
+}
+
+// SortOwners orders the owners of a repository by one of SortOptions. An
+// empty 'by' leaves them from the largest owner down.
+func SortOwners(owners []AuthorOwnership, by string) {
+	if by == "" {
+		return
+	}
+
+	entry := func(o AuthorOwnership) sortEntry {
+		return sortEntry{o.Author, o.Share, o.Lines, o.LastTouched}
+	}
+	sort.SliceStable(owners, func(i, j int) bool {
+		return lessBy(by, entry(owners[i]), entry(owners[j]))
+	})
+}
+
+// SortProjection orders the owners of each file and directory in 'p' by one
+// of SortOptions, using the shares and lines they will own once the branch
+// merges. Projections don't know when lines were committed, so 'recency'
+// falls back to the share. An empty 'by' leaves them from the largest owner
+// down.
+func SortProjection(p *Projection, by string) {
+	if by == "" {
+		return
+	}
+
+	entry := func(o OwnerProjection) sortEntry {
+		return sortEntry{name: o.Author, percentage: o.After, lines: o.LinesAfter}
+	}
+	for _, paths := range [][]PathProjection{p.Directories, p.Files} {
+		for _, path := range paths {
+			owners := path.Owners
+			sort.SliceStable(owners, func(i, j int) bool {
+				return lessBy(by, entry(owners[i]), entry(owners[j]))
+			})
+		}
+	}
+}
+
+// formatExperience describes the experience of a collaborator the way 'show'
+// asks for. A percentage alone hides whether it stands for 4 lines or 4,000,
+// so counts can be displayed alongside or instead of it. Files are left out
+// when 'files' is 0, such as for a single hunk.
+func formatExperience(show string, pct float64, lines, files int) string {
+	percent := fmt.Sprintf("%.2f%%", pct*100.0)
+
+	counts := pluralize(lines, "line")
+	if files > 0 {
+		counts += " in " + pluralize(files, "file")
+	}
+
+	switch show {
+	case ShowCounts:
+		return counts
+	case ShowBoth:
+		return fmt.Sprintf("%s (%s)", percent, counts)
+	default:
+		return percent
+	}
+}
+
+// Experience describes the experience of a suggested reviewer the way 'show'
+// asks for, like the tables of FindReviewers do.
+func (cs *Stat) Experience(show string) string {
+	return formatExperience(show, cs.Percentage, cs.Lines, cs.Files)
+}
+
+// Rationale explains in one line why the reviewer is suggested, such as in a
+// pull request description.
+func (cs *Stat) Rationale() string {
+	var reasons []string
+	if cs.Lines > 0 || cs.Percentage > 0 {
+		reason := fmt.Sprintf("owns %.0f%% of the changed lines", cs.Percentage*100.0)
+		if cs.Files > 0 {
+			reason += " across " + pluralize(cs.Files, "file")
+		}
+		reasons = append(reasons, reason)
+	}
+	if cs.Score > 0 {
+		reasons = append(reasons, fmt.Sprintf("%.0f%% likely to review it according to past reviews",
+			cs.Score*100.0))
+	}
+	if cs.Learner {
+		reasons = append(reasons, "suggested to learn the code")
+	}
+	if len(cs.Required) > 0 {
+		reasons = append(reasons, "required by "+strings.Join(cs.Required, ", "))
+	}
+	if len(cs.Topics) > 0 {
+		reasons = append(reasons, "knows about "+strings.Join(cs.Topics, ", "))
+	}
+	if len(cs.Tickets) > 0 {
+		reasons = append(reasons, "worked on related tickets "+strings.Join(cs.Tickets, ", "))
+	}
+	if len(cs.Owners) > 0 {
+		reasons = append(reasons, "listed in "+strings.Join(cs.Owners, ", "))
+	}
+	if len(cs.Defaults) > 0 {
+		reasons = append(reasons, "default reviewer for "+strings.Join(cs.Defaults, ", "))
+	}
+	if cs.Infra > 0 {
+		reasons = append(reasons, "platform reviewer for "+pluralize(cs.Infra,
+			"infrastructure file"))
+	}
+	if cs.Overlap > 0 {
+		reasons = append(reasons, "shares "+pluralize(cs.Overlap, "working hour")+
+			" with the author")
+	}
+
+	if len(reasons) == 0 {
+		return ""
+	}
+	r := strings.Join(reasons, "; ")
+	return strings.ToUpper(r[:1]) + r[1:]
+}
+
+// TableHeader returns the heading of a suggestion table in 'lang', meant for
+// a tabwriter: the column names underlined with dashes.
+func TableHeader(lang string) string {
+	reviewer, experience := Translate(lang, "Reviewer"), Translate(lang, "Experience")
+	return fmt.Sprintf("%s\t%s\n%s\t%s\n", reviewer, experience,
+		strings.Repeat("-", utf8.RuneCountInString(reviewer)),
+		strings.Repeat("-", utf8.RuneCountInString(experience)))
+}
+
+// pluralize formats a count followed by a noun, adding an "s" (or "es" after
+// an "s") unless the count is exactly one.
+func pluralize(n int, noun string) string {
+	switch {
+	case n == 1:
+		return fmt.Sprintf("%d %s", n, noun)
+	case strings.HasSuffix(noun, "s"):
+		return fmt.Sprintf("%d %ses", n, noun)
+	default:
+		return fmt.Sprintf("%d %ss", n, noun)
+	}
+}
@@ -0,0 +1,123 @@
+package gitreviewers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFormatExperience(t *testing.T) {
+	cases := []struct {
+		Show         string
+		Pct          float64
+		Lines, Files int
+		Expected     string
+	}{
+		{"", 0.4, 4, 1, "40.00%"},
+		{ShowPercent, 0.4, 4, 1, "40.00%"},
+		{ShowCounts, 0.4, 4000, 12, "4000 lines in 12 files"},
+		{ShowCounts, 0.4, 1, 1, "1 line in 1 file"},
+		{ShowCounts, 0.4, 4, 0, "4 lines"},
+		{ShowBoth, 0.125, 5, 2, "12.50% (5 lines in 2 files)"},
+	}
+
+	for _, c := range cases {
+		actual := formatExperience(c.Show, c.Pct, c.Lines, c.Files)
+		if actual != c.Expected {
+			t.Errorf("Got '%s', expected '%s'\n", actual, c.Expected)
+		}
+	}
+}
+
+func TestStatRationale(t *testing.T) {
+	tests := []struct {
+		stat     Stat
+		expected string
+	}{
+		{Stat{}, ""},
+		{Stat{Percentage: 0.625, Lines: 125, Files: 3}, "Owns 62% of the changed lines across 3 files"},
+		{Stat{Percentage: 0.1, Lines: 2, Learner: true},
+			"Owns 10% of the changed lines; suggested to learn the code"},
+		{Stat{Required: []string{"security"}, Topics: []string{"cache"}, Tickets: []string{"PAY-1"}},
+			"Required by security; knows about cache; worked on related tickets PAY-1"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.stat.Rationale(); got != tt.expected {
+			t.Errorf("Got '%s', expected '%s'\n", got, tt.expected)
+		}
+	}
+}
+
+func TestStatNotes(t *testing.T) {
+	tests := []struct {
+		stat     Stat
+		expected string
+	}{
+		{Stat{}, ""},
+		{Stat{Learner: true}, " (learning reviewer)"},
+		{Stat{Required: []string{"security", "data"}}, " (mandatory: security, data)"},
+		{Stat{Topics: []string{"cache", "ttl"}}, " (topics: cache, ttl)"},
+		{Stat{Tickets: []string{"PAY-1"}}, " (tickets: PAY-1)"},
+		{Stat{Owners: []string{"api/OWNERS"}}, " (owners: api/OWNERS)"},
+		{Stat{Overlap: 1}, " (overlap: 1 hour)"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.stat.Notes(); got != tt.expected {
+			t.Errorf("Got '%s', expected '%s'\n", got, tt.expected)
+		}
+	}
+}
+
+func TestSortStats(t *testing.T) {
+	stats := func() Stats {
+		return Stats{
+			{Reviewer: "tom@git-reviewer.com", Percentage: 0.2, Lines: 20, LastTouched: "2017-03-01"},
+			{Reviewer: "abe@git-reviewer.com", Percentage: 0.5, Lines: 10, LastTouched: "2017-01-01"},
+			{Reviewer: "ben@git-reviewer.com", Percentage: 0.2, Lines: 20, LastTouched: "2017-03-01"},
+		}
+	}
+
+	tests := []struct {
+		by       string
+		expected []string
+	}{
+		{"", []string{"tom", "abe", "ben"}},
+		{SortPercentage, []string{"abe", "ben", "tom"}},
+		{SortRecency, []string{"ben", "tom", "abe"}},
+		{SortName, []string{"abe", "ben", "tom"}},
+		{SortLines, []string{"ben", "tom", "abe"}},
+	}
+
+	for _, tt := range tests {
+		s := stats()
+		SortStats(s, tt.by)
+
+		var got []string
+		for _, stat := range s {
+			got = append(got, strings.TrimSuffix(stat.Reviewer, "@git-reviewer.com"))
+		}
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("Got %v, expected %v sorting by '%s'\n", got, tt.expected, tt.by)
+		}
+	}
+}
+
+func TestSortProjection(t *testing.T) {
+	p := &Projection{Files: []PathProjection{{Path: "src/a.go", Owners: []OwnerProjection{
+		{Author: "tom@git-reviewer.com", LinesAfter: 6, After: 0.6},
+		{Author: "abe@git-reviewer.com", LinesAfter: 2, After: 0.2},
+		{Author: "ben@git-reviewer.com", LinesAfter: 2, After: 0.2},
+	}}}}
+
+	SortProjection(p, SortName)
+
+	var got []string
+	for _, o := range p.Files[0].Owners {
+		got = append(got, strings.TrimSuffix(o.Author, "@git-reviewer.com"))
+	}
+	if expected := []string{"abe", "ben", "tom"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("Got %v, expected %v\n", got, expected)
+	}
+}
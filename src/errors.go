@@ -0,0 +1,203 @@
+package gitreviewers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Kinds of errors the package reports. Errors carrying more context match
+// them through Is, for example Is(err, ErrGitExecFailed) for a *GitError.
+var (
+	ErrNoChanges     = errors.New("no changes to review")
+	ErrNoReviewers   = errors.New("no reviewers found")
+	ErrBranchBehind  = errors.New("branch is behind its base")
+	ErrRepoNotFound  = errors.New("repository not found")
+	ErrGitExecFailed = errors.New("git command failed")
+	ErrBlameTimedOut = errors.New("git blame timed out")
+	ErrExecDisabled  = errors.New("running external commands is disabled")
+)
+
+// Is reports whether 'err', or any error it wraps, is of the 'target' kind.
+// It follows the causes of errors wrapped with github.com/pkg/errors, and
+// errors wrapped by the standard library, such as by HTTP clients.
+func Is(err, target error) bool {
+	for err != nil {
+		if err == target {
+			return true
+		}
+		if k, ok := err.(interface{ Is(error) bool }); ok && k.Is(target) {
+			return true
+		}
+
+		switch w := err.(type) {
+		case interface{ Cause() error }:
+			err = w.Cause()
+		case interface{ Unwrap() error }:
+			err = w.Unwrap()
+		default:
+			return false
+		}
+	}
+
+	return false
+}
+
+// GitError reports an external git command that failed.
+type GitError struct {
+	// Args holds the arguments given to git and Dir where it ran.
+	Args []string
+	Dir  string
+	// Stderr holds what git printed about the failure, if anything.
+	Stderr string
+	Err    error
+}
+
+// Error describes the command and why it failed.
+func (e *GitError) Error() string {
+	msg := fmt.Sprintf("git %s: %v", strings.Join(e.Args, " "), e.Err)
+	if e.Stderr != "" {
+		msg += ": " + e.Stderr
+	}
+
+	return msg
+}
+
+// Is matches ErrGitExecFailed, and ErrExecDisabled when git was refused
+// rather than run.
+func (e *GitError) Is(target error) bool {
+	return target == ErrGitExecFailed || Is(e.Err, target)
+}
+
+// RepoNotFoundError reports that no repository contains Path.
+type RepoNotFoundError struct {
+	Path string
+}
+
+// Error names the path that isn't in a repository.
+func (e *RepoNotFoundError) Error() string {
+	return fmt.Sprintf("%s is not inside a git repository", e.Path)
+}
+
+// Is matches ErrRepoNotFound.
+func (e *RepoNotFoundError) Is(target error) bool {
+	return target == ErrRepoNotFound
+}
+
+// NoChangesError reports that Head changes nothing compared to Base.
+type NoChangesError struct {
+	Base string
+	Head string
+}
+
+// Error names the revisions that were compared.
+func (e *NoChangesError) Error() string {
+	return fmt.Sprintf("no changes between %s and %s", e.Base, e.Head)
+}
+
+// Is matches ErrNoChanges.
+func (e *NoChangesError) Is(target error) bool {
+	return target == ErrNoChanges
+}
+
+// BranchBehindError reports that the base has commits the branch doesn't.
+type BranchBehindError struct {
+	Status BranchStatus
+}
+
+// Error describes how far behind the branch is.
+func (e *BranchBehindError) Error() string {
+	return e.Status.String()
+}
+
+// Is matches ErrBranchBehind.
+func (e *BranchBehindError) Is(target error) bool {
+	return target == ErrBranchBehind
+}
+
+// NoReviewersErr is returned when nobody owns any of the changed lines. Help
+// suggests how to find someone anyway.
+type NoReviewersErr interface {
+	Error() string
+	Help() string
+}
+
+type noReviewersErr struct{}
+
+func (nre noReviewersErr) Error() string {
+	return "no reviewers found"
+}
+
+func (nre noReviewersErr) Help() string {
+	return "Try using a wider date range, or set default reviewers for new areas"
+}
+
+// Is matches ErrNoReviewers.
+func (nre noReviewersErr) Is(target error) bool {
+	return target == ErrNoReviewers
+}
+
+// Codes identifying the kinds of errors in an ErrorReport. They don't change
+// between versions, unlike messages.
+const (
+	CodeInvalidArguments = "invalid-arguments"
+	CodeNoChanges        = "no-changes"
+	CodeNoReviewers      = "no-reviewers"
+	CodeBranchBehind     = "branch-behind"
+	CodeRepoNotFound     = "repo-not-found"
+	CodeGitExecFailed    = "git-failed"
+	CodeBlameTimedOut    = "blame-timed-out"
+	CodeExecDisabled     = "exec-disabled"
+	CodeUnknown          = "error"
+)
+
+// ErrorReport describes an error for programs: a Code out of the Code
+// constants to react to, the Message people read and a Hint on what to do
+// about it.
+type ErrorReport struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Hint    string `json:"hint,omitempty"`
+	// Problems lists every invalid option of ValidationErrors.
+	Problems []ValidationError `json:"problems,omitempty"`
+}
+
+// Report describes 'err' for programs, picking its Code by kind.
+func Report(err error) ErrorReport {
+	report := ErrorReport{Code: CodeUnknown, Message: err.Error()}
+
+	if problems, ok := errors.Cause(err).(ValidationErrors); ok {
+		report.Code = CodeInvalidArguments
+		report.Problems = problems
+		if len(problems) == 1 {
+			report.Hint = problems[0].Fix
+		}
+		return report
+	}
+
+	for _, kind := range []struct {
+		err  error
+		code string
+		hint string
+	}{
+		{ErrNoChanges, CodeNoChanges, "Commit changes on the branch, or compare it to another base"},
+		{ErrNoReviewers, CodeNoReviewers, ""},
+		{ErrBranchBehind, CodeBranchBehind, "Merge or rebase the branch onto its base"},
+		{ErrRepoNotFound, CodeRepoNotFound, "Run git reviewer from inside a git repository"},
+		{ErrBlameTimedOut, CodeBlameTimedOut, "Raise --blame-timeout, or 0 to wait as long as it takes"},
+		{ErrExecDisabled, CodeExecDisabled, "Leave out --no-exec to let git reviewer run git"},
+		{ErrGitExecFailed, CodeGitExecFailed, "Check that git works in this repository"},
+	} {
+		if Is(err, kind.err) {
+			report.Code, report.Hint = kind.code, kind.hint
+			break
+		}
+	}
+
+	if h, ok := errors.Cause(err).(interface{ Help() string }); ok {
+		report.Hint = h.Help()
+	}
+
+	return report
+}
@@ -0,0 +1,134 @@
+package gitreviewers
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestIs(t *testing.T) {
+	gitErr := &GitError{Args: []string{"blame", "a.go"}, Err: errors.New("exit status 128")}
+
+	cases := []struct {
+		Name     string
+		Err      error
+		Target   error
+		Expected bool
+	}{
+		{"sentinel", ErrNoChanges, ErrNoChanges, true},
+		{"typed", gitErr, ErrGitExecFailed, true},
+		{"wrapped", errors.Wrap(gitErr, "unable to blame"), ErrGitExecFailed, true},
+		{"wrapped twice", errors.Wrap(errors.Wrap(gitErr, "a"), "b"), ErrGitExecFailed, true},
+		{"other kind", gitErr, ErrRepoNotFound, false},
+		{"no reviewers", noReviewersErr{}, ErrNoReviewers, true},
+		{"behind", BranchStatus{Base: "master", Behind: 1}.Err(), ErrBranchBehind, true},
+		{"nil", nil, ErrNoChanges, false},
+	}
+
+	for _, c := range cases {
+		if actual := Is(c.Err, c.Target); actual != c.Expected {
+			t.Errorf("%s: got %t, expected %t\n", c.Name, actual, c.Expected)
+		}
+	}
+
+	if err := (BranchStatus{Base: "master", Ahead: 2}).Err(); err != nil {
+		t.Errorf("Got %v, expected no error for a branch that isn't behind\n", err)
+	}
+}
+
+func TestGitErrorContext(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	r := ContributionCounter{Dir: dir}
+	_, _, err := r.blameAttributions("missing.go", "master")
+	if !Is(err, ErrGitExecFailed) {
+		t.Fatalf("Got %v, expected a failed git command\n", err)
+	}
+
+	gitErr, ok := errors.Cause(err).(*GitError)
+	if !ok {
+		t.Fatalf("Got %T, expected *GitError\n", errors.Cause(err))
+	}
+	if gitErr.Dir != dir || gitErr.Args[len(gitErr.Args)-1] != "missing.go" {
+		t.Errorf("Got dir '%s' and args %v, expected the blame command\n",
+			gitErr.Dir, gitErr.Args)
+	}
+	if gitErr.Stderr == "" {
+		t.Error("Expected git's error message to be kept")
+	}
+}
+
+func TestResolveGitError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "git-reviewer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	r := ContributionCounter{Dir: dir}
+	_, err = r.resolve("master")
+	gitErr, ok := errors.Cause(err).(*GitError)
+	if !ok {
+		t.Fatalf("Got %T, expected *GitError\n", errors.Cause(err))
+	}
+	if !strings.Contains(gitErr.Stderr, "not a git repository") {
+		t.Errorf("Got stderr '%s', expected git's error message\n", gitErr.Stderr)
+	}
+	if !strings.HasPrefix(err.Error(), "unknown revision 'master'") {
+		t.Errorf("Got '%v', expected the revision to be named\n", err)
+	}
+}
+
+func TestOpenRepositoryNotFound(t *testing.T) {
+	_, _, err := OpenRepository(os.TempDir())
+	if !Is(err, ErrRepoNotFound) {
+		t.Errorf("Got %v, expected ErrRepoNotFound\n", err)
+	}
+}
+
+func TestFindReviewersNoChanges(t *testing.T) {
+	r := ContributionCounter{}
+	if _, err := r.FindReviewers(nil); !Is(err, ErrNoChanges) {
+		t.Errorf("Got %v, expected ErrNoChanges\n", err)
+	}
+}
+
+func TestReport(t *testing.T) {
+	gitErr := &GitError{Args: []string{"blame", "a.go"}, Err: errors.New("exit status 128")}
+	problems := ValidationErrors{{"since", "'yesterday' is not a valid date", "Use YYYY-MM-DD"}}
+
+	cases := []struct {
+		Name string
+		Err  error
+		Code string
+		Hint string
+	}{
+		{"git", errors.Wrap(gitErr, "unable to blame"), CodeGitExecFailed,
+			"Check that git works in this repository"},
+		{"no reviewers", noReviewersErr{}, CodeNoReviewers,
+			"Try using a wider date range, or set default reviewers for new areas"},
+		{"no changes", &NoChangesError{Base: "master", Head: "HEAD"}, CodeNoChanges,
+			"Commit changes on the branch, or compare it to another base"},
+		{"problems", problems, CodeInvalidArguments, "Use YYYY-MM-DD"},
+		{"other", errors.New("disk full"), CodeUnknown, ""},
+	}
+
+	for _, c := range cases {
+		report := Report(c.Err)
+		if report.Code != c.Code || report.Hint != c.Hint {
+			t.Errorf("%s: got code '%s' and hint '%s', expected '%s' and '%s'\n", c.Name,
+				report.Code, report.Hint, c.Code, c.Hint)
+		}
+		if report.Message != c.Err.Error() {
+			t.Errorf("%s: got message '%s', expected '%s'\n", c.Name, report.Message, c.Err)
+		}
+	}
+
+	if report := Report(problems); len(report.Problems) != 1 {
+		t.Errorf("Got %d problems, expected 1\n", len(report.Problems))
+	}
+}
@@ -0,0 +1,130 @@
+package gitreviewers
+
+import (
+	"bytes"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Kinds of lines ExcludeLines can leave out of ownership.
+const (
+	// ExcludeComments leaves out lines holding nothing but a comment.
+	ExcludeComments = "comments"
+	// ExcludeImports leaves out import, include and require lines.
+	ExcludeImports = "imports"
+	// ExcludeLicense leaves out the comments and blank lines at the top of
+	// files, where license headers go.
+	ExcludeLicense = "license"
+)
+
+// ExcludeLineKinds are the kinds of lines ExcludeLines accepts.
+var ExcludeLineKinds = []string{ExcludeComments, ExcludeImports, ExcludeLicense}
+
+// languageLines matches a kind of line in the files with one of a set of
+// extensions.
+type languageLines struct {
+	extensions []string
+	pattern    *regexp.Regexp
+}
+
+// commentLines match lines holding only a comment, or the inside of a block
+// comment, in the languages they know the comments of.
+var commentLines = []languageLines{
+	{[]string{"go", "js", "jsx", "ts", "tsx", "java", "kt", "scala", "c", "h", "cc", "cpp",
+		"hpp", "cs", "swift", "rs", "php", "css", "scss"},
+		regexp.MustCompile(`^\s*(//|/\*|\*/|\*(\s|$))`)},
+	{[]string{"py", "rb", "sh", "bash", "pl", "r", "yml", "yaml", "toml", "tf"},
+		regexp.MustCompile(`^\s*#`)},
+	{[]string{"sql", "lua", "hs"}, regexp.MustCompile(`^\s*--`)},
+	{[]string{"html", "xml", "md"}, regexp.MustCompile(`^\s*<!--`)},
+}
+
+// importLines match the lines bringing in other code, in the languages they
+// know the imports of.
+var importLines = []languageLines{
+	{[]string{"go"}, regexp.MustCompile(`^import\b|^\s+(\w+\s+|[_.]\s+)?"[^"\s]+"\s*$`)},
+	{[]string{"js", "jsx", "ts", "tsx"},
+		regexp.MustCompile(`^\s*import\b|^\s*(const|let|var)\s.*=\s*require\(`)},
+	{[]string{"py"}, regexp.MustCompile(`^\s*(import|from)\s`)},
+	{[]string{"java", "kt", "scala"}, regexp.MustCompile(`^\s*import\s`)},
+	{[]string{"c", "h", "cc", "cpp", "hpp"}, regexp.MustCompile(`^\s*#\s*include\b`)},
+	{[]string{"rs"}, regexp.MustCompile(`^\s*(pub\s+)?use\s`)},
+	{[]string{"cs"}, regexp.MustCompile(`^\s*using\s[\w.]+;`)},
+	{[]string{"rb"}, regexp.MustCompile(`^\s*require(_relative)?\s`)},
+}
+
+// languagePattern returns the pattern of 'kinds' for the language of 'file',
+// or nil if the language isn't known.
+func languagePattern(kinds []languageLines, file string) *regexp.Regexp {
+	ext := strings.ToLower(strings.TrimPrefix(path.Ext(file), "."))
+	for _, k := range kinds {
+		if containsString(k.extensions, ext) {
+			return k.pattern
+		}
+	}
+	return nil
+}
+
+// lineExcluder tells, line by line, which lines of a blamed file to leave out
+// of ownership. A nil lineExcluder leaves out nothing.
+type lineExcluder struct {
+	comment  *regexp.Regexp
+	comments bool
+	imports  *regexp.Regexp
+	patterns []*regexp.Regexp
+	// header is set while reading the comments at the top of the file,
+	// when leaving out license headers.
+	header bool
+}
+
+// lineExcluder builds the lineExcluder for 'file', or returns nil if no lines
+// are excluded. Invalid patterns are ignored here, Validate reports them.
+// License headers are only left out when 'whole' says the lines start at the
+// top of the file, rather than somewhere in the middle of it.
+func (r *ContributionCounter) lineExcluder(file string, whole bool) *lineExcluder {
+	if len(r.ExcludeLines) == 0 && len(r.ExcludeLinePatterns) == 0 {
+		return nil
+	}
+
+	e := &lineExcluder{comment: languagePattern(commentLines, file)}
+	e.comments = e.comment != nil && containsString(r.ExcludeLines, ExcludeComments)
+	e.header = whole && e.comment != nil && containsString(r.ExcludeLines, ExcludeLicense)
+	if containsString(r.ExcludeLines, ExcludeImports) {
+		e.imports = languagePattern(importLines, file)
+	}
+	for _, p := range r.ExcludeLinePatterns {
+		if re, err := regexp.Compile(p); err == nil {
+			e.patterns = append(e.patterns, re)
+		}
+	}
+
+	return e
+}
+
+// excluded reports whether the next line of the file, holding 'content',
+// should be left out. Lines must be passed in order.
+func (e *lineExcluder) excluded(content []byte) bool {
+	if e == nil {
+		return false
+	}
+
+	comment := e.comment != nil && e.comment.Match(content)
+	if e.header {
+		if comment || len(bytes.TrimSpace(content)) == 0 {
+			return true
+		}
+		e.header = false
+	}
+
+	if comment && e.comments || e.imports != nil && e.imports.Match(content) {
+		return true
+	}
+	for _, re := range e.patterns {
+		if re.Match(content) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,87 @@
+package gitreviewers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLineExcluder(t *testing.T) {
+	lines := []string{
+		"// Copyright 2017 Abe",
+		"// Licensed under the MIT license",
+		"",
+		"package a",
+		"",
+		"import (",
+		"\t\"fmt\"",
+		"\tgr \"github.com/thedahv/git-reviewer/src\"",
+		")",
+		"",
+		"// Hello says hello",
+		"func Hello() { fmt.Println(\"hi\") } // Code generated",
+		"\tname := \"not an import\" + x",
+	}
+
+	tests := []struct {
+		kinds    []string
+		patterns []string
+		file     string
+		expected []bool
+	}{
+		{nil, nil, "a.go", make([]bool, len(lines))},
+		{[]string{ExcludeLicense}, nil, "a.go",
+			[]bool{true, true, true, false, false, false, false, false, false, false, false, false, false}},
+		{[]string{ExcludeComments}, nil, "a.go",
+			[]bool{true, true, false, false, false, false, false, false, false, false, true, false, false}},
+		{[]string{ExcludeImports}, nil, "a.go",
+			[]bool{false, false, false, false, false, true, true, true, false, false, false, false, false}},
+		{nil, []string{`Code generated`}, "a.go",
+			[]bool{false, false, false, false, false, false, false, false, false, false, false, true, false}},
+		{[]string{ExcludeComments, ExcludeLicense, ExcludeImports}, nil, "a.unknown",
+			make([]bool, len(lines))},
+	}
+
+	for _, tt := range tests {
+		r := ContributionCounter{ExcludeLines: tt.kinds, ExcludeLinePatterns: tt.patterns}
+		e := r.lineExcluder(tt.file, true)
+		for i, line := range lines {
+			if got := e.excluded([]byte(line)); got != tt.expected[i] {
+				t.Errorf("Got %t, expected %t for line %d of %s with %v %v\n", got,
+					tt.expected[i], i+1, tt.file, tt.kinds, tt.patterns)
+			}
+		}
+	}
+}
+
+func TestBlameAttributionsExcludeLines(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "src", "a.go"),
+		[]byte("// Copyright 2017 Abe\npackage a\n\nimport \"fmt\"\n\nvar b = fmt.Sprint(1)\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "commit", "-q", "-a", "-m", "Add b")
+
+	r := ContributionCounter{Dir: dir, Since: "2000-01-01",
+		ExcludeLines: []string{ExcludeLicense, ExcludeImports}}
+	attributions, lines, err := r.blameAttributions("src/a.go", "master")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if lines != 4 || len(attributions) != 4 {
+		t.Errorf("Got %d lines and %d attributions, expected 4 of each\n", lines, len(attributions))
+	}
+
+	// The blank line and comment in the middle of the file aren't a header
+	r.ExcludeLines = []string{ExcludeLicense}
+	if _, lines, err = r.blameAttributions("src/a.go", "master", "3,+3"); err != nil {
+		t.Fatal(err)
+	}
+	if lines != 3 {
+		t.Errorf("Got %d lines, expected all 3 lines of the range\n", lines)
+	}
+}
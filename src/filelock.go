@@ -0,0 +1,119 @@
+package gitreviewers
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Settings of the locks that keep concurrent runs, such as an editor plugin,
+// the command line and a hook, from updating a cache file at the same time.
+const (
+	// lockTimeout bounds how long we wait for another run to let go of a
+	// lock.
+	lockTimeout = 5 * time.Second
+	// staleLockAge is how old a lock file has to be to be taken for one left
+	// behind by a run that died. Locks are only held for as long as a file
+	// takes to be read and written, so this is generous.
+	staleLockAge = 30 * time.Second
+	// lockRetry is the wait between attempts to take a lock.
+	lockRetry = 10 * time.Millisecond
+)
+
+// fileLock is a lock on a file shared between processes, held by creating a
+// lock file next to it.
+type fileLock struct {
+	path string
+}
+
+// lockFile takes the lock on the file at 'path', waiting up to 'timeout' for
+// another process to release it. Lock files older than 'stale' are removed
+// as left behind by processes that died.
+func lockFile(path string, timeout, stale time.Duration) (*fileLock, error) {
+	l := &fileLock{path: path + ".lock"}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return nil, errors.Wrap(err, "unable to create cache directory")
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			// Who holds the lock helps whoever finds it stuck
+			fmt.Fprintf(f, "%d\n", os.Getpid())
+			f.Close()
+			return l, nil
+		}
+		if !os.IsExist(err) {
+			return nil, errors.Wrapf(err, "unable to lock %s", path)
+		}
+
+		if l.breakStale(stale) {
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, errors.Errorf("timed out waiting for the lock on %s, remove %s if no other git-reviewer is running",
+				path, l.path)
+		}
+		time.Sleep(lockRetry)
+	}
+}
+
+// breakStale removes the lock file if it is older than 'stale', reporting
+// whether it did. The file is moved aside before being checked, so that of
+// several processes finding the same stale lock, only one removes it. A lock
+// that turns out to have just been taken by another process is put back.
+func (l *fileLock) breakStale(stale time.Duration) bool {
+	info, err := os.Stat(l.path)
+	if err != nil {
+		// Released in the meantime
+		return os.IsNotExist(err)
+	}
+	if time.Since(info.ModTime()) < stale {
+		return false
+	}
+
+	aside := fmt.Sprintf("%s.%d.stale", l.path, os.Getpid())
+	if err := os.Rename(l.path, aside); err != nil {
+		return os.IsNotExist(err)
+	}
+	defer os.Remove(aside)
+
+	if info, err := os.Stat(aside); err == nil && time.Since(info.ModTime()) < stale {
+		os.Link(aside, l.path)
+		return false
+	}
+	return true
+}
+
+// unlock releases the lock.
+func (l *fileLock) unlock() error {
+	return errors.Wrap(os.Remove(l.path), "unable to release lock")
+}
+
+// writeFileAtomic writes 'data' to the file at 'path' by writing a temporary
+// file next to it and renaming it into place, so readers, even in other
+// processes, see either the old content or the new, never part of it.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), perm)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	return err
+}
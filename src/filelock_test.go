@@ -0,0 +1,131 @@
+package gitreviewers
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLockFileContention(t *testing.T) {
+	dir, err := ioutil.TempDir("", "git-reviewer-lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "counter")
+
+	// Every run reads the counter and writes it back incremented while
+	// holding the lock, so none of the increments may be lost
+	const runs = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, runs)
+	for i := 0; i < runs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l, err := lockFile(path, 10*time.Second, time.Minute)
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer l.unlock()
+
+			b, _ := ioutil.ReadFile(path)
+			n, _ := strconv.Atoi(string(b))
+			time.Sleep(time.Millisecond)
+			errs <- writeFileAtomic(path, []byte(strconv.Itoa(n+1)), 0644)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Unexpected error updating the counter: %v\n", err)
+		}
+	}
+	if b, _ := ioutil.ReadFile(path); string(b) != strconv.Itoa(runs) {
+		t.Errorf("Got %s, expected %d\n", b, runs)
+	}
+	if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("Expected the lock to be released, got %v\n", err)
+	}
+}
+
+func TestLockFileStale(t *testing.T) {
+	dir, err := ioutil.TempDir("", "git-reviewer-lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "identities.json")
+
+	held, err := lockFile(path, time.Second, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A lock held by a live run is waited for
+	if _, err := lockFile(path, 20*time.Millisecond, time.Minute); err == nil {
+		t.Error("Expected to time out waiting for a held lock")
+	}
+
+	// One left behind by a run that died is broken
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(held.path, old, old); err != nil {
+		t.Fatal(err)
+	}
+	l, err := lockFile(path, 20*time.Millisecond, time.Minute)
+	if err != nil {
+		t.Fatalf("Unexpected error taking a stale lock: %v\n", err)
+	}
+	if err := l.unlock(); err != nil {
+		t.Errorf("Unexpected error releasing the lock: %v\n", err)
+	}
+}
+
+func TestFileCacheConcurrentSet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "git-reviewer-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	c := NewFileCache(dir)
+
+	// Values are large enough to take several writes, and readers must only
+	// ever see one of them whole
+	values := [][]byte{bytes.Repeat([]byte("a"), 1<<20), bytes.Repeat([]byte("b"), 1<<20)}
+
+	var wg sync.WaitGroup
+	torn := make(chan int, 100)
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func(val []byte) {
+			defer wg.Done()
+			if err := c.Set("blame/key", val, 0); err != nil {
+				t.Errorf("Unexpected error setting the value: %v\n", err)
+			}
+		}(values[i%2])
+		go func() {
+			defer wg.Done()
+			val, ok, err := c.Get("blame/key")
+			if err != nil {
+				t.Errorf("Unexpected error getting the value: %v\n", err)
+			}
+			if ok && !bytes.Equal(val, values[0]) && !bytes.Equal(val, values[1]) {
+				torn <- len(val)
+			}
+		}()
+	}
+	wg.Wait()
+	close(torn)
+
+	for n := range torn {
+		t.Errorf("Got a value of %d bytes mixing several writes\n", n)
+	}
+}
@@ -0,0 +1,187 @@
+package gitreviewers
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// gerritPrefix guards every JSON response of the Gerrit REST API.
+const gerritPrefix = ")]}'"
+
+// gerrit builds the provider for a Gerrit remote, at reviewer.gerritUrl or
+// over HTTPS at the remote's host otherwise. Requests are authenticated as
+// reviewer.gerritUser with the HTTP password in GERRIT_HTTP_PASSWORD.
+func (r *ContributionCounter) gerrit(c *Config, remote Remote) ReviewProvider {
+	user, _ := c.Get("reviewer.gerritUser")
+
+	u, _ := c.Get("reviewer.gerritUrl")
+	if u == "" {
+		u = "https://" + remote.Host
+	}
+
+	project := remote.Repo
+	if remote.Owner != "" {
+		project = remote.Owner + "/" + remote.Repo
+	}
+
+	return &Gerrit{URL: strings.TrimSuffix(u, "/"), Project: project, Username: user,
+		Password: os.Getenv("GERRIT_HTTP_PASSWORD"), Client: r.HTTPClient(),
+		changeID: r.changeID}
+}
+
+// changeID reads the Change-Id trailer Gerrit's commit-msg hook adds to the
+// message of the head revision, or returns an empty string if there is none.
+func (r *ContributionCounter) changeID() (string, error) {
+	out, err := r.output("log", "-1", "--format=%B", r.headRev())
+	if err != nil {
+		return "", errors.Wrap(err, "unable to read the commit message")
+	}
+
+	var id string
+	scn := bufio.NewScanner(bytes.NewReader(out))
+	for scn.Scan() {
+		if line := scn.Text(); strings.HasPrefix(line, "Change-Id:") {
+			id = strings.TrimSpace(strings.TrimPrefix(line, "Change-Id:"))
+		}
+	}
+	return id, nil
+}
+
+// Gerrit is the ReviewProvider of repositories reviewed on Gerrit, where the
+// pull request of a branch is the change of its latest commit.
+type Gerrit struct {
+	// URL is the address of the web interface, which serves the REST API.
+	URL     string
+	Project string
+	// Username and Password authenticate with an HTTP password. Without
+	// them, only what anonymous users may see is found.
+	Username string
+	Password string
+	Client   *http.Client
+
+	// changeID reads the Change-Id of the commit under review.
+	changeID func() (string, error)
+}
+
+func (g *Gerrit) api() apiClient {
+	return apiClient{URL: g.URL, User: g.Username, Token: g.Password, Client: g.Client,
+		Prefix: gerritPrefix}
+}
+
+// path prefixes the REST API 'path' with /a, which Gerrit requires of
+// authenticated requests.
+func (g *Gerrit) path(path string) string {
+	if g.Username == "" {
+		return path
+	}
+	return "/a" + path
+}
+
+// gerritAccount holds the fields of a Gerrit account we use.
+type gerritAccount struct {
+	ID       int    `json:"_account_id"`
+	Name     string `json:"name"`
+	Username string `json:"username"`
+}
+
+func (a gerritAccount) account() Account {
+	name := a.Username
+	if name == "" {
+		name = a.Name
+	}
+	return Account{ID: strconv.Itoa(a.ID), Name: name}
+}
+
+// User finds the only account with 'email'.
+func (g *Gerrit) User(email string) (Account, error) {
+	q := url.QueryEscape("email:" + email)
+
+	var accounts []gerritAccount
+	err := g.api().do("GET", g.path("/accounts/?o=DETAILS&q="+q), nil, &accounts)
+	switch {
+	case err == errAPINotFound || err == nil && len(accounts) != 1:
+		return Account{}, nil
+	case err != nil:
+		return Account{}, errors.Wrapf(err, "unable to look up %s on Gerrit", email)
+	}
+
+	return accounts[0].account(), nil
+}
+
+// PullRequest finds the open change of the latest commit, by its Change-Id.
+// Gerrit reviews commits rather than branches, so 'branch' isn't used.
+func (g *Gerrit) PullRequest(branch string) (*PullRequest, error) {
+	if g.changeID == nil {
+		return nil, nil
+	}
+	id, err := g.changeID()
+	if err != nil || id == "" {
+		return nil, err
+	}
+
+	q := url.QueryEscape(fmt.Sprintf("change:%s status:open project:%s", id, g.Project))
+
+	var changes []struct {
+		Number  int           `json:"_number"`
+		Subject string        `json:"subject"`
+		Branch  string        `json:"branch"`
+		Owner   gerritAccount `json:"owner"`
+	}
+	err = g.api().do("GET", g.path("/changes/?o=DETAILED_ACCOUNTS&q="+q), nil, &changes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to find change %s on Gerrit", id)
+	}
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	c := changes[0]
+	return &PullRequest{ID: strconv.Itoa(c.Number), Title: c.Subject, Author: c.Owner.account(),
+		Base: c.Branch, URL: fmt.Sprintf("%s/c/%s/+/%d", g.URL, g.Project, c.Number)}, nil
+}
+
+// AddReviewers adds 'accounts' to the reviewers of the change 'pr'.
+func (g *Gerrit) AddReviewers(pr *PullRequest, accounts []Account) error {
+	for _, a := range accounts {
+		in := struct {
+			Reviewer string `json:"reviewer"`
+		}{a.ID}
+		if err := g.api().do("POST", g.path("/changes/"+pr.ID+"/reviewers"), in, nil); err != nil {
+			return errors.Wrapf(err, "unable to add %s as a reviewer", a.Name)
+		}
+	}
+	return nil
+}
+
+// OpenReviews counts the open changes 'account' is a reviewer of, on every
+// project, leaving out their own. Gerrit caps how many changes it lists at
+// once, so the rest are asked for until it has no more.
+func (g *Gerrit) OpenReviews(account Account) (int, error) {
+	q := url.QueryEscape(fmt.Sprintf("status:open reviewer:%s -owner:%s", account.ID, account.ID))
+
+	var open int
+	for {
+		var changes []struct {
+			Number int `json:"_number"`
+			// More is set on the last change listed when there are more
+			More bool `json:"_more_changes"`
+		}
+		path := g.path(fmt.Sprintf("/changes/?q=%s&S=%d", q, open))
+		if err := g.api().do("GET", path, nil, &changes); err != nil {
+			return 0, errors.Wrapf(err, "unable to count the open reviews of %s", account.Name)
+		}
+		open += len(changes)
+
+		if len(changes) == 0 || !changes[len(changes)-1].More {
+			return open, nil
+		}
+	}
+}
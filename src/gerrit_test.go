@@ -0,0 +1,138 @@
+package gitreviewers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestGerrit(t *testing.T) {
+	var added []string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if user, password, _ := req.BasicAuth(); user != "me" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		fmt.Fprintln(w, gerritPrefix)
+		switch req.Method + " " + req.URL.Path {
+		case "GET /a/accounts/":
+			switch req.URL.Query().Get("q") {
+			case "email:abe@git-reviewer.com":
+				fmt.Fprint(w, `[{"_account_id": 1000, "name": "Abe", "username": "abe"}]`)
+			case "email:ben@git-reviewer.com":
+				fmt.Fprint(w, `[{"_account_id": 1001, "name": "Ben"}]`)
+			default:
+				fmt.Fprint(w, `[]`)
+			}
+		case "GET /a/changes/":
+			switch req.URL.Query().Get("q") {
+			case "change:Iabc status:open project:platform/build":
+				fmt.Fprint(w, `[{"_number": 42, "subject": "Add a feature", "branch": "main",
+					"owner": {"_account_id": 1002, "username": "carl"}}]`)
+			case "status:open reviewer:1000 -owner:1000":
+				if req.URL.Query().Get("S") == "2" {
+					fmt.Fprint(w, `[{"_number": 39}]`)
+					return
+				}
+				fmt.Fprint(w, `[{"_number": 40}, {"_number": 41, "_more_changes": true}]`)
+			default:
+				fmt.Fprint(w, `[]`)
+			}
+		case "POST /a/changes/42/reviewers":
+			var in struct {
+				Reviewer string `json:"reviewer"`
+			}
+			json.NewDecoder(req.Body).Decode(&in)
+			added = append(added, in.Reviewer)
+			fmt.Fprint(w, `{}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	id := "Iabc"
+	g := &Gerrit{URL: srv.URL, Project: "platform/build", Username: "me", Password: "secret",
+		changeID: func() (string, error) {
+			return id, nil
+		}}
+
+	users := []struct {
+		email    string
+		expected Account
+	}{
+		{"abe@git-reviewer.com", Account{ID: "1000", Name: "abe"}},
+		{"ben@git-reviewer.com", Account{ID: "1001", Name: "Ben"}},
+		{"carl@git-reviewer.com", Account{}},
+	}
+	for _, u := range users {
+		got, err := g.User(u.email)
+		if err != nil {
+			t.Fatalf("Unexpected error looking up %s: %v\n", u.email, err)
+		}
+		if got != u.expected {
+			t.Errorf("Got %+v, expected %+v for %s\n", got, u.expected, u.email)
+		}
+	}
+
+	pr, err := g.PullRequest("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &PullRequest{ID: "42", Title: "Add a feature", Author: Account{ID: "1002", Name: "carl"},
+		Base: "main", URL: srv.URL + "/c/platform/build/+/42"}
+	if !reflect.DeepEqual(pr, expected) {
+		t.Errorf("Got %+v, expected %+v\n", pr, expected)
+	}
+
+	if err := g.AddReviewers(pr, []Account{{ID: "1000"}, {ID: "1001"}}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(added, []string{"1000", "1001"}) {
+		t.Errorf("Got %v, expected 1000 and 1001 added as reviewers\n", added)
+	}
+
+	if n, err := g.OpenReviews(Account{ID: "1000", Name: "abe"}); err != nil || n != 3 {
+		t.Errorf("Got %d (error: %v), expected 3 open reviews for abe over two pages\n", n, err)
+	}
+
+	for _, id = range []string{"Iother", ""} {
+		if pr, err := g.PullRequest(""); err != nil || pr != nil {
+			t.Errorf("Got %+v (error: %v), expected no change for '%s'\n", pr, err, id)
+		}
+	}
+
+	g.Password = "wrong"
+	if _, err := g.User("abe@git-reviewer.com"); err == nil {
+		t.Error("Expected an error with the wrong password")
+	}
+}
+
+func TestChangeID(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	r := &ContributionCounter{Dir: dir}
+	if id, err := r.changeID(); err != nil || id != "" {
+		t.Errorf("Got '%s' (error: %v), expected no Change-Id\n", id, err)
+	}
+
+	runGit(t, dir, "commit", "-q", "--allow-empty", "-m",
+		"Add a feature\n\nChange-Id: I0123456789abcdef\nSigned-off-by: Abe <abe@git-reviewer.com>")
+	if id, err := r.changeID(); err != nil || id != "I0123456789abcdef" {
+		t.Errorf("Got '%s' (error: %v), expected 'I0123456789abcdef'\n", id, err)
+	}
+
+	p, err := r.ReviewProvider(&Config{}, Remote{Host: "review.example.com", Repo: "tools",
+		Provider: ProviderGerrit})
+	if g, ok := p.(*Gerrit); err != nil || !ok || g.Project != "tools" ||
+		g.URL != "https://review.example.com" {
+		t.Errorf("Got %+v (error: %v), expected Gerrit for tools\n", p, err)
+	}
+}
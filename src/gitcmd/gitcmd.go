@@ -0,0 +1,107 @@
+// Package gitcmd prepares external git commands that behave the same no
+// matter how the user's environment is set up.
+//
+// Every command runs with the C locale, so messages can be matched, without a
+// pager or terminal prompts, so nothing waits on input, and without optional
+// locks, so several commands can run on the same repository at once.
+// Settings that change output meant for programs, such as colors, external
+// diff drivers and the file system monitor, are turned off too.
+package gitcmd
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Runner prepares git commands. Its zero value runs "git" from PATH in the
+// current directory.
+type Runner struct {
+	// Git is the git executable to run, "git" if empty.
+	Git string
+	// Dir is the directory commands run in, the current directory if empty.
+	Dir string
+}
+
+// overrides are settings passed with -c ahead of every command, since they
+// can change output or hang git and may be set in any config file.
+var overrides = []string{
+	"core.pager=cat",
+	"core.fsmonitor=false",
+	"color.ui=false",
+}
+
+// cleanEnv are the variables every command runs with.
+var cleanEnv = []string{
+	"LC_ALL=C",
+	"GIT_PAGER=cat",
+	"PAGER=cat",
+	"GIT_TERMINAL_PROMPT=0",
+	"GIT_OPTIONAL_LOCKS=0",
+}
+
+// droppedEnv are variables from the user's environment that could change the
+// output of git or point it at another repository than Dir.
+var droppedEnv = []string{
+	"LANG", "LANGUAGE", "LC_", "GIT_PAGER", "PAGER", "GIT_EXTERNAL_DIFF",
+	"GIT_DIFF_OPTS", "GIT_DIR", "GIT_WORK_TREE", "GIT_INDEX_FILE", "GIT_PREFIX",
+	"GIT_TERMINAL_PROMPT", "GIT_OPTIONAL_LOCKS",
+}
+
+// Command prepares git to run with 'args'. The command is killed if 'ctx' is
+// done before it finishes.
+func (r Runner) Command(ctx context.Context, args ...string) *exec.Cmd {
+	var full []string
+	for _, o := range overrides {
+		full = append(full, "-c", o)
+	}
+	full = append(full, args...)
+
+	cmd := exec.CommandContext(ctx, r.git(), full...)
+	cmd.Dir = r.Dir
+	cmd.Env = Env(os.Environ())
+	return cmd
+}
+
+// Output runs git with 'args' and returns its standard output. Standard
+// error is available from the *exec.ExitError of failed commands.
+func (r Runner) Output(ctx context.Context, args ...string) ([]byte, error) {
+	return r.Command(ctx, args...).Output()
+}
+
+// git is the executable to run.
+func (r Runner) git() string {
+	if r.Git == "" {
+		return "git"
+	}
+	return r.Git
+}
+
+// Env returns 'environ', such as os.Environ(), without the variables that
+// could change how git behaves and with the ones that make it deterministic.
+func Env(environ []string) []string {
+	env := make([]string, 0, len(environ)+len(cleanEnv))
+	for _, kv := range environ {
+		if !dropped(kv) {
+			env = append(env, kv)
+		}
+	}
+	return append(env, cleanEnv...)
+}
+
+// dropped reports whether the "KEY=value" variable 'kv' is left out of the
+// environment. Entries of droppedEnv ending in an underscore are prefixes.
+func dropped(kv string) bool {
+	key := kv
+	if i := strings.IndexByte(kv, '='); i >= 0 {
+		key = kv[:i]
+	}
+
+	for _, d := range droppedEnv {
+		if key == d || strings.HasSuffix(d, "_") && strings.HasPrefix(key, d) {
+			return true
+		}
+	}
+	return false
+}
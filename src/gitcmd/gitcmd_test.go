@@ -0,0 +1,64 @@
+package gitcmd
+
+import (
+	"context"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEnv(t *testing.T) {
+	environ := []string{
+		"HOME=/home/abe",
+		"LANG=fr_FR.UTF-8",
+		"LC_MESSAGES=fr_FR.UTF-8",
+		"GIT_PAGER=less",
+		"GIT_DIR=/elsewhere/.git",
+		"GIT_AUTHOR_NAME=Abraham Lincoln",
+		"LC_ALLOWED=yes",
+	}
+
+	expected := []string{
+		"HOME=/home/abe",
+		"GIT_AUTHOR_NAME=Abraham Lincoln",
+		"LC_ALL=C",
+		"GIT_PAGER=cat",
+		"PAGER=cat",
+		"GIT_TERMINAL_PROMPT=0",
+		"GIT_OPTIONAL_LOCKS=0",
+	}
+
+	if got := Env(environ); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Got %v, expected %v\n", got, expected)
+	}
+}
+
+func TestCommand(t *testing.T) {
+	cmd := Runner{Git: "/opt/git/bin/git", Dir: "/src"}.Command(context.Background(),
+		"log", "-1")
+
+	if cmd.Path != "/opt/git/bin/git" {
+		t.Errorf("Got path %s, expected /opt/git/bin/git\n", cmd.Path)
+	}
+	if cmd.Dir != "/src" {
+		t.Errorf("Got dir %s, expected /src\n", cmd.Dir)
+	}
+	if args := strings.Join(cmd.Args[len(cmd.Args)-2:], " "); args != "log -1" {
+		t.Errorf("Got trailing args '%s', expected 'log -1'\n", args)
+	}
+}
+
+func TestOutput(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	out, err := Runner{}.Output(context.Background(), "config", "core.pager")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	if got := strings.TrimSpace(string(out)); got != "cat" {
+		t.Errorf("Got pager '%s', expected 'cat'\n", got)
+	}
+}
@@ -0,0 +1,114 @@
+package gitcmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Version is the version of a git executable.
+type Version struct {
+	Major, Minor, Patch int
+}
+
+// Versions git features first appeared in. Older versions are too old to
+// run at all, or run without the feature.
+var (
+	// MinVersion is the oldest git that runs: config --show-origin is needed
+	// to read settings.
+	MinVersion = Version{2, 8, 0}
+	// IgnoreRevVersion brings blame --ignore-rev, without which merges and
+	// reverts are credited like any commit.
+	IgnoreRevVersion = Version{2, 23, 0}
+)
+
+// String formats the version like git does, such as "2.23.0".
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// AtLeast reports whether 'v' is 'min' or newer.
+func (v Version) AtLeast(min Version) bool {
+	if v.Major != min.Major {
+		return v.Major > min.Major
+	}
+	if v.Minor != min.Minor {
+		return v.Minor > min.Minor
+	}
+	return v.Patch >= min.Patch
+}
+
+// ParseVersion reads the output of `git version`, such as "git version
+// 2.39.2" or "git version 2.24.3 (Apple Git-128)". Vendor suffixes like
+// ".windows.1" and release candidates like "-rc1" are ignored.
+func ParseVersion(out string) (Version, error) {
+	fields := strings.Fields(out)
+	if len(fields) < 3 || fields[0] != "git" || fields[1] != "version" {
+		return Version{}, errors.Errorf("unexpected git version '%s'", strings.TrimSpace(out))
+	}
+
+	var numbers [3]int
+	for i, part := range strings.SplitN(fields[2], ".", 4) {
+		if i == len(numbers) {
+			break
+		}
+		if dash := strings.IndexByte(part, '-'); dash >= 0 {
+			part = part[:dash]
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return Version{}, errors.Errorf("unexpected git version '%s'", fields[2])
+		}
+		numbers[i] = n
+	}
+
+	return Version{numbers[0], numbers[1], numbers[2]}, nil
+}
+
+// versions remembers the version of each git executable, which doesn't
+// change while a program runs.
+var versions = struct {
+	sync.Mutex
+	byGit map[string]Version
+}{byGit: make(map[string]Version)}
+
+// Version runs `git version` the first time it is asked for the version of
+// Git, and returns the version it reported.
+func (r Runner) Version(ctx context.Context) (Version, error) {
+	versions.Lock()
+	defer versions.Unlock()
+
+	if v, ok := versions.byGit[r.git()]; ok {
+		return v, nil
+	}
+
+	out, err := r.Output(ctx, "version")
+	if err != nil {
+		return Version{}, errors.Wrapf(err, "unable to run %s", r.git())
+	}
+	v, err := ParseVersion(string(out))
+	if err != nil {
+		return Version{}, err
+	}
+
+	versions.byGit[r.git()] = v
+	return v, nil
+}
+
+// CheckVersion returns an error explaining the problem if Git can't run or is
+// older than MinVersion.
+func (r Runner) CheckVersion(ctx context.Context) (Version, error) {
+	v, err := r.Version(ctx)
+	if err != nil {
+		return v, err
+	}
+	if !v.AtLeast(MinVersion) {
+		return v, errors.Errorf("git %s is older than %s, the oldest version supported",
+			v, MinVersion)
+	}
+	return v, nil
+}
@@ -0,0 +1,66 @@
+package gitcmd
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		out      string
+		expected Version
+		err      bool
+	}{
+		{"git version 2.39.2\n", Version{2, 39, 2}, false},
+		{"git version 2.24.3 (Apple Git-128)\n", Version{2, 24, 3}, false},
+		{"git version 2.41.0.windows.1\n", Version{2, 41, 0}, false},
+		{"git version 2.40.0-rc1\n", Version{2, 40, 0}, false},
+		{"git version 2.7\n", Version{2, 7, 0}, false},
+		{"hub version 2.14.2\n", Version{}, true},
+		{"git version two\n", Version{}, true},
+		{"", Version{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseVersion(tt.out)
+		if (err != nil) != tt.err {
+			t.Errorf("Got error %v for '%s', expected error: %t\n", err, tt.out, tt.err)
+		}
+		if got != tt.expected {
+			t.Errorf("Got %s, expected %s for '%s'\n", got, tt.expected, tt.out)
+		}
+	}
+}
+
+func TestVersionAtLeast(t *testing.T) {
+	tests := []struct {
+		v, min   Version
+		expected bool
+	}{
+		{Version{2, 23, 0}, Version{2, 23, 0}, true},
+		{Version{2, 23, 1}, Version{2, 23, 0}, true},
+		{Version{2, 22, 9}, Version{2, 23, 0}, false},
+		{Version{3, 0, 0}, Version{2, 23, 0}, true},
+		{Version{1, 99, 0}, Version{2, 8, 0}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.v.AtLeast(tt.min); got != tt.expected {
+			t.Errorf("Got %t, expected %t for %s at least %s\n", got, tt.expected, tt.v, tt.min)
+		}
+	}
+}
+
+func TestCheckVersion(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	if _, err := (Runner{}).CheckVersion(context.Background()); err != nil {
+		t.Errorf("Unexpected error checking the installed git: %v\n", err)
+	}
+	if _, err := (Runner{Git: "/nonexistent/git"}).CheckVersion(context.Background()); err == nil {
+		t.Errorf("Expected an error for a missing git\n")
+	}
+}
@@ -0,0 +1,145 @@
+package gitreviewers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// GitHubAPI is the address of the GitHub REST API.
+const GitHubAPI = "https://api.github.com"
+
+// noreplyEmail matches the private addresses GitHub commits with, which
+// embed the username.
+var noreplyEmail = regexp.MustCompile(`^(?:\d+\+)?([^@]+)@users\.noreply\.github\.com$`)
+
+// github builds the provider for a GitHub remote: github.com, or GitHub
+// Enterprise at reviewer.githubUrl, or the API of the remote's host otherwise.
+// Requests are authenticated with the token in GH_TOKEN or GITHUB_TOKEN, the
+// variables the GitHub CLI reads.
+func (r *ContributionCounter) github(c *Config, remote Remote) ReviewProvider {
+	u, _ := c.Get("reviewer.githubUrl")
+	switch {
+	case u != "":
+	case remote.Host == "" || remote.Host == "github.com" || remote.Host == "ssh.github.com":
+		u = GitHubAPI
+	default:
+		u = "https://" + remote.Host + "/api/v3"
+	}
+
+	token := os.Getenv("GH_TOKEN")
+	if token == "" {
+		token = os.Getenv("GITHUB_TOKEN")
+	}
+
+	return &GitHub{URL: strings.TrimSuffix(u, "/"), Owner: remote.Owner, Repo: remote.Repo,
+		Token: token, Client: r.HTTPClient()}
+}
+
+// GitHub is the ReviewProvider of repositories on GitHub or GitHub
+// Enterprise. Accounts are identified by username.
+type GitHub struct {
+	// URL is the address of the API, GitHubAPI for github.com.
+	URL   string
+	Owner string
+	Repo  string
+	// Token is a personal access token, or the token gh is logged in with.
+	Token  string
+	Client *http.Client
+}
+
+func (g *GitHub) api() apiClient {
+	return apiClient{URL: g.URL, Token: g.Token, Client: g.Client}
+}
+
+func (g *GitHub) repoPath() string {
+	return fmt.Sprintf("/repos/%s/%s", url.PathEscape(g.Owner), url.PathEscape(g.Repo))
+}
+
+// githubUser holds the fields of a GitHub user we use.
+type githubUser struct {
+	Login string `json:"login"`
+}
+
+// User finds the account of a committer. Private noreply addresses carry the
+// username, and other addresses are looked up through the commits of the
+// repository they authored.
+func (g *GitHub) User(email string) (Account, error) {
+	if m := noreplyEmail.FindStringSubmatch(email); m != nil {
+		return Account{ID: m[1], Name: m[1]}, nil
+	}
+
+	var commits []struct {
+		Author *githubUser `json:"author"`
+	}
+	err := g.api().do("GET", g.repoPath()+"/commits?per_page=1&author="+url.QueryEscape(email),
+		nil, &commits)
+	switch {
+	case err == errAPINotFound || err == nil && (len(commits) == 0 || commits[0].Author == nil):
+		return Account{}, nil
+	case err != nil:
+		return Account{}, errors.Wrapf(err, "unable to look up %s on GitHub", email)
+	}
+
+	login := commits[0].Author.Login
+	return Account{ID: login, Name: login}, nil
+}
+
+// PullRequest finds the open pull request from 'branch' of the repository.
+func (g *GitHub) PullRequest(branch string) (*PullRequest, error) {
+	head := url.QueryEscape(g.Owner + ":" + branch)
+
+	var pulls []struct {
+		Number int        `json:"number"`
+		Title  string     `json:"title"`
+		User   githubUser `json:"user"`
+		Base   struct {
+			Ref string `json:"ref"`
+		} `json:"base"`
+		HTMLURL string `json:"html_url"`
+	}
+	if err := g.api().do("GET", g.repoPath()+"/pulls?state=open&head="+head, nil, &pulls); err != nil {
+		return nil, errors.Wrap(err, "unable to find pull request on GitHub")
+	}
+	if len(pulls) == 0 {
+		return nil, nil
+	}
+
+	p := pulls[0]
+	return &PullRequest{ID: strconv.Itoa(p.Number), Title: p.Title,
+		Author: Account{ID: p.User.Login, Name: p.User.Login}, Base: p.Base.Ref,
+		URL: p.HTMLURL}, nil
+}
+
+// AddReviewers requests reviews from 'accounts' on 'pr'. GitHub keeps the
+// reviews already requested.
+func (g *GitHub) AddReviewers(pr *PullRequest, accounts []Account) error {
+	logins := make([]string, 0, len(accounts))
+	for _, a := range accounts {
+		logins = append(logins, a.ID)
+	}
+
+	request := map[string][]string{"reviewers": logins}
+	return errors.Wrap(g.api().do("POST", g.repoPath()+"/pulls/"+pr.ID+"/requested_reviewers",
+		request, nil), "unable to request reviews on GitHub")
+}
+
+// OpenReviews counts the open pull requests 'account' is asked to review, in
+// every repository.
+func (g *GitHub) OpenReviews(account Account) (int, error) {
+	q := url.QueryEscape("is:pr is:open review-requested:" + account.ID)
+
+	var result struct {
+		TotalCount int `json:"total_count"`
+	}
+	if err := g.api().do("GET", "/search/issues?per_page=1&q="+q, nil, &result); err != nil {
+		return 0, errors.Wrapf(err, "unable to count the open reviews of %s", account.Name)
+	}
+	return result.TotalCount, nil
+}
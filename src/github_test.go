@@ -0,0 +1,105 @@
+package gitreviewers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestGitHub(t *testing.T) {
+	var requested map[string][]string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		q := req.URL.Query()
+		switch req.Method + " " + req.URL.Path {
+		case "GET /repos/team/app/commits":
+			switch q.Get("author") {
+			case "abe@git-reviewer.com":
+				fmt.Fprint(w, `[{"sha": "abc", "author": {"login": "abe"}}]`)
+			case "ben@git-reviewer.com":
+				// Commits by addresses not linked to an account
+				fmt.Fprint(w, `[{"sha": "def", "author": null}]`)
+			default:
+				fmt.Fprint(w, `[]`)
+			}
+		case "GET /repos/team/app/pulls":
+			if q.Get("head") != "team:feature" || q.Get("state") != "open" {
+				fmt.Fprint(w, `[]`)
+				return
+			}
+			fmt.Fprint(w, `[{"number": 7, "title": "Add a feature", "user": {"login": "carl"},
+				"base": {"ref": "main"}, "html_url": "https://github.com/team/app/pull/7"}]`)
+		case "POST /repos/team/app/pulls/7/requested_reviewers":
+			json.NewDecoder(req.Body).Decode(&requested)
+			fmt.Fprint(w, `{}`)
+		case "GET /search/issues":
+			if q.Get("q") == "is:pr is:open review-requested:abe" {
+				fmt.Fprint(w, `{"total_count": 4}`)
+				return
+			}
+			fmt.Fprint(w, `{"total_count": 0}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	g := &GitHub{URL: srv.URL, Owner: "team", Repo: "app", Token: "secret"}
+
+	users := []struct {
+		email    string
+		expected Account
+	}{
+		{"abe@git-reviewer.com", Account{ID: "abe", Name: "abe"}},
+		{"ben@git-reviewer.com", Account{}},
+		{"carl@git-reviewer.com", Account{}},
+		{"123+dan@users.noreply.github.com", Account{ID: "dan", Name: "dan"}},
+	}
+	for _, u := range users {
+		got, err := g.User(u.email)
+		if err != nil {
+			t.Fatalf("Unexpected error looking up %s: %v\n", u.email, err)
+		}
+		if got != u.expected {
+			t.Errorf("Got %+v, expected %+v for %s\n", got, u.expected, u.email)
+		}
+	}
+
+	pr, err := g.PullRequest("feature")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &PullRequest{ID: "7", Title: "Add a feature", Author: Account{ID: "carl", Name: "carl"},
+		Base: "main", URL: "https://github.com/team/app/pull/7"}
+	if !reflect.DeepEqual(pr, expected) {
+		t.Errorf("Got %+v, expected %+v\n", pr, expected)
+	}
+
+	if pr, err := g.PullRequest("other"); err != nil || pr != nil {
+		t.Errorf("Got %+v (error: %v), expected no pull request\n", pr, err)
+	}
+
+	if err := g.AddReviewers(expected, []Account{{ID: "abe", Name: "abe"}, {ID: "dan"}}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(requested["reviewers"], []string{"abe", "dan"}) {
+		t.Errorf("Got request %v, expected reviews from abe and dan\n", requested)
+	}
+
+	if n, err := g.OpenReviews(Account{ID: "abe", Name: "abe"}); err != nil || n != 4 {
+		t.Errorf("Got %d (error: %v), expected 4 open reviews for abe\n", n, err)
+	}
+
+	g.Token = "wrong"
+	if _, err := g.PullRequest("feature"); err == nil {
+		t.Error("Expected an error with the wrong token")
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"bytes"
 	"io"
 	"os"
+
+	"github.com/pkg/errors"
 )
 
 // runGuard supports programming with the "sticky errors" pattern, allowing
@@ -45,11 +47,13 @@ func (rg *runGuard) maybeRunMany(fns ...func()) {
 
 type mailmap map[string]string
 
-func readMailmap(paths []string) (mailmap, error) {
+// readMailmap merges the mailmap files at 'paths', opened with 'open', and
+// skips those that can't be opened.
+func readMailmap(open func(string) (io.ReadCloser, error), paths []string) (mailmap, error) {
 	mm := make(mailmap)
 
 	for _, p := range paths {
-		if f, err := os.Open(p); err == nil {
+		if f, err := open(p); err == nil {
 			readMailmapFromSource(mm, f)
 			f.Close()
 		}
@@ -111,6 +115,38 @@ func readMailmapFromSource(mm mailmap, src io.Reader) error {
 	}
 }
 
+// CheckMailmap reads the mailmap file at 'path' and reports the first line
+// that maps no email address, which git ignores. A missing file is fine.
+func CheckMailmap(path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "unable to open mailmap")
+	}
+	defer f.Close()
+
+	return errors.Wrap(checkMailmapSource(f), path)
+}
+
+func checkMailmapSource(src io.Reader) error {
+	scanner := bufio.NewScanner(src)
+
+	for n := 1; scanner.Scan(); n++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+
+		if _, email, _ := parseMailmapLine(line, 0); email == "" {
+			return errors.Errorf("line %d has no <email>: %s", n, line)
+		}
+	}
+
+	return scanner.Err()
+}
+
 func parseMailmapLine(line []byte, offset int) (name string, email string, right int) {
 	var left int
 
@@ -132,3 +168,26 @@ func parseMailmapLine(line []byte, offset int) (name string, email string, right
 
 	return
 }
+
+// readTeams reads a team mapping where each line names a team followed by the
+// email of one of its members, e.g. "Platform <abe@git-reviewer.com>". The
+// format mirrors mailmap files so the same parsing rules apply.
+func readTeams(src io.Reader) (map[string]string, error) {
+	teams := make(map[string]string)
+
+	scanner := bufio.NewScanner(src)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+
+		// Skip comments and blank lines
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+
+		if team, email, _ := parseMailmapLine(line, 0); team != "" && email != "" {
+			teams[email] = team
+		}
+	}
+
+	return teams, scanner.Err()
+}
@@ -53,6 +53,28 @@ func TestParseMailmap(t *testing.T) {
 	}
 }
 
+func TestCheckMailmap(t *testing.T) {
+	cases := []struct {
+		Input    string
+		Expected string
+	}{
+		{mapcontent, ""},
+		{"Abraham Lincoln abe@git-reviewer.com\n", "line 1 has no <email>: Abraham Lincoln abe@git-reviewer.com"},
+		{"# Nobody\n\nAbe <abe@git-reviewer.com>\n  Abe\n", "line 4 has no <email>: Abe"},
+		{"Abe <>\n", "line 1 has no <email>: Abe <>"},
+	}
+
+	for _, c := range cases {
+		var got string
+		if err := checkMailmapSource(strings.NewReader(c.Input)); err != nil {
+			got = err.Error()
+		}
+		if got != c.Expected {
+			t.Errorf("Got '%s', expected '%s'\n", got, c.Expected)
+		}
+	}
+}
+
 func BenchmarkParseMailmap(t *testing.B) {
 	var (
 		mm  mailmap
@@ -135,3 +157,23 @@ func TestParseMailmapLine(t *testing.T) {
 		}
 	}
 }
+
+func TestReadTeams(t *testing.T) {
+	content := `# Teams
+Core <abe@git-reviewer.com>
+Platform <george@git-reviewer.com>
+<nobody@git-reviewer.com>
+`
+	teams, err := readTeams(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Unexpected error reading teams: %v\n", err)
+	}
+
+	if len(teams) != 2 {
+		t.Errorf("Got %d team members, expected 2\n", len(teams))
+	}
+
+	if team := teams["george@git-reviewer.com"]; team != "Platform" {
+		t.Errorf("Got team '%s', expected 'Platform'\n", team)
+	}
+}
@@ -0,0 +1,217 @@
+package gitreviewers
+
+import (
+	"bufio"
+	"container/heap"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// trailerRx matches the commit message trailers teams use to record who
+// reviewed a change, such as "Reviewed-by: Jane Doe <jane@example.com>".
+var trailerRx = regexp.MustCompile(`(?i)^(reviewed-by|approved-by|acked-by):\s*(.+)$`)
+
+// Review records that a collaborator reviewed a commit written by someone
+// else.
+type Review struct {
+	Commit   string
+	Date     time.Time
+	Author   string
+	Reviewer string
+}
+
+// ReviewHistory walks the history of the base revision and returns every review
+// recorded in commit trailers for commits made after Since. Authors and
+// reviewers are resolved through the mailmap so they line up with the
+// identities used for suggestions.
+//
+// History is walked back to Since, commit by commit, which is slow on large
+// repositories, so when the repository has a commit-graph file, as written by
+// `git commit-graph write` or `git gc`, git reads it instead.
+func (r *ContributionCounter) ReviewHistory() ([]Review, error) {
+	r.defaultSince()
+
+	m, err := r.resolve(r.historyRev())
+	if err != nil {
+		return nil, errors.Wrap(err, "issue resolving base revision")
+	}
+
+	if r.hasCommitGraph() {
+		return r.gitReviewHistory(m.String())
+	}
+	return r.walkReviewHistory(m)
+}
+
+// walkReviewHistory reads the reviews of the commits reachable from 'm'
+// through go-git, which needs no git binary. Commits are visited newest first,
+// and the walk stops once every commit left was made before the day before
+// Since, like gitReviewHistory.
+func (r *ContributionCounter) walkReviewHistory(m plumbing.Hash) ([]Review, error) {
+	var cutoff time.Time
+	if since, err := time.Parse("2006-01-02", r.Since); err == nil {
+		cutoff = since.AddDate(0, 0, -1)
+	}
+
+	start, err := r.Repo.CommitObject(m)
+	if err != nil {
+		return nil, errors.Wrap(err, "issue reading master history")
+	}
+
+	var (
+		reviews []Review
+		queue   = commitsByTime{start}
+		seen    = map[plumbing.Hash]bool{m: true}
+	)
+	for queue.Len() > 0 {
+		c := heap.Pop(&queue).(*object.Commit)
+		if c.Committer.When.Before(cutoff) {
+			break
+		}
+
+		reviews = append(reviews, r.commitReviews(c.Hash.String(), c.Committer.When,
+			c.Author.Email, c.Message)...)
+
+		err := c.Parents().ForEach(func(p *object.Commit) error {
+			if !seen[p.Hash] {
+				seen[p.Hash] = true
+				heap.Push(&queue, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "issue reading master history")
+		}
+	}
+
+	return reviews, nil
+}
+
+// commitsByTime is a heap of commits that pops the most recently committed
+// first.
+type commitsByTime []*object.Commit
+
+func (c commitsByTime) Len() int           { return len(c) }
+func (c commitsByTime) Less(i, j int) bool { return c[i].Committer.When.After(c[j].Committer.When) }
+func (c commitsByTime) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+
+func (c *commitsByTime) Push(val interface{}) {
+	*c = append(*c, val.(*object.Commit))
+}
+
+func (c *commitsByTime) Pop() interface{} {
+	n := len(*c)
+	commit := (*c)[n-1]
+	*c = (*c)[:n-1]
+	return commit
+}
+
+// gitReviewHistory reads the reviews of the commits reachable from 'rev' with
+// git log, limited to commits made since the day before Since so git can cut
+// the walk short. The exact Since is applied on top, as walkReviewHistory
+// does, since git compares dates in the local time zone.
+func (r *ContributionCounter) gitReviewHistory(rev string) ([]Review, error) {
+	args := []string{"log", "-z", "--format=%H%n%cI%n%ae%n%B"}
+	if since, err := time.Parse("2006-01-02", r.Since); err == nil {
+		args = append(args, "--since="+since.AddDate(0, 0, -1).Format("2006-01-02"))
+	}
+	out, err := r.output(append(args, rev, "--")...)
+	if err != nil {
+		return nil, errors.Wrap(err, "issue reading master history")
+	}
+
+	var reviews []Review
+	for _, record := range strings.Split(string(out), "\x00") {
+		fields := strings.SplitN(strings.TrimPrefix(record, "\n"), "\n", 4)
+		if len(fields) < 3 {
+			continue
+		}
+		date, err := time.Parse(time.RFC3339, fields[1])
+		if err != nil {
+			return nil, errors.Wrapf(err, "issue parsing date of commit %s", fields[0])
+		}
+
+		var msg string
+		if len(fields) == 4 {
+			msg = fields[3]
+		}
+		reviews = append(reviews, r.commitReviews(fields[0], date, fields[2], msg)...)
+	}
+
+	return reviews, nil
+}
+
+// commitReviews returns the reviews recorded in the trailers of a commit, or
+// none if it was made before Since.
+func (r *ContributionCounter) commitReviews(hash string, date time.Time, email,
+	msg string) []Review {
+	if date.Format("2006-01-02") < r.Since {
+		return nil
+	}
+
+	var reviews []Review
+	author := reviewerKey(email, r.Mailmap)
+	for _, reviewer := range parseReviewTrailers(msg) {
+		reviews = append(reviews, Review{
+			Commit:   hash,
+			Date:     date,
+			Author:   author,
+			Reviewer: reviewerKey(reviewer, r.Mailmap),
+		})
+	}
+
+	return reviews
+}
+
+// hasCommitGraph reports whether the repository has a commit-graph file,
+// either whole or split into a chain.
+func (r *ContributionCounter) hasCommitGraph() bool {
+	out, err := r.output("rev-parse", "--git-path", "objects/info/commit-graph",
+		"--git-path", "objects/info/commit-graphs/commit-graph-chain")
+	if err != nil {
+		return false
+	}
+
+	for _, p := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(r.Dir, p)
+		}
+		if f, err := r.open(p); err == nil {
+			f.Close()
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseReviewTrailers returns the email of everyone named in a review trailer
+// of a commit message. Trailers without an email fall back to the name.
+func parseReviewTrailers(msg string) []string {
+	var reviewers []string
+
+	scn := bufio.NewScanner(strings.NewReader(msg))
+	for scn.Scan() {
+		match := trailerRx.FindStringSubmatch(strings.TrimSpace(scn.Text()))
+		if match == nil {
+			continue
+		}
+
+		name, email, _ := parseMailmapLine([]byte(match[2]), 0)
+		switch {
+		case email != "":
+			reviewers = append(reviewers, email)
+		case name != "":
+			reviewers = append(reviewers, name)
+		default:
+			reviewers = append(reviewers, strings.TrimSpace(match[2]))
+		}
+	}
+
+	return reviewers
+}
@@ -0,0 +1,167 @@
+package gitreviewers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseReviewTrailers(t *testing.T) {
+	msg := `Fix the frobnicator
+
+Reviewed-by: Abraham Lincoln <abe@git-reviewer.com>
+Signed-off-by: George Washington <george@git-reviewer.com>
+acked-by: <george@gmail.com>
+Approved-by: ben
+`
+	expected := []string{"abe@git-reviewer.com", "george@gmail.com", "ben"}
+
+	actual := parseReviewTrailers(msg)
+	if len(actual) != len(expected) {
+		t.Fatalf("Got reviewers %v, expected %v\n", actual, expected)
+	}
+
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Errorf("Got reviewer '%s', expected '%s'\n", actual[i], expected[i])
+		}
+	}
+}
+
+func TestReviewHistoryCommitGraph(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	// An old review left out by Since and two recent ones
+	os.Setenv("GIT_COMMITTER_DATE", "2017-03-01T12:00:00Z")
+	runGit(t, dir, "commit", "-q", "--allow-empty", "-m",
+		"Old change\n\nReviewed-by: Ben <ben@git-reviewer.com>")
+	os.Unsetenv("GIT_COMMITTER_DATE")
+	runGit(t, dir, "commit", "-q", "--allow-empty", "-m",
+		"Change\n\nReviewed-by: George <george@gmail.com>\nAcked-by: ben")
+	runGit(t, dir, "commit", "-q", "--allow-empty", "-m", "Unreviewed change")
+
+	repo, _, err := OpenRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := ContributionCounter{Repo: repo, Dir: dir, Since: "2018-01-01",
+		Mailmap: mailmap{"george@gmail.com": "george@git-reviewer.com"}}
+
+	render := func(reviews []Review) []string {
+		var s []string
+		for _, rv := range reviews {
+			s = append(s, fmt.Sprintf("%s %s %s", rv.Date.Format(time.RFC3339), rv.Author,
+				rv.Reviewer))
+		}
+		return s
+	}
+
+	if r.hasCommitGraph() {
+		t.Fatal("Expected no commit-graph before writing one")
+	}
+	walked, err := r.ReviewHistory()
+	if err != nil {
+		t.Fatalf("Unexpected error walking history: %v\n", err)
+	}
+	if len(walked) != 2 {
+		t.Errorf("Got %v, expected the 2 recent reviews\n", render(walked))
+	}
+
+	runGit(t, dir, "commit-graph", "write", "--reachable")
+	if !r.hasCommitGraph() {
+		t.Fatal("Expected to find the commit-graph")
+	}
+	read, err := r.ReviewHistory()
+	if err != nil {
+		t.Fatalf("Unexpected error reading history with git: %v\n", err)
+	}
+	if !reflect.DeepEqual(render(read), render(walked)) {
+		t.Errorf("Got %v with the commit-graph, expected %v\n", render(read), render(walked))
+	}
+}
+
+func TestWalkReviewHistoryStopsAtSince(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+	root := strings.TrimSpace(runGit(t, dir, "rev-parse", "HEAD"))
+
+	os.Setenv("GIT_COMMITTER_DATE", "2017-03-01T12:00:00Z")
+	runGit(t, dir, "commit", "-q", "--allow-empty", "-m",
+		"Old change\n\nReviewed-by: Ben <ben@git-reviewer.com>")
+	os.Unsetenv("GIT_COMMITTER_DATE")
+	runGit(t, dir, "commit", "-q", "--allow-empty", "-m",
+		"Change\n\nReviewed-by: Ben <ben@git-reviewer.com>")
+
+	// Reading any commit past the old change would fail
+	if err := os.Remove(filepath.Join(dir, ".git", "objects", root[:2], root[2:])); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, _, err := OpenRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := ContributionCounter{Repo: repo, Dir: dir, Since: "2018-01-01"}
+	m, err := r.resolve("master")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reviews, err := r.walkReviewHistory(m)
+	if err != nil {
+		t.Fatalf("Unexpected error walking history: %v\n", err)
+	}
+	if len(reviews) != 1 || reviews[0].Reviewer != "ben@git-reviewer.com" {
+		t.Errorf("Got %+v, expected the recent review only\n", reviews)
+	}
+}
+
+// BenchmarkReviewHistory compares walking history with go-git to reading it
+// with git through a commit-graph, in a repository where most commits are
+// older than Since.
+func BenchmarkReviewHistory(b *testing.B) {
+	dir := newTestRepo(b)
+	defer os.RemoveAll(dir)
+
+	for i := 0; i < 500; i++ {
+		if i == 490 {
+			os.Setenv("GIT_COMMITTER_DATE", time.Now().Format(time.RFC3339))
+		} else if i == 0 {
+			os.Setenv("GIT_COMMITTER_DATE", "2017-03-01T12:00:00Z")
+		}
+		runGit(b, dir, "commit", "-q", "--allow-empty", "-m",
+			"Change\n\nReviewed-by: Ben <ben@git-reviewer.com>")
+	}
+	os.Unsetenv("GIT_COMMITTER_DATE")
+	runGit(b, dir, "commit-graph", "write", "--reachable")
+
+	repo, _, err := OpenRepository(dir)
+	if err != nil {
+		b.Fatal(err)
+	}
+	r := ContributionCounter{Repo: repo, Dir: dir, Since: "2018-01-01"}
+	m, err := r.resolve("master")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("go-git", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := r.walkReviewHistory(m); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("commit-graph", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := r.gitReviewHistory(m.String()); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,85 @@
+package gitreviewers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// hookMarker identifies the hooks git-reviewer installed, which it may
+// replace or remove.
+const hookMarker = "# Installed by git-reviewer"
+
+// prePushHook runs the pre-push check of git-reviewer, passing along the
+// remote git pushes to.
+const prePushHook = "#!/bin/sh\n" + hookMarker + "\nexec git reviewer hook pre-push \"$@\"\n"
+
+// prePushPath finds where git looks for the pre-push hook of the repository
+// in Dir, which may be outside of it, such as in a linked worktree.
+func (r *ContributionCounter) prePushPath() (string, error) {
+	out, err := r.output("rev-parse", "--git-path", "hooks/pre-push")
+	if err != nil {
+		return "", errors.Wrap(err, "unable to find the hooks directory")
+	}
+
+	path := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(r.Dir, path)
+	}
+	return path, nil
+}
+
+// ownHook reports whether git-reviewer installed the hook at 'path'. It
+// returns an error if something else did, and false if there is no hook.
+func ownHook(path string) (bool, error) {
+	existing, err := ioutil.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+		return false, nil
+	case err != nil:
+		return false, err
+	case !strings.Contains(string(existing), hookMarker):
+		return false, errors.Errorf("%s was not installed by git-reviewer", path)
+	}
+	return true, nil
+}
+
+// InstallPrePushHook writes the pre-push hook of the repository, which runs
+// `git reviewer hook pre-push`, and returns where it was written. A hook
+// git-reviewer installed before is replaced, and hooks installed by something
+// else are left alone.
+func (r *ContributionCounter) InstallPrePushHook() (string, error) {
+	path, err := r.prePushPath()
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := ownHook(path); err != nil {
+		return "", errors.Errorf("%s already exists; add 'git reviewer hook pre-push \"$@\"' to it",
+			path)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	return path, ioutil.WriteFile(path, []byte(prePushHook), 0755)
+}
+
+// UninstallPrePushHook removes the pre-push hook InstallPrePushHook wrote and
+// returns where it was, or an empty string if there was none. Hooks installed
+// by something else are left alone.
+func (r *ContributionCounter) UninstallPrePushHook() (string, error) {
+	path, err := r.prePushPath()
+	if err != nil {
+		return "", err
+	}
+
+	own, err := ownHook(path)
+	if err != nil || !own {
+		return "", err
+	}
+	return path, os.Remove(path)
+}
@@ -0,0 +1,63 @@
+package gitreviewers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPrePushHook(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	r := ContributionCounter{Dir: dir}
+	hook := filepath.Join(dir, ".git", "hooks", "pre-push")
+
+	// Installing twice replaces the hook git-reviewer installed
+	for i := 0; i < 2; i++ {
+		path, err := r.InstallPrePushHook()
+		if err != nil {
+			t.Fatalf("Unexpected error installing the hook: %v\n", err)
+		}
+		if path != hook {
+			t.Errorf("Got hook at %s, expected %s\n", path, hook)
+		}
+	}
+
+	info, err := os.Stat(hook)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode()&0111 == 0 {
+		t.Errorf("Got mode %v, expected the hook to be executable\n", info.Mode())
+	}
+	if content, _ := ioutil.ReadFile(hook); string(content) != prePushHook {
+		t.Errorf("Got hook %q, expected %q\n", content, prePushHook)
+	}
+
+	if path, err := r.UninstallPrePushHook(); err != nil || path != hook {
+		t.Errorf("Got %s (error: %v), expected the hook to be removed from %s\n", path, err, hook)
+	}
+	if _, err := os.Stat(hook); !os.IsNotExist(err) {
+		t.Errorf("Expected the hook to be gone, got %v\n", err)
+	}
+	if path, err := r.UninstallPrePushHook(); err != nil || path != "" {
+		t.Errorf("Got %s (error: %v), expected nothing to uninstall\n", path, err)
+	}
+
+	// Hooks installed by something else are left alone
+	other := []byte("#!/bin/sh\nexec lint\n")
+	if err := ioutil.WriteFile(hook, other, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.InstallPrePushHook(); err == nil {
+		t.Error("Expected an error installing over another hook")
+	}
+	if _, err := r.UninstallPrePushHook(); err == nil {
+		t.Error("Expected an error uninstalling another hook")
+	}
+	if content, _ := ioutil.ReadFile(hook); string(content) != string(other) {
+		t.Errorf("Got hook %q, expected the other hook to be kept\n", content)
+	}
+}
@@ -0,0 +1,355 @@
+package gitreviewers
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Settings of the HTTP client provider integrations share.
+const (
+	// apiTimeout bounds connecting to an API and waiting for its answer.
+	apiTimeout = 10 * time.Second
+	// apiRetries is how many times a request is tried again after failing
+	// with a network error, a rate limit or an unavailable server.
+	apiRetries = 3
+	// apiBackoff is the wait before the first retry, doubled before each of
+	// the next ones, when the API doesn't say how long to wait.
+	apiBackoff = 500 * time.Millisecond
+	// maxAPIWait is the longest we wait for a rate limit to reset. Requests
+	// that would wait longer fail, or are answered from the cache.
+	maxAPIWait = 30 * time.Second
+	// responseTTL is how long API responses are kept to revalidate them and
+	// to answer from when the API can't be reached.
+	responseTTL = 7 * 24 * time.Hour
+)
+
+// NewHTTPClient builds the client provider integrations call their APIs
+// with. It retries requests that fail with network errors, rate limits or
+// unavailable servers, backing off exponentially or as long as the API asks
+// with Retry-After, and waits for exhausted rate limits to reset when
+// X-RateLimit-Remaining and X-RateLimit-Reset say they will shortly.
+//
+// With a cache, successful GET responses are stored in it and revalidated
+// with If-None-Match and If-Modified-Since, so unchanged answers, such as the
+// accounts of reviewers, don't count against rate limits on most APIs. When
+// the API can't be reached or is rate limited, the stored response is used
+// instead. Progress is reported through 'logf' if it isn't nil.
+func NewHTTPClient(c Cache, logf func(string, ...interface{})) *http.Client {
+	if logf == nil {
+		logf = func(string, ...interface{}) {}
+	}
+
+	return &http.Client{Transport: &apiTransport{
+		base: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			DialContext:           (&net.Dialer{Timeout: apiTimeout}).DialContext,
+			TLSHandshakeTimeout:   apiTimeout,
+			ResponseHeaderTimeout: apiTimeout,
+		},
+		cache:  c,
+		logf:   logf,
+		sleep:  time.Sleep,
+		resets: make(map[string]time.Time),
+	}}
+}
+
+// HTTPClient returns the client integrations call their APIs with, storing
+// responses in the cache suggestions are stored in. Providers built by
+// ReviewProvider use it already; set it as the Client of a JiraProvider.
+//
+// With NoExec, the client makes no requests: it only answers those with a
+// response in the cache, and fails with ErrExecDisabled otherwise.
+func (r *ContributionCounter) HTTPClient() *http.Client {
+	if r.NoExec {
+		return &http.Client{Transport: &apiTransport{cache: r.cache(), logf: r.logf}}
+	}
+	return NewHTTPClient(r.cache(), r.logf)
+}
+
+// apiTransport sends requests through base with the retries, rate limiting
+// and caching described in NewHTTPClient. Without a base, it only answers
+// from the cache.
+type apiTransport struct {
+	base  http.RoundTripper
+	cache Cache
+	logf  func(string, ...interface{})
+	sleep func(time.Duration)
+
+	mu sync.Mutex
+	// resets holds, for each host whose rate limit ran out, when it resets.
+	resets map[string]time.Time
+}
+
+// cachedResponse is a response stored in the cache.
+type cachedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+// response rebuilds the stored response as the answer to 'req'.
+func (c *cachedResponse) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", c.Status, http.StatusText(c.Status)),
+		StatusCode:    c.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        c.Header,
+		Body:          ioutil.NopCloser(bytes.NewReader(c.Body)),
+		ContentLength: int64(len(c.Body)),
+		Request:       req,
+	}
+}
+
+// RoundTrip sends 'req', answering it from the cache when the API says
+// nothing changed or can't answer.
+func (t *apiTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key, cached := t.lookup(req)
+	host := req.URL.Host
+
+	if t.base == nil {
+		if cached == nil {
+			return nil, errors.Wrapf(ErrExecDisabled, "no cached response for %s %s",
+				req.Method, req.URL.Redacted())
+		}
+		return cached.response(req), nil
+	}
+
+	if cached != nil {
+		req = req.Clone(req.Context())
+		if etag := cached.Header.Get("ETag"); etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if modified := cached.Header.Get("Last-Modified"); modified != "" {
+			req.Header.Set("If-Modified-Since", modified)
+		}
+	}
+
+	if wait := t.limited(host); wait > 0 {
+		switch {
+		case cached != nil:
+			t.logf("Rate limit of %s reached, using a cached response\n", host)
+			return cached.response(req), nil
+		case wait > maxAPIWait:
+			return nil, errors.Errorf("rate limit of %s reached, try again in %s", host,
+				wait.Round(time.Second))
+		}
+		t.logf("Rate limit of %s reached, waiting %s\n", host, wait.Round(time.Second))
+		t.sleep(wait)
+	}
+
+	resp, err := t.send(req)
+	switch {
+	case err != nil && cached != nil:
+		t.logf("Unable to reach %s, using a cached response: %v\n", host, err)
+		return cached.response(req), nil
+	case err != nil:
+		return nil, err
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		resp.Body.Close()
+		t.store(key, cached)
+		return cached.response(req), nil
+	case cached != nil && (resp.StatusCode == http.StatusTooManyRequests ||
+		resp.StatusCode >= 500 || rateLimited(resp)):
+		t.logf("%s answered %s, using a cached response\n", host, resp.Status)
+		resp.Body.Close()
+		return cached.response(req), nil
+	case resp.StatusCode == http.StatusOK && key != "":
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to read response")
+		}
+		t.store(key, &cachedResponse{Status: resp.StatusCode, Header: resp.Header, Body: body})
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	return resp, nil
+}
+
+// send tries 'req' until it succeeds, fails for good or runs out of retries.
+// Only GET and HEAD requests are tried again after network errors or server
+// errors, since others may have been carried out, but any request refused
+// for a rate limit is.
+func (t *apiTransport) send(req *http.Request) (*http.Response, error) {
+	idempotent := req.Method == "GET" || req.Method == "HEAD"
+	backoff := apiBackoff
+
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.Body != nil {
+			if req.GetBody == nil {
+				return nil, errors.New("unable to resend request")
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, errors.Wrap(err, "unable to resend request")
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+
+		resp, err := t.base.RoundTrip(req)
+		if err == nil {
+			t.track(req.URL.Host, resp)
+		}
+
+		var (
+			retry bool
+			wait  = backoff
+		)
+		switch {
+		case err != nil:
+			retry = idempotent && req.Context().Err() == nil
+		case resp.StatusCode == http.StatusTooManyRequests || rateLimited(resp):
+			retry = true
+			if after, ok := retryAfter(resp, time.Now()); ok {
+				wait = after
+			}
+		case resp.StatusCode == http.StatusBadGateway ||
+			resp.StatusCode == http.StatusServiceUnavailable ||
+			resp.StatusCode == http.StatusGatewayTimeout:
+			retry = idempotent
+			if after, ok := retryAfter(resp, time.Now()); ok {
+				wait = after
+			}
+		}
+
+		if !retry || attempt == apiRetries || wait > maxAPIWait {
+			return resp, err
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		if err != nil {
+			t.logf("Request to %s failed, retrying in %s: %v\n", req.URL.Host, wait, err)
+		} else {
+			t.logf("%s answered %s, retrying in %s\n", req.URL.Host, resp.Status, wait)
+		}
+		t.sleep(wait)
+		backoff *= 2
+	}
+}
+
+// rateLimited reports whether 'resp' refuses a request because the rate limit
+// ran out, as GitHub does with a 403 rather than a 429.
+func rateLimited(resp *http.Response) bool {
+	return resp.StatusCode == http.StatusForbidden &&
+		resp.Header.Get("X-RateLimit-Remaining") == "0"
+}
+
+// retryAfter reads how long 'resp' asks to wait before trying again, from
+// Retry-After in seconds or as a date, or from X-RateLimit-Reset when the
+// rate limit ran out.
+func retryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	if after := resp.Header.Get("Retry-After"); after != "" {
+		if secs, err := strconv.Atoi(after); err == nil {
+			return time.Duration(secs) * time.Second, true
+		}
+		if at, err := http.ParseTime(after); err == nil {
+			return at.Sub(now), true
+		}
+	}
+
+	if reset, ok := rateLimitReset(resp); ok {
+		return reset.Sub(now), true
+	}
+
+	return 0, false
+}
+
+// rateLimitReset reads when the rate limit that 'resp' says ran out resets.
+func rateLimitReset(resp *http.Response) (time.Time, bool) {
+	if resp.Header.Get("X-RateLimit-Remaining") != "0" {
+		return time.Time{}, false
+	}
+
+	secs, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return time.Unix(secs, 0), true
+}
+
+// track remembers when the rate limit of 'host' resets if 'resp' says it ran
+// out, so the next requests wait for it instead of being refused.
+func (t *apiTransport) track(host string, resp *http.Response) {
+	reset, ok := rateLimitReset(resp)
+	if !ok {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.resets[host] = reset
+}
+
+// limited returns how long until the rate limit of 'host' resets, or 0 if it
+// didn't run out.
+func (t *apiTransport) limited(host string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	reset, ok := t.resets[host]
+	if !ok {
+		return 0
+	}
+	if wait := time.Until(reset); wait > 0 {
+		return wait
+	}
+	delete(t.resets, host)
+	return 0
+}
+
+// lookup finds the cache key of 'req' and the response stored under it. Only
+// GET requests are cached, under a key that includes the credentials they
+// were made with so people sharing a cache never see each other's answers.
+func (t *apiTransport) lookup(req *http.Request) (string, *cachedResponse) {
+	if t.cache == nil || req.Method != "GET" {
+		return "", nil
+	}
+
+	h := sha1.New()
+	fmt.Fprintf(h, "%s\n%s\n%s", req.URL.String(), req.Header.Get("Authorization"),
+		req.Header.Get("Accept"))
+	key := fmt.Sprintf("http/%x", h.Sum(nil))
+
+	b, ok, err := t.cache.Get(key)
+	if err != nil {
+		t.logf("Unable to read cached response: %v\n", err)
+	}
+	if !ok {
+		return key, nil
+	}
+
+	var cached cachedResponse
+	if err := json.Unmarshal(b, &cached); err != nil {
+		return key, nil
+	}
+
+	return key, &cached
+}
+
+// store saves 'resp' under 'key'. Caching is an optimization, so failures
+// are only reported in verbose mode.
+func (t *apiTransport) store(key string, resp *cachedResponse) {
+	b, err := json.Marshal(resp)
+	if err == nil {
+		err = t.cache.Set(key, b, responseTTL)
+	}
+	if err != nil {
+		t.logf("Unable to cache response: %v\n", err)
+	}
+}
@@ -0,0 +1,142 @@
+package gitreviewers
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testHTTPClient builds a client like NewHTTPClient that records how long it
+// would have waited instead of sleeping.
+func testHTTPClient(c Cache, waits *[]time.Duration) *http.Client {
+	client := NewHTTPClient(c, nil)
+	client.Transport.(*apiTransport).sleep = func(d time.Duration) {
+		*waits = append(*waits, d)
+	}
+	return client
+}
+
+func getBody(t *testing.T, client *http.Client, url string) (int, string) {
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("Unexpected error requesting %s: %v\n", url, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(body)
+}
+
+func TestHTTPClientRetries(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		switch {
+		case req.URL.Path == "/busy" && requests == 1:
+			w.Header().Set("Retry-After", "2")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case req.URL.Path == "/down" && requests < 3:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case req.URL.Path == "/gone":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			fmt.Fprint(w, "ok")
+		}
+	}))
+	defer srv.Close()
+
+	cases := []struct {
+		path     string
+		status   int
+		requests int
+		waits    []time.Duration
+	}{
+		{"/busy", http.StatusOK, 2, []time.Duration{2 * time.Second}},
+		{"/down", http.StatusOK, 3, []time.Duration{apiBackoff, 2 * apiBackoff}},
+		{"/gone", http.StatusServiceUnavailable, apiRetries + 1,
+			[]time.Duration{apiBackoff, 2 * apiBackoff, 4 * apiBackoff}},
+	}
+
+	for _, c := range cases {
+		var waits []time.Duration
+		requests = 0
+
+		status, _ := getBody(t, testHTTPClient(nil, &waits), srv.URL+c.path)
+		if status != c.status {
+			t.Errorf("Got status %d for %s, expected %d\n", status, c.path, c.status)
+		}
+		if requests != c.requests {
+			t.Errorf("Got %d requests for %s, expected %d\n", requests, c.path, c.requests)
+		}
+		if !reflect.DeepEqual(waits, c.waits) {
+			t.Errorf("Got waits %v for %s, expected %v\n", waits, c.path, c.waits)
+		}
+	}
+}
+
+func TestHTTPClientRateLimit(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Unix()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", fmt.Sprint(reset))
+		fmt.Fprint(w, "last one")
+	}))
+	defer srv.Close()
+
+	var waits []time.Duration
+	client := testHTTPClient(nil, &waits)
+
+	if status, _ := getBody(t, client, srv.URL); status != http.StatusOK {
+		t.Errorf("Got status %d, expected the last request to go through\n", status)
+	}
+	if _, err := client.Get(srv.URL); err == nil {
+		t.Error("Expected an error once the rate limit ran out")
+	}
+	if len(waits) != 0 {
+		t.Errorf("Got waits %v, expected none for a limit resetting in an hour\n", waits)
+	}
+}
+
+func TestHTTPClientCaching(t *testing.T) {
+	var requests, revalidated int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		if req.Header.Get("If-None-Match") == `"v1"` {
+			revalidated++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, `{"name": "abe"}`)
+	}))
+
+	var waits []time.Duration
+	client := testHTTPClient(NewMemoryCache(), &waits)
+
+	for i := 0; i < 2; i++ {
+		if status, body := getBody(t, client, srv.URL+"/user"); status != http.StatusOK ||
+			body != `{"name": "abe"}` {
+			t.Errorf("Got %d %q on request %d, expected the account\n", status, body, i+1)
+		}
+	}
+	if requests != 2 || revalidated != 1 {
+		t.Errorf("Got %d requests and %d revalidated, expected 2 and 1\n", requests, revalidated)
+	}
+
+	// Once the API is unreachable, the cached answer is used
+	srv.Close()
+	if status, body := getBody(t, client, srv.URL+"/user"); status != http.StatusOK ||
+		body != `{"name": "abe"}` {
+		t.Errorf("Got %d %q offline, expected the cached account\n", status, body)
+	}
+	if _, err := client.Get(srv.URL + "/other"); err == nil {
+		t.Error("Expected an error offline for a request that was never cached")
+	}
+}
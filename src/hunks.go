@@ -0,0 +1,254 @@
+package gitreviewers
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// HunkOwner describes the collaborator with the most experience in the lines
+// touched by a single hunk of the branch diff.
+type HunkOwner struct {
+	// Path is the name of the file in "master".
+	Path string
+	// Line is where the hunk starts in the branch version of the file, which is
+	// what editors have open.
+	Line int
+	// BaseLine and BaseLines describe the range of lines the hunk replaces in
+	// "master". BaseLines is 0 for pure additions.
+	BaseLine  int
+	BaseLines int
+	// Reviewer is empty if nobody committed to the range after Since.
+	Reviewer   string
+	Percentage float64
+	// Lines counts the lines of the range owned by Reviewer.
+	Lines int
+}
+
+// String formats the hunk owner as a "file:line: message" entry that editors
+// understand as a jump list or quickfix location.
+func (h HunkOwner) String() string {
+	return h.Format(ShowPercent)
+}
+
+// Format is like String but displays experience the way 'show' asks for.
+func (h HunkOwner) Format(show string) string {
+	if h.Reviewer == "" {
+		return fmt.Sprintf("%s:%d: no recent owner", h.Path, h.Line)
+	}
+
+	return fmt.Sprintf("%s:%d: %s (%s)", h.Path, h.Line, h.Reviewer,
+		formatExperience(show, h.Percentage, h.Lines, 0))
+}
+
+// hunk is a range of changed lines parsed from a unified diff header.
+type hunk struct {
+	path      string
+	baseStart int
+	baseCount int
+	headStart int
+	headCount int
+}
+
+// FindHunkOwners finds the top owner of the lines touched by each hunk of the
+// diff between "master" and HEAD for the given paths. Ownership is measured in
+// "master" so it reflects experience from before the branch was written.
+func (r *ContributionCounter) FindHunkOwners(paths []string) ([]HunkOwner, error) {
+	var owners []HunkOwner
+
+	if len(paths) == 0 {
+		return owners, nil
+	}
+
+	r.defaultSince()
+
+	base, head, err := r.branchTips()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to resolve branch tips")
+	}
+
+	hunks, err := r.diffHunks(base, head, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, h := range hunks {
+		owner, err := r.hunkOwner(h, base)
+		if err != nil {
+			return nil, err
+		}
+		owners = append(owners, owner)
+	}
+
+	sort.Slice(owners, func(i, j int) bool {
+		if owners[i].Path != owners[j].Path {
+			return owners[i].Path < owners[j].Path
+		}
+		return owners[i].Line < owners[j].Line
+	})
+
+	return owners, nil
+}
+
+// hunkOwner blames the base range of a hunk and picks its top owner.
+func (r *ContributionCounter) hunkOwner(h hunk, rev string) (HunkOwner, error) {
+	owner := HunkOwner{
+		Path:      h.path,
+		Line:      h.headStart,
+		BaseLine:  h.baseStart,
+		BaseLines: h.baseCount,
+	}
+	if owner.Line == 0 {
+		owner.Line = 1
+	}
+
+	counts, total, err := r.hunkAttributions(h, rev)
+	if err != nil {
+		return owner, err
+	}
+
+	for author, c := range counts {
+		p := float64(c) / float64(total)
+		if p > owner.Percentage || (p == owner.Percentage && author < owner.Reviewer) {
+			owner.Reviewer, owner.Percentage, owner.Lines = author, p, c
+		}
+	}
+
+	return owner, nil
+}
+
+// hunkAttributions blames the lines a hunk replaces in the base revision and
+// counts them by author. It also returns the number of lines blamed, which
+// includes lines committed before Since.
+func (r *ContributionCounter) hunkAttributions(h hunk, rev string) (map[string]int, int, error) {
+	counts := make(map[string]int)
+
+	// Pure additions don't replace anything, so we credit the owner of the
+	// line the new content was inserted after.
+	start, count := h.baseStart, h.baseCount
+	if count == 0 {
+		count = 1
+	}
+	if start == 0 {
+		// Nothing to attribute in a file that used to be empty
+		return counts, count, nil
+	}
+
+	attributions, _, err := r.blameAttributions(h.path, rev, fmt.Sprintf("%d,+%d", start, count))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for _, a := range attributions {
+		if a.author != initialImportAuthor {
+			counts[a.author]++
+		}
+	}
+
+	return counts, count, nil
+}
+
+// diffHunks runs git diff between two revisions without context lines and
+// parses the changed ranges for each file that exists in the base revision.
+func (r *ContributionCounter) diffHunks(base, head string, paths []string) ([]hunk, error) {
+	args := []string{"diff", "-U0", "--no-color", "--no-ext-diff",
+		"--src-prefix=a/", "--dst-prefix=b/", base, head, "--"}
+	args = append(args, paths...)
+
+	out, err := r.output(args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to execute external git diff command")
+	}
+
+	return parseDiffHunks(out)
+}
+
+// parseDiffHunks extracts hunk ranges from unified diff output. Hunks of files
+// that did not exist in the base revision are skipped since there is nobody
+// to attribute them to.
+func parseDiffHunks(out []byte) ([]hunk, error) {
+	var (
+		hunks []hunk
+		path  string
+	)
+
+	scn := bufio.NewScanner(bytes.NewReader(out))
+	// Changed lines of minified or generated files can be arbitrarily long
+	scn.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), len(out)+1)
+	for scn.Scan() {
+		line := scn.Text()
+
+		switch {
+		case strings.HasPrefix(line, "diff "):
+			path = ""
+		case strings.HasPrefix(line, "--- "):
+			path = diffPath(strings.TrimPrefix(line, "--- "), "a/")
+		case strings.HasPrefix(line, "@@ ") && path != "":
+			h, err := parseHunkHeader(line)
+			if err != nil {
+				return nil, err
+			}
+			h.path = path
+			hunks = append(hunks, h)
+		}
+	}
+
+	return hunks, scn.Err()
+}
+
+// diffPath extracts a file name from a "---" or "+++" diff header, returning
+// an empty string for /dev/null.
+func diffPath(name, prefix string) string {
+	if strings.HasPrefix(name, "\"") {
+		if unquoted, err := strconv.Unquote(name); err == nil {
+			name = unquoted
+		}
+	}
+
+	if name == "/dev/null" {
+		return ""
+	}
+
+	return strings.TrimPrefix(name, prefix)
+}
+
+// parseHunkHeader reads the ranges out of a "@@ -a,b +c,d @@" hunk header. A
+// missing count means the range is a single line.
+func parseHunkHeader(line string) (hunk, error) {
+	var h hunk
+
+	fields := strings.Fields(line)
+	if len(fields) < 3 || !strings.HasPrefix(fields[1], "-") ||
+		!strings.HasPrefix(fields[2], "+") {
+		return h, fmt.Errorf("malformed hunk header '%s'", line)
+	}
+
+	var err error
+	if h.baseStart, h.baseCount, err = parseRange(fields[1][1:]); err != nil {
+		return h, errors.Wrapf(err, "malformed hunk header '%s'", line)
+	}
+	if h.headStart, h.headCount, err = parseRange(fields[2][1:]); err != nil {
+		return h, errors.Wrapf(err, "malformed hunk header '%s'", line)
+	}
+
+	return h, nil
+}
+
+func parseRange(rng string) (start, count int, err error) {
+	count = 1
+
+	parts := strings.SplitN(rng, ",", 2)
+	if start, err = strconv.Atoi(parts[0]); err != nil {
+		return
+	}
+	if len(parts) == 2 {
+		count, err = strconv.Atoi(parts[1])
+	}
+
+	return
+}
@@ -0,0 +1,120 @@
+package gitreviewers
+
+import (
+	"strings"
+	"testing"
+)
+
+var diffcontent = `diff --git a/README.md b/README.md
+index 1111111..2222222 100644
+--- a/README.md
++++ b/README.md
+@@ -2,0 +3 @@ y
++z
+diff --git a/new.go b/new.go
+new file mode 100644
+index 0000000..3333333
+--- /dev/null
++++ b/new.go
+@@ -0,0 +1,2 @@
++package main
++
+diff --git a/src/a.go b/src/a.go
+index 4444444..5555555 100644
+--- a/src/a.go
++++ b/src/a.go
+@@ -1 +1 @@
+-line 1
++line one
+@@ -5,3 +5,2 @@ line 4
+-line five
+-line 6
+-line 7
++LINE5
++line 6
+`
+
+func TestParseDiffHunks(t *testing.T) {
+	expected := []hunk{
+		{path: "README.md", baseStart: 2, baseCount: 0, headStart: 3, headCount: 1},
+		{path: "src/a.go", baseStart: 1, baseCount: 1, headStart: 1, headCount: 1},
+		{path: "src/a.go", baseStart: 5, baseCount: 3, headStart: 5, headCount: 2},
+	}
+
+	actual, err := parseDiffHunks([]byte(diffcontent))
+	if err != nil {
+		t.Fatalf("Unexpected error parsing diff: %v\n", err)
+	}
+
+	if len(actual) != len(expected) {
+		t.Fatalf("Got %d hunks, expected %d\n", len(actual), len(expected))
+	}
+
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Errorf("Got hunk %+v, expected %+v\n", actual[i], expected[i])
+		}
+	}
+}
+
+func TestParseDiffHunksLongLines(t *testing.T) {
+	long := strings.Repeat("x", 1<<20)
+	diff := "diff --git a/app.min.js b/app.min.js\n--- a/app.min.js\n+++ b/app.min.js\n" +
+		"@@ -1 +1 @@\n-" + long + "\n+" + long + "y\n@@ -3 +3 @@\n-a\n+b\n"
+
+	actual, err := parseDiffHunks([]byte(diff))
+	if err != nil {
+		t.Fatalf("Unexpected error parsing diff: %v\n", err)
+	}
+	if len(actual) != 2 || actual[1].baseStart != 3 {
+		t.Errorf("Got %+v, expected both hunks past the long line\n", actual)
+	}
+}
+
+func TestParseHunkHeader(t *testing.T) {
+	if _, err := parseHunkHeader("@@ nonsense @@"); err == nil {
+		t.Error("Expected an error for a malformed hunk header")
+	}
+}
+
+func TestHunkOwnerString(t *testing.T) {
+	cases := []struct {
+		Owner    HunkOwner
+		Expected string
+	}{
+		{
+			HunkOwner{Path: "src/a.go", Line: 5, Reviewer: "abe@git-reviewer.com", Percentage: 0.5},
+			"src/a.go:5: abe@git-reviewer.com (50.00%)",
+		},
+		{
+			HunkOwner{Path: "src/a.go", Line: 1},
+			"src/a.go:1: no recent owner",
+		},
+	}
+
+	for _, c := range cases {
+		if actual := c.Owner.String(); actual != c.Expected {
+			t.Errorf("Got '%s', expected '%s'\n", actual, c.Expected)
+		}
+	}
+}
+
+func TestHunkOwnerFormat(t *testing.T) {
+	owner := HunkOwner{Path: "src/a.go", Line: 5, Reviewer: "abe@git-reviewer.com",
+		Percentage: 0.5, Lines: 3}
+
+	cases := []struct {
+		Show     string
+		Expected string
+	}{
+		{ShowPercent, "src/a.go:5: abe@git-reviewer.com (50.00%)"},
+		{ShowCounts, "src/a.go:5: abe@git-reviewer.com (3 lines)"},
+		{ShowBoth, "src/a.go:5: abe@git-reviewer.com (50.00% (3 lines))"},
+	}
+
+	for _, c := range cases {
+		if actual := owner.Format(c.Show); actual != c.Expected {
+			t.Errorf("Got '%s', expected '%s'\n", actual, c.Expected)
+		}
+	}
+}
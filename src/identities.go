@@ -0,0 +1,240 @@
+package gitreviewers
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultIdentityTTL is how long accounts resolved by a provider are trusted
+// before asking it again.
+const DefaultIdentityTTL = 30 * 24 * time.Hour
+
+// IdentityCache remembers which account providers resolved the email of
+// committers to, in a JSON file, so repeated runs don't ask about the same
+// people again. Emails without an account are remembered too.
+type IdentityCache struct {
+	// Path is the file entries are read from and saved to, and TTL how long
+	// they are trusted, DefaultIdentityTTL if 0.
+	Path string
+	TTL  time.Duration
+
+	entries map[string]IdentityEntry
+	changed bool
+	// updated holds the keys of the entries stored or forgotten since the
+	// cache was loaded, which are all saving changes in the file.
+	updated map[string]bool
+}
+
+// IdentityEntry is the account a provider resolved an email to.
+type IdentityEntry struct {
+	// Provider is the host the account is on, such as "github.com".
+	Provider string `json:"provider"`
+	Email    string `json:"email"`
+	// Account is empty if the email belongs to no account.
+	Account  Account   `json:"account"`
+	Resolved time.Time `json:"resolved"`
+}
+
+// LoadIdentityCache reads the identity cache at 'path'. A missing file is an
+// empty cache.
+func LoadIdentityCache(path string, ttl time.Duration) (*IdentityCache, error) {
+	c := &IdentityCache{Path: path, TTL: ttl, entries: make(map[string]IdentityEntry),
+		updated: make(map[string]bool)}
+
+	entries, err := readIdentities(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		c.entries[identityKey(e.Provider, e.Email)] = e
+	}
+
+	return c, nil
+}
+
+// readIdentities reads the entries of the identity cache file at 'path',
+// none if it is missing.
+func readIdentities(path string) ([]IdentityEntry, error) {
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "unable to read identity cache")
+	}
+
+	var entries []IdentityEntry
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse identity cache %s", path)
+	}
+	return entries, nil
+}
+
+// identityKey is the key of the entry for 'email' on 'provider'. Emails are
+// matched regardless of case.
+func identityKey(provider, email string) string {
+	return provider + " " + strings.ToLower(email)
+}
+
+func (c *IdentityCache) ttl() time.Duration {
+	if c.TTL == 0 {
+		return DefaultIdentityTTL
+	}
+	return c.TTL
+}
+
+// Lookup returns the account 'email' was resolved to on 'provider', or false
+// if it wasn't or the entry expired.
+func (c *IdentityCache) Lookup(provider, email string) (Account, bool) {
+	e, ok := c.entries[identityKey(provider, email)]
+	if !ok || time.Since(e.Resolved) > c.ttl() {
+		return Account{}, false
+	}
+	return e.Account, true
+}
+
+// Store remembers that 'email' was resolved to 'account' on 'provider'.
+func (c *IdentityCache) Store(provider, email string, account Account) {
+	c.entries[identityKey(provider, email)] = IdentityEntry{Provider: provider,
+		Email: email, Account: account, Resolved: time.Now()}
+	c.updated[identityKey(provider, email)] = true
+	c.changed = true
+}
+
+// Refresh forgets the entries of 'emails', or every entry if none are given,
+// so they are resolved again on the next run. It returns how many entries it
+// forgot.
+func (c *IdentityCache) Refresh(emails ...string) int {
+	var forgotten int
+	for key, e := range c.entries {
+		if len(emails) == 0 || containsFold(emails, e.Email) {
+			delete(c.entries, key)
+			c.updated[key] = true
+			forgotten++
+		}
+	}
+	if forgotten > 0 {
+		c.changed = true
+	}
+	return forgotten
+}
+
+// containsFold reports whether 'list' has 's', regardless of case.
+func containsFold(list []string, s string) bool {
+	for _, l := range list {
+		if strings.EqualFold(l, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Entries lists the entries that haven't expired, by provider and email.
+func (c *IdentityCache) Entries() []IdentityEntry {
+	entries := []IdentityEntry{}
+	for _, e := range c.entries {
+		if time.Since(e.Resolved) <= c.ttl() {
+			entries = append(entries, e)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Provider != entries[j].Provider {
+			return entries[i].Provider < entries[j].Provider
+		}
+		return entries[i].Email < entries[j].Email
+	})
+	return entries
+}
+
+// Emails maps the names of the accounts providers resolved, lower-cased, to
+// the emails they were resolved from, to tell who usernames belong to. If
+// several emails belong to the same account, the first in Entries wins.
+func (c *IdentityCache) Emails() map[string]string {
+	emails := make(map[string]string)
+	for _, e := range c.Entries() {
+		name := strings.ToLower(e.Account.Name)
+		if _, ok := emails[name]; !ok && name != "" {
+			emails[name] = e.Email
+		}
+	}
+	return emails
+}
+
+// Save writes the entries that haven't expired to Path, if anything changed
+// since it was loaded. Runs saving at the same time take turns, and only the
+// entries this one stored or forgot replace those in the file, so what other
+// runs saved in the meantime is kept. The file is replaced at once so
+// concurrent runs never read half of it.
+func (c *IdentityCache) Save() error {
+	if !c.changed {
+		return nil
+	}
+
+	lock, err := lockFile(c.Path, lockTimeout, staleLockAge)
+	if err != nil {
+		return errors.Wrap(err, "unable to write identity cache")
+	}
+	defer lock.unlock()
+
+	saved, err := readIdentities(c.Path)
+	if err != nil {
+		return err
+	}
+	entries := make(map[string]IdentityEntry)
+	for _, e := range saved {
+		entries[identityKey(e.Provider, e.Email)] = e
+	}
+	for key := range c.updated {
+		if e, ok := c.entries[key]; ok {
+			entries[key] = e
+		} else {
+			delete(entries, key)
+		}
+	}
+	c.entries = entries
+
+	b, err := json.MarshalIndent(c.Entries(), "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "unable to encode identity cache")
+	}
+	if err := writeFileAtomic(c.Path, append(b, '\n'), 0644); err != nil {
+		return errors.Wrap(err, "unable to write identity cache")
+	}
+
+	c.changed = false
+	c.updated = make(map[string]bool)
+	return nil
+}
+
+// CachedProvider wraps 'p' so the accounts of committers are looked up in 'c'
+// first, and those it resolves are remembered there under 'provider', the
+// host of the repository. Lookups that fail aren't remembered.
+func CachedProvider(p ReviewProvider, c *IdentityCache, provider string) ReviewProvider {
+	return &cachedProvider{ReviewProvider: p, cache: c, provider: provider}
+}
+
+type cachedProvider struct {
+	ReviewProvider
+	cache    *IdentityCache
+	provider string
+}
+
+// User answers from the cache when it can, and asks the provider otherwise.
+func (p *cachedProvider) User(email string) (Account, error) {
+	if account, ok := p.cache.Lookup(p.provider, email); ok {
+		return account, nil
+	}
+
+	account, err := p.ReviewProvider.User(email)
+	if err != nil {
+		return Account{}, err
+	}
+	p.cache.Store(p.provider, email, account)
+
+	return account, nil
+}
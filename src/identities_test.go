@@ -0,0 +1,146 @@
+package gitreviewers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingProvider resolves emails to the part before the @ and counts how
+// many times it was asked.
+type countingProvider struct {
+	ReviewProvider
+	lookups int
+}
+
+func (p *countingProvider) User(email string) (Account, error) {
+	p.lookups++
+	if email == "nobody@git-reviewer.com" {
+		return Account{}, nil
+	}
+	return Account{ID: email[:3], Name: email[:3]}, nil
+}
+
+func TestIdentityCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "identities")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "git-reviewer", "identities.json")
+
+	ids, err := LoadIdentityCache(path, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error loading a missing cache: %v\n", err)
+	}
+
+	backend := &countingProvider{}
+	p := CachedProvider(backend, ids, "bitbucket.org")
+	for _, email := range []string{"abe@git-reviewer.com", "ABE@git-reviewer.com",
+		"nobody@git-reviewer.com", "nobody@git-reviewer.com"} {
+		if _, err := p.User(email); err != nil {
+			t.Fatalf("Unexpected error looking up %s: %v\n", email, err)
+		}
+	}
+	if backend.lookups != 2 {
+		t.Errorf("Got %d lookups, expected 2 with the rest answered from the cache\n",
+			backend.lookups)
+	}
+	if err := ids.Save(); err != nil {
+		t.Fatalf("Unexpected error saving the cache: %v\n", err)
+	}
+
+	// Another run reads what was resolved from the file
+	ids, err = LoadIdentityCache(path, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error loading the cache: %v\n", err)
+	}
+	if account, ok := ids.Lookup("bitbucket.org", "abe@git-reviewer.com"); !ok ||
+		account != (Account{ID: "abe", Name: "abe"}) {
+		t.Errorf("Got %+v (cached: %t), expected abe\n", account, ok)
+	}
+	if account, ok := ids.Lookup("bitbucket.org", "nobody@git-reviewer.com"); !ok ||
+		account != (Account{}) {
+		t.Errorf("Got %+v (cached: %t), expected no account to be cached\n", account, ok)
+	}
+	if _, ok := ids.Lookup("github.com", "abe@git-reviewer.com"); ok {
+		t.Error("Expected accounts to be cached for each host")
+	}
+	if emails := ids.Emails(); !reflect.DeepEqual(emails, map[string]string{"abe": "abe@git-reviewer.com"}) {
+		t.Errorf("Got %v, expected abe's username to map to their email\n", emails)
+	}
+
+	if n := ids.Refresh("Abe@git-reviewer.com"); n != 1 {
+		t.Errorf("Got %d forgotten, expected 1\n", n)
+	}
+	var emails []string
+	for _, e := range ids.Entries() {
+		emails = append(emails, e.Email)
+	}
+	if expected := []string{"nobody@git-reviewer.com"}; !reflect.DeepEqual(emails, expected) {
+		t.Errorf("Got %v, expected %v\n", emails, expected)
+	}
+
+	ids.TTL = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	if _, ok := ids.Lookup("bitbucket.org", "nobody@git-reviewer.com"); ok {
+		t.Error("Expected expired entries to be looked up again")
+	}
+}
+
+func TestIdentityCacheConcurrentSave(t *testing.T) {
+	dir, err := ioutil.TempDir("", "identities")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "identities.json")
+
+	first, _ := LoadIdentityCache(path, 0)
+	first.Store("github.com", "old@git-reviewer.com", Account{ID: "old"})
+	first.Store("github.com", "gone@git-reviewer.com", Account{ID: "gone"})
+	if err := first.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Runs that loaded the cache at the same time each resolve someone else,
+	// and one forgets an entry
+	emails := []string{"abe@git-reviewer.com", "ben@git-reviewer.com",
+		"cal@git-reviewer.com", "dee@git-reviewer.com"}
+	var caches []*IdentityCache
+	for _, email := range emails {
+		ids, _ := LoadIdentityCache(path, 0)
+		ids.Store("github.com", email, Account{ID: email[:3]})
+		caches = append(caches, ids)
+	}
+	caches[0].Refresh("gone@git-reviewer.com")
+
+	var wg sync.WaitGroup
+	for _, ids := range caches {
+		wg.Add(1)
+		go func(ids *IdentityCache) {
+			defer wg.Done()
+			if err := ids.Save(); err != nil {
+				t.Errorf("Unexpected error saving the cache: %v\n", err)
+			}
+		}(ids)
+	}
+	wg.Wait()
+
+	ids, err := LoadIdentityCache(path, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error loading the cache: %v\n", err)
+	}
+	var saved []string
+	for _, e := range ids.Entries() {
+		saved = append(saved, e.Email)
+	}
+	expected := append(emails, "old@git-reviewer.com")
+	if !reflect.DeepEqual(saved, expected) {
+		t.Errorf("Got %v, expected %v\n", saved, expected)
+	}
+}
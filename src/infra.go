@@ -0,0 +1,126 @@
+package gitreviewers
+
+import (
+	"path"
+	"strings"
+)
+
+// infraNames are file names that always hold infrastructure or CI
+// configuration.
+var infraNames = map[string]bool{
+	"Jenkinsfile":             true,
+	"terragrunt.hcl":          true,
+	".dockerignore":           true,
+	"docker-compose.yml":      true,
+	"docker-compose.yaml":     true,
+	"compose.yml":             true,
+	"compose.yaml":            true,
+	".gitlab-ci.yml":          true,
+	".travis.yml":             true,
+	".drone.yml":              true,
+	"azure-pipelines.yml":     true,
+	"bitbucket-pipelines.yml": true,
+	"cloudbuild.yaml":         true,
+	"appveyor.yml":            true,
+	"kustomization.yaml":      true,
+	"Chart.yaml":              true,
+}
+
+// infraExts are the extensions of Terraform and HCL files.
+var infraExts = map[string]bool{
+	".tf":         true,
+	".tfvars":     true,
+	".hcl":        true,
+	".dockerfile": true,
+}
+
+// ciDirs are directories holding CI pipelines.
+var ciDirs = []string{".github/workflows", ".circleci", ".buildkite"}
+
+// manifestDirs are directories whose YAML files are usually Kubernetes
+// manifests or Helm charts.
+var manifestDirs = map[string]bool{
+	"k8s":        true,
+	"kubernetes": true,
+	"kube":       true,
+	"manifests":  true,
+	"helm":       true,
+	"charts":     true,
+}
+
+// IsInfraFile reports whether 'p' looks like infrastructure or CI
+// configuration rather than application code, following common conventions:
+// Terraform and HCL files, Dockerfiles and Compose files, CI pipelines such
+// as GitHub Actions workflows or .gitlab-ci.yml, and YAML files under k8s,
+// kubernetes, kube, manifests, helm or charts directories.
+func IsInfraFile(p string) bool {
+	base := path.Base(p)
+	ext := strings.ToLower(path.Ext(base))
+
+	switch {
+	case infraNames[base] || infraExts[ext]:
+		return true
+	case base == "Dockerfile" || strings.HasPrefix(base, "Dockerfile."):
+		return true
+	}
+
+	dir := path.Dir(p)
+	for _, ci := range ciDirs {
+		if dir == ci || strings.HasPrefix(dir, ci+"/") {
+			return true
+		}
+	}
+
+	if ext == ".yml" || ext == ".yaml" {
+		for _, d := range strings.Split(dir, "/") {
+			if manifestDirs[d] {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// isInfra reports whether 'p' is infrastructure configuration, either by
+// IsInfraFile or by matching one of InfraPaths.
+func (r *ContributionCounter) isInfra(p string) bool {
+	return IsInfraFile(p) || SensitiveRule{Paths: r.InfraPaths}.matches(p)
+}
+
+// routeInfra puts the InfraReviewers first when 'paths' include
+// infrastructure files, so changes to them go to the platform team. The
+// reviewers picked from blame follow as secondary reviewers.
+func (r *ContributionCounter) routeInfra(top, all Stats, paths []string) Stats {
+	if len(r.InfraReviewers) == 0 {
+		return top
+	}
+
+	var infra int
+	for _, p := range paths {
+		if r.isInfra(p) {
+			infra++
+		}
+	}
+	for _, p := range r.added {
+		if r.isInfra(p) {
+			infra++
+		}
+	}
+	if infra == 0 {
+		return top
+	}
+
+	top, routed := r.mergeOwners(top, all, r.InfraReviewers)
+	for _, stat := range routed {
+		stat.Infra = infra
+	}
+
+	for _, s := range top {
+		if findStat(routed, s.Reviewer) == nil {
+			routed = append(routed, s)
+		}
+	}
+
+	return routed
+}
@@ -0,0 +1,65 @@
+package gitreviewers
+
+import (
+	"testing"
+)
+
+func TestIsInfraFile(t *testing.T) {
+	cases := []struct {
+		Path     string
+		Expected bool
+	}{
+		{"infra/main.tf", true},
+		{"envs/prod.tfvars", true},
+		{"Dockerfile", true},
+		{"build/Dockerfile.dev", true},
+		{"docker-compose.yml", true},
+		{".github/workflows/ci.yml", true},
+		{".gitlab-ci.yml", true},
+		{"Jenkinsfile", true},
+		{"deploy/k8s/api/deployment.yaml", true},
+		{"charts/api/values.yaml", true},
+		{"config/settings.yaml", false},
+		{".github/CODEOWNERS", false},
+		{"src/docker.go", false},
+		{"k8s/client.go", false},
+	}
+
+	for _, c := range cases {
+		if actual := IsInfraFile(c.Path); actual != c.Expected {
+			t.Errorf("Got %t, expected %t for '%s'\n", actual, c.Expected, c.Path)
+		}
+	}
+}
+
+func TestRouteInfra(t *testing.T) {
+	all := Stats{
+		{Reviewer: "abe@git-reviewer.com", Percentage: 0.6},
+		{Reviewer: "ben@git-reviewer.com", Percentage: 0.3},
+		{Reviewer: "ops@git-reviewer.com", Percentage: 0.1},
+	}
+	top := Stats{all[0], all[1]}
+
+	r := &ContributionCounter{InfraReviewers: []string{"ops@git-reviewer.com", "sre@git-reviewer.com"},
+		InfraPaths: []string{"scripts/deploy*"}}
+
+	routed := r.routeInfra(top, all, []string{"src/a.go"})
+	if len(routed) != 2 {
+		t.Errorf("Got %d reviewers, expected application changes not to be routed\n", len(routed))
+	}
+
+	routed = r.routeInfra(top, all, []string{"src/a.go", "Dockerfile", "scripts/deploy.sh"})
+	expected := []string{"ops@git-reviewer.com", "sre@git-reviewer.com", "abe@git-reviewer.com",
+		"ben@git-reviewer.com"}
+	if len(routed) != len(expected) {
+		t.Fatalf("Got %d reviewers, expected %d\n", len(routed), len(expected))
+	}
+	for i, s := range routed {
+		if s.Reviewer != expected[i] {
+			t.Errorf("Got '%s' at %d, expected '%s'\n", s.Reviewer, i, expected[i])
+		}
+	}
+	if routed[0].Infra != 2 || routed[0].Percentage != 0.1 || routed[2].Infra != 0 {
+		t.Errorf("Got %+v, expected ops to keep their experience and route 2 files\n", routed[0])
+	}
+}
@@ -0,0 +1,127 @@
+package gitreviewers
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+)
+
+// FileCoverage tells whether a changed file has a qualified owner: an active
+// collaborator owning more than OwnershipAlert of its lines, or any of them
+// if OwnershipAlert is 0.
+type FileCoverage struct {
+	Path string
+	// Lines counts the lines of the file, and Owner is its largest active
+	// owner, owning Share of them.
+	Lines int
+	Owner string
+	Share float64
+	Owned bool
+	// Skipped says why the file wasn't blamed, such as being too large, in
+	// which case it is neither owned nor unowned.
+	Skipped string
+}
+
+// Coverage blames the changed files in 'paths' and tells which have a
+// qualified owner, sorted by path.
+func (r *ContributionCounter) Coverage(paths []string) ([]FileCoverage, error) {
+	r.defaultSince()
+
+	counts, err := r.generateCounts(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	unowned := make(map[string]bool)
+	for _, p := range r.unownedFiles(counts) {
+		unowned[p] = true
+	}
+
+	var coverage []FileCoverage
+	for _, p := range paths {
+		c := FileCoverage{Path: p, Lines: counts.fileLines[p], Skipped: counts.skipped[p]}
+		switch {
+		case c.Skipped != "":
+		case c.Lines == 0:
+			c.Skipped = "empty"
+		default:
+			var lines int
+			c.Owner, lines = topOwner(counts.byFile[p])
+			c.Share = float64(lines) / float64(c.Lines)
+			c.Owned = c.Owner != "" && !unowned[p]
+		}
+		coverage = append(coverage, c)
+	}
+	sort.Slice(coverage, func(i, j int) bool {
+		return coverage[i].Path < coverage[j].Path
+	})
+
+	return coverage, nil
+}
+
+// junitSuite is a JUnit XML test suite, in the dialect Jenkins, GitLab and
+// most CI dashboards read.
+type junitSuite struct {
+	XMLName  xml.Name    `xml:"testsuite"`
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Skipped  int         `xml:"skipped,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *junitMessage `xml:"failure"`
+	Skipped   *junitMessage `xml:"skipped"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes 'coverage' to 'w' as a JUnit XML report, with a test case
+// for each changed file that passes when it has a qualified owner, so CI
+// dashboards show ownership coverage without plugins. Test cases are grouped
+// by directory, and the largest owner of each file is named either way.
+// 'threshold' is the OwnershipAlert the coverage was measured with.
+func WriteJUnit(w io.Writer, coverage []FileCoverage, threshold float64) error {
+	suite := junitSuite{Name: "git-reviewer ownership", Tests: len(coverage),
+		Cases: []junitCase{}}
+
+	for _, c := range coverage {
+		tc := junitCase{ClassName: path.Dir(c.Path), Name: c.Path}
+		if c.Owner != "" {
+			tc.SystemOut = fmt.Sprintf("%s owns %.0f%% of %d lines", c.Owner, c.Share*100,
+				c.Lines)
+		}
+
+		switch {
+		case c.Skipped != "":
+			suite.Skipped++
+			tc.Skipped = &junitMessage{Message: "Not blamed: " + c.Skipped}
+		case !c.Owned:
+			suite.Failures++
+			tc.Failure = &junitMessage{Message: unownedTitle, Type: RuleUnowned,
+				Text: unownedMessage(threshold, c.Owner, c.Share)}
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suite); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
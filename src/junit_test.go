@@ -0,0 +1,71 @@
+package gitreviewers
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestCoverage(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	repo, _, err := OpenRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		since    string
+		expected FileCoverage
+	}{
+		{"2000-01-01", FileCoverage{Path: "src/a.go", Lines: 1, Owner: "abe@git-reviewer.com",
+			Share: 1, Owned: true}},
+		// Every line was committed before Since, so nobody active owns it
+		{"2100-01-01", FileCoverage{Path: "src/a.go", Lines: 1}},
+	}
+
+	for _, c := range cases {
+		r := ContributionCounter{Repo: repo, Dir: dir, Since: c.since, OwnershipAlert: 0.1}
+
+		got, err := r.Coverage([]string{"src/a.go"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := []FileCoverage{c.expected}; !reflect.DeepEqual(got, expected) {
+			t.Errorf("Got %+v since %s, expected %+v\n", got, c.since, expected)
+		}
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	coverage := []FileCoverage{
+		{Path: "data/big.csv", Skipped: skipTooLarge},
+		{Path: "src/a.go", Lines: 10, Owner: "abe@git-reviewer.com", Share: 0.6, Owned: true},
+		{Path: "src/b.go", Lines: 20, Owner: "ben@git-reviewer.com", Share: 0.05},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteJUnit(&buf, coverage, 0.1); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<testsuite name="git-reviewer ownership" tests="3" failures="1" skipped="1">
+  <testcase classname="data" name="data/big.csv">
+    <skipped message="Not blamed: too large"></skipped>
+  </testcase>
+  <testcase classname="src" name="src/a.go">
+    <system-out>abe@git-reviewer.com owns 60% of 10 lines</system-out>
+  </testcase>
+  <testcase classname="src" name="src/b.go">
+    <failure message="No knowledgeable owner" type="unowned-file">Nobody active owns more than 10% of this file. The largest active owner is ben@git-reviewer.com with 5% of its lines.</failure>
+    <system-out>ben@git-reviewer.com owns 5% of 20 lines</system-out>
+  </testcase>
+</testsuite>
+`
+	if got := buf.String(); got != expected {
+		t.Errorf("Got %s, expected %s\n", got, expected)
+	}
+}
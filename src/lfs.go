@@ -0,0 +1,103 @@
+package gitreviewers
+
+import (
+	"bufio"
+	"path"
+	"strings"
+
+	"gopkg.in/src-d/go-git.v4/plumbing/format/gitignore"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// filterRule is a line of a .gitattributes file that sets or unsets the
+// "filter" attribute for the paths matching its pattern.
+type filterRule struct {
+	pattern gitignore.Pattern
+	lfs     bool
+}
+
+// lfsPaths finds which of 'paths' are tracked by Git LFS in 'tree'. The
+// content of those files is a pointer, so blaming them credits whoever last
+// updated the pointer rather than the people who know the file.
+//
+// Only the .gitattributes files in the directories leading to each path are
+// read, from the root down, so deeper files and later lines take precedence
+// the same way they do in git.
+func lfsPaths(tree *object.Tree, paths []string) map[string]bool {
+	var (
+		lfs   = make(map[string]bool)
+		rules = make(map[string][]filterRule)
+	)
+
+	for _, p := range paths {
+		parts := strings.Split(p, "/")
+		tracked := false
+
+		for depth := 0; depth < len(parts); depth++ {
+			dir := strings.Join(parts[:depth], "/")
+
+			dirRules, ok := rules[dir]
+			if !ok {
+				dirRules = readFilterRules(tree, dir, parts[:depth])
+				rules[dir] = dirRules
+			}
+
+			for _, rule := range dirRules {
+				if rule.pattern.Match(parts, false) == gitignore.Exclude {
+					tracked = rule.lfs
+				}
+			}
+		}
+
+		if tracked {
+			lfs[p] = true
+		}
+	}
+
+	return lfs
+}
+
+// readFilterRules reads the .gitattributes file of a directory in 'tree', if
+// there is one.
+func readFilterRules(tree *object.Tree, dir string, domain []string) []filterRule {
+	f, err := tree.File(path.Join(dir, ".gitattributes"))
+	if err != nil {
+		return nil
+	}
+
+	content, err := f.Contents()
+	if err != nil {
+		return nil
+	}
+
+	return parseFilterRules(content, domain)
+}
+
+// parseFilterRules parses the lines of a .gitattributes file that mention the
+// "filter" attribute. 'domain' is the directory the file lives in, split into
+// its components.
+func parseFilterRules(content string, domain []string) []filterRule {
+	var rules []filterRule
+
+	scn := bufio.NewScanner(strings.NewReader(content))
+	for scn.Scan() {
+		fields := strings.Fields(scn.Text())
+
+		// Skip comments, blank lines and macro definitions
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") ||
+			strings.HasPrefix(fields[0], "[attr]") {
+			continue
+		}
+
+		for _, attr := range fields[1:] {
+			switch {
+			case attr == "filter=lfs":
+				rules = append(rules, filterRule{gitignore.ParsePattern(fields[0], domain), true})
+			case strings.HasPrefix(attr, "filter="), attr == "-filter", attr == "!filter":
+				rules = append(rules, filterRule{gitignore.ParsePattern(fields[0], domain), false})
+			}
+		}
+	}
+
+	return rules
+}
@@ -0,0 +1,45 @@
+package gitreviewers
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/src-d/go-git.v4/plumbing/format/gitignore"
+)
+
+func TestParseFilterRules(t *testing.T) {
+	content := `# Binary assets
+*.psd filter=lfs diff=lfs merge=lfs -text
+[attr]binary -diff -merge -text
+*.go text
+assets/keep.psd -filter
+`
+	rules := parseFilterRules(content, nil)
+	if len(rules) != 2 {
+		t.Fatalf("Got %d rules, expected 2\n", len(rules))
+	}
+
+	cases := []struct {
+		Path    string
+		Matches []bool
+	}{
+		{"design/logo.psd", []bool{true, false}},
+		{"assets/keep.psd", []bool{true, true}},
+		{"main.go", []bool{false, false}},
+	}
+
+	for _, c := range cases {
+		parts := strings.Split(c.Path, "/")
+		for i, rule := range rules {
+			matched := rule.pattern.Match(parts, false) == gitignore.Exclude
+			if matched != c.Matches[i] {
+				t.Errorf("Rule %d matching '%s' was %t, expected %t\n",
+					i, c.Path, matched, c.Matches[i])
+			}
+		}
+	}
+
+	if !rules[0].lfs || rules[1].lfs {
+		t.Error("Expected only the first rule to track files with LFS")
+	}
+}
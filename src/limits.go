@@ -0,0 +1,93 @@
+package gitreviewers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Limits applied to each changed file unless MaxFileSize or BlameTimeout say
+// otherwise. A single huge data file can otherwise stall the whole run.
+const (
+	DefaultMaxFileSize  int64 = 5 << 20
+	DefaultBlameTimeout       = time.Minute
+)
+
+// Reasons a changed file is skipped instead of blamed.
+const (
+	skipTooLarge = "too large"
+	skipTimedOut = "timed out"
+)
+
+// maxFileSize is the size in bytes above which files aren't blamed, or 0 for
+// no limit.
+func (r *ContributionCounter) maxFileSize() int64 {
+	switch {
+	case r.MaxFileSize < 0:
+		return 0
+	case r.MaxFileSize == 0:
+		return DefaultMaxFileSize
+	default:
+		return r.MaxFileSize
+	}
+}
+
+// blameTimeout is how long blaming a single file may take, or 0 for no limit.
+func (r *ContributionCounter) blameTimeout() time.Duration {
+	switch {
+	case r.BlameTimeout < 0:
+		return 0
+	case r.BlameTimeout == 0:
+		return DefaultBlameTimeout
+	default:
+		return r.BlameTimeout
+	}
+}
+
+// blameContext bounds a git blame command by blameTimeout. The returned
+// function releases its resources and must be called.
+func (r *ContributionCounter) blameContext() (context.Context, context.CancelFunc) {
+	if timeout := r.blameTimeout(); timeout > 0 {
+		return context.WithTimeout(r.runContext(), timeout)
+	}
+	return context.WithCancel(r.runContext())
+}
+
+// timedOut wraps the error of a git blame command that was stopped by
+// blameTimeout so it matches ErrBlameTimedOut.
+func (r *ContributionCounter) timedOut(ctx context.Context, path string, err error) error {
+	if ctx.Err() != context.DeadlineExceeded {
+		return err
+	}
+	return errors.Wrapf(ErrBlameTimedOut, "%s after %s", path, r.blameTimeout())
+}
+
+// skip records that 'path' was left out of the counts and why.
+func (c *contributions) skip(path, reason string) {
+	c.skipped[path] = reason
+}
+
+// timedOut reports whether any file was skipped for taking too long to blame.
+func (c *contributions) timedOut() bool {
+	for _, reason := range c.skipped {
+		if reason == skipTimedOut {
+			return true
+		}
+	}
+	return false
+}
+
+// skippedFiles lists the skipped files, sorted, each followed by the reason
+// it was skipped.
+func (c *contributions) skippedFiles() []string {
+	var files []string
+	for path, reason := range c.skipped {
+		files = append(files, fmt.Sprintf("%s (%s)", path, reason))
+	}
+	sort.Strings(files)
+
+	return files
+}
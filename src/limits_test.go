@@ -0,0 +1,75 @@
+package gitreviewers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGenerateCountsSkips(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "src", "big.csv"),
+		[]byte(strings.Repeat("1,2,3\n", 100)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-q", "-m", "Add data")
+
+	repo, _, err := OpenRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		maxFileSize  int64
+		blameTimeout time.Duration
+		expected     map[string]string
+	}{
+		{"defaults", 0, 0, map[string]string{}},
+		{"too large", 100, 0, map[string]string{"src/big.csv": skipTooLarge}},
+		{"no limit", -1, -1, map[string]string{}},
+		{"timed out", 0, time.Nanosecond, map[string]string{
+			"src/a.go":    skipTimedOut,
+			"src/big.csv": skipTimedOut,
+		}},
+	}
+
+	for _, tt := range tests {
+		r := ContributionCounter{Repo: repo, Dir: dir, Since: "2000-01-01",
+			MaxFileSize: tt.maxFileSize, BlameTimeout: tt.blameTimeout}
+
+		counts, err := r.generateCounts([]string{"src/a.go", "src/big.csv"})
+		if err != nil {
+			t.Fatalf("Unexpected error in case %s: %v\n", tt.name, err)
+		}
+		if !reflect.DeepEqual(counts.skipped, tt.expected) {
+			t.Errorf("Got '%v', expected '%v' in case %s\n", counts.skipped,
+				tt.expected, tt.name)
+		}
+		if _, blamed := counts.fileLines["src/big.csv"]; blamed == (len(tt.expected) > 0) {
+			t.Errorf("Got blamed %t, expected %t in case %s\n", blamed,
+				len(tt.expected) == 0, tt.name)
+		}
+	}
+}
+
+func TestSkippedFiles(t *testing.T) {
+	c := newContributions()
+	c.skip("data/b.json", skipTimedOut)
+	c.skip("data/a.csv", skipTooLarge)
+
+	expected := []string{"data/a.csv (too large)", "data/b.json (timed out)"}
+	if got := c.skippedFiles(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Got '%v', expected '%v'\n", got, expected)
+	}
+	if !c.timedOut() {
+		t.Errorf("Expected a timed out file\n")
+	}
+}
@@ -0,0 +1,66 @@
+package gitreviewers
+
+import (
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// maxHistoryPattern matches the lengths of history MaxHistory accepts, such as
+// 90d, 6w, 18m or 2y.
+var maxHistoryPattern = regexp.MustCompile(`^([0-9]+)([dwmy])$`)
+
+// parseMaxHistory reads a length of history such as 2y into the years, months
+// and days it spans.
+func parseMaxHistory(s string) (years, months, days int, err error) {
+	m := maxHistoryPattern.FindStringSubmatch(s)
+	if m == nil {
+		return 0, 0, 0, errors.Errorf("'%s' is not a valid length of history", s)
+	}
+
+	n, err := strconv.Atoi(m[1])
+	if err != nil || n == 0 {
+		return 0, 0, 0, errors.Errorf("'%s' is not a valid length of history", s)
+	}
+	switch m[2] {
+	case "y":
+		return n, 0, 0, nil
+	case "m":
+		return 0, n, 0, nil
+	case "w":
+		return 0, 0, 7 * n, nil
+	}
+	return 0, 0, n, nil
+}
+
+// resolveHistoryStart sets historyStart from MaxHistory, counting back from
+// the same moment the default Since does. It is resolved by defaultSince,
+// before blaming anything concurrently.
+func (r *ContributionCounter) resolveHistoryStart() {
+	if r.MaxHistory == "" || r.historyStart != "" {
+		return
+	}
+
+	years, months, days, err := parseMaxHistory(r.MaxHistory)
+	if err != nil {
+		r.logf("Following all of history: %v\n", err)
+		return
+	}
+	r.historyStart = r.sinceAnchor().AddDate(-years, -months, -days).Format("2006-01-02")
+}
+
+// beyondHistory reports whether a line blamed on a boundary commit committed
+// on 'date' is older than MaxHistory. Blame stops following history there and
+// credits every older line to the commit it stopped at, who may not have
+// written them, so those lines aren't credited to anyone.
+func (r *ContributionCounter) beyondHistory(date string) bool {
+	return r.historyStart != "" && date < r.historyStart
+}
+
+// pastHistoryStart reports whether a commit made at 'when' is older than
+// MaxHistory, for blaming without git to stop where git blame would.
+func (r *ContributionCounter) pastHistoryStart(when time.Time) bool {
+	return r.historyStart != "" && when.Format("2006-01-02") < r.historyStart
+}
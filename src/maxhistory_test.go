@@ -0,0 +1,100 @@
+package gitreviewers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseMaxHistory(t *testing.T) {
+	cases := []struct {
+		history string
+		years   int
+		months  int
+		days    int
+		valid   bool
+	}{
+		{"2y", 2, 0, 0, true},
+		{"18m", 0, 18, 0, true},
+		{"6w", 0, 0, 42, true},
+		{"90d", 0, 0, 90, true},
+		{"0y", 0, 0, 0, false},
+		{"2 years", 0, 0, 0, false},
+		{"y", 0, 0, 0, false},
+		{"-1d", 0, 0, 0, false},
+	}
+
+	for _, c := range cases {
+		years, months, days, err := parseMaxHistory(c.history)
+		if (err == nil) != c.valid || years != c.years || months != c.months || days != c.days {
+			t.Errorf("Got %d years, %d months, %d days (%v) for '%s', expected %d, %d, %d\n",
+				years, months, days, err, c.history, c.years, c.months, c.days)
+		}
+	}
+}
+
+func TestMaxHistory(t *testing.T) {
+	commitOn := func(date string) {
+		os.Setenv("GIT_AUTHOR_DATE", date+"T12:00:00Z")
+		os.Setenv("GIT_COMMITTER_DATE", date+"T12:00:00Z")
+	}
+	defer os.Unsetenv("GIT_AUTHOR_DATE")
+	defer os.Unsetenv("GIT_COMMITTER_DATE")
+
+	commitOn("2016-01-01")
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	write := func(content string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, "src", "a.go"),
+			[]byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	commitOn("2017-01-01")
+	write("package a\nvar x = 1\n")
+	runGit(t, dir, "commit", "-q", "-a", "-m", "Add x",
+		"--author", "Ben Franklin <ben@git-reviewer.com>")
+	commitOn("2018-06-01")
+	write("package a\nvar x = 1\nvar y = 2\n")
+	runGit(t, dir, "commit", "-q", "-a", "-m", "Add y",
+		"--author", "Cal Coolidge <cal@git-reviewer.com>")
+
+	repo, _, err := OpenRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		maxHistory string
+		noExec     bool
+		expected   map[string]int
+	}{
+		{"", false, map[string]int{"abe@git-reviewer.com": 1, "ben@git-reviewer.com": 1,
+			"cal@git-reviewer.com": 1}},
+		// A year before the last commit, blame stops at Ben's commit, so
+		// neither Ben's line nor Abe's is credited
+		{"1y", false, map[string]int{"cal@git-reviewer.com": 1}},
+		{"1y", true, map[string]int{"cal@git-reviewer.com": 1}},
+		{"2y", false, map[string]int{"ben@git-reviewer.com": 1, "cal@git-reviewer.com": 1}},
+		{"2y", true, map[string]int{"ben@git-reviewer.com": 1, "cal@git-reviewer.com": 1}},
+	}
+
+	for _, c := range cases {
+		r := ContributionCounter{Repo: repo, Dir: dir, Since: "2000-01-01",
+			MaxHistory: c.maxHistory, NoExec: c.noExec}
+		r.defaultSince()
+
+		counts, err := r.generateCounts([]string{"src/a.go"})
+		if err != nil {
+			t.Fatalf("Unexpected error blaming with --max-history '%s': %v\n", c.maxHistory, err)
+		}
+		if actual := counts.byFile["src/a.go"]; !reflect.DeepEqual(actual, c.expected) ||
+			counts.fileLines["src/a.go"] != 3 {
+			t.Errorf("Got %v out of %d lines with --max-history '%s' (no exec: %t), expected %v out of 3\n",
+				actual, counts.fileLines["src/a.go"], c.maxHistory, c.noExec, c.expected)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package gitreviewers
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/thedahv/git-reviewer/src/blame"
+)
+
+// ReviewMerge sets up the counter to look at a change that was already
+// merged, given its merge commit: the merge is compared to its first parent,
+// which is the branch as it was before the change landed. That helps assign
+// reviews after the fact, such as for audits or after an incident. Any other
+// commit works too and is compared to its parent.
+func (r *ContributionCounter) ReviewMerge(rev string) error {
+	out, err := r.output("rev-list", "--parents", "-n", "1", rev+"^{commit}", "--")
+	if err != nil {
+		return errors.Wrapf(err, "unknown revision '%s'", rev)
+	}
+
+	commits := strings.Fields(string(out))
+	if len(commits) < 2 {
+		return errors.Errorf("'%s' has no parent to compare to", rev)
+	}
+
+	r.Base, r.Head = commits[1], commits[0]
+	return nil
+}
+
+// history adds options to the arguments of a git command walking history,
+// starting with the command name, so it follows the same commits as blame.
+func (r *ContributionCounter) history(args ...string) []string {
+	if !r.FirstParent {
+		return args
+	}
+	return append([]string{args[0], "--first-parent"}, args[1:]...)
+}
+
+// revCache remembers whether commits are merges or reverts so each one is
+// looked up only once per run, no matter how many files it touched.
+type revCache struct {
+	sync.Mutex
+	mass map[string]bool
+}
+
+func newRevCache() *revCache {
+	return &revCache{mass: make(map[string]bool)}
+}
+
+// ignoredRevs picks the merge and revert commits out of 'revs', which blame
+// should look past with --ignore-rev. A merge only carries lines when it
+// resolved conflicts or was amended, and a revert brings back lines somebody
+// else wrote, so neither says much about who knows the code.
+func (r *ContributionCounter) ignoredRevs(revs []string) ([]string, error) {
+	cache := r.massRevs
+	if cache == nil {
+		cache = newRevCache()
+	}
+
+	cache.Lock()
+	var unknown []string
+	for _, rev := range revs {
+		if _, ok := cache.mass[rev]; !ok {
+			unknown = append(unknown, rev)
+		}
+	}
+	cache.Unlock()
+
+	if len(unknown) > 0 {
+		args := append([]string{"log", "--no-walk=unsorted", "--format=%H%x09%P%x09%s"},
+			unknown...)
+		out, err := r.output(args...)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to look up blamed commits")
+		}
+
+		cache.Lock()
+		for _, rev := range unknown {
+			cache.mass[rev] = false
+		}
+		for rev, mass := range parseRevKinds(out) {
+			cache.mass[rev] = mass
+		}
+		cache.Unlock()
+	}
+
+	var ignored []string
+
+	cache.Lock()
+	for _, rev := range revs {
+		if cache.mass[rev] {
+			ignored = append(ignored, rev)
+		}
+	}
+	cache.Unlock()
+
+	return ignored, nil
+}
+
+// parseRevKinds reads "hash<TAB>parents<TAB>subject" lines and reports which
+// commits are merges or reverts.
+func parseRevKinds(out []byte) map[string]bool {
+	mass := make(map[string]bool)
+
+	scn := bufio.NewScanner(bytes.NewReader(out))
+	for scn.Scan() {
+		fields := strings.SplitN(scn.Text(), "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+
+		merge := len(strings.Fields(fields[1])) > 1
+		revert := strings.HasPrefix(fields[2], "Revert \"")
+		mass[fields[0]] = merge || revert
+	}
+
+	return mass
+}
+
+// blamedRevs lists the distinct commits blame credited lines to, leaving out
+// boundary and uncommitted lines.
+func blamedRevs(lines []blame.LineAttribution) []string {
+	var (
+		revs []string
+		seen = make(map[string]bool)
+	)
+
+	for _, l := range lines {
+		if l.Boundary || l.Uncommitted() {
+			continue
+		}
+
+		if !seen[l.Commit] {
+			seen[l.Commit] = true
+			revs = append(revs, l.Commit)
+		}
+	}
+
+	return revs
+}
@@ -0,0 +1,142 @@
+package gitreviewers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseRevKinds(t *testing.T) {
+	out := []byte("aaa\tbbb\tAdd a feature\n" +
+		"ccc\taaa ddd\tMerge branch 'feature'\n" +
+		"eee\tccc\tRevert \"Add a feature\"\n" +
+		"fff\t\tInitial commit\n" +
+		"garbage\n")
+
+	expected := map[string]bool{
+		"aaa": false,
+		"ccc": true,
+		"eee": true,
+		"fff": false,
+	}
+
+	if got := parseRevKinds(out); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Got '%v', expected '%v'\n", got, expected)
+	}
+}
+
+func TestBlameAttributionsIgnoreMerges(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	write := func(content string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, "src", "a.go"),
+			[]byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("package a\n\nvar b = 1\n")
+	runGit(t, dir, "commit", "-q", "-a", "-m", "Add b")
+
+	runGit(t, dir, "checkout", "-q", "-b", "feature")
+	if err := ioutil.WriteFile(filepath.Join(dir, "src", "c.go"),
+		[]byte("package a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-q", "-m", "Add c")
+
+	// An amended merge makes the merger look like the author of the line
+	runGit(t, dir, "checkout", "-q", "master")
+	runGit(t, dir, "merge", "-q", "--no-ff", "--no-commit", "feature")
+	write("package a\n\nvar b = 2\n")
+	runGit(t, dir, "commit", "-q", "-a", "--author", "Merger <merger@git-reviewer.com>",
+		"-m", "Merge branch 'feature'")
+
+	for _, ignoreMerges := range []bool{false, true} {
+		r := ContributionCounter{Dir: dir, Since: "2000-01-01", IgnoreMerges: ignoreMerges}
+
+		attributions, _, err := r.blameAttributions("src/a.go", "master")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := "merger@git-reviewer.com"
+		if ignoreMerges {
+			expected = "abe@git-reviewer.com"
+		}
+		if len(attributions) != 3 || attributions[2].author != expected {
+			t.Errorf("Got %v, expected the last line credited to '%s'\n",
+				attributions, expected)
+		}
+	}
+}
+
+func TestBlameAttributionsFirstParent(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	runGit(t, dir, "checkout", "-q", "-b", "fork")
+	if err := ioutil.WriteFile(filepath.Join(dir, "src", "a.go"),
+		[]byte("package a\n\nvar b = 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "commit", "-q", "-a", "--author", "Forker <forker@git-reviewer.com>",
+		"-m", "Add b")
+
+	runGit(t, dir, "checkout", "-q", "master")
+	runGit(t, dir, "merge", "-q", "--no-ff", "-m", "Merge branch 'fork'", "fork")
+	// git merge has no --author, so amend the merge to credit the merger
+	runGit(t, dir, "commit", "-q", "--amend", "--no-edit", "--author",
+		"Merger <merger@git-reviewer.com>")
+
+	for _, firstParent := range []bool{false, true} {
+		r := ContributionCounter{Dir: dir, Since: "2000-01-01", FirstParent: firstParent}
+
+		attributions, _, err := r.blameAttributions("src/a.go", "master")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := "forker@git-reviewer.com"
+		if firstParent {
+			expected = "merger@git-reviewer.com"
+		}
+		if len(attributions) != 3 || attributions[2].author != expected {
+			t.Errorf("Got %v, expected the last line credited to '%s'\n",
+				attributions, expected)
+		}
+	}
+}
+
+func TestReviewMerge(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	root := strings.TrimSpace(runGit(t, dir, "rev-parse", "HEAD"))
+	runGit(t, dir, "checkout", "-q", "-b", "feature")
+	runGit(t, dir, "commit", "-q", "--allow-empty", "-m", "Feature")
+	runGit(t, dir, "checkout", "-q", "master")
+	runGit(t, dir, "commit", "-q", "--allow-empty", "-m", "Master")
+	first := strings.TrimSpace(runGit(t, dir, "rev-parse", "HEAD"))
+	runGit(t, dir, "merge", "-q", "--no-ff", "-m", "Merge feature", "feature")
+	merge := strings.TrimSpace(runGit(t, dir, "rev-parse", "HEAD"))
+
+	r := ContributionCounter{Dir: dir}
+	if err := r.ReviewMerge("HEAD"); err != nil {
+		t.Fatal(err)
+	}
+	if r.Base != first || r.Head != merge {
+		t.Errorf("Got %s..%s, expected %s..%s\n", r.Base, r.Head, first, merge)
+	}
+
+	for _, rev := range []string{root, "nope"} {
+		if err := r.ReviewMerge(rev); err == nil {
+			t.Errorf("Expected an error for '%s'\n", rev)
+		}
+	}
+}
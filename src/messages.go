@@ -0,0 +1,193 @@
+package gitreviewers
+
+import (
+	"os"
+	"strings"
+)
+
+// Languages messages can be displayed in, set through
+// ContributionCounter.Language.
+const (
+	// LanguageEnglish is the language messages are written in, and the
+	// default.
+	LanguageEnglish = "en"
+	// LanguageSpanish displays messages in Spanish.
+	LanguageSpanish = "es"
+)
+
+// Languages lists the valid values of ContributionCounter.Language.
+var Languages = []string{LanguageEnglish, LanguageSpanish}
+
+// catalogs holds the translations of user-facing messages by language. The
+// English message is the key, so a message without a translation is displayed
+// in English rather than not at all. Format verbs must be kept in the same
+// order as in the English message.
+var catalogs = map[string]map[string]string{
+	LanguageSpanish: {
+		// Suggestion tables
+		"Reviewer":   "Revisor",
+		"Experience": "Experiencia",
+		"\nWARNING: nobody active owns more than %.0f%% of these files:\n":               "\nAVISO: nadie activo es dueño de más del %.0f%% de estos archivos:\n",
+		"\nLines owned by quarter over the last %d quarters, oldest first:\n":            "\nLíneas por trimestre en los últimos %d trimestres, de la más antigua a la más reciente:\n",
+		"\nWARNING: these files were too large or too slow to blame:\n":                  "\nAVISO: estos archivos eran demasiado grandes o lentos para git blame:\n",
+		"\nWARNING: the branch makes its author the main owner of files others owned:\n": "\nAVISO: la rama hace a su autor el dueño principal de archivos de otros:\n",
+		"  %s: %s takes over from %s (%.0f%% of the lines, up from %.0f%%)\n":            "  %s: %s toma el relevo de %s (%.0f%% de las líneas, antes %.0f%%)\n",
+		"Consider asking the previous owners to review.\n":                               "Considera pedir la revisión a los dueños anteriores.\n",
+
+		// Command line
+		"Unknown output format '%s'. Run 'git reviewer -h'\n":             "Formato de salida desconocido '%s'. Ejecuta 'git reviewer -h'\n",
+		"Unable to open current directory: %v\n":                          "No se pudo abrir el directorio actual: %v\n",
+		"Unable to read path filters: %v\n":                               "No se pudieron leer los filtros de rutas: %v\n",
+		"Problem reading teams: %v\n":                                     "Problema al leer los equipos: %v\n",
+		"There was an error determining branch state: %v\n":               "Hubo un error al determinar el estado de la rama: %v\n",
+		"%s. Merge up!\n":                                                 "%s. ¡Actualiza la rama!\n",
+		"WARNING: %s. Suggestions may miss the latest changes on %s.\n\n": "AVISO: %s. Las sugerencias pueden omitir los últimos cambios de %s.\n\n",
+		"There was an error finding files: %v\n":                          "Hubo un error al buscar los archivos: %v\n",
+		"No changes on this branch!":                                      "¡No hay cambios en esta rama!",
+		"Reviewers across the following changed files:":                   "Revisores de los siguientes archivos modificados:",
+		"There was an error annotating the diff: %v\n":                    "Hubo un error al anotar el diff: %v\n",
+		"There was an error finding reviewers: %v\n":                      "Hubo un error al buscar revisores: %v\n",
+		"There was an error finding hunk owners: %v\n":                    "Hubo un error al buscar los dueños de los fragmentos: %v\n",
+		"There was an error finding annotations: %v\n":                    "Hubo un error al buscar las anotaciones: %v\n",
+		"Suggested reviewers":                                             "Revisores sugeridos",
+		"There was an error finding packages: %v\n":                       "Hubo un error al buscar los paquetes: %v\n",
+		"Changed areas": "Áreas modificadas",
+		"Nobody has enough experience with these changes.": "Nadie tiene suficiente experiencia con estos cambios.",
+		"Risks":                                                 "Riesgos",
+		"None found.":                                           "No se encontró ninguno.",
+		"1 file":                                                "1 archivo",
+		"%d files":                                              "%d archivos",
+		"Based on %d changed files.":                            "Basado en %d archivos modificados.",
+		"Unable to write step outputs: %v\n":                    "No se pudieron escribir las salidas del paso: %v\n",
+		"Unable to write step summary: %v\n":                    "No se pudo escribir el resumen del paso: %v\n",
+		"Installed the pre-push hook in %s\n":                   "Se instaló el hook pre-push en %s\n",
+		"Unable to install the hook: %v\n":                      "No se pudo instalar el hook: %v\n",
+		"Unable to uninstall the hook: %v\n":                    "No se pudo desinstalar el hook: %v\n",
+		"No pre-push hook to uninstall":                         "No hay hook pre-push que desinstalar",
+		"Removed the pre-push hook from %s\n":                   "Se quitó el hook pre-push de %s\n",
+		"git-reviewer: unable to check %s: %v\n":                "git-reviewer: no se pudo revisar %s: %v\n",
+		"git-reviewer: %s changes files that need attention:\n": "git-reviewer: %s cambia archivos que necesitan atención:\n",
+		"Suggested contacts: %s\n":                              "Contactos sugeridos: %s\n",
+		"git-reviewer: push blocked by reviewer.prePushBlock. Get the changes reviewed, or push with --no-verify.": "git-reviewer: push bloqueado por reviewer.prePushBlock. Haz revisar los cambios, o usa push con --no-verify.",
+		"Problem finding reviewers: %s":                                        "Problema al buscar revisores: %s",
+		"Run git-reviewer again with the --since argument":                     "Vuelve a ejecutar git-reviewer con el argumento --since",
+		"Unable to write the bundle: %v\n":                                     "No se pudo escribir el paquete: %v\n",
+		"Unable to open the bundle: %v\n":                                      "No se pudo abrir el paquete: %v\n",
+		"Unable to read the bundle: %v\n":                                      "No se pudo leer el paquete: %v\n",
+		"Replaying %.7s..%.7s since %s, captured by git-reviewer %s on %s\n\n": "Reproduciendo %.7s..%.7s desde %s, capturado por git-reviewer %s el %s\n\n",
+		"Unable to write signals: %v\n":                                        "No se pudieron escribir las señales: %v\n",
+		"There is a problem with the arguments:":                               "Hay un problema con los argumentos:",
+		"There are %d problems with the arguments:\n":                          "Hay %d problemas con los argumentos:\n",
+		"\nUnable to request reviews: %v\n":                                    "\nNo se pudieron pedir las revisiones: %v\n",
+		"\nNo open pull request to request reviews on.":                        "\nNo hay un pull request abierto en el que pedir revisiones.",
+		"\nNone of the reviewers have an account to request reviews from.":     "\nNinguno de los revisores tiene una cuenta a la que pedir revisiones.",
+		"\nRequested reviews from @%s on %s.\n":                                "\nSe pidieron revisiones a @%s en %s.\n",
+		"No pull request found, comparing to the default branch: %v\n\n":       "No se encontró un pull request, se compara con la rama por defecto: %v\n\n",
+		"Run 'git reviewer -h' for help.":                                      "Ejecuta 'git reviewer -h' para obtener ayuda.",
+		"Unable to read repository state: %v\n":                                "No se pudo leer el estado del repositorio: %v\n",
+		"No changes on this branch yet":                                        "Todavía no hay cambios en esta rama",
+		"%d changed files\n\n":                                                 "%d archivos modificados\n\n",
+		"Whole stack (%s on %s)\n\n":                                           "Toda la pila (%s sobre %s)\n\n",
+		"%s (on %s)\n\n":                                                       "%s (sobre %s)\n\n",
+		"There was an error finding files: %v\n\n":                             "Hubo un error al buscar los archivos: %v\n\n",
+		"No changes in %s!\n\n":                                                "¡No hay cambios en %s!\n\n",
+		"Problem finding reviewers: %s\n\n":                                    "Problema al buscar revisores: %s\n\n",
+		"There was an error finding reviewers: %v\n\n":                         "Hubo un error al buscar revisores: %v\n\n",
+		"No reviews recorded in commit trailers since %s\n":                    "No hay revisiones registradas en trailers de commits desde %s\n",
+		"Package %s (%s)\n\n":                                                  "Paquete %s (%s)\n\n",
+		"All packages (%s)\n\n":                                                "Todos los paquetes (%s)\n\n",
+		"Production code (%s)\n\n":                                             "Código de producción (%s)\n\n",
+		"Tests (%s)\n\n":                                                       "Pruebas (%s)\n\n",
+		"Checking the environment git-reviewer runs in:":                       "Comprobando el entorno en el que se ejecuta git-reviewer:",
+		"\n%d of %d checks failed.\n":                                          "\nFallaron %d de %d comprobaciones.\n",
+		"\nAll checks passed.":                                                 "\nTodas las comprobaciones pasaron.",
+		"%d settings":                                                          "%d ajustes",
+		"logged in with gh":                                                    "sesión iniciada con gh",
+		"no mailmap files":                                                     "no hay archivos mailmap",
+		"gh is not installed, only the gh command needs it":                    "gh no está instalado, solo el comando gh lo necesita",
+		"reviewer.jiraUrl is not set, only --tickets needs it":                 "reviewer.jiraUrl no está definido, solo --tickets lo necesita",
+		"Install a recent git and make sure it is on your PATH, or point --git-bin at it":     "Instala un git reciente y asegúrate de que esté en tu PATH, o indica su ruta con --git-bin",
+		"git %s, too old for --ignore-merges":                                                 "git %s, demasiado antiguo para --ignore-merges",
+		"Run git reviewer from inside a git repository":                                       "Ejecuta git reviewer dentro de un repositorio git",
+		"Check the syntax of %s and your git config":                                          "Revisa la sintaxis de %s y de tu configuración de git",
+		"Write each line as 'Name <email>' optionally followed by 'Other Name <other email>'": "Escribe cada línea como 'Nombre <email>' seguido opcionalmente de 'Otro Nombre <otro email>'",
+		"Run 'gh auth login'": "Ejecuta 'gh auth login'",
+		"Set JIRA_API_TOKEN to an API token of reviewer.jiraUser":            "Define JIRA_API_TOKEN con un token de API de reviewer.jiraUser",
+		"Set HOME, or run with --no-cache":                                   "Define HOME, o ejecuta con --no-cache",
+		"Make the directory writable, or run with --no-cache":                "Permite escribir en el directorio, o ejecuta con --no-cache",
+		"There was an error measuring ownership: %v\n":                       "Hubo un error al medir la propiedad: %v\n",
+		"Ownership of %d lines in %d files at %.7s since %s\n\n":             "Propiedad de %d líneas en %d archivos en %.7s desde %s\n\n",
+		"Setting\tValue\tSource":                                             "Ajuste\tValor\tOrigen",
+		"Owner\tShare\tLines\tFiles":                                         "Dueño\tParte\tLíneas\tArchivos",
+		"Projected ownership of %d changed files once the branch merges\n\n": "Propiedad prevista de %d archivos cambiados cuando se fusione la rama\n\n",
+		"%d lines, %+d":                                                  "%d líneas, %+d",
+		"Path\tOwner\tBefore\tAfter\tChange":                             "Ruta\tDueño\tAntes\tDespués\tCambio",
+		"Path\tChange\tAdded\tRemoved\tExcluded":                         "Ruta\tCambio\tAñadidas\tQuitadas\tExcluido",
+		"through %s":                                                     "a través de %s",
+		"There was an error finding conflicts: %v\n":                     "Hubo un error al buscar conflictos: %v\n",
+		"No conflicts left to resolve in this %s\n":                      "No quedan conflictos por resolver en este %s\n",
+		"Owners of the other side of each conflict, from %.7s (%s):\n\n": "Dueños del otro lado de cada conflicto, de %.7s (%s):\n\n",
+		"\nPeople to consult:\n\n":                                       "\nPersonas a consultar:\n\n",
+		"Reviewer\tConflicts":                                            "Revisor\tConflictos",
+		"Ignoring the identity cache: %v\n":                              "Se ignora la caché de identidades: %v\n",
+		"Unable to save the identity cache: %v\n":                        "No se pudo guardar la caché de identidades: %v\n",
+		"Unable to find the identity cache: %v\n":                        "No se encontró la caché de identidades: %v\n",
+		"Unable to read the identity cache: %v\n":                        "No se pudo leer la caché de identidades: %v\n",
+		"Forgot %d cached accounts; they will be looked up again.\n":     "Se olvidaron %d cuentas en caché; se volverán a buscar.\n",
+		"No accounts cached yet.":                                        "Todavía no hay cuentas en caché.",
+		"Host\tEmail\tAccount\tResolved":                                 "Servidor\tCorreo\tCuenta\tResuelta",
+		"There was an error reading review history: %v\n":                "Hubo un error al leer el historial de revisiones: %v\n",
+		"Ask about the suggestion, or type help or quit.":                "Pregunta por la sugerencia, o escribe help o quit.",
+	},
+}
+
+// Translate returns 'msg' in 'lang', or 'msg' itself when 'lang' is English,
+// unknown, or has no translation for it.
+func Translate(lang, msg string) string {
+	if t, ok := catalogs[lang][msg]; ok {
+		return t
+	}
+	return msg
+}
+
+// tr translates a message into the language of the counter.
+func (r *ContributionCounter) tr(msg string) string {
+	return Translate(r.Language, msg)
+}
+
+// DetectLanguage picks the language to display messages in. An explicit
+// 'lang', such as from a --lang flag, is returned as given so it can be
+// validated. Otherwise the locale is read from LC_ALL, LC_MESSAGES and LANG
+// in the order gettext uses, falling back to English for unsupported
+// locales.
+func DetectLanguage(lang string) string {
+	if lang != "" {
+		return strings.ToLower(lang)
+	}
+
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if locale := os.Getenv(name); locale != "" {
+			if l := localeLanguage(locale); containsString(Languages, l) {
+				return l
+			}
+			return LanguageEnglish
+		}
+	}
+
+	return LanguageEnglish
+}
+
+// localeLanguage extracts the language from a POSIX locale name such as
+// "es_ES.UTF-8" or "de_DE@euro". The "C" and "POSIX" locales are English.
+func localeLanguage(locale string) string {
+	if i := strings.IndexAny(locale, "_.@"); i >= 0 {
+		locale = locale[:i]
+	}
+
+	switch locale = strings.ToLower(locale); locale {
+	case "c", "posix":
+		return LanguageEnglish
+	default:
+		return locale
+	}
+}
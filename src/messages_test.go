@@ -0,0 +1,75 @@
+package gitreviewers
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestTranslate(t *testing.T) {
+	tests := []struct {
+		lang, msg, expected string
+	}{
+		{LanguageEnglish, "Reviewer", "Reviewer"},
+		{LanguageSpanish, "Reviewer", "Revisor"},
+		{LanguageSpanish, "not in the catalog", "not in the catalog"},
+		{"fr", "Reviewer", "Reviewer"},
+		{"", "Reviewer", "Reviewer"},
+	}
+
+	for _, tt := range tests {
+		if got := Translate(tt.lang, tt.msg); got != tt.expected {
+			t.Errorf("Got '%s', expected '%s'\n", got, tt.expected)
+		}
+	}
+}
+
+func TestCatalogVerbs(t *testing.T) {
+	verbs := regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+
+	for lang, catalog := range catalogs {
+		for msg, translation := range catalog {
+			expected := strings.Join(verbs.FindAllString(msg, -1), " ")
+			got := strings.Join(verbs.FindAllString(translation, -1), " ")
+			if got != expected {
+				t.Errorf("Got verbs '%s' in %s translation of %q, expected '%s'\n",
+					got, lang, msg, expected)
+			}
+		}
+	}
+}
+
+func TestLocaleLanguage(t *testing.T) {
+	tests := []struct {
+		locale, expected string
+	}{
+		{"es_ES.UTF-8", "es"},
+		{"es", "es"},
+		{"de_DE@euro", "de"},
+		{"en_US.UTF-8", "en"},
+		{"C", "en"},
+		{"POSIX", "en"},
+		{"C.UTF-8", "en"},
+	}
+
+	for _, tt := range tests {
+		if got := localeLanguage(tt.locale); got != tt.expected {
+			t.Errorf("Got '%s', expected '%s'\n", got, tt.expected)
+		}
+	}
+}
+
+func TestTableHeader(t *testing.T) {
+	tests := []struct {
+		lang, expected string
+	}{
+		{LanguageEnglish, "Reviewer\tExperience\n--------\t----------\n"},
+		{LanguageSpanish, "Revisor\tExperiencia\n-------\t-----------\n"},
+	}
+
+	for _, tt := range tests {
+		if got := TableHeader(tt.lang); got != tt.expected {
+			t.Errorf("Got '%s', expected '%s'\n", got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,366 @@
+package gitreviewers
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"github.com/thedahv/git-reviewer/src/blame"
+	gogit "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	formatconfig "gopkg.in/src-d/go-git.v4/plumbing/format/config"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/utils/diff"
+)
+
+// noExecRunner refuses to run git, for NoExec.
+type noExecRunner struct{}
+
+func (noExecRunner) Output(ctx context.Context, args ...string) ([]byte, error) {
+	return nil, ErrExecDisabled
+}
+
+// resolveGoGit is resolve without git. Like git, it looks 'rev' up as a full
+// commit hash, then as a reference, tag, branch and remote branch, in that
+// order. A ~N or ^N suffix is followed from there by go-git.
+func (r *ContributionCounter) resolveGoGit(rev string) (plumbing.Hash, error) {
+	name, suffix := rev, ""
+	if i := strings.IndexAny(rev, "~^"); i >= 0 {
+		name, suffix = rev[:i], rev[i:]
+	}
+
+	if suffix == "" && len(name) == 40 {
+		if _, err := r.Repo.CommitObject(plumbing.NewHash(name)); err == nil {
+			return plumbing.NewHash(name), nil
+		}
+	}
+
+	for _, ref := range []string{name, "refs/" + name, "refs/tags/" + name,
+		"refs/heads/" + name, "refs/remotes/" + name, "refs/remotes/" + name + "/HEAD"} {
+		if _, err := r.Repo.Reference(plumbing.ReferenceName(ref), true); err != nil {
+			continue
+		}
+
+		h, err := r.Repo.ResolveRevision(plumbing.Revision(ref + suffix))
+		if err != nil || *h == plumbing.ZeroHash {
+			break
+		}
+		return *h, nil
+	}
+
+	return plumbing.ZeroHash, errors.Errorf("unknown revision '%s'", rev)
+}
+
+// compareGoGit finds the merge base of the 'base' and 'head' revisions and
+// counts the commits each has that the other doesn't, like git merge-base and
+// git rev-list --left-right --count do. When the revisions have several best
+// common ancestors, the most recently committed is picked.
+func (r *ContributionCounter) compareGoGit(base, head string) (string, int, int, error) {
+	var (
+		reachable [2]map[plumbing.Hash]*object.Commit
+		mergeBase *object.Commit
+	)
+	for i, rev := range []string{base, head} {
+		h, err := r.resolve(rev)
+		if err != nil {
+			return "", 0, 0, err
+		}
+		iter, err := r.Repo.Log(&gogit.LogOptions{From: h})
+		if err != nil {
+			return "", 0, 0, errors.Wrapf(err, "issue reading the history of %s", rev)
+		}
+
+		reachable[i] = make(map[plumbing.Hash]*object.Commit)
+		err = iter.ForEach(func(c *object.Commit) error {
+			reachable[i][c.Hash] = c
+			return nil
+		})
+		if err != nil {
+			return "", 0, 0, errors.Wrapf(err, "issue reading the history of %s", rev)
+		}
+	}
+
+	// Common ancestors that are parents of other common ancestors aren't the
+	// best ones
+	common := make(map[plumbing.Hash]*object.Commit)
+	for h, c := range reachable[1] {
+		if reachable[0][h] != nil {
+			common[h] = c
+		}
+	}
+	older := make(map[plumbing.Hash]bool)
+	for _, c := range common {
+		for _, p := range c.ParentHashes {
+			older[p] = true
+		}
+	}
+	for h, c := range common {
+		if !older[h] && (mergeBase == nil ||
+			c.Committer.When.After(mergeBase.Committer.When)) {
+			mergeBase = c
+		}
+	}
+	if mergeBase == nil {
+		return "", 0, 0, errors.Errorf("'%s' and '%s' have no common ancestor", base, head)
+	}
+
+	return mergeBase.Hash.String(), len(reachable[0]) - len(common),
+		len(reachable[1]) - len(common), nil
+}
+
+// goGitBlame is blame without git. It blames the lines from the history
+// go-git reads, as git blame would, and understands the line ranges of 'opts'
+// and stops at MaxHistory. Other options, such as ignored revisions, need
+// git.
+//
+// History is followed through the parent that has the file unchanged, if a
+// merge has one, and through the first parent that has it otherwise, so lines
+// brought in by a merge are credited to the merge unless one side of it
+// already had them. Renames are not followed.
+func (r *ContributionCounter) goGitBlame(ctx context.Context, path, rev string,
+	opts blame.Options) ([]blame.LineAttribution, error) {
+	if len(opts.IgnoreRevs) > 0 {
+		return nil, errors.Wrapf(ErrExecDisabled, "git blame --ignore-rev %s",
+			strings.Join(opts.IgnoreRevs, " "))
+	}
+
+	var ranges [][2]int
+	for _, l := range opts.Lines {
+		rng, err := parseLineRange(l)
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, rng)
+	}
+
+	h, err := r.resolve(rev)
+	if err != nil {
+		return nil, err
+	}
+	c, err := r.Repo.CommitObject(h)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to find commit to blame at")
+	}
+
+	// The commits that changed the file, newest first
+	var (
+		revs     []*object.Commit
+		contents []string
+		boundary bool
+	)
+	for c != nil {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		f, err := c.File(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to find %s in %s", path, c.Hash)
+		}
+
+		var same, older *object.Commit
+		err = c.Parents().ForEach(func(p *object.Commit) error {
+			pf, err := p.File(path)
+			switch {
+			case err != nil:
+			case pf.Hash == f.Hash:
+				same = p
+			case older == nil:
+				older = p
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to read parent commits")
+		}
+
+		if same != nil {
+			c = same
+			continue
+		}
+
+		content, err := f.Contents()
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read %s in %s", path, c.Hash)
+		}
+		revs = append(revs, c)
+		contents = append(contents, content)
+		boundary = c.NumParents() == 0
+		if r.pastHistoryStart(c.Committer.When) {
+			// Like git blame --since, older lines are left on this commit
+			boundary = true
+			break
+		}
+		c = older
+	}
+
+	// Lines start out credited to the oldest commit and are passed on
+	// through each diff to the commits that kept them
+	last := len(revs) - 1
+	origins := make([]int, len(contentLines(contents[last])))
+	for i := range origins {
+		origins[i] = last
+	}
+	for i := last - 1; i >= 0; i-- {
+		origins = passOrigins(origins, i, contents[i+1], contents[i])
+	}
+
+	var lines []blame.LineAttribution
+	for n, line := range contentLines(contents[0]) {
+		if !inRanges(n+1, ranges) {
+			continue
+		}
+
+		c := revs[origins[n]]
+		lines = append(lines, blame.LineAttribution{
+			Commit: c.Hash.String(), Line: n + 1, OriginalPath: path,
+			Author: c.Author.Name, AuthorEmail: c.Author.Email, AuthorTime: c.Author.When,
+			Committer: c.Committer.Name, CommitterEmail: c.Committer.Email,
+			CommitterTime: c.Committer.When,
+			Boundary:      boundary && origins[n] == last,
+			Content:       line,
+		})
+	}
+
+	return lines, nil
+}
+
+// passOrigins maps the origins of the lines of the 'before' content of a file
+// to the lines of its 'after' content, crediting new lines to 'rev'.
+func passOrigins(origins []int, rev int, before, after string) []int {
+	next := make([]int, 0, len(contentLines(after)))
+
+	var src int
+	for _, d := range diff.Do(before, after) {
+		n := len(contentLines(d.Text))
+		switch d.Type {
+		case diffmatchpatch.DiffEqual:
+			next = append(next, origins[src:src+n]...)
+			src += n
+		case diffmatchpatch.DiffInsert:
+			for i := 0; i < n; i++ {
+				next = append(next, rev)
+			}
+		case diffmatchpatch.DiffDelete:
+			src += n
+		}
+	}
+
+	return next
+}
+
+// contentLines splits file content into lines without their line ending. A
+// last line without one still counts.
+func contentLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
+
+// parseLineRange reads a range of lines in the forms blame -L takes that
+// blame is given: "start,", "start,end" and "start,+count". An end of 0
+// runs to the end of the file.
+func parseLineRange(spec string) ([2]int, error) {
+	var rng [2]int
+
+	comma := strings.IndexByte(spec, ',')
+	if comma < 0 {
+		return rng, errors.Errorf("invalid line range '%s'", spec)
+	}
+
+	start, err := strconv.Atoi(spec[:comma])
+	if err != nil || start < 1 {
+		return rng, errors.Errorf("invalid line range '%s'", spec)
+	}
+	rng[0] = start
+
+	end := spec[comma+1:]
+	switch {
+	case end == "":
+	case strings.HasPrefix(end, "+"):
+		count, err := strconv.Atoi(end[1:])
+		if err != nil || count < 1 {
+			return rng, errors.Errorf("invalid line range '%s'", spec)
+		}
+		rng[1] = start + count - 1
+	default:
+		if rng[1], err = strconv.Atoi(end); err != nil || rng[1] < start {
+			return rng, errors.Errorf("invalid line range '%s'", spec)
+		}
+	}
+
+	return rng, nil
+}
+
+// inRanges reports whether line 'n' is in any of 'ranges', or whether there
+// are none to limit it.
+func inRanges(n int, ranges [][2]int) bool {
+	for _, rng := range ranges {
+		if n >= rng[0] && (rng[1] == 0 || n <= rng[1]) {
+			return true
+		}
+	}
+	return len(ranges) == 0
+}
+
+// loadConfigGoGit is LoadConfig without git: it reads ConfigFile and the
+// repository's own config file, but not the system and global ones.
+func (r *ContributionCounter) loadConfigGoGit() (*Config, error) {
+	c := &Config{}
+
+	file := filepath.Join(r.Dir, ConfigFile)
+	b, err := ioutil.ReadFile(file)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, errors.Wrapf(err, "unable to read %s", ConfigFile)
+	default:
+		raw := formatconfig.New()
+		if err := formatconfig.NewDecoder(bytes.NewReader(b)).Decode(raw); err != nil {
+			return nil, errors.Wrapf(err, "unable to read %s", ConfigFile)
+		}
+		c.Entries = append(c.Entries, configEntries(raw, "file:"+file)...)
+	}
+
+	cfg, err := r.Repo.Config()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read git config")
+	}
+	if cfg.Raw != nil {
+		c.Entries = append(c.Entries, configEntries(cfg.Raw, "file:.git/config")...)
+	}
+
+	return c, nil
+}
+
+// configEntries lists the settings of the reviewer section of a config file
+// read from 'origin', with keys named like git config --list names them.
+func configEntries(raw *formatconfig.Config, origin string) []ConfigEntry {
+	var entries []ConfigEntry
+
+	add := func(prefix string, options formatconfig.Options) {
+		for _, o := range options {
+			entries = append(entries, ConfigEntry{Key: prefix + strings.ToLower(o.Key),
+				Value: o.Value, Origin: origin})
+		}
+	}
+
+	for _, s := range raw.Sections {
+		if !strings.EqualFold(s.Name, strings.TrimSuffix(configSection, ".")) {
+			continue
+		}
+		add(configSection, s.Options)
+		for _, sub := range s.Subsections {
+			add(configSection+sub.Name+".", sub.Options)
+		}
+	}
+
+	return entries
+}
@@ -0,0 +1,139 @@
+package gitreviewers
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestNoExecMatchesGit(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	write := func(content string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, "src", "a.go"),
+			[]byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("package a\n\nvar x = 1\nvar y = 2\n")
+	runGit(t, dir, "commit", "-q", "-a", "-m", "Add x and y",
+		"--author", "Ben Franklin <ben@git-reviewer.com>")
+	runGit(t, dir, "checkout", "-q", "-b", "side", "HEAD~1")
+	if err := ioutil.WriteFile(filepath.Join(dir, "src", "b.go"), []byte("package a\n"),
+		0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-q", "-m", "Add b")
+	runGit(t, dir, "checkout", "-q", "master")
+	runGit(t, dir, "merge", "-q", "--no-edit", "side")
+
+	runGit(t, dir, "checkout", "-q", "-b", "feature")
+	write("package a\n\nvar x = 3\nvar y = 2\n")
+	runGit(t, dir, "commit", "-q", "-a", "-m", "Change x")
+	runGit(t, dir, "config", "reviewer.since", "2001-01-01")
+
+	repo, _, err := OpenRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type result struct {
+		Files        []string
+		Status       BranchStatus
+		Attributions []attribution
+		Lines        int
+		Range        []attribution
+		Since        string
+	}
+	run := func(noExec bool) result {
+		var res result
+		r := ContributionCounter{Repo: repo, Dir: dir, Since: "2000-01-01",
+			InitialImport: true, NoExec: noExec}
+
+		if res.Files, err = r.FindFiles(); err != nil {
+			t.Fatalf("Unexpected error finding files (no exec: %t): %v\n", noExec, err)
+		}
+		sort.Strings(res.Files)
+		if res.Status, err = r.BranchStatus(); err != nil {
+			t.Fatalf("Unexpected error comparing branches (no exec: %t): %v\n", noExec, err)
+		}
+		res.Attributions, res.Lines, err = r.blameAttributions("src/a.go", "master")
+		if err != nil {
+			t.Fatalf("Unexpected error blaming (no exec: %t): %v\n", noExec, err)
+		}
+		if res.Range, _, err = r.blameAttributions("src/a.go", "feature", "3,+1"); err != nil {
+			t.Fatalf("Unexpected error blaming a range (no exec: %t): %v\n", noExec, err)
+		}
+		cfg, err := r.LoadConfig()
+		if err != nil {
+			t.Fatalf("Unexpected error loading config (no exec: %t): %v\n", noExec, err)
+		}
+		res.Since, _ = cfg.Get("reviewer.since")
+
+		return res
+	}
+
+	expected, actual := run(false), run(true)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %+v without git, expected %+v\n", actual, expected)
+	}
+	if expected.Attributions[0].author != initialImportAuthor || expected.Status.Ahead != 1 {
+		t.Errorf("Expected the initial commit to be a boundary and feature to be 1 ahead, got %+v\n",
+			expected)
+	}
+
+	// Anything else git is needed for is refused
+	r := ContributionCounter{Repo: repo, Dir: dir, NoExec: true, GitBin: "/nonexistent/git"}
+	if _, err := r.output("status"); !Is(err, ErrExecDisabled) {
+		t.Errorf("Got %v, expected git to be refused\n", err)
+	}
+	if err := r.Validate(); err != nil {
+		t.Errorf("Unexpected error validating without git: %v\n", err)
+	}
+}
+
+func TestNoExecHTTPClient(t *testing.T) {
+	c := NewMemoryCache()
+	r := ContributionCounter{Cache: c, NoExec: true}
+
+	req, _ := http.NewRequest("GET", "https://api.bitbucket.org/2.0/user", nil)
+	if _, err := r.HTTPClient().Do(req); !Is(err, ErrExecDisabled) {
+		t.Errorf("Got %v, expected requests without a cached response to be refused\n", err)
+	}
+
+	key, _ := (&apiTransport{cache: c}).lookup(req)
+	(&apiTransport{cache: c}).store(key, &cachedResponse{Status: 200, Body: []byte("{}")})
+	resp, err := r.HTTPClient().Do(req)
+	if err != nil || resp.StatusCode != 200 {
+		t.Errorf("Got %v, expected the cached response\n", err)
+	}
+}
+
+func TestParseLineRange(t *testing.T) {
+	cases := []struct {
+		spec     string
+		expected [2]int
+		valid    bool
+	}{
+		{"3,", [2]int{3, 0}, true},
+		{"3,5", [2]int{3, 5}, true},
+		{"3,+2", [2]int{3, 4}, true},
+		{"3", [2]int{}, false},
+		{"0,2", [2]int{}, false},
+		{"5,3", [2]int{}, false},
+	}
+
+	for _, c := range cases {
+		rng, err := parseLineRange(c.spec)
+		if (err == nil) != c.valid || (c.valid && rng != c.expected) {
+			t.Errorf("Got %v (%v) for '%s', expected %v\n", rng, err, c.spec, c.expected)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package gitreviewers
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/pkg/errors"
+	gogit "gopkg.in/src-d/go-git.v4"
+)
+
+// Option configures a ContributionCounter built with New. Options validate
+// their input and report problems as errors.
+type Option func(*ContributionCounter) error
+
+// Filters selects which changed files count towards suggestions. Extensions
+// and paths in the Only lists take precedence over the Ignored ones.
+type Filters struct {
+	IgnoredExtensions []string
+	OnlyExtensions    []string
+	IgnoredPaths      []string
+	OnlyPaths         []string
+	// NoDefaultIgnores also considers the extensions ignored by default.
+	NoDefaultIgnores bool
+	// Presets names filter presets whose filters are added to these, see
+	// BuiltinPresets.
+	Presets []string
+}
+
+// New builds a ContributionCounter for 'repo' configured by 'opts'. The result
+// is checked with Validate, which resolves the base and head revisions, so
+// mistakes surface here rather than in the middle of finding reviewers. Path
+// filters are then normalized with NormalizeFilters. Without WithBase, the base
+// is picked by DetectBase.
+//
+// Filling the fields of ContributionCounter directly still works, but options
+// keep code building as new settings are added.
+func New(repo *gogit.Repository, opts ...Option) (*ContributionCounter, error) {
+	if repo == nil {
+		return nil, errors.New("no repository given")
+	}
+
+	r := &ContributionCounter{Repo: repo}
+	for _, opt := range opts {
+		if err := opt(r); err != nil {
+			return nil, err
+		}
+	}
+
+	if r.Base == "" {
+		if base, err := r.DetectBase(); err == nil {
+			r.Base = base
+		}
+	}
+
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+	if err := r.NormalizeFilters(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// WithDir sets the root of the working tree external git commands run from.
+// It defaults to the current directory.
+func WithDir(dir string) Option {
+	return func(r *ContributionCounter) error {
+		r.Dir = dir
+		return nil
+	}
+}
+
+// WithBase compares changes to 'rev' instead of "master".
+func WithBase(rev string) Option {
+	return func(r *ContributionCounter) error {
+		if rev == "" {
+			return errors.New("empty base revision")
+		}
+		r.Base = rev
+		return nil
+	}
+}
+
+// WithHead reviews 'rev' instead of HEAD.
+func WithHead(rev string) Option {
+	return func(r *ContributionCounter) error {
+		if rev == "" {
+			return errors.New("empty head revision")
+		}
+		r.Head = rev
+		return nil
+	}
+}
+
+// WithSince only considers lines committed on or after the day of 't'. It
+// defaults to 6 months before the branch diverged from its base.
+func WithSince(t time.Time) Option {
+	return func(r *ContributionCounter) error {
+		if t.After(time.Now()) {
+			return errors.Errorf("since date %s is in the future",
+				t.Format("2006-01-02"))
+		}
+		r.Since = t.Format("2006-01-02")
+		return nil
+	}
+}
+
+// WithAsOf measures ownership as it was at 'rev', a revision or a YYYY-MM-DD
+// date, instead of today.
+func WithAsOf(rev string) Option {
+	return func(r *ContributionCounter) error {
+		if rev == "" {
+			return errors.New("empty as-of revision")
+		}
+		r.AsOf = rev
+		return nil
+	}
+}
+
+// WithFilters restricts which changed files are considered.
+func WithFilters(f Filters) Option {
+	return func(r *ContributionCounter) error {
+		for _, list := range [][]string{f.IgnoredExtensions, f.OnlyExtensions,
+			f.IgnoredPaths, f.OnlyPaths} {
+			for _, v := range list {
+				if v == "" {
+					return errors.New("empty extension or path in filters")
+				}
+			}
+		}
+
+		r.IgnoredExtensions = f.IgnoredExtensions
+		r.OnlyExtensions = f.OnlyExtensions
+		r.IgnoredPaths = f.IgnoredPaths
+		r.OnlyPaths = f.OnlyPaths
+		r.NoDefaultIgnores = f.NoDefaultIgnores
+		r.Presets = f.Presets
+		return nil
+	}
+}
+
+// WithCache stores suggestions in 'c', such as a FileCache or MemoryCache,
+// along with the answers of provider APIs.
+func WithCache(c Cache) Option {
+	return func(r *ContributionCounter) error {
+		if c == nil {
+			return errors.New("nil cache")
+		}
+		r.Cache = c
+		r.CacheResults = true
+		return nil
+	}
+}
+
+// WithConfig applies settings loaded with LoadConfig. Options after it
+// override them.
+func WithConfig(c *Config) Option {
+	return func(r *ContributionCounter) error {
+		if c == nil {
+			return errors.New("nil config")
+		}
+		r.ApplyConfig(c)
+		return nil
+	}
+}
+
+// WithLogger turns on progress and error information and sends it to 'l'.
+func WithLogger(l *log.Logger) Option {
+	return func(r *ContributionCounter) error {
+		if l == nil {
+			return errors.New("nil logger")
+		}
+		r.Logger = l
+		r.Verbose = true
+		return nil
+	}
+}
+
+// logf reports progress and error information if Verbose is set.
+func (r *ContributionCounter) logf(format string, args ...interface{}) {
+	if !r.Verbose {
+		return
+	}
+
+	if r.Logger != nil {
+		r.Logger.Printf(format, args...)
+		return
+	}
+
+	fmt.Printf(format, args...)
+}
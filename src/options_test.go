@@ -0,0 +1,83 @@
+package gitreviewers
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	gogit "gopkg.in/src-d/go-git.v4"
+)
+
+func TestNew(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+	runGit(t, dir, "branch", "main")
+
+	repo, err := gogit.PlainOpen(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var logs bytes.Buffer
+	since := time.Date(2017, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	r, err := New(repo,
+		WithDir(dir),
+		WithBase("main"),
+		WithSince(since),
+		WithFilters(Filters{OnlyPaths: []string{"src"}}),
+		WithLogger(log.New(&logs, "", 0)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r.Base != "main" {
+		t.Errorf("Got base '%s', expected '%s'\n", r.Base, "main")
+	}
+	if r.Since != "2017-01-02" {
+		t.Errorf("Got since '%s', expected '%s'\n", r.Since, "2017-01-02")
+	}
+	if len(r.OnlyPaths) != 1 || r.OnlyPaths[0] != "src" {
+		t.Errorf("Got only paths %v, expected [src]\n", r.OnlyPaths)
+	}
+
+	r.logf("hello %s\n", "logger")
+	if logs.String() != "hello logger\n" {
+		t.Errorf("Got log '%s', expected '%s'\n", logs.String(), "hello logger\n")
+	}
+}
+
+func TestNewValidation(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	repo, err := gogit.PlainOpen(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		Name string
+		Opt  Option
+	}{
+		{"unknown base", WithBase("nope")},
+		{"empty base", WithBase("")},
+		{"unknown head", WithHead("nope")},
+		{"future since", WithSince(time.Now().AddDate(0, 1, 0))},
+		{"empty filter", WithFilters(Filters{IgnoredPaths: []string{""}})},
+		{"nil logger", WithLogger(nil)},
+	}
+
+	for _, c := range cases {
+		if _, err := New(repo, WithDir(dir), c.Opt); err == nil {
+			t.Errorf("%s: expected an error\n", c.Name)
+		}
+	}
+
+	if _, err := New(nil); err == nil {
+		t.Error("Expected an error without a repository")
+	}
+}
@@ -0,0 +1,449 @@
+package gitreviewers
+
+import (
+	"bufio"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// Ways OWNERS files weigh in on suggestions, set through
+// ContributionCounter.OwnersPolicy.
+const (
+	// OwnersBoost ranks the owners of changed files higher, as if they owned
+	// more of the changed lines.
+	OwnersBoost = "boost"
+	// OwnersRequired makes sure one owner listed in the nearest OWNERS file
+	// of every changed file is suggested, as approvers must be.
+	OwnersRequired = "required"
+	// OwnersOff ignores OWNERS files. It is the default.
+	OwnersOff = "off"
+)
+
+// OwnersPolicies lists the valid values of ContributionCounter.OwnersPolicy.
+var OwnersPolicies = []string{OwnersBoost, OwnersRequired, OwnersOff}
+
+// ownersWeight is the most being listed in the OWNERS files of every changed
+// file adds to the share of lines a candidate owns when ranking them.
+const ownersWeight = 0.25
+
+// ownersFileName and ownersAliasesName are the files Kubernetes and Chromium
+// style repositories list the owners of each directory in.
+const (
+	ownersFileName    = "OWNERS"
+	ownersAliasesName = "OWNERS_ALIASES"
+)
+
+// ownersYAMLKey spots the Kubernetes flavor of OWNERS files, written in YAML.
+// The Chromium flavor lists an owner per line.
+var ownersYAMLKey = regexp.MustCompile(`(?m)^(approvers|reviewers|options|filters|emeritus_approvers|labels)\s*:`)
+
+// ownersFile is what an OWNERS file says about the files under its
+// directory.
+type ownersFile struct {
+	// approvers can approve changes and reviewers can only review them. The
+	// Chromium flavor only has approvers.
+	approvers []string
+	reviewers []string
+	// noParent stops owners of parent directories from owning the files.
+	noParent bool
+	// perFile lists owners of some of the files only.
+	perFile []perFileOwners
+}
+
+// perFileOwners are extra owners of the files of an OWNERS file's directory
+// whose name matches.
+type perFileOwners struct {
+	match  func(name string) bool
+	owners []string
+}
+
+// owners lists who may review 'name', a file in the directory of the OWNERS
+// file, approvers first.
+func (o ownersFile) owners(name string) []string {
+	owners := append(append([]string{}, o.approvers...), o.reviewers...)
+	for _, p := range o.perFile {
+		if p.match(name) {
+			owners = append(owners, p.owners...)
+		}
+	}
+	return owners
+}
+
+// parseOwners reads an OWNERS file of either flavor. 'aliases' expands the
+// team names Kubernetes OWNERS files may list instead of people.
+func parseOwners(content string, aliases map[string][]string) ownersFile {
+	if ownersYAMLKey.MatchString(content) {
+		return parseYAMLOwners(content, aliases)
+	}
+	return parseChromiumOwners(content)
+}
+
+// parseYAMLOwners reads the Kubernetes flavor of OWNERS files. Filters other
+// than the one matching every file are not supported and left out.
+func parseYAMLOwners(content string, aliases map[string][]string) ownersFile {
+	var (
+		o              ownersFile
+		section, field string
+		filter         string
+	)
+
+	add := func(list *[]string, v string) {
+		if members, ok := aliases[v]; ok {
+			*list = append(*list, members...)
+		} else if v != "" {
+			*list = append(*list, v)
+		}
+	}
+
+	for _, l := range yamlLines(content) {
+		switch {
+		case l.indent == 0 && l.key != "":
+			section, field, filter = l.key, "", ""
+			for _, v := range l.values {
+				addOwner(&o, section, v, add)
+			}
+		case section == "options":
+			if l.key == "no_parent_owners" {
+				o.noParent = len(l.values) == 1 && l.values[0] == "true"
+			}
+		case section != "filters":
+			if l.item != "" {
+				addOwner(&o, section, l.item, add)
+			}
+		// Filters map a pattern of file names to their own lists of owners
+		case l.key == "approvers" || l.key == "reviewers":
+			field = l.key
+			if filter == ".*" {
+				for _, v := range l.values {
+					addOwner(&o, field, v, add)
+				}
+			}
+		case l.key != "":
+			filter, field = l.key, ""
+		case l.item != "" && filter == ".*":
+			addOwner(&o, field, l.item, add)
+		}
+	}
+
+	return o
+}
+
+// addOwner adds 'v' to the list of 'o' that 'section' names.
+func addOwner(o *ownersFile, section, v string, add func(*[]string, string)) {
+	switch section {
+	case "approvers":
+		add(&o.approvers, v)
+	case "reviewers":
+		add(&o.reviewers, v)
+	}
+}
+
+// yamlLine is a line of the subset of YAML OWNERS files use: "key: value",
+// "key: [a, b]" or "- item".
+type yamlLine struct {
+	indent int
+	key    string
+	values []string
+	item   string
+}
+
+// yamlLines splits YAML into lines, leaving out comments and blank lines.
+func yamlLines(content string) []yamlLine {
+	var lines []yamlLine
+
+	scn := bufio.NewScanner(strings.NewReader(content))
+	for scn.Scan() {
+		text := scn.Text()
+		if i := strings.Index(text, " #"); i >= 0 {
+			text = text[:i]
+		}
+		trimmed := strings.TrimSpace(text)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		l := yamlLine{indent: len(text) - len(strings.TrimLeft(text, " \t"))}
+		if strings.HasPrefix(trimmed, "- ") || trimmed == "-" {
+			l.item = unquote(strings.TrimSpace(strings.TrimPrefix(trimmed, "-")))
+		} else if i := strings.Index(trimmed, ":"); i >= 0 {
+			l.key = unquote(strings.TrimSpace(trimmed[:i]))
+			value := strings.TrimSpace(trimmed[i+1:])
+			value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+			for _, v := range strings.Split(value, ",") {
+				if v = unquote(strings.TrimSpace(v)); v != "" {
+					l.values = append(l.values, v)
+				}
+			}
+		}
+		lines = append(lines, l)
+	}
+
+	return lines
+}
+
+// unquote strips the quotes around a YAML string.
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// parseChromiumOwners reads the Chromium flavor of OWNERS files: an owner
+// per line, "set noparent", and "per-file <glob>=<owner>" lines. Includes
+// with "file://" and the "*" wildcard are left out.
+func parseChromiumOwners(content string) ownersFile {
+	var o ownersFile
+
+	scn := bufio.NewScanner(strings.NewReader(content))
+	for scn.Scan() {
+		line := strings.TrimSpace(scn.Text())
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+
+		switch {
+		case line == "" || line == "*" || strings.HasPrefix(line, "file://") ||
+			strings.HasPrefix(line, "include "):
+		case line == "set noparent":
+			o.noParent = true
+		case strings.HasPrefix(line, "per-file "):
+			parts := strings.SplitN(strings.TrimPrefix(line, "per-file "), "=", 2)
+			if len(parts) != 2 {
+				continue
+			}
+			globs := splitList(parts[0])
+			owners := splitList(parts[1])
+			o.perFile = append(o.perFile, perFileOwners{owners: owners,
+				match: func(name string) bool {
+					for _, g := range globs {
+						if ok, _ := path.Match(g, name); ok {
+							return true
+						}
+					}
+					return false
+				}})
+		case !strings.Contains(line, " "):
+			o.approvers = append(o.approvers, line)
+		}
+	}
+
+	return o
+}
+
+// parseAliases reads the aliases of an OWNERS_ALIASES file.
+func parseAliases(content string) map[string][]string {
+	aliases := make(map[string][]string)
+
+	var name string
+	for _, l := range yamlLines(content) {
+		switch {
+		case l.indent == 0:
+			name = ""
+		case l.key != "":
+			name = l.key
+			aliases[name] = append(aliases[name], l.values...)
+		case l.item != "" && name != "":
+			aliases[name] = append(aliases[name], l.item)
+		}
+	}
+
+	return aliases
+}
+
+// ownership lists who OWNERS files make responsible for a set of changed
+// files.
+type ownership struct {
+	// byOwner lists the changed files each owner owns, and files the OWNERS
+	// files naming them.
+	byOwner map[string][]string
+	files   map[string][]string
+	// nearest holds, for each OWNERS file nearest to a changed file, the
+	// owners it lists in order, approvers first.
+	nearest map[string][]string
+}
+
+// findOwners reads the OWNERS files of the base revision that own 'paths'.
+// Owners of a directory also own everything under it, unless an OWNERS file
+// below says otherwise with no_parent_owners or "set noparent".
+func (r *ContributionCounter) findOwners(paths []string) (*ownership, error) {
+	own := &ownership{byOwner: make(map[string][]string), files: make(map[string][]string),
+		nearest: make(map[string][]string)}
+	if r.Repo == nil {
+		return own, nil
+	}
+
+	base, err := r.resolve(r.historyRev())
+	if err != nil {
+		return nil, err
+	}
+	commit, err := r.Repo.CommitObject(base)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to find commit for base")
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to find tree for base")
+	}
+
+	var aliases map[string][]string
+	if content, ok := treeFile(tree, ownersAliasesName); ok {
+		aliases = parseAliases(content)
+	}
+
+	parsed := make(map[string]*ownersFile)
+	read := func(dir string) *ownersFile {
+		if o, ok := parsed[dir]; ok {
+			return o
+		}
+		var o *ownersFile
+		if content, ok := treeFile(tree, path.Join(dir, ownersFileName)); ok {
+			f := parseOwners(content, aliases)
+			o = &f
+		}
+		parsed[dir] = o
+		return o
+	}
+
+	for _, p := range paths {
+		seen := make(map[string]bool)
+		first := true
+		for dir := path.Dir(p); ; dir = path.Dir(dir) {
+			if dir == "." || dir == "/" {
+				dir = ""
+			}
+
+			if o := read(dir); o != nil {
+				file := path.Join(dir, ownersFileName)
+				var owners []string
+				for _, owner := range o.owners(path.Base(p)) {
+					owner = reviewerKey(r.ownerEmail(owner), r.Mailmap)
+					owners = append(owners, owner)
+					if !seen[owner] {
+						seen[owner] = true
+						own.byOwner[owner] = append(own.byOwner[owner], p)
+					}
+					if !containsString(own.files[owner], file) {
+						own.files[owner] = append(own.files[owner], file)
+					}
+				}
+				if first && len(owners) > 0 {
+					own.nearest[file] = owners
+					first = false
+				}
+				if o.noParent {
+					break
+				}
+			}
+
+			if dir == "" {
+				break
+			}
+		}
+	}
+
+	return own, nil
+}
+
+// treeFile reads the file at 'p' of 'tree', if there is one.
+func treeFile(tree *object.Tree, p string) (string, bool) {
+	f, err := tree.File(p)
+	if err != nil {
+		return "", false
+	}
+	content, err := f.Contents()
+	return content, err == nil
+}
+
+// ownerEmail is the email of 'owner', as an OWNERS file lists them: Kubernetes
+// OWNERS files list usernames, which OwnerEmails maps to the emails people
+// commit with. Emails, and usernames it doesn't know, are kept as they are.
+func (r *ContributionCounter) ownerEmail(owner string) string {
+	if email, ok := r.OwnerEmails[strings.ToLower(owner)]; ok {
+		return email
+	}
+	return owner
+}
+
+// ownersPolicy is the OwnersPolicy in effect.
+func (r *ContributionCounter) ownersPolicy() string {
+	if r.OwnersPolicy == "" {
+		return OwnersOff
+	}
+	return r.OwnersPolicy
+}
+
+// boostOwners boosts the candidates that OWNERS files name as owners of the
+// changed files, in proportion to how many of the files they own, adding the
+// owners who own no changed lines.
+func boostOwners(candidates Stats, own *ownership, files int) Stats {
+	known := make(map[string]*Stat)
+	for _, s := range candidates {
+		known[s.Reviewer] = s
+	}
+
+	var owners []string
+	for owner := range own.byOwner {
+		owners = append(owners, owner)
+	}
+	sort.Strings(owners)
+
+	for _, owner := range owners {
+		s, ok := known[owner]
+		if !ok {
+			s = &Stat{Reviewer: owner}
+			candidates = append(candidates, s)
+		}
+		s.Boost += ownersWeight * float64(len(own.byOwner[owner])) / float64(files)
+		s.Owners = own.files[owner]
+		if len(s.Owners) > maxTermNotes {
+			s.Owners = s.Owners[:maxTermNotes]
+		}
+	}
+
+	return candidates
+}
+
+// requireOwners makes sure every OWNERS file nearest to a changed file has
+// one of its owners among the suggestions, adding the owner with the most
+// experience, or the first listed, when none is. The added owners are
+// required by the OWNERS file.
+func requireOwners(top, all Stats, own *ownership) Stats {
+	var files []string
+	for file := range own.nearest {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		owners := own.nearest[file]
+
+		covered := false
+		for _, owner := range owners {
+			if findStat(top, owner) != nil {
+				covered = true
+				break
+			}
+		}
+		if covered {
+			continue
+		}
+
+		pick := &Stat{Reviewer: owners[0]}
+		for _, owner := range owners {
+			if s := findStat(all, owner); s != nil && s.Percentage > pick.Percentage {
+				copied := *s
+				pick = &copied
+			}
+		}
+		pick.Required = append(pick.Required, file)
+		top = append(top, pick)
+	}
+
+	return top
+}
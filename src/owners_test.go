@@ -0,0 +1,145 @@
+package gitreviewers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseOwners(t *testing.T) {
+	aliases := parseAliases(`aliases:
+  sig-api:
+    - carl
+    - dan # lead
+  sig-docs: [erin]
+`)
+	if expected := map[string][]string{"sig-api": {"carl", "dan"}, "sig-docs": {"erin"}}; !reflect.DeepEqual(aliases, expected) {
+		t.Errorf("Got aliases %v, expected %v\n", aliases, expected)
+	}
+
+	tests := []struct {
+		name      string
+		content   string
+		approvers []string
+		reviewers []string
+		noParent  bool
+		perFile   map[string][]string
+	}{
+		{"kubernetes", `# See the OWNERS docs
+approvers:
+  - abe
+  - sig-api
+reviewers: [ben, "sig-docs"]
+options:
+  no_parent_owners: true
+`, []string{"abe", "carl", "dan"}, []string{"ben", "erin"}, true, nil},
+		{"kubernetes filters", `filters:
+  ".*":
+    approvers:
+      - abe
+  "\\.md$":
+    reviewers:
+      - ben
+`, []string{"abe"}, nil, false, nil},
+		{"chromium", `# Owners of the api
+abe@git-reviewer.com
+ben@git-reviewer.com  # backup
+*
+file://docs/OWNERS
+set noparent
+per-file *.proto=carl@git-reviewer.com,dan@git-reviewer.com
+`, []string{"abe@git-reviewer.com", "ben@git-reviewer.com"}, nil, true,
+			map[string][]string{"api.proto": {"carl@git-reviewer.com", "dan@git-reviewer.com"},
+				"api.go": nil}},
+	}
+
+	for _, tt := range tests {
+		o := parseOwners(tt.content, aliases)
+		if !reflect.DeepEqual(o.approvers, tt.approvers) || !reflect.DeepEqual(o.reviewers, tt.reviewers) ||
+			o.noParent != tt.noParent {
+			t.Errorf("%s: got approvers %v, reviewers %v and no parent %t, expected %v, %v and %t\n",
+				tt.name, o.approvers, o.reviewers, o.noParent, tt.approvers, tt.reviewers, tt.noParent)
+		}
+		for name, extra := range tt.perFile {
+			expected := append(append(append([]string{}, tt.approvers...), tt.reviewers...), extra...)
+			if got := o.owners(name); !reflect.DeepEqual(got, expected) {
+				t.Errorf("%s: got owners %v of %s, expected %v\n", tt.name, got, name, expected)
+			}
+		}
+	}
+}
+
+func TestFindOwners(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	for p, content := range map[string]string{
+		"OWNERS":            "approvers:\n  - Root\n",
+		"src/OWNERS":        "abe@git-reviewer.com\nben@git-reviewer.com\n",
+		"src/vendor/OWNERS": "set noparent\ncarl@git-reviewer.com\n",
+		"src/vendor/v.go":   "package vendor\n",
+	} {
+		if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(p)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, p), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-q", "-m", "Add owners")
+
+	repo, _, err := OpenRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Kubernetes OWNERS files list usernames
+	r := ContributionCounter{Repo: repo, Dir: dir,
+		OwnerEmails: map[string]string{"root": "root@git-reviewer.com"}}
+
+	own, err := r.findOwners([]string{"src/a.go", "src/vendor/v.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	byOwner := map[string][]string{
+		"abe@git-reviewer.com":  {"src/a.go"},
+		"ben@git-reviewer.com":  {"src/a.go"},
+		"root@git-reviewer.com": {"src/a.go"},
+		"carl@git-reviewer.com": {"src/vendor/v.go"},
+	}
+	if !reflect.DeepEqual(own.byOwner, byOwner) {
+		t.Errorf("Got %v, expected %v\n", own.byOwner, byOwner)
+	}
+	nearest := map[string][]string{
+		"src/OWNERS":        {"abe@git-reviewer.com", "ben@git-reviewer.com"},
+		"src/vendor/OWNERS": {"carl@git-reviewer.com"},
+	}
+	if !reflect.DeepEqual(own.nearest, nearest) {
+		t.Errorf("Got %v, expected %v\n", own.nearest, nearest)
+	}
+
+	all := Stats{{Reviewer: "ben@git-reviewer.com", Percentage: 0.5}, {Reviewer: "dan@git-reviewer.com", Percentage: 0.5}}
+	boosted := boostOwners(append(Stats{}, all...), own, 2)
+	if ben := findStat(boosted, "ben@git-reviewer.com"); ben == nil || ben.Boost != ownersWeight/2 ||
+		!reflect.DeepEqual(ben.Owners, []string{"src/OWNERS"}) {
+		t.Errorf("Got %+v, expected ben boosted for src/OWNERS\n", ben)
+	}
+	if carl := findStat(boosted, "carl@git-reviewer.com"); carl == nil || carl.Boost != ownersWeight/2 {
+		t.Errorf("Got %+v, expected carl added with a boost\n", carl)
+	}
+
+	all = Stats{{Reviewer: "ben@git-reviewer.com", Percentage: 0.5}, {Reviewer: "dan@git-reviewer.com", Percentage: 0.5}}
+	required := requireOwners(Stats{all[1]}, all, own)
+	var got []string
+	for _, s := range required {
+		got = append(got, s.Reviewer+":"+s.Notes())
+	}
+	expected := []string{"dan@git-reviewer.com:", "ben@git-reviewer.com: (mandatory: src/OWNERS)",
+		"carl@git-reviewer.com: (mandatory: src/vendor/OWNERS)"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Got %v, expected %v\n", got, expected)
+	}
+}
@@ -0,0 +1,68 @@
+package gitreviewers
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// Ownership finds who owns lines 'startLine' through 'endLine' of a file in
+// the head revision, so editors and bots can ask about a piece of code without
+// diffing a whole branch. Lines are numbered from 1 and the range includes
+// both ends. An 'endLine' of 0 reads to the end of the file.
+//
+// The result is sorted from the largest owner down. Percentages are shares of
+// every line in the range, including lines committed before Since.
+func (r *ContributionCounter) Ownership(path string, startLine, endLine int) (Stats, error) {
+	if startLine < 1 {
+		return nil, errors.Errorf("start line %d is before the first line", startLine)
+	}
+	if endLine != 0 && endLine < startLine {
+		return nil, errors.Errorf("end line %d comes before start line %d",
+			endLine, startLine)
+	}
+
+	r.defaultSince()
+
+	rng := fmt.Sprintf("%d,", startLine)
+	if endLine != 0 {
+		rng += fmt.Sprintf("%d", endLine)
+	}
+
+	attributions, total, err := r.blameAttributions(path, r.headRev(), rng)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int)
+	for _, a := range attributions {
+		if a.author != initialImportAuthor {
+			counts[a.author]++
+		}
+	}
+
+	return rankOwners(counts, total), nil
+}
+
+// rankOwners turns line counts by author into Stats sorted from the largest
+// owner down, breaking ties by name so results are stable.
+func rankOwners(counts map[string]int, total int) Stats {
+	var stats Stats
+	for author, lines := range counts {
+		stats = append(stats, &Stat{
+			Reviewer:   author,
+			Percentage: float64(lines) / float64(total),
+			Lines:      lines,
+		})
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Percentage != stats[j].Percentage {
+			return stats[i].Percentage > stats[j].Percentage
+		}
+		return stats[i].Reviewer < stats[j].Reviewer
+	})
+
+	return stats
+}
@@ -0,0 +1,57 @@
+package gitreviewers
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOwnership(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "src", "a.go"),
+		[]byte("package a\n\nvar b = 1\nvar c = 2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "commit", "-q", "-a", "--author", "Ben Franklin <ben@git-reviewer.com>",
+		"-m", "Add b and c")
+
+	tests := []struct {
+		start, end int
+		expected   string
+	}{
+		{1, 1, "abe@git-reviewer.com 1/1"},
+		{3, 4, "ben@git-reviewer.com 2/2"},
+		{1, 0, "ben@git-reviewer.com 3/4, abe@git-reviewer.com 1/4"},
+	}
+
+	r := ContributionCounter{Dir: dir, Since: "2000-01-01"}
+	for _, tt := range tests {
+		stats, err := r.Ownership("src/a.go", tt.start, tt.end)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got string
+		for i, s := range stats {
+			if i > 0 {
+				got += ", "
+			}
+			got += fmt.Sprintf("%s %d/%d", s.Reviewer, s.Lines,
+				int(float64(s.Lines)/s.Percentage+0.5))
+		}
+		if got != tt.expected {
+			t.Errorf("Got '%s', expected '%s' for lines %d-%d\n", got, tt.expected,
+				tt.start, tt.end)
+		}
+	}
+
+	for _, rng := range [][2]int{{0, 3}, {3, 2}} {
+		if _, err := r.Ownership("src/a.go", rng[0], rng[1]); err == nil {
+			t.Errorf("Expected an error for lines %d-%d\n", rng[0], rng[1])
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package gitreviewers
+
+import (
+	"path"
+	"sort"
+
+	"github.com/pkg/errors"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// PackageManifests are the files that mark the root of a package, checked in
+// this order when a directory holds several.
+var PackageManifests = []string{
+	"go.mod",
+	"package.json",
+	"Cargo.toml",
+	"pyproject.toml",
+	"pom.xml",
+	"BUILD.bazel",
+	"BUILD",
+}
+
+// Package is a group of changed files belonging to the same package.
+type Package struct {
+	// Dir is the directory of the package's manifest, or "" for files outside
+	// of any package.
+	Dir string
+	// Manifest names the file that marks Dir as a package, such as "go.mod".
+	Manifest string
+	Files    []string
+}
+
+// Name describes the package for display: its directory, or the root of the
+// repository.
+func (p Package) Name() string {
+	if p.Dir == "" {
+		return "(root)"
+	}
+	return p.Dir
+}
+
+// Packages groups changed files by the package they belong to: the nearest
+// directory above them, in the base revision, with one of PackageManifests.
+// In monorepos, package boundaries say more about who should review a change
+// than directories do. Packages are sorted by directory.
+func (r *ContributionCounter) Packages(paths []string) ([]Package, error) {
+	base, err := r.resolve(r.baseRev())
+	if err != nil {
+		return nil, err
+	}
+	commit, err := r.Repo.CommitObject(base)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to find commit for base")
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to find tree for base")
+	}
+
+	var (
+		manifests = make(map[string]string)
+		byDir     = make(map[string]*Package)
+		packages  []Package
+	)
+
+	for _, p := range paths {
+		dir := packageDir(tree, path.Dir(p), manifests)
+
+		pkg, ok := byDir[dir]
+		if !ok {
+			pkg = &Package{Dir: dir, Manifest: manifests[dir]}
+			byDir[dir] = pkg
+		}
+		pkg.Files = append(pkg.Files, p)
+	}
+
+	for _, pkg := range byDir {
+		sort.Strings(pkg.Files)
+		packages = append(packages, *pkg)
+	}
+	sort.Slice(packages, func(i, j int) bool {
+		return packages[i].Dir < packages[j].Dir
+	})
+
+	return packages, nil
+}
+
+// packageDir walks up from 'dir' to the nearest directory of 'tree' with a
+// manifest and returns it, or "" if there is none. 'manifests' remembers the
+// manifest of each directory visited, empty for directories without one.
+func packageDir(tree *object.Tree, dir string, manifests map[string]string) string {
+	for {
+		if dir == "." || dir == "/" {
+			dir = ""
+		}
+
+		manifest, ok := manifests[dir]
+		if !ok {
+			manifest = findManifest(tree, dir)
+			manifests[dir] = manifest
+		}
+		if manifest != "" || dir == "" {
+			return dir
+		}
+
+		dir = path.Dir(dir)
+	}
+}
+
+// findManifest returns the first of PackageManifests in 'dir' of 'tree'.
+func findManifest(tree *object.Tree, dir string) string {
+	for _, m := range PackageManifests {
+		if _, err := tree.File(path.Join(dir, m)); err == nil {
+			return m
+		}
+	}
+	return ""
+}
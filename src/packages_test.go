@@ -0,0 +1,65 @@
+package gitreviewers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPackages(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	for _, file := range []string{
+		"services/api/go.mod",
+		"services/api/handlers/users.go",
+		"services/api/main.go",
+		"web/package.json",
+		"web/BUILD",
+		"web/src/app.js",
+		"tools/BUILD/notes.txt",
+		"tools/lint.sh",
+	} {
+		if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(file)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, file), []byte("x\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-q", "-m", "Add packages")
+
+	repo, _, err := OpenRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := ContributionCounter{Repo: repo, Dir: dir}
+
+	got, err := r.Packages([]string{
+		"web/src/app.js",
+		"services/api/main.go",
+		"src/a.go",
+		"services/api/handlers/users.go",
+		"tools/lint.sh",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Package{
+		{Dir: "", Files: []string{"src/a.go", "tools/lint.sh"}},
+		{Dir: "services/api", Manifest: "go.mod",
+			Files: []string{"services/api/handlers/users.go", "services/api/main.go"}},
+		{Dir: "web", Manifest: "package.json", Files: []string{"web/src/app.js"}},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Got '%+v', expected '%+v'\n", got, expected)
+	}
+
+	if name := got[0].Name(); name != "(root)" {
+		t.Errorf("Got '%s', expected '(root)'\n", name)
+	}
+}
@@ -0,0 +1,144 @@
+package gitreviewers
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// attributor credits the lines of a file at a revision to their authors. It
+// returns the attributions made after Since along with the total number of
+// lines considered.
+type attributor func(path string, rev string) ([]attribution, int, error)
+
+// prefetchBatchSize limits how many objects are requested per fetch so the
+// command line stays within system limits.
+const prefetchBatchSize = 1000
+
+// promisorRemote returns the name of the remote that lazily provides missing
+// objects in a partial clone (e.g. `git clone --filter=blob:none`), or an
+// empty string if the repository is a full clone.
+func (r *ContributionCounter) promisorRemote() string {
+	cfg, err := r.Repo.Config()
+	if err != nil || cfg.Raw == nil {
+		return ""
+	}
+
+	for _, sub := range cfg.Raw.Section("remote").Subsections {
+		if strings.EqualFold(sub.Option("promisor"), "true") {
+			return sub.Name
+		}
+	}
+
+	// Repositories cloned by older versions of git record the remote here
+	return cfg.Raw.Section("extensions").Option("partialClone")
+}
+
+// chooseAttributor decides how to attribute lines of the changed files. Full
+// clones are blamed as usual. In partial clones, blaming a file would fetch
+// every missing historical version of it one at a time, so we first try to
+// fetch them all in a single batch. If that fails, for example when offline,
+// we fall back to counting the commits that touched each file, which only
+// needs commit and tree objects.
+func (r *ContributionCounter) chooseAttributor(rev string, paths []string) attributor {
+	remote := r.promisorRemote()
+	if remote == "" {
+		return r.blameFile
+	}
+
+	err := r.prefetchBlobs(remote, rev, paths)
+	if err == nil {
+		r.logf("Partial clone detected: prefetched missing blobs before blaming\n")
+		return r.blameFile
+	}
+
+	r.logf("Partial clone detected and prefetching failed (%v): "+
+		"scoring by commit metadata instead of blame\n", err)
+	return r.commitAttributions
+}
+
+// blameFile attributes every line of a file to its author with git blame.
+func (r *ContributionCounter) blameFile(path string, rev string) ([]attribution, int, error) {
+	return r.blameAttributions(path, rev)
+}
+
+// prefetchBlobs fetches, in batches, every blob missing from a partial clone
+// that blaming 'paths' at 'rev' would need.
+func (r *ContributionCounter) prefetchBlobs(remote, rev string, paths []string) error {
+	args := append([]string{"rev-list", "--objects", "--missing=print", rev, "--"},
+		paths...)
+	out, err := r.output(args...)
+	if err != nil {
+		return errors.Wrap(err, "unable to list missing objects")
+	}
+
+	missing := parseMissingObjects(out)
+	for start := 0; start < len(missing); start += prefetchBatchSize {
+		end := start + prefetchBatchSize
+		if end > len(missing) {
+			end = len(missing)
+		}
+
+		args := append([]string{"-c", "fetch.negotiationAlgorithm=noop", "fetch",
+			"--quiet", "--no-tags", "--no-write-fetch-head",
+			"--recurse-submodules=no", "--filter=blob:none", remote},
+			missing[start:end]...)
+		if _, err := r.output(args...); err != nil {
+			return errors.Wrap(err, "unable to fetch missing objects")
+		}
+	}
+
+	return nil
+}
+
+// parseMissingObjects extracts the hashes git rev-list reports as missing
+// with a leading '?' when run with --missing=print.
+func parseMissingObjects(out []byte) []string {
+	var missing []string
+
+	scn := bufio.NewScanner(bytes.NewReader(out))
+	for scn.Scan() {
+		if line := scn.Text(); strings.HasPrefix(line, "?") {
+			missing = append(missing, strings.TrimPrefix(line, "?"))
+		}
+	}
+
+	return missing
+}
+
+// commitAttributions credits each non-merge commit that touched a file to its
+// author, as a stand-in for blame when file contents aren't available.
+func (r *ContributionCounter) commitAttributions(path string, rev string) ([]attribution, int, error) {
+	out, err := r.output(r.history("log", "--no-merges", "--format=%H%x09%ae%x09%ad",
+		"--date=short", rev, "--", path)...)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "unable to execute external git log command")
+	}
+
+	var (
+		attributions []attribution
+		commits      int
+	)
+
+	scn := bufio.NewScanner(bytes.NewReader(out))
+	for scn.Scan() {
+		fields := strings.SplitN(scn.Text(), "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		commits++
+
+		if !r.counted(fields[2]) || r.skipped(fields[0]) {
+			continue
+		}
+
+		attributions = append(attributions, attribution{
+			author: reviewerKey(fields[1], r.Mailmap),
+			date:   fields[2],
+		})
+	}
+
+	return attributions, commits, scn.Err()
+}
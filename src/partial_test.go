@@ -0,0 +1,27 @@
+package gitreviewers
+
+import (
+	"testing"
+)
+
+func TestParseMissingObjects(t *testing.T) {
+	out := []byte(`55787e2e4475b578dd96d9298ef2e23e3dc434d4
+1f498ee7d5785c9d0b8a2f21089209a1eafe3a56 src
+?e9858f90a0c004549f2f20f8b78b8db820a99fa4
+?c4352f8b46de5cdb88d0cc96958316db42dd2398
+`)
+	expected := []string{
+		"e9858f90a0c004549f2f20f8b78b8db820a99fa4",
+		"c4352f8b46de5cdb88d0cc96958316db42dd2398",
+	}
+
+	actual := parseMissingObjects(out)
+	if len(actual) != len(expected) {
+		t.Fatalf("Got missing objects %v, expected %v\n", actual, expected)
+	}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Errorf("Got missing object '%s', expected '%s'\n", actual[i], expected[i])
+		}
+	}
+}
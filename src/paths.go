@@ -0,0 +1,136 @@
+package gitreviewers
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// NormalizeFilters rewrites the path filters so they compare cleanly to the
+// repository-relative paths git reports. Relative filters such as "./src" are
+// taken from the root of the working tree in Dir, and absolute ones must point
+// inside it. A trailing slash is kept to mark a filter that only matches
+// directories. The filters of Presets are added first.
+func (r *ContributionCounter) NormalizeFilters() error {
+	var err error
+
+	r.applyPresets()
+
+	if r.IgnoredPaths, err = normalizePaths(r.Dir, r.IgnoredPaths); err != nil {
+		return err
+	}
+	if r.OnlyPaths, err = normalizePaths(r.Dir, r.OnlyPaths); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func normalizePaths(root string, paths []string) ([]string, error) {
+	if len(paths) == 0 {
+		return paths, nil
+	}
+
+	normalized := make([]string, 0, len(paths))
+	for _, p := range paths {
+		n, err := normalizePath(root, p)
+		if err != nil {
+			return nil, err
+		}
+		normalized = append(normalized, n)
+	}
+
+	return normalized, nil
+}
+
+// normalizePath turns a path filter into a slash-separated path relative to
+// 'root', or an empty string for the root itself. Symbolic links in the
+// directories leading to the path are resolved, such as a temporary directory
+// that is a link, but the last element is left alone since git tracks links
+// as files of their own.
+func normalizePath(root, p string) (string, error) {
+	dirOnly := strings.HasSuffix(p, "/") || strings.HasSuffix(p, string(filepath.Separator))
+
+	if root == "" {
+		root = "."
+	}
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to find repository root")
+	}
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+
+	abs := filepath.Clean(p)
+	if !filepath.IsAbs(abs) {
+		abs = filepath.Join(root, abs)
+	}
+	abs = resolveParents(abs)
+
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", errors.Errorf("'%s' is outside the repository", p)
+	}
+
+	if rel == "." {
+		return "", nil
+	}
+
+	rel = filepath.ToSlash(rel)
+	if dirOnly {
+		rel += "/"
+	}
+
+	return rel, nil
+}
+
+// resolveParents resolves symbolic links in the deepest existing directory
+// above 'path' and puts the remaining elements back on.
+func resolveParents(path string) string {
+	dir, rest := filepath.Dir(path), filepath.Base(path)
+	for {
+		if _, err := os.Lstat(dir); err == nil {
+			if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+				return filepath.Join(resolved, rest)
+			}
+			return path
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return path
+		}
+		rest = filepath.Join(filepath.Base(dir), rest)
+		dir = parent
+	}
+}
+
+// matchesPath reports whether a repository-relative 'path' is the file or
+// under the directory named by 'filter'. A filter ending in a slash only
+// matches directories, and an empty filter matches everything.
+func matchesPath(path, filter string) bool {
+	switch {
+	case filter == "":
+		return true
+	case strings.HasSuffix(filter, "/"):
+		return strings.HasPrefix(path, filter)
+	default:
+		return path == filter || strings.HasPrefix(path, filter+"/")
+	}
+}
+
+// matchesExt reports whether the file name in 'path' ends in the extension
+// 'ext', given with or without its leading dot. The match is anchored at a
+// dot, so "on" doesn't match "icon" and "js" doesn't match "a.mjs", and
+// multi-part extensions such as "pb.go" or ".d.ts" work too.
+func matchesExt(path, ext string) bool {
+	ext = strings.TrimPrefix(ext, ".")
+	if ext == "" {
+		return false
+	}
+
+	return strings.HasSuffix(filepath.Base(path), "."+ext)
+}
@@ -0,0 +1,127 @@
+package gitreviewers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNormalizePath(t *testing.T) {
+	root, err := ioutil.TempDir("", "git-reviewer-paths")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	if root, err = filepath.EvalSymlinks(root); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.MkdirAll(filepath.Join(root, "repo", "src", "pkg"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	repo := filepath.Join(root, "repo")
+
+	// A link to the repository, like a temporary directory behind a link, and
+	// a link inside it that git tracks as a file
+	link := filepath.Join(root, "link")
+	if err := os.Symlink(repo, link); err != nil {
+		t.Skip("symbolic links are not supported")
+	}
+	if err := os.Symlink("src", filepath.Join(repo, "lib")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		root     string
+		path     string
+		expected string
+		fails    bool
+	}{
+		{repo, "src", "src", false},
+		{repo, "./src", "src", false},
+		{repo, "src/", "src/", false},
+		{repo, "./src/pkg/", "src/pkg/", false},
+		{repo, "src/pkg/../main.go", "src/main.go", false},
+		{repo, "src/missing/a.go", "src/missing/a.go", false},
+		{repo, ".", "", false},
+		{repo, filepath.Join(repo, "src", "pkg"), "src/pkg", false},
+		{repo, filepath.Join(link, "src") + "/", "src/", false},
+		{link, filepath.Join(repo, "src"), "src", false},
+		{link, "lib", "lib", false},
+		{repo, "../other", "", true},
+		{repo, filepath.Join(root, "other"), "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := normalizePath(tt.root, tt.path)
+		if tt.fails {
+			if err == nil {
+				t.Errorf("Expected '%s' to be outside the repository\n", tt.path)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unexpected error for '%s': %v\n", tt.path, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("Got '%s', expected '%s' for '%s'\n", got, tt.expected, tt.path)
+		}
+	}
+}
+
+func TestMatchesPath(t *testing.T) {
+	tests := []struct {
+		path     string
+		filter   string
+		expected bool
+	}{
+		{"src/a.go", "src", true},
+		{"src/a.go", "src/", true},
+		{"src/pkg/a.go", "src/pkg", true},
+		{"src2/a.go", "src", false},
+		{"src", "src", true},
+		{"src", "src/", false},
+		{"main.go", "main.go", true},
+		{"main.go.orig", "main.go", false},
+		{"main.go", "", true},
+	}
+
+	for _, tt := range tests {
+		if got := matchesPath(tt.path, tt.filter); got != tt.expected {
+			t.Errorf("Got %t, expected %t for '%s' against '%s'\n", got, tt.expected,
+				tt.path, tt.filter)
+		}
+	}
+}
+
+func TestMatchesExt(t *testing.T) {
+	tests := []struct {
+		path     string
+		ext      string
+		expected bool
+	}{
+		{"src/a.go", "go", true},
+		{"src/a.go", ".go", true},
+		{"src/a.json", "on", false},
+		{"assets/icon", "on", false},
+		{"main.python", "on", false},
+		{"web/a.mjs", "js", false},
+		{"web/a.js", "js", true},
+		{"api/a.pb.go", "pb.go", true},
+		{"api/a.pb.go", ".pb.go", true},
+		{"api/a.go", "pb.go", false},
+		{"types/index.d.ts", ".d.ts", true},
+		{"types/index.ts", "d.ts", false},
+		{"go/a", "go", false},
+		{"src/a.go", "", false},
+	}
+
+	for _, tt := range tests {
+		if got := matchesExt(tt.path, tt.ext); got != tt.expected {
+			t.Errorf("Got %t, expected %t for '%s' against '%s'\n", got, tt.expected,
+				tt.path, tt.ext)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package gitreviewers
+
+import (
+	"sort"
+	"strings"
+)
+
+// Preset bundles extension and path filters suited to a kind of project, so
+// they don't have to be worked out from scratch. The filters are added to
+// those of the counter, in the same forms as the filter flags take them.
+type Preset struct {
+	Name              string
+	IgnoredExtensions []string
+	IgnoredPaths      []string
+	OnlyExtensions    []string
+	OnlyPaths         []string
+}
+
+// BuiltinPresets are the presets available without any configuration. They
+// only ignore files, such as build output and generated or vendored code, so
+// they combine with each other and with the filter flags. Their paths are
+// globs, so directories like node_modules are ignored wherever they are
+// nested.
+var BuiltinPresets = []Preset{
+	{
+		Name:              "frontend",
+		IgnoredExtensions: []string{"map", "snap", "lock", "min.js", "min.css"},
+		IgnoredPaths: []string{"**/node_modules/**", "**/dist/**", "**/build/**",
+			"**/coverage/**", "**/.next/**", "**/package-lock.json", "**/pnpm-lock.yaml"},
+	},
+	{
+		Name:              "go-service",
+		IgnoredExtensions: []string{"pb.go", "pb.gw.go", "sum"},
+		IgnoredPaths:      []string{"**/vendor/**"},
+	},
+	{
+		Name:              "data",
+		IgnoredExtensions: []string{"csv", "tsv", "parquet", "avro", "pkl", "h5"},
+		IgnoredPaths:      []string{"**/data/**", "**/.ipynb_checkpoints/**"},
+	},
+}
+
+// presets maps the names of the presets Presets can name to them: the
+// built-in ones and CustomPresets, which take precedence.
+func (r *ContributionCounter) presets() map[string]Preset {
+	presets := make(map[string]Preset)
+	for _, p := range BuiltinPresets {
+		presets[p.Name] = p
+	}
+	for _, p := range r.CustomPresets {
+		presets[p.Name] = p
+	}
+	return presets
+}
+
+// presetNames lists the names of the available presets, sorted.
+func (r *ContributionCounter) presetNames() []string {
+	var names []string
+	for name := range r.presets() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applyPresets adds the filters of the presets in Presets to those of the
+// counter. Unknown presets are reported by Validate.
+func (r *ContributionCounter) applyPresets() {
+	presets := r.presets()
+	for _, name := range r.Presets {
+		p := presets[strings.ToLower(name)]
+		r.IgnoredExtensions = append(r.IgnoredExtensions, p.IgnoredExtensions...)
+		r.IgnoredPaths = append(r.IgnoredPaths, p.IgnoredPaths...)
+		r.OnlyExtensions = append(r.OnlyExtensions, p.OnlyExtensions...)
+		r.OnlyPaths = append(r.OnlyPaths, p.OnlyPaths...)
+	}
+}
+
+// customPresets reads presets out of [reviewer "<name>"] sections with
+// 'presetIgnoreExtension', 'presetIgnorePath', 'presetOnlyExtension' or
+// 'presetOnlyPath' settings, where the name of the section names the preset.
+func (c *Config) customPresets() []Preset {
+	var presets []Preset
+	for _, name := range c.subsections() {
+		p := Preset{Name: strings.ToLower(name)}
+		for _, f := range []struct {
+			key    string
+			values *[]string
+		}{
+			{"presetIgnoreExtension", &p.IgnoredExtensions},
+			{"presetIgnorePath", &p.IgnoredPaths},
+			{"presetOnlyExtension", &p.OnlyExtensions},
+			{"presetOnlyPath", &p.OnlyPaths},
+		} {
+			for _, v := range c.GetAll(configSection + name + "." + f.key) {
+				*f.values = append(*f.values, splitList(v)...)
+			}
+		}
+
+		if len(p.IgnoredExtensions)+len(p.IgnoredPaths)+len(p.OnlyExtensions)+
+			len(p.OnlyPaths) > 0 {
+			presets = append(presets, p)
+		}
+	}
+
+	return presets
+}
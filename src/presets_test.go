@@ -0,0 +1,65 @@
+package gitreviewers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestApplyPresets(t *testing.T) {
+	c := &Config{Entries: []ConfigEntry{
+		{Key: "reviewer.frontend.presetignorepath", Value: "dist/, storybook-static/"},
+		{Key: "reviewer.docs.presetonlypath", Value: "docs"},
+		{Key: "reviewer.docs.presetonlyextension", Value: "md"},
+		// Sections without preset settings define no preset
+		{Key: "reviewer.src.sensitivepath", Value: "src/"},
+	}}
+
+	cases := []struct {
+		presets  []string
+		expected Filters
+	}{
+		{nil, Filters{IgnoredPaths: []string{"vendor"}}},
+		{[]string{"go-service"}, Filters{IgnoredExtensions: []string{"pb.go", "pb.gw.go", "sum"},
+			IgnoredPaths: []string{"vendor", "**/vendor/**"}}},
+		// The configuration replaces the built-in frontend preset
+		{[]string{"Frontend", "docs"}, Filters{IgnoredPaths: []string{"vendor", "dist/",
+			"storybook-static/"}, OnlyExtensions: []string{"md"}, OnlyPaths: []string{"docs"}}},
+	}
+
+	for _, tt := range cases {
+		r := &ContributionCounter{IgnoredPaths: []string{"vendor"}, Presets: tt.presets}
+		r.ApplyConfig(c)
+		r.applyPresets()
+
+		actual := Filters{IgnoredExtensions: r.IgnoredExtensions, OnlyExtensions: r.OnlyExtensions,
+			IgnoredPaths: r.IgnoredPaths, OnlyPaths: r.OnlyPaths}
+		if !reflect.DeepEqual(actual, tt.expected) {
+			t.Errorf("Got %+v for %v, expected %+v\n", actual, tt.presets, tt.expected)
+		}
+	}
+}
+
+func TestPresetsIgnoreNestedPaths(t *testing.T) {
+	r := &ContributionCounter{Dir: ".", Presets: []string{"frontend", "go-service"}}
+	if err := r.NormalizeFilters(); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		path     string
+		expected bool
+	}{
+		{"node_modules/react/index.js", false},
+		{"web/node_modules/react/index.js", false},
+		{"services/api/vendor/lib.go", false},
+		{"web/package-lock.json", false},
+		{"web/src/app.js", true},
+		{"src/vendors.go", true},
+	}
+
+	for _, tt := range cases {
+		if actual := considerPath(tt.path, r); actual != tt.expected {
+			t.Errorf("Got %t considering %s, expected %t\n", actual, tt.path, tt.expected)
+		}
+	}
+}
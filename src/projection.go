@@ -0,0 +1,171 @@
+package gitreviewers
+
+import (
+	"path"
+	"sort"
+	"time"
+)
+
+// Projection describes how ownership of the changed files, and of the
+// directories they are in, shifts once the branch merges: who owns their lines
+// at the base revision, and who will own them with the branch's lines credited
+// to the people who wrote them.
+type Projection struct {
+	// Files holds every changed file, sorted by path, and Directories the
+	// changed files of each directory taken together. Files unchanged by the
+	// branch keep their owners, so they are left out.
+	Files       []PathProjection `json:"files"`
+	Directories []PathProjection `json:"directories"`
+	// Skipped lists the files left out for being too large or too slow to
+	// blame, each followed by the reason.
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+// PathProjection holds the owners of a file or directory before and after the
+// branch merges.
+type PathProjection struct {
+	Path        string `json:"path"`
+	LinesBefore int    `json:"linesBefore"`
+	LinesAfter  int    `json:"linesAfter"`
+	// Owners is sorted by the share they will own, the largest first.
+	Owners []OwnerProjection `json:"owners"`
+}
+
+// OwnerProjection holds the lines an author owns of a file or directory, and
+// their share of its lines, before and after the branch merges.
+type OwnerProjection struct {
+	Author      string  `json:"author"`
+	LinesBefore int     `json:"linesBefore"`
+	LinesAfter  int     `json:"linesAfter"`
+	Before      float64 `json:"before"`
+	After       float64 `json:"after"`
+}
+
+// Change is how much the share of the author changes when the branch merges.
+func (o OwnerProjection) Change() float64 {
+	return o.After - o.Before
+}
+
+// Projection projects who will own the changed files in 'paths', and the
+// files added on the branch, once the branch merges. The files are blamed at
+// the base revision and again at the tip of the branch, whatever BlameAt and
+// AsOf are set to. Only lines committed since Since are credited, like when
+// suggesting reviewers.
+func (r *ContributionCounter) Projection(paths []string) (*Projection, error) {
+	r.defaultSince()
+	defer r.Summary.stage("projection", time.Now())
+
+	// Files standing in for added ones don't change
+	var changed []string
+	for _, p := range paths {
+		if !r.standIns[p] {
+			changed = append(changed, p)
+		}
+	}
+
+	before, err := r.countsAt(BlameAtBase, changed)
+	if err != nil {
+		return nil, err
+	}
+	// Added files only have owners afterwards
+	all := append(changed, r.added...)
+	after, err := r.countsAt(BlameAtHead, all)
+	if err != nil {
+		return nil, err
+	}
+
+	return project(before, after, all), nil
+}
+
+// project compares who owns each of 'paths' 'before' and 'after' the branch,
+// file by file and directory by directory. Files skipped in either blame are
+// left out of both.
+func project(before, after *contributions, paths []string) *Projection {
+	p := &Projection{}
+
+	skipped := newContributions()
+	dirs := make(map[string]*PathProjection)
+	dirOwners := make(map[string]map[string]*OwnerProjection)
+	for _, file := range paths {
+		reason := before.skipped[file]
+		if reason == "" {
+			reason = after.skipped[file]
+		}
+		if reason != "" {
+			skipped.skip(file, reason)
+			continue
+		}
+
+		f := PathProjection{Path: file, LinesBefore: before.fileLines[file],
+			LinesAfter: after.fileLines[file]}
+		owners := make(map[string]*OwnerProjection)
+		for author, lines := range before.byFile[file] {
+			owners[author] = &OwnerProjection{Author: author, LinesBefore: lines}
+		}
+		for author, lines := range after.byFile[file] {
+			if owners[author] == nil {
+				owners[author] = &OwnerProjection{Author: author}
+			}
+			owners[author].LinesAfter = lines
+		}
+
+		dir := path.Dir(file) + "/"
+		if dirs[dir] == nil {
+			dirs[dir] = &PathProjection{Path: dir}
+			dirOwners[dir] = make(map[string]*OwnerProjection)
+		}
+		dirs[dir].LinesBefore += f.LinesBefore
+		dirs[dir].LinesAfter += f.LinesAfter
+		for author, o := range owners {
+			if dirOwners[dir][author] == nil {
+				dirOwners[dir][author] = &OwnerProjection{Author: author}
+			}
+			dirOwners[dir][author].LinesBefore += o.LinesBefore
+			dirOwners[dir][author].LinesAfter += o.LinesAfter
+		}
+
+		f.Owners = rankProjectedOwners(owners, f.LinesBefore, f.LinesAfter)
+		p.Files = append(p.Files, f)
+	}
+
+	for dir, d := range dirs {
+		d.Owners = rankProjectedOwners(dirOwners[dir], d.LinesBefore, d.LinesAfter)
+		p.Directories = append(p.Directories, *d)
+	}
+
+	sort.Slice(p.Files, func(i, j int) bool { return p.Files[i].Path < p.Files[j].Path })
+	sort.Slice(p.Directories, func(i, j int) bool {
+		return p.Directories[i].Path < p.Directories[j].Path
+	})
+	p.Skipped = skipped.skippedFiles()
+
+	return p
+}
+
+// rankProjectedOwners computes the shares of 'owners' of a file or directory
+// with 'before' lines before the branch and 'after' lines after, and sorts
+// them by the share they will own, the largest first.
+func rankProjectedOwners(owners map[string]*OwnerProjection, before, after int) []OwnerProjection {
+	ranked := make([]OwnerProjection, 0, len(owners))
+	for _, o := range owners {
+		if before > 0 {
+			o.Before = float64(o.LinesBefore) / float64(before)
+		}
+		if after > 0 {
+			o.After = float64(o.LinesAfter) / float64(after)
+		}
+		ranked = append(ranked, *o)
+	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].After != ranked[j].After {
+			return ranked[i].After > ranked[j].After
+		}
+		if ranked[i].Before != ranked[j].Before {
+			return ranked[i].Before > ranked[j].Before
+		}
+		return ranked[i].Author < ranked[j].Author
+	})
+
+	return ranked
+}
@@ -0,0 +1,106 @@
+package gitreviewers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestProject(t *testing.T) {
+	before, after := newContributions(), newContributions()
+
+	abe, ben := attribution{author: "abe"}, attribution{author: "ben"}
+	// Ben adds to Abe's a.go and writes b.go, and c.go was too slow to blame
+	before.add("src/a.go", []attribution{abe, abe, abe}, 3)
+	after.add("src/a.go", []attribution{abe, abe, abe, ben}, 4)
+	after.add("src/b.go", []attribution{ben, ben, ben, ben}, 4)
+	after.skip("c.go", skipTimedOut)
+
+	expected := &Projection{
+		Files: []PathProjection{
+			{Path: "src/a.go", LinesBefore: 3, LinesAfter: 4, Owners: []OwnerProjection{
+				{Author: "abe", LinesBefore: 3, LinesAfter: 3, Before: 1, After: 0.75},
+				{Author: "ben", LinesBefore: 0, LinesAfter: 1, Before: 0, After: 0.25},
+			}},
+			{Path: "src/b.go", LinesBefore: 0, LinesAfter: 4, Owners: []OwnerProjection{
+				{Author: "ben", LinesBefore: 0, LinesAfter: 4, Before: 0, After: 1},
+			}},
+		},
+		Directories: []PathProjection{
+			{Path: "src/", LinesBefore: 3, LinesAfter: 8, Owners: []OwnerProjection{
+				{Author: "ben", LinesBefore: 0, LinesAfter: 5, Before: 0, After: 0.625},
+				{Author: "abe", LinesBefore: 3, LinesAfter: 3, Before: 1, After: 0.375},
+			}},
+		},
+		Skipped: []string{"c.go (" + skipTimedOut + ")"},
+	}
+
+	actual := project(before, after, []string{"src/b.go", "src/a.go", "c.go"})
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %+v, expected %+v\n", actual, expected)
+	}
+}
+
+func TestProjection(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	write := func(name, content string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("src/b.go", "package a\n\nfunc b() {}\n")
+	write("src/c.go", "package a\n\nfunc c() {}\n")
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-q", "-m", "Add b and c",
+		"--author", "Cal Coolidge <cal@git-reviewer.com>")
+
+	// The branch grows a.go, deletes b.go and renames c.go
+	runGit(t, dir, "checkout", "-q", "-b", "feature")
+	write("src/a.go", "package a\n\nvar x = 1\n")
+	runGit(t, dir, "rm", "-q", "src/b.go")
+	runGit(t, dir, "mv", "src/c.go", "src/d.go")
+	runGit(t, dir, "commit", "-q", "-a", "-m", "Add x",
+		"--author", "Ben Franklin <ben@git-reviewer.com>")
+
+	repo, _, err := OpenRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := ContributionCounter{Repo: repo, Dir: dir, Since: "2000-01-01"}
+
+	paths, err := r.FindFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := r.Projection(paths)
+	if err != nil {
+		t.Fatalf("Unexpected error projecting ownership: %v\n", err)
+	}
+
+	expected := []PathProjection{
+		{Path: "src/a.go", LinesBefore: 1, LinesAfter: 3, Owners: []OwnerProjection{
+			{Author: "ben@git-reviewer.com", LinesAfter: 2, After: 2.0 / 3},
+			{Author: "abe@git-reviewer.com", LinesBefore: 1, LinesAfter: 1, Before: 1, After: 1.0 / 3},
+		}},
+		// Nobody owns the lines of a deleted file afterwards
+		{Path: "src/b.go", LinesBefore: 3, Owners: []OwnerProjection{
+			{Author: "cal@git-reviewer.com", LinesBefore: 3, Before: 1},
+		}},
+		// and the lines of a renamed one keep their owner
+		{Path: "src/c.go", LinesBefore: 3, LinesAfter: 3, Owners: []OwnerProjection{
+			{Author: "cal@git-reviewer.com", LinesBefore: 3, LinesAfter: 3, Before: 1, After: 1},
+		}},
+	}
+	if !reflect.DeepEqual(p.Files, expected) {
+		t.Errorf("Got %+v, expected %+v\n", p.Files, expected)
+	}
+
+	if len(p.Directories) != 1 || p.Directories[0].LinesBefore != 7 ||
+		p.Directories[0].LinesAfter != 6 {
+		t.Errorf("Got %+v, expected src/ to go from 7 lines to 6\n", p.Directories)
+	}
+}
@@ -0,0 +1,188 @@
+package gitreviewers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ReviewProvider talks to the service hosting the code reviews of a
+// repository, so suggested reviewers can be matched to accounts and asked to
+// review the open pull request of a branch.
+type ReviewProvider interface {
+	// User finds the account of the person committing with 'email', or
+	// returns an empty Account if it can't be told.
+	User(email string) (Account, error)
+	// PullRequest finds the open pull request of 'branch', or returns nil if
+	// there is none.
+	PullRequest(branch string) (*PullRequest, error)
+	// AddReviewers asks 'accounts' to review 'pr', keeping the reviewers it
+	// already has.
+	AddReviewers(pr *PullRequest, accounts []Account) error
+}
+
+// ReviewLoad is implemented by the ReviewProviders that can count the open
+// pull requests an account is asked to review, for BalanceLoad.
+type ReviewLoad interface {
+	OpenReviews(account Account) (int, error)
+}
+
+// OpenReviewsOf counts the open reviews of people, by email, through the
+// accounts 'p' finds for them, to set OpenReviews with. It returns nil if 'p'
+// can't count open reviews. People without an account have none.
+func OpenReviewsOf(p ReviewProvider) func(email string) (int, error) {
+	load, ok := p.(ReviewLoad)
+	if c, cached := p.(*cachedProvider); cached {
+		// Counts change too quickly to be cached
+		load, ok = c.ReviewProvider.(ReviewLoad)
+	}
+	if !ok {
+		return nil
+	}
+
+	return func(email string) (int, error) {
+		account, err := p.User(email)
+		if err != nil || account.ID == "" {
+			return 0, err
+		}
+		return load.OpenReviews(account)
+	}
+}
+
+// Account is a user of a ReviewProvider.
+type Account struct {
+	// ID identifies the account to the provider's API, and Name is what
+	// people know it by, such as a username.
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+// PullRequest is an open request to merge a branch, whatever the provider
+// calls it.
+type PullRequest struct {
+	// ID identifies the pull request to the provider's API.
+	ID    string
+	Title string
+	// Author is the account that opened it, and Base the branch it targets.
+	Author Account
+	Base   string
+	URL    string
+}
+
+// ReviewProvider picks the ReviewProvider for 'remote', set up with the
+// settings of 'c'. It fails for providers without an integration.
+func (r *ContributionCounter) ReviewProvider(c *Config, remote Remote) (ReviewProvider, error) {
+	switch remote.Provider {
+	case ProviderBitbucket:
+		return r.bitbucket(c, remote), nil
+	case ProviderGerrit:
+		return r.gerrit(c, remote), nil
+	case ProviderAzure:
+		a, err := azure(c, remote)
+		if err != nil {
+			return nil, err
+		}
+		a.Client = r.HTTPClient()
+		return a, nil
+	case ProviderGitHub:
+		return r.github(c, remote), nil
+	case "":
+		return nil, errors.Errorf("%s is not a known host; map it to a provider with reviewer.providerHost",
+			remote.Host)
+	default:
+		return nil, errors.Errorf("%s pull requests are not supported yet", remote.Provider)
+	}
+}
+
+// lastCommitBy returns the latest commit of the head revision authored with
+// 'email', or an empty string if there is none.
+func (r *ContributionCounter) lastCommitBy(email string) (string, error) {
+	out, err := r.output("log", "-1", "--format=%H", "--author=<"+email+">", r.headRev())
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to find commits by %s", email)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// errAPINotFound is returned by apiClient when the API answers that what was
+// asked for doesn't exist.
+var errAPINotFound = errors.New("not found")
+
+// apiClient sends JSON requests to the REST API of a provider at URL. With a
+// User, Token is sent with basic authentication, as for app passwords, and as
+// a bearer token otherwise.
+type apiClient struct {
+	URL    string
+	User   string
+	Token  string
+	Client *http.Client
+	// Prefix is skipped at the start of responses, for APIs that guard their
+	// JSON against being run as a script.
+	Prefix string
+	// Basic sends Token with basic authentication even without a User, as
+	// for Azure DevOps personal access tokens.
+	Basic bool
+}
+
+// do sends 'in', if not nil, as the JSON body of a 'method' request for
+// 'path' and decodes the JSON response into 'out', if not nil.
+func (a apiClient) do(method, path string, in, out interface{}) error {
+	var body bytes.Buffer
+	if in != nil {
+		if err := json.NewEncoder(&body).Encode(in); err != nil {
+			return errors.Wrap(err, "unable to encode request")
+		}
+	}
+
+	req, err := http.NewRequest(method, a.URL+path, &body)
+	if err != nil {
+		return errors.Wrap(err, "unable to build request")
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	switch {
+	case a.User != "" || a.Basic:
+		req.SetBasicAuth(a.User, a.Token)
+	case a.Token != "":
+		req.Header.Set("Authorization", "Bearer "+a.Token)
+	}
+
+	client := a.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return errAPINotFound
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return errors.Errorf("%s %s: %s", method, path, resp.Status)
+	case out == nil:
+		return nil
+	}
+
+	var data io.Reader = resp.Body
+	if a.Prefix != "" {
+		buf := bufio.NewReader(resp.Body)
+		if p, _ := buf.Peek(len(a.Prefix)); string(p) == a.Prefix {
+			buf.Discard(len(a.Prefix))
+		}
+		data = buf
+	}
+
+	return errors.Wrap(json.NewDecoder(data).Decode(out), "unable to read response")
+}
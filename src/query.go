@@ -0,0 +1,177 @@
+package gitreviewers
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+)
+
+// QueryHelp lists the questions a Query answers.
+const QueryHelp = `owner PATH     who owns the lines of a changed file
+score EMAIL    how a candidate ranks and what their score is made of
+why EMAIL      why someone is or isn't suggested
+help           this list
+`
+
+// Query answers questions about how reviewers were picked out of the signals
+// captured in a Bundle, such as who owns a changed file or why someone wasn't
+// suggested. Answers come from the bundle alone, so asking is instant, which
+// helps when tuning weights.
+type Query struct {
+	bundle *Bundle
+	counts *contributions
+	// ranked holds every candidate, best first, and picked the suggested
+	// reviewers.
+	ranked Stats
+	picked Stats
+}
+
+// NewQuery picks reviewers out of 'b' like Replay does, to answer questions
+// about them.
+func NewQuery(b *Bundle) (*Query, error) {
+	_, counts, final, topN, err := b.replay()
+	if _, ok := err.(NoReviewersErr); err != nil && !ok {
+		return nil, err
+	}
+
+	return &Query{bundle: b, counts: counts, ranked: chooseTopN(len(final), final),
+		picked: topN}, nil
+}
+
+// Run answers one question, a line such as "owner src/reviewers.go", see
+// QueryHelp.
+func (q *Query) Run(line string) (string, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return "", nil
+	}
+
+	if fields[0] == "help" {
+		return QueryHelp, nil
+	}
+	if len(fields) != 2 {
+		return "", errors.Errorf("unknown query '%s', try help", line)
+	}
+
+	switch fields[0] {
+	case "owner":
+		return q.owner(fields[1])
+	case "score":
+		return q.score(fields[1])
+	case "why":
+		return q.why(fields[1]), nil
+	}
+	return "", errors.Errorf("unknown query '%s', try help", line)
+}
+
+// owner lists the authors of the lines of the changed file at 'path', from
+// the largest owner down.
+func (q *Query) owner(path string) (string, error) {
+	for _, added := range q.bundle.Added {
+		if added == path {
+			return fmt.Sprintf("%s was added on the branch, so nobody owns it yet\n", path), nil
+		}
+	}
+	if _, ok := q.counts.fileLines[path]; !ok {
+		return "", errors.Errorf("'%s' is not one of the changed files", path)
+	}
+	if reason := q.counts.skipped[path]; reason != "" {
+		return fmt.Sprintf("%s was not blamed: %s\n", path, reason), nil
+	}
+
+	lines := q.counts.fileLines[path]
+	authors := make([]string, 0, len(q.counts.byFile[path]))
+	for author := range q.counts.byFile[path] {
+		authors = append(authors, author)
+	}
+	sort.Slice(authors, func(i, j int) bool {
+		a, b := q.counts.byFile[path][authors[i]], q.counts.byFile[path][authors[j]]
+		if a != b {
+			return a > b
+		}
+		return authors[i] < authors[j]
+	})
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s: %s, %d blamed since %s\n", path, pluralize(lines, "line"),
+		q.counts.fileTotal[path], q.bundle.Since)
+	w := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+	for _, author := range authors {
+		owned := q.counts.byFile[path][author]
+		fmt.Fprintf(w, "  %s\t%s\t%.2f%%\n", author, pluralize(owned, "line"),
+			float64(owned)/float64(lines)*100)
+	}
+	w.Flush()
+
+	return buf.String(), nil
+}
+
+// score breaks down how the candidate 'email' ranks.
+func (q *Query) score(email string) (string, error) {
+	rank, s := q.candidate(email)
+	if s == nil {
+		return "", errors.Errorf("%s owns none of the blamed lines", email)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s ranks %d of %d\n", s.Reviewer, rank, len(q.ranked))
+	w := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "  experience\t%.2f%%\t%s across %s\n", s.Percentage*100,
+		pluralize(s.Lines, "line"), pluralize(s.Files, "file"))
+	fmt.Fprintf(w, "  boost\t%+.2f%%\t%s\n", s.Boost*100, strings.TrimSpace(s.Notes()))
+	fmt.Fprintf(w, "  total\t%.2f%%\t\n", (s.Percentage+s.Boost)*100)
+	if s.Score > 0 {
+		fmt.Fprintf(w, "  learned\t%.2f%%\tranks ahead of the total\n", s.Score*100)
+	}
+	w.Flush()
+
+	return buf.String(), nil
+}
+
+// why explains whether 'email' is suggested, and what for or what ranks
+// ahead of them.
+func (q *Query) why(email string) string {
+	for _, s := range q.picked {
+		if strings.EqualFold(s.Reviewer, email) {
+			return fmt.Sprintf("%s is suggested: %s\n", s.Reviewer, s.Rationale())
+		}
+	}
+
+	rank, s := q.candidate(email)
+	if s == nil {
+		return fmt.Sprintf("%s is not suggested: they own none of the blamed lines and no rule calls for them\n",
+			email)
+	}
+
+	var ahead []string
+	for _, better := range q.ranked[:rank-1] {
+		ahead = append(ahead, better.Reviewer)
+	}
+	msg := fmt.Sprintf("%s is not suggested: they rank %d of %d", s.Reviewer, rank,
+		len(q.ranked))
+	switch {
+	case len(ahead) > maxReviewers:
+		msg += fmt.Sprintf(", behind %s and %d more", strings.Join(ahead[:maxReviewers], ", "),
+			len(ahead)-maxReviewers)
+	case len(ahead) > 0:
+		msg += ", behind " + strings.Join(ahead, ", ")
+	}
+	if rationale := s.Rationale(); rationale != "" {
+		msg += ". " + rationale
+	}
+	return msg + "\n"
+}
+
+// candidate finds the candidate 'email' and their rank, starting from 1.
+func (q *Query) candidate(email string) (int, *Stat) {
+	for i, s := range q.ranked {
+		if strings.EqualFold(s.Reviewer, email) {
+			return i + 1, s
+		}
+	}
+	return 0, nil
+}
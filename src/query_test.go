@@ -0,0 +1,47 @@
+package gitreviewers
+
+import "testing"
+
+func TestQuery(t *testing.T) {
+	b := testBundle()
+	b.Paths = append(b.Paths, "src/c.go")
+	b.Added = []string{"src/d.go"}
+	b.Files = append(b.Files, BundleFile{Path: "src/c.go", Lines: 2, Blamed: 1,
+		Authors: map[string]int{"cal@git-reviewer.com": 1}})
+
+	q, err := NewQuery(b)
+	if err != nil {
+		t.Fatalf("Unexpected error picking reviewers: %v\n", err)
+	}
+
+	cases := []struct {
+		query    string
+		expected string
+		valid    bool
+	}{
+		{"owner src/a.go", "src/a.go: 10 lines, 10 blamed since 2017-01-01\n" +
+			"  abe@git-reviewer.com  6 lines  60.00%\n" +
+			"  ben@git-reviewer.com  4 lines  40.00%\n", true},
+		{"owner src/d.go", "src/d.go was added on the branch, so nobody owns it yet\n", true},
+		{"owner src/e.go", "", false},
+		{"score BEN@git-reviewer.com", "ben@git-reviewer.com ranks 1 of 4\n" +
+			"  experience  19.05%   4 lines across 1 file\n" +
+			"  boost       +50.00%  (topics: cache)\n" +
+			"  total       69.05%   \n", true},
+		{"score sec@git-reviewer.com", "", false},
+		{"why sec@git-reviewer.com", "sec@git-reviewer.com is suggested: Required by src\n", true},
+		{"why cal@git-reviewer.com", "cal@git-reviewer.com is not suggested: they rank 4 of 4," +
+			" behind ben@git-reviewer.com, abe@git-reviewer.com, tom@git-reviewer.com." +
+			" Owns 5% of the changed lines across 1 file\n", true},
+		{"why", "", false},
+		{"blame src/a.go", "", false},
+		{"help", QueryHelp, true},
+	}
+
+	for _, c := range cases {
+		actual, err := q.Run(c.query)
+		if (err == nil) != c.valid || actual != c.expected {
+			t.Errorf("Got %q (%v) for '%s', expected %q\n", actual, err, c.query, c.expected)
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package gitreviewers
+
+import (
+	"bytes"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Ways candidates are ranked, set through ContributionCounter.Ranker.
+const (
+	// RankerHeuristic ranks candidates by the share of the changed lines they
+	// own, boosted by the other signals asked for. It is the default.
+	RankerHeuristic = "heuristic"
+	// RankerLearned ranks candidates by how likely a model trained on the
+	// review trailers of past commits says they are to review the changes.
+	// It is experimental.
+	RankerLearned = "learned"
+)
+
+// RankerOptions lists the valid values of ContributionCounter.Ranker.
+var RankerOptions = []string{RankerHeuristic, RankerLearned}
+
+// minTrainingReviews is the fewest reviewed commits the learned ranker trains
+// on. With less history it falls back on the heuristic ranking.
+const minTrainingReviews = 20
+
+// Training parameters of the learned ranker's logistic regression.
+const (
+	trainingRounds = 500
+	learningRate   = 0.5
+	regularization = 0.01
+)
+
+// numFeatures is the number of features describing a candidate, see
+// reviewHistory.features.
+const numFeatures = 4
+
+// rankingModel is a logistic regression over the features of a candidate.
+type rankingModel struct {
+	weights [numFeatures]float64
+	bias    float64
+}
+
+// score is the chance the model gives a candidate with features 'x' of
+// reviewing the changes.
+func (m *rankingModel) score(x [numFeatures]float64) float64 {
+	z := m.bias
+	for i := range x {
+		z += m.weights[i] * x[i]
+	}
+	return 1 / (1 + math.Exp(-z))
+}
+
+// example is a candidate for reviewing a past commit, labeled with whether
+// they did.
+type example struct {
+	features [numFeatures]float64
+	reviewed bool
+}
+
+// trainModel fits a logistic regression to the examples with batch gradient
+// descent. Starting from zero weights keeps training deterministic.
+func trainModel(examples []example) *rankingModel {
+	m := &rankingModel{}
+	n := float64(len(examples))
+
+	for round := 0; round < trainingRounds; round++ {
+		var (
+			grad [numFeatures]float64
+			bias float64
+		)
+		for _, e := range examples {
+			diff := m.score(e.features)
+			if e.reviewed {
+				diff--
+			}
+			for i, x := range e.features {
+				grad[i] += diff * x
+			}
+			bias += diff
+		}
+
+		for i := range m.weights {
+			m.weights[i] -= learningRate * (grad[i]/n + regularization*m.weights[i])
+		}
+		m.bias -= learningRate * bias / n
+	}
+
+	return m
+}
+
+// reviewHistory tracks who committed to each file, when they last did, and
+// how many reviews everyone gave, as it replays the history of the base
+// revision.
+type reviewHistory struct {
+	commits     map[string]map[string]int
+	lastTouched map[string]map[string]time.Time
+	reviews     map[string]int
+}
+
+func newReviewHistory() *reviewHistory {
+	return &reviewHistory{
+		commits:     make(map[string]map[string]int),
+		lastTouched: make(map[string]map[string]time.Time),
+		reviews:     make(map[string]int),
+	}
+}
+
+// historyCommit is a commit read while mining review history.
+type historyCommit struct {
+	author    string
+	date      time.Time
+	files     []string
+	reviewers []string
+}
+
+// record adds a commit to the history.
+func (h *reviewHistory) record(c historyCommit) {
+	for _, f := range c.files {
+		if h.commits[f] == nil {
+			h.commits[f] = make(map[string]int)
+			h.lastTouched[f] = make(map[string]time.Time)
+		}
+		h.commits[f][c.author]++
+		h.lastTouched[f][c.author] = c.date
+	}
+	for _, reviewer := range c.reviewers {
+		h.reviews[reviewer]++
+	}
+}
+
+// candidates lists everyone who committed to one of 'files' or reviewed
+// anything so far, except 'author'.
+func (h *reviewHistory) candidates(files []string, author string) []string {
+	seen := make(map[string]bool)
+	for _, f := range files {
+		for a := range h.commits[f] {
+			seen[a] = true
+		}
+	}
+	for reviewer := range h.reviews {
+		seen[reviewer] = true
+	}
+	delete(seen, author)
+
+	var candidates []string
+	for c := range seen {
+		candidates = append(candidates, c)
+	}
+	sort.Strings(candidates)
+
+	return candidates
+}
+
+// features describes the experience of 'author' with 'files' at 'now': their
+// share of the commits to the files, the share of the files they committed
+// to, how recently they did, and how many reviews they gave. They only come
+// from commit history so they can be measured the same way for past commits
+// and for the changes being reviewed.
+func (h *reviewHistory) features(author string, files []string, now time.Time) [numFeatures]float64 {
+	var (
+		x                    [numFeatures]float64
+		mine, total, touched int
+		last                 time.Time
+	)
+
+	for _, f := range files {
+		for a, n := range h.commits[f] {
+			total += n
+			if a == author {
+				mine += n
+			}
+		}
+		if h.commits[f][author] > 0 {
+			touched++
+		}
+		if t := h.lastTouched[f][author]; t.After(last) {
+			last = t
+		}
+	}
+
+	if total > 0 {
+		x[0] = float64(mine) / float64(total)
+	}
+	if len(files) > 0 {
+		x[1] = float64(touched) / float64(len(files))
+	}
+	if !last.IsZero() {
+		days := now.Sub(last).Hours() / 24
+		x[2] = 1 / (1 + math.Max(days, 0)/30)
+	}
+	x[3] = math.Log1p(float64(h.reviews[author]))
+
+	return x
+}
+
+// scoreLearned trains a ranking model on the reviewed commits in the history
+// of the base revision, or AsOf, since Since, and sets the Score of each
+// candidate to the chance it gives them of reviewing changes to 'paths'.
+// Candidates are left unscored, and ranked by experience, when too few
+// commits carry review trailers to learn from.
+func (r *ContributionCounter) scoreLearned(candidates Stats, paths []string) error {
+	commits, err := r.minedHistory()
+	if err != nil {
+		return err
+	}
+
+	var (
+		history  = newReviewHistory()
+		examples []example
+		reviewed int
+	)
+	for _, c := range commits {
+		if len(c.reviewers) > 0 {
+			reviewed++
+			reviewers := make(map[string]bool)
+			for _, reviewer := range c.reviewers {
+				reviewers[reviewer] = true
+			}
+			for _, candidate := range history.candidates(c.files, c.author) {
+				examples = append(examples, example{
+					features: history.features(candidate, c.files, c.date),
+					reviewed: reviewers[candidate],
+				})
+			}
+		}
+		history.record(c)
+	}
+
+	if reviewed < minTrainingReviews {
+		r.logf("Only %d commits have review trailers, ranking by experience instead of"+
+			" the learned ranker which needs %d\n", reviewed, minTrainingReviews)
+		return nil
+	}
+
+	model := trainModel(examples)
+	for _, s := range candidates {
+		s.Score = model.score(history.features(s.Reviewer, paths, r.now()))
+	}
+
+	return nil
+}
+
+// minedHistory reads the commits in the history of the base revision, or
+// AsOf, since Since, oldest first, with the files they changed and the
+// reviewers named in their trailers.
+func (r *ContributionCounter) minedHistory() ([]historyCommit, error) {
+	args := []string{"log", "--reverse", "--no-renames", "--name-only",
+		"--format=%x1e%ae%x1f%ct%x1f%B%x1f", "--since=" + r.Since, r.historyRev()}
+	out, err := r.output(r.history(args...)...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read review history")
+	}
+
+	var commits []historyCommit
+	for _, record := range bytes.Split(out, []byte{0x1e}) {
+		fields := strings.Split(string(record), "\x1f")
+		if len(fields) != 4 {
+			continue
+		}
+
+		ts, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to parse commit time '%s'", fields[1])
+		}
+
+		c := historyCommit{
+			author: reviewerKey(fields[0], r.Mailmap),
+			date:   time.Unix(ts, 0),
+		}
+		for _, f := range strings.Split(fields[3], "\n") {
+			if f = strings.TrimSpace(f); f != "" {
+				c.files = append(c.files, f)
+			}
+		}
+		for _, reviewer := range parseReviewTrailers(fields[2]) {
+			c.reviewers = append(c.reviewers, reviewerKey(reviewer, r.Mailmap))
+		}
+		commits = append(commits, c)
+	}
+
+	return commits, nil
+}
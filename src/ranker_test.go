@@ -0,0 +1,93 @@
+package gitreviewers
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTrainModel(t *testing.T) {
+	var examples []example
+	for i := 0; i < 10; i++ {
+		examples = append(examples,
+			example{features: [numFeatures]float64{0.8, 1, 0.9, 1}, reviewed: true},
+			example{features: [numFeatures]float64{0.1, 0, 0.1, 0}, reviewed: false})
+	}
+
+	m := trainModel(examples)
+	expert, stranger := m.score(examples[0].features), m.score(examples[1].features)
+	if expert <= 0.5 || stranger >= 0.5 {
+		t.Errorf("Got scores %f and %f, expected the reviewer above 0.5 and the"+
+			" other below\n", expert, stranger)
+	}
+}
+
+func TestReviewHistoryFeatures(t *testing.T) {
+	now := time.Date(2018, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	h := newReviewHistory()
+	h.record(historyCommit{author: "abe@git-reviewer.com", date: now.AddDate(0, 0, -30),
+		files: []string{"a.go", "b.go"}})
+	h.record(historyCommit{author: "ben@git-reviewer.com", date: now,
+		files: []string{"a.go"}, reviewers: []string{"abe@git-reviewer.com"}})
+
+	expected := [numFeatures]float64{2.0 / 3.0, 1, 0.5, 0.6931471805599453}
+	if actual := h.features("abe@git-reviewer.com", []string{"a.go", "b.go"}, now); actual != expected {
+		t.Errorf("Got %v, expected %v\n", actual, expected)
+	}
+
+	candidates := h.candidates([]string{"b.go"}, "ben@git-reviewer.com")
+	if len(candidates) != 1 || candidates[0] != "abe@git-reviewer.com" {
+		t.Errorf("Got candidates %v, expected [abe@git-reviewer.com]\n", candidates)
+	}
+}
+
+func TestScoreLearned(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	// Ben reviews every change to a.go and nobody reviews b.go
+	commit := func(file string, n int, trailer string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, "src", file),
+			[]byte(fmt.Sprintf("package a\n\nvar x = %d\n", n)), 0644); err != nil {
+			t.Fatal(err)
+		}
+		runGit(t, dir, "add", ".")
+		runGit(t, dir, "commit", "-q", "-m", fmt.Sprintf("Change %s\n\n%s", file, trailer))
+	}
+
+	candidates := func() Stats {
+		return Stats{
+			&Stat{Reviewer: "abe@git-reviewer.com", Percentage: 0.5},
+			&Stat{Reviewer: "ben@git-reviewer.com", Percentage: 0.5},
+		}
+	}
+
+	r := ContributionCounter{Dir: dir, Since: "2000-01-01"}
+	few := candidates()
+	commit("a.go", 0, "Reviewed-by: Ben Franklin <ben@git-reviewer.com>")
+	if err := r.scoreLearned(few, []string{"src/a.go"}); err != nil {
+		t.Fatalf("Unexpected error scoring candidates: %v\n", err)
+	}
+	if few[0].Score != 0 || few[1].Score != 0 {
+		t.Errorf("Expected no scores with too little history, got %f and %f\n",
+			few[0].Score, few[1].Score)
+	}
+
+	for i := 1; i < minTrainingReviews; i++ {
+		commit("a.go", i, "Reviewed-by: Ben Franklin <ben@git-reviewer.com>")
+		commit("b.go", i, "")
+	}
+
+	stats := candidates()
+	if err := r.scoreLearned(stats, []string{"src/a.go"}); err != nil {
+		t.Fatalf("Unexpected error scoring candidates: %v\n", err)
+	}
+	if stats[1].Score <= stats[0].Score {
+		t.Errorf("Got %f for Ben and %f for Abe, expected Ben to score higher\n",
+			stats[1].Score, stats[0].Score)
+	}
+}
@@ -0,0 +1,95 @@
+package gitreviewers
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// maxRelatives limits how many existing files stand in for each added file.
+const maxRelatives = 2
+
+// closestRelatives finds the files in 'tree' most likely to be known by the
+// people who should review 'added', a file that only exists on the branch.
+// Nobody but the branch author owns a new file, so the owners of its closest
+// relatives are the next best candidates.
+//
+// Relatives are the files of the nearest directory of 'added' that exists in
+// 'tree', ranked by how much their names look alike. Files sharing neither a
+// prefix nor an extension with 'added' aren't related at all, and neither are
+// files the extension and path filters of 'opts' leave out.
+func closestRelatives(tree *object.Tree, added string, opts *ContributionCounter) []string {
+	dir := path.Dir(added)
+
+	for {
+		dt := tree
+		if dir != "." {
+			var err error
+			if dt, err = tree.Tree(dir); err != nil {
+				dir = path.Dir(dir)
+				continue
+			}
+		}
+
+		return rankRelatives(dt, dir, added, opts)
+	}
+}
+
+// rankRelatives scores the files directly under 'dir' against 'added' and
+// keeps the best ones.
+func rankRelatives(dt *object.Tree, dir, added string, opts *ContributionCounter) []string {
+	type relative struct {
+		name  string
+		score int
+	}
+
+	var candidates []relative
+	for _, e := range dt.Entries {
+		if !e.Mode.IsFile() {
+			continue
+		}
+
+		name := path.Join(dir, e.Name)
+		if opts.filterReason(name) != "" {
+			continue
+		}
+		if score := nameSimilarity(path.Base(added), e.Name); score > 0 {
+			candidates = append(candidates, relative{name, score})
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].name < candidates[j].name
+	})
+
+	var relatives []string
+	for i := 0; i < len(candidates) && i < maxRelatives; i++ {
+		relatives = append(relatives, candidates[i].name)
+	}
+
+	return relatives
+}
+
+// nameSimilarity scores how alike two file names are: every leading character
+// their names share counts double and a matching extension counts once, so
+// "user_test.go" is closer to "user.go" than to "main.go".
+func nameSimilarity(a, b string) int {
+	var score int
+
+	extA, extB := path.Ext(a), path.Ext(b)
+	if extA != "" && extA == extB {
+		score++
+	}
+
+	stemA, stemB := strings.TrimSuffix(a, extA), strings.TrimSuffix(b, extB)
+	for i := 0; i < len(stemA) && i < len(stemB) && stemA[i] == stemB[i]; i++ {
+		score += 2
+	}
+
+	return score
+}
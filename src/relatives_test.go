@@ -0,0 +1,194 @@
+package gitreviewers
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	gogit "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+func TestNameSimilarity(t *testing.T) {
+	cases := []struct {
+		A, B     string
+		Expected int
+	}{
+		{"user_cache.go", "user.go", 9},
+		{"user_cache.go", "main.go", 1},
+		{"user_cache.go", "README.md", 0},
+		{"Makefile", "Makefile.old", 16},
+		{"a", "b", 0},
+	}
+
+	for _, c := range cases {
+		if actual := nameSimilarity(c.A, c.B); actual != c.Expected {
+			t.Errorf("%s vs %s: got %d, expected %d\n", c.A, c.B, actual, c.Expected)
+		}
+	}
+}
+
+func TestClosestRelatives(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"src/user.go", "src/user_test.go", "src/main.go",
+		"src/README.md", "docs/guide.md"} {
+		if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte("x\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-q", "-m", "Add files")
+
+	repo, err := gogit.PlainOpen(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := repo.Reference(plumbing.Master, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := repo.CommitObject(m.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := c.Tree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		Added    string
+		Ignored  []string
+		Expected []string
+	}{
+		{"src/user_cache.go", nil, []string{"src/user_test.go", "src/user.go"}},
+		{"src/cache/store.go", nil, []string{"src/a.go", "src/main.go"}},
+		{"docs/api.md", nil, []string{"docs/guide.md"}},
+		{"tools/gen.sh", nil, nil},
+		// Filtered files aren't blamed, so the next closest stand in instead
+		{"src/user_cache.go", []string{"src/user_test.go"}, []string{"src/user.go", "src/a.go"}},
+		{"docs/api.md", []string{"docs"}, nil},
+	}
+
+	for _, c := range cases {
+		opts := &ContributionCounter{IgnoredPaths: c.Ignored}
+		if actual := closestRelatives(tree, c.Added, opts); !reflect.DeepEqual(actual, c.Expected) {
+			t.Errorf("%s: got %v, expected %v\n", c.Added, actual, c.Expected)
+		}
+	}
+}
+
+func TestFindFilesIncludeUntracked(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	write := func(name, content string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("src/b.go", "package a\n")
+	write(".gitignore", "*.log\n")
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-q", "-m", "Add b")
+
+	// A new file, a staged one and an ignored one, none of them committed
+	write("src/a_test.go", "package a\n")
+	write("src/b_cache.go", "package a\n")
+	runGit(t, dir, "add", "src/b_cache.go")
+	write("src/debug.log", "log\n")
+
+	repo, _, err := OpenRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		workingTree, untracked bool
+		files, added           []string
+	}{
+		{false, true, nil, nil},
+		{true, false, nil, nil},
+		{true, true, []string{"src/a.go", "src/b.go"}, []string{"src/a_test.go", "src/b_cache.go"}},
+	}
+
+	for _, c := range cases {
+		r := ContributionCounter{Repo: repo, Dir: dir, WorkingTree: c.workingTree,
+			IncludeUntracked: c.untracked}
+		files, err := r.FindFiles()
+		if err != nil {
+			t.Fatal(err)
+		}
+		sort.Strings(files)
+
+		if !reflect.DeepEqual(files, c.files) || !reflect.DeepEqual(r.AddedFiles(), c.added) {
+			t.Errorf("Got %v and added %v (working tree: %t, untracked: %t), expected %v and %v\n",
+				files, r.AddedFiles(), c.workingTree, c.untracked, c.files, c.added)
+		}
+	}
+}
+
+func TestDirWeight(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	write := func(name, content string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("src/b.go", "package a\n")
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-q", "-m", "Add b", "--author", "Cal Coolidge <cal@git-reviewer.com>")
+
+	// b.go stands in for the new b_test.go
+	runGit(t, dir, "checkout", "-q", "-b", "feature")
+	write("src/a.go", "package a\n\nvar x = 1\n")
+	write("src/b_test.go", "package a\n")
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-q", "-m", "Test b")
+
+	repo, _, err := OpenRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	half, none := 0.5, 0.0
+	cases := []struct {
+		weight   *float64
+		expected string
+	}{
+		{nil, "0.500"},
+		{&half, "0.333"},
+		// Nothing is left of the stand-in's lines
+		{&none, "0.000"},
+	}
+
+	for _, c := range cases {
+		r := ContributionCounter{Repo: repo, Dir: dir, Since: "2000-01-01", IncludeAdded: true,
+			DirWeight: c.weight}
+		paths, err := r.FindFiles()
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts, err := r.generateCounts(paths)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if share := fmt.Sprintf("%.3f", counts.share("cal@git-reviewer.com", false)); share != c.expected {
+			t.Errorf("Got a share of %s for cal with weight %v, expected %s\n", share,
+				r.dirWeight(), c.expected)
+		}
+	}
+}
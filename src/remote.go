@@ -0,0 +1,112 @@
+package gitreviewers
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Hosting providers a remote can be detected as.
+const (
+	ProviderGitHub    = "github"
+	ProviderGitLab    = "gitlab"
+	ProviderBitbucket = "bitbucket"
+	ProviderGerrit    = "gerrit"
+	ProviderAzure     = "azure"
+)
+
+// knownHosts maps the public hosts of each provider to its name. Self-hosted
+// instances are added with ContributionCounter.ProviderHosts.
+var knownHosts = map[string]string{
+	"github.com":        ProviderGitHub,
+	"ssh.github.com":    ProviderGitHub,
+	"gitlab.com":        ProviderGitLab,
+	"bitbucket.org":     ProviderBitbucket,
+	"dev.azure.com":     ProviderAzure,
+	"ssh.dev.azure.com": ProviderAzure,
+}
+
+// Remote describes the repository a remote URL points to.
+type Remote struct {
+	// Host is the lower-case host name, without user or port.
+	Host string
+	// Owner is the user or organization, or the group path on GitLab, such
+	// as "group/subgroup". Gerrit projects need not have one.
+	Owner string
+	// Repo is the repository name without a ".git" suffix.
+	Repo string
+	// Provider is one of the Provider constants, or empty if the host isn't
+	// known. Guessing from the host name would risk talking to the wrong API.
+	Provider string
+}
+
+// ParseRemote reads a remote URL in any of the forms git accepts for hosted
+// repositories: "https://host/owner/repo.git", "ssh://git@host:22/owner/repo",
+// "git://host/owner/repo" or the scp-like "git@host:owner/repo.git". The
+// provider is detected from the host, looking in 'hosts' first, which maps
+// the host names of self-hosted instances to a provider.
+func ParseRemote(remote string, hosts map[string]string) (Remote, error) {
+	var host, path string
+
+	if strings.Contains(remote, "://") {
+		u, err := url.Parse(remote)
+		if err != nil {
+			return Remote{}, errors.Wrapf(err, "unable to parse remote '%s'", remote)
+		}
+		if u.Scheme == "file" || u.Host == "" {
+			return Remote{}, errors.Errorf("remote '%s' is not a hosted repository", remote)
+		}
+		host, path = u.Hostname(), u.Path
+	} else {
+		// The scp-like syntax needs a colon before the first slash, otherwise
+		// git reads the remote as a local path
+		colon := strings.Index(remote, ":")
+		if colon < 0 || strings.Contains(remote[:colon], "/") {
+			return Remote{}, errors.Errorf("remote '%s' is not a hosted repository", remote)
+		}
+		host, path = remote[:colon], remote[colon+1:]
+		if at := strings.LastIndex(host, "@"); at >= 0 {
+			host = host[at+1:]
+		}
+	}
+
+	r := Remote{Host: strings.ToLower(host)}
+	if p, ok := hosts[r.Host]; ok {
+		r.Provider = p
+	} else if strings.HasSuffix(r.Host, ".visualstudio.com") {
+		r.Provider = ProviderAzure
+	} else {
+		r.Provider = knownHosts[r.Host]
+	}
+
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	if r.Provider == ProviderGerrit {
+		// Gerrit serves authenticated HTTP under /a, and its projects are
+		// often named without a parent
+		path = strings.TrimPrefix(path, "a/")
+		if host != "" && path != "" && !strings.Contains(path, "/") {
+			r.Repo = path
+			return r, nil
+		}
+	}
+
+	slash := strings.LastIndex(path, "/")
+	if host == "" || slash <= 0 || slash == len(path)-1 {
+		return Remote{}, errors.Errorf("remote '%s' does not name an owner and repository", remote)
+	}
+	r.Owner, r.Repo = path[:slash], path[slash+1:]
+
+	return r, nil
+}
+
+// Remote reads the URL of the remote called 'name', such as "origin", and
+// parses it with the provider hosts of the counter.
+func (r *ContributionCounter) Remote(name string) (Remote, error) {
+	out, err := r.output("remote", "get-url", name)
+	if err != nil {
+		return Remote{}, errors.Errorf("no remote named '%s'", name)
+	}
+
+	return ParseRemote(strings.TrimSpace(string(out)), r.ProviderHosts)
+}
@@ -0,0 +1,199 @@
+package gitreviewers
+
+import (
+	"encoding/json"
+	"io"
+	"runtime"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// RepositoryOwnership describes how the lines of a whole repository are
+// spread across the people who wrote them.
+type RepositoryOwnership struct {
+	// Rev is the commit that was measured.
+	Rev string `json:"rev"`
+	// Files and Lines count the files blamed and their lines, including
+	// lines committed before Since.
+	Files int `json:"files"`
+	Lines int `json:"lines"`
+	// Owners is sorted from the largest owner down. Files counts the files
+	// each owner has lines in.
+	Owners []AuthorOwnership `json:"owners"`
+	// Skipped lists the files left out for being too large or too slow to
+	// blame, each followed by the reason.
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+// AuthorOwnership holds the share of a repository a single author owns.
+type AuthorOwnership struct {
+	Author string  `json:"author"`
+	Lines  int     `json:"lines"`
+	Files  int     `json:"files"`
+	Share  float64 `json:"share"`
+	// LastTouched is the most recent day one of their lines was committed,
+	// as YYYY-MM-DD.
+	LastTouched string `json:"lastTouched,omitempty"`
+}
+
+// RepositoryOwnership measures who owns the lines of every file in the head
+// revision, for audits and as the data behind bus factor reports. Files are
+// filtered like changed files are, binary and too large files are left out,
+// and LFS files are left out unless IncludeLFS is set.
+//
+// No ownership index is kept between runs, so every file is blamed, which
+// takes a while on large repositories. The result is cached for the head
+// commit and options when a cache is set.
+func (r *ContributionCounter) RepositoryOwnership() (*RepositoryOwnership, error) {
+	r.defaultSince()
+
+	h, err := r.resolve(r.headRev())
+	if err != nil {
+		return nil, errors.Wrap(err, "issue resolving head revision")
+	}
+
+	key := "ownership/" + r.suggestionKey(h.String(), h.String(), nil)
+	if c := r.cache(); c != nil && r.CacheResults {
+		if b, ok, err := c.Get(key); err == nil && ok {
+			var o RepositoryOwnership
+			if err := json.Unmarshal(b, &o); err == nil {
+				return &o, nil
+			}
+		}
+	}
+
+	hc, err := r.Repo.CommitObject(h)
+	if err != nil {
+		return nil, errors.Wrap(err, "issue opening head commit")
+	}
+	ht, err := hc.Tree()
+	if err != nil {
+		return nil, errors.Wrap(err, "issue opening tree at head")
+	}
+
+	counts := newContributions()
+	paths, err := r.ownedPaths(ht, counts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Like changed files, LFS files are attributed by commit history when
+	// included and skipped otherwise
+	attribute := r.blameFile
+	if lfs := lfsPaths(ht, paths); len(lfs) > 0 {
+		if r.IncludeLFS {
+			attribute = func(path string, rev string) ([]attribution, int, error) {
+				if lfs[path] {
+					return r.commitAttributions(path, rev)
+				}
+				return r.blameFile(path, rev)
+			}
+		} else {
+			kept := paths[:0]
+			for _, p := range paths {
+				if lfs[p] {
+					r.logf("Skipping Git LFS file %s\n", p)
+					continue
+				}
+				kept = append(kept, p)
+			}
+			paths = kept
+		}
+	}
+
+	if err := r.blameAll(h.String(), paths, attribute, counts); err != nil {
+		return nil, err
+	}
+
+	o := &RepositoryOwnership{
+		Rev:     h.String(),
+		Files:   len(counts.byFile),
+		Lines:   counts.total,
+		Skipped: counts.skippedFiles(),
+	}
+	for _, s := range rankOwners(counts.byAuthor, counts.total) {
+		o.Owners = append(o.Owners, AuthorOwnership{Author: s.Reviewer, Lines: s.Lines,
+			Files: counts.filesTouched(s.Reviewer), Share: s.Percentage,
+			LastTouched: counts.lastTouched[s.Reviewer]})
+	}
+
+	if c := r.cache(); c != nil && r.CacheResults && !counts.timedOut() {
+		b, err := json.Marshal(o)
+		if err == nil {
+			err = c.Set(key, b, suggestionTTL)
+		}
+		if err != nil {
+			r.logf("Unable to cache ownership: %v\n", err)
+		}
+	}
+
+	return o, nil
+}
+
+// ownedPaths lists the files of 'tree' worth blaming, recording in 'counts'
+// those skipped for being too large.
+func (r *ContributionCounter) ownedPaths(tree *object.Tree, counts *contributions) ([]string, error) {
+	var paths []string
+
+	files := tree.Files()
+	defer files.Close()
+	for {
+		f, err := files.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "issue listing files at head")
+		}
+
+		if !r.consider(f.Name) {
+			continue
+		}
+		if r.maxFileSize() > 0 && f.Size > r.maxFileSize() {
+			counts.skip(f.Name, skipTooLarge)
+			continue
+		}
+		if binary, err := f.IsBinary(); err != nil || binary {
+			continue
+		}
+		paths = append(paths, f.Name)
+	}
+
+	return paths, nil
+}
+
+// blameAll blames 'paths' at 'rev' into 'counts', a few files at a time so a
+// whole repository doesn't start thousands of git processes at once.
+func (r *ContributionCounter) blameAll(rev string, paths []string, attribute attributor,
+	counts *contributions) error {
+	defer r.Summary.stage("blame", time.Now())
+
+	var (
+		queue    = make(chan string)
+		reporter = make(chan fileReport)
+	)
+	for i := 0; i < runtime.NumCPU(); i++ {
+		go func() {
+			for p := range queue {
+				runAndReport(r.runContext(), p, rev, attribute, reporter)
+			}
+		}()
+	}
+	go func() {
+		for _, p := range paths {
+			queue <- p
+		}
+		close(queue)
+	}()
+
+	var firstErr error
+	for range paths {
+		if err := r.record(counts, <-reporter); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
@@ -0,0 +1,64 @@
+package gitreviewers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRepositoryOwnership(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	write := func(name, content string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("src/b.go", "package a\n\nvar b = 1\n")
+	write("logo.png", "\x89PNG\x00\x00\x00binary")
+	if err := os.MkdirAll(filepath.Join(dir, "vendor"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	write("vendor/c.go", "package c\n")
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-q", "--author", "Ben Franklin <ben@git-reviewer.com>",
+		"-m", "Add b")
+
+	repo, _, err := OpenRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := ContributionCounter{Repo: repo, Dir: dir, Since: "2000-01-01",
+		IgnoredPaths: []string{"vendor"}, Cache: NewMemoryCache(), CacheResults: true}
+
+	o, err := r.RepositoryOwnership()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	today := time.Now().Format("2006-01-02")
+	expected := []AuthorOwnership{
+		{Author: "ben@git-reviewer.com", Lines: 3, Files: 1, Share: 0.75, LastTouched: today},
+		{Author: "abe@git-reviewer.com", Lines: 1, Files: 1, Share: 0.25, LastTouched: today},
+	}
+	if !reflect.DeepEqual(o.Owners, expected) {
+		t.Errorf("Got %+v, expected %+v\n", o.Owners, expected)
+	}
+	if o.Files != 2 || o.Lines != 4 {
+		t.Errorf("Got %d lines in %d files, expected 4 lines in 2 files\n", o.Lines, o.Files)
+	}
+
+	// The second run is answered from the cache, even without the repository
+	r.Repo = nil
+	cached, err := r.RepositoryOwnership()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cached, o) {
+		t.Errorf("Got %+v from the cache, expected %+v\n", cached, o)
+	}
+}
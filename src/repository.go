@@ -0,0 +1,290 @@
+package gitreviewers
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/thedahv/git-reviewer/src/gitcmd"
+	"gopkg.in/src-d/go-billy.v3/osfs"
+	gogit "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/storage/filesystem"
+)
+
+// OpenRepository finds the repository containing 'path', looking in parent
+// directories the same way git does, and opens it. It returns the repository
+// along with the root of its working tree, which external git commands should
+// run from so that repository-relative paths resolve correctly.
+//
+// Linked worktrees created with `git worktree add` are supported. Their .git
+// is a file pointing at a private directory that only holds HEAD and the
+// index, while objects and refs live in the main repository.
+func OpenRepository(path string) (*gogit.Repository, string, error) {
+	root, err := findWorktreeRoot(path)
+	if err != nil {
+		return nil, "", &RepoNotFoundError{Path: path}
+	}
+
+	gitdir, err := readGitFile(filepath.Join(root, ".git"))
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Regular repositories and submodules are handled by go-git directly
+	common, err := commonDir(gitdir)
+	if gitdir == "" || err != nil {
+		repo, err := gogit.PlainOpen(root)
+		return repo, root, err
+	}
+
+	s, err := filesystem.NewStorage(osfs.New(common))
+	if err != nil {
+		return nil, "", errors.Wrap(err, "unable to open main repository of worktree")
+	}
+
+	head, err := ioutil.ReadFile(filepath.Join(gitdir, "HEAD"))
+	if err != nil {
+		return nil, "", errors.Wrap(err, "unable to read HEAD of worktree")
+	}
+
+	ws := worktreeStorage{
+		Storage: s,
+		head: plumbing.NewReferenceFromStrings(string(plumbing.HEAD),
+			strings.TrimSpace(string(head))),
+	}
+
+	repo, err := gogit.Open(ws, osfs.New(root))
+	return repo, root, err
+}
+
+// runner prepares external git commands that run from the root of the
+// working tree in Dir, or the current directory if Dir is empty, in the clean
+// environment gitcmd sets up.
+func (r *ContributionCounter) runner() gitcmd.Runner {
+	return gitcmd.Runner{Git: r.GitBin, Dir: r.Dir}
+}
+
+// gitAtLeast reports whether the git being run is 'v' or newer.
+func (r *ContributionCounter) gitAtLeast(v gitcmd.Version) bool {
+	if r.NoExec {
+		return false
+	}
+	have, err := r.runner().Version(context.Background())
+	return err == nil && have.AtLeast(v)
+}
+
+// output runs an external git command like git does and returns its standard
+// output. Failures are reported as a *GitError.
+func (r *ContributionCounter) output(args ...string) ([]byte, error) {
+	return r.outputContext(r.runContext(), args...)
+}
+
+// runContext is the context git commands run in, which is only ever done
+// while blaming for a canceled SuggestStream.
+func (r *ContributionCounter) runContext() context.Context {
+	if r.ctx == nil {
+		return context.Background()
+	}
+	return r.ctx
+}
+
+// outputContext is like output but kills git if 'ctx' is done before it
+// finishes.
+func (r *ContributionCounter) outputContext(ctx context.Context, args ...string) ([]byte, error) {
+	out, err := r.commandRunner().Output(ctx, args...)
+	if err != nil {
+		gerr := &GitError{Args: args, Dir: r.Dir, Err: err}
+		if exit, ok := err.(*exec.ExitError); ok {
+			gerr.Stderr = strings.TrimSpace(string(exit.Stderr))
+		}
+		return out, gerr
+	}
+
+	return out, nil
+}
+
+// mergeBase finds the commit where the 'a' and 'b' revisions diverged, with
+// go-git if NoExec is set.
+func (r *ContributionCounter) mergeBase(a, b string) (string, error) {
+	if r.NoExec {
+		mergeBase, _, _, err := r.compareGoGit(a, b)
+		return mergeBase, err
+	}
+
+	out, err := r.output("merge-base", a, b)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// baseRev names the revision changes are compared to.
+func (r *ContributionCounter) baseRev() string {
+	if r.Base == "" {
+		return "master"
+	}
+	return r.Base
+}
+
+// defaultBases are the branches DetectBase looks for, in order.
+var defaultBases = []string{"master", "main"}
+
+// DetectBase picks the revision to compare changes to when Base isn't set:
+// "master" or "main", whichever exists, or the branch origin/HEAD points to.
+// A repository with a single branch and none of those is compared to its
+// first commit, so a brand new project still gets suggestions.
+func (r *ContributionCounter) DetectBase() (string, error) {
+	if r.NoExec {
+		for _, branch := range defaultBases {
+			if _, err := r.resolveGoGit("refs/heads/" + branch); err == nil {
+				return branch, nil
+			}
+		}
+		return "", errors.Errorf("no '%s' branch found", strings.Join(defaultBases, "' or '"))
+	}
+
+	for _, branch := range defaultBases {
+		if _, err := r.output("rev-parse", "--verify", "--quiet",
+			"refs/heads/"+branch); err == nil {
+			return branch, nil
+		}
+	}
+
+	if out, err := r.output("symbolic-ref", "--quiet", "--short",
+		"refs/remotes/origin/HEAD"); err == nil {
+		return strings.TrimSpace(string(out)), nil
+	}
+
+	out, err := r.output("for-each-ref", "--format=%(refname)", "refs/heads/")
+	if err != nil {
+		return "", errors.Wrap(err, "unable to list branches")
+	}
+	if branches := strings.Fields(string(out)); len(branches) > 1 {
+		return "", errors.Errorf("no '%s' branch found",
+			strings.Join(defaultBases, "' or '"))
+	}
+
+	out, err = r.output("rev-list", "--max-parents=0", r.headRev())
+	if err != nil {
+		return "", errors.New("the repository has no commits yet")
+	}
+	roots := strings.Fields(string(out))
+
+	// A history joining several projects has several root commits, the
+	// oldest of which is listed last
+	return roots[len(roots)-1], nil
+}
+
+// headRev names the revision under review.
+func (r *ContributionCounter) headRev() string {
+	if r.Head == "" {
+		return "HEAD"
+	}
+	return r.Head
+}
+
+// resolve finds the commit a revision such as a branch name, tag or
+// "HEAD~2" points to. go-git only resolves full reference names, so we ask
+// git, unless NoExec is set.
+func (r *ContributionCounter) resolve(rev string) (plumbing.Hash, error) {
+	if r.NoExec {
+		return r.resolveGoGit(rev)
+	}
+
+	out, err := r.output("rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	if err != nil {
+		return plumbing.ZeroHash, errors.Wrapf(err, "unknown revision '%s'", rev)
+	}
+
+	return plumbing.NewHash(strings.TrimSpace(string(out))), nil
+}
+
+// findWorktreeRoot walks up from 'path' until it finds a directory with a .git
+// entry in it.
+func findWorktreeRoot(path string) (string, error) {
+	dir, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", gogit.ErrRepositoryNotExists
+		}
+		dir = parent
+	}
+}
+
+// readGitFile reads the directory a .git file points to. It returns an empty
+// string if .git is a regular directory.
+func readGitFile(dotgit string) (string, error) {
+	fi, err := os.Stat(dotgit)
+	if err != nil {
+		return "", err
+	}
+	if fi.IsDir() {
+		return "", nil
+	}
+
+	b, err := ioutil.ReadFile(dotgit)
+	if err != nil {
+		return "", err
+	}
+
+	const prefix = "gitdir: "
+	line := strings.TrimSpace(string(b))
+	if !strings.HasPrefix(line, prefix) {
+		return "", errors.Errorf("%s file has no %s prefix", dotgit, prefix)
+	}
+
+	gitdir := strings.TrimPrefix(line, prefix)
+	if !filepath.IsAbs(gitdir) {
+		gitdir = filepath.Join(filepath.Dir(dotgit), gitdir)
+	}
+
+	return gitdir, nil
+}
+
+// commonDir reads the location of the main repository out of the "commondir"
+// file of a linked worktree's git directory.
+func commonDir(gitdir string) (string, error) {
+	b, err := ioutil.ReadFile(filepath.Join(gitdir, "commondir"))
+	if err != nil {
+		return "", err
+	}
+
+	common := strings.TrimSpace(string(b))
+	if !filepath.IsAbs(common) {
+		common = filepath.Join(gitdir, common)
+	}
+
+	return common, nil
+}
+
+// worktreeStorage reads objects and refs from the main repository but answers
+// with the worktree's own HEAD, since each linked worktree has a branch of its
+// own checked out.
+type worktreeStorage struct {
+	*filesystem.Storage
+	head *plumbing.Reference
+}
+
+// Reference returns the worktree HEAD or looks up any other reference in the
+// main repository.
+func (s worktreeStorage) Reference(name plumbing.ReferenceName) (*plumbing.Reference, error) {
+	if name == plumbing.HEAD {
+		return s.head, nil
+	}
+
+	return s.Storage.Reference(name)
+}
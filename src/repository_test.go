@@ -0,0 +1,215 @@
+package gitreviewers
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+// runGit runs a git command in 'dir' with a fixed identity, failing the test
+// if it doesn't succeed.
+func runGit(t testing.TB, dir string, args ...string) string {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=Abraham Lincoln", "GIT_AUTHOR_EMAIL=abe@git-reviewer.com",
+		"GIT_COMMITTER_NAME=Abraham Lincoln", "GIT_COMMITTER_EMAIL=abe@git-reviewer.com")
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, out)
+	}
+
+	return string(out)
+}
+
+// newTestRepo creates a repository with a single commit on "master" and
+// returns its path. Callers should remove it when they are done.
+func newTestRepo(t testing.TB) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "git-reviewer-repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Resolve symlinked temp directories so paths compare cleanly
+	if dir, err = filepath.EvalSymlinks(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	runGit(t, dir, "init", "-q")
+	runGit(t, dir, "symbolic-ref", "HEAD", "refs/heads/master")
+	if err := os.MkdirAll(filepath.Join(dir, "src"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "src", "a.go"),
+		[]byte("package a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-q", "-m", "Initial commit")
+
+	return dir
+}
+
+func TestOpenRepositoryFromSubdirectory(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	_, root, err := OpenRepository(filepath.Join(dir, "src"))
+	if err != nil {
+		t.Fatalf("Unexpected error opening repository: %v\n", err)
+	}
+
+	if root != dir {
+		t.Errorf("Got root '%s', expected '%s'\n", root, dir)
+	}
+}
+
+func TestOpenRepositoryInWorktree(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	wt := dir + "-worktree"
+	defer os.RemoveAll(wt)
+	runGit(t, dir, "worktree", "add", "-q", "-b", "feature", wt)
+
+	repo, root, err := OpenRepository(wt)
+	if err != nil {
+		t.Fatalf("Unexpected error opening worktree: %v\n", err)
+	}
+
+	if root != wt {
+		t.Errorf("Got root '%s', expected '%s'\n", root, wt)
+	}
+
+	head, err := repo.Reference(plumbing.HEAD, false)
+	if err != nil {
+		t.Fatalf("Unexpected error reading HEAD: %v\n", err)
+	}
+	if target := head.Target(); target != "refs/heads/feature" {
+		t.Errorf("Got HEAD pointing at '%s', expected 'refs/heads/feature'\n", target)
+	}
+
+	if _, err := repo.Reference(plumbing.Master, true); err != nil {
+		t.Errorf("Expected master to resolve through the main repository: %v\n", err)
+	}
+}
+
+func TestOpenRepositoryOutsideRepository(t *testing.T) {
+	dir, err := ioutil.TempDir("", "git-reviewer-norepo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if _, _, err := OpenRepository(dir); err == nil {
+		t.Error("Expected an error opening a directory outside a repository")
+	}
+}
+
+func TestFindFilesBetweenRevisions(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	runGit(t, dir, "checkout", "-q", "-b", "feat-1")
+	if err := ioutil.WriteFile(filepath.Join(dir, "src", "a.go"),
+		[]byte("package a\n\nvar a = 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "commit", "-q", "-a", "-m", "Change a")
+
+	runGit(t, dir, "checkout", "-q", "-b", "feat-2")
+	if err := ioutil.WriteFile(filepath.Join(dir, "README"), []byte("Readme\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "add", "README")
+	runGit(t, dir, "commit", "-q", "-m", "Add readme")
+	runGit(t, dir, "checkout", "-q", "master")
+
+	repo, _, err := OpenRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := ContributionCounter{Repo: repo, Dir: dir, Base: "feat-1", Head: "feat-2",
+		IncludeAdded: true}
+	files, err := r.FindFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The only change on feat-2 is the new README, which has no relatives
+	if len(files) != 0 {
+		t.Errorf("Got %v, expected no files between feat-1 and feat-2\n", files)
+	}
+
+	r.Base = ""
+	r.Head = "feat-2~1"
+	files, err = r.FindFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0] != "src/a.go" {
+		t.Errorf("Got %v, expected [src/a.go]\n", files)
+	}
+
+	r.Head = "nope"
+	if _, err := r.FindFiles(); err == nil {
+		t.Error("Expected an error for an unknown revision")
+	}
+}
+
+func TestDetectBase(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	r := ContributionCounter{Dir: dir}
+	root := strings.TrimSpace(runGit(t, dir, "rev-parse", "HEAD"))
+
+	// A single branch without master or main falls back to the first commit
+	runGit(t, dir, "branch", "-q", "-m", "master", "trunk")
+	runGit(t, dir, "commit", "-q", "--allow-empty", "-m", "Second commit")
+	if base, err := r.DetectBase(); err != nil || base != root {
+		t.Errorf("Got '%s' (error: %v), expected the first commit '%s'\n", base, err, root)
+	}
+
+	runGit(t, dir, "branch", "-q", "feature")
+	if base, err := r.DetectBase(); err == nil {
+		t.Errorf("Got '%s', expected an error with several branches\n", base)
+	}
+
+	runGit(t, dir, "branch", "-q", "main")
+	if base, err := r.DetectBase(); err != nil || base != "main" {
+		t.Errorf("Got '%s' (error: %v), expected 'main'\n", base, err)
+	}
+
+	runGit(t, dir, "branch", "-q", "master")
+	if base, err := r.DetectBase(); err != nil || base != "master" {
+		t.Errorf("Got '%s' (error: %v), expected 'master'\n", base, err)
+	}
+}
+
+func TestDetectBaseWithoutCommits(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "git-reviewer-repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	runGit(t, dir, "init", "-q")
+
+	r := ContributionCounter{Dir: dir}
+	if base, err := r.DetectBase(); err == nil {
+		t.Errorf("Got '%s', expected an error without commits\n", base)
+	}
+}
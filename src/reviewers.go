@@ -1,20 +1,22 @@
 package gitreviewers
 
 import (
-	"bufio"
 	"bytes"
 	"container/heap"
+	"context"
 	"fmt"
+	"log"
 	"os"
-	"os/exec"
-	"os/user"
+	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
-	"sync"
 	"text/tabwriter"
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/thedahv/git-reviewer/src/blame"
+	"github.com/thedahv/git-reviewer/src/gitcmd"
 	gogit "gopkg.in/src-d/go-git.v4"
 	"gopkg.in/src-d/go-git.v4/plumbing"
 	"gopkg.in/src-d/go-git.v4/plumbing/object"
@@ -32,6 +34,223 @@ type ContributionCounter struct {
 	IgnoredPaths      []string
 	OnlyPaths         []string
 	Mailmap           mailmap
+	CacheDir          string
+	WorkingTree       bool
+	Diverse           bool
+	Teams             map[string]string
+	RecentDays        int
+	IncludeLearners   bool
+	OwnershipAlert    float64
+	Dir               string
+	IncludeLFS        bool
+	// InitialImport credits lines from boundary commits, such as the root
+	// commit of a project imported into git, to a pseudo-author that is
+	// never suggested instead of to whoever made the import.
+	InitialImport bool
+	// Show selects how experience is displayed: ShowPercent (the default),
+	// ShowCounts or ShowBoth.
+	Show string
+	// IncludeAdded considers the owners of similar existing files for files
+	// that were added on the branch, see closestRelatives.
+	IncludeAdded bool
+	// IncludeUntracked considers the owners of similar existing files for
+	// new files that aren't committed yet, whether untracked or only staged,
+	// leaving out those git ignores. It only has an effect with WorkingTree.
+	IncludeUntracked bool
+	// DirWeight is how much the lines of those similar existing files count
+	// compared to the lines of changed files, from 0 to 1. It is 1 if nil, so
+	// knowing the neighborhood of new files counts as much as knowing the
+	// changed files themselves, and 0 leaves them out of everyone's share.
+	DirWeight *float64
+	// Base is the revision changes are compared to, "master" if empty. Head is
+	// the revision under review, HEAD if empty.
+	Base string
+	Head string
+	// Logger receives progress and error information when Verbose is set.
+	// It is printed to stdout if nil.
+	Logger *log.Logger
+	// Summary collects statistics about the run when set.
+	Summary *RunSummary
+	// IgnoreMerges credits lines from merge and revert commits, which can
+	// carry a lot of lines nobody really wrote in them, to the commits they
+	// brought in instead.
+	IgnoreMerges bool
+
+	// massRevs remembers which blamed commits are merges or reverts across
+	// the files of a run.
+	massRevs *revCache
+	// PerFile weighs every changed file equally when computing ownership, by
+	// averaging the share of lines each author owns in each file, so one huge
+	// file doesn't drown out many small ones.
+	PerFile bool
+	// Cache stores the answers of provider APIs, and suggestions with
+	// CacheResults. CacheDir is a shortcut for a FileCache in that directory
+	// when Cache is nil.
+	Cache Cache
+	// CacheResults also stores suggestions and repository ownership in Cache,
+	// so running again with the same commits and options is instant.
+	CacheResults bool
+	// NoDefaultIgnores considers files with the extensions that are ignored
+	// by default, such as JSON and XML.
+	NoDefaultIgnores bool
+	// DefaultIgnoredExtensions replaces the extensions ignored by default
+	// when it isn't nil.
+	DefaultIgnoredExtensions []string
+	// Presets names the filter presets whose filters are added to the
+	// extension and path filters by NormalizeFilters, see BuiltinPresets.
+	// CustomPresets are defined in the configuration and replace the
+	// built-in presets of the same name.
+	Presets       []string
+	CustomPresets []Preset
+	// SensitiveRules add mandatory reviewers to changes touching sensitive
+	// paths.
+	SensitiveRules []SensitiveRule
+	// DefaultRules add reviewers for changed files nobody owns, such as
+	// files in new directories.
+	DefaultRules []DefaultRule
+	// InfraReviewers are put ahead of everyone else when infrastructure
+	// files change, see IsInfraFile. InfraPaths are glob patterns, like
+	// those of SensitiveRule, of more files to treat as infrastructure.
+	InfraReviewers []string
+	InfraPaths     []string
+	// Signals collects the raw evidence suggestions are made from when it
+	// isn't nil. Cached suggestions are skipped while collecting.
+	Signals *Signals
+	// Bundle captures what suggestions are made from, to replay them
+	// offline, when it isn't nil. Cached suggestions are skipped while
+	// capturing.
+	Bundle *Bundle
+	// ProviderHosts maps the host names of self-hosted GitHub, GitLab,
+	// Bitbucket or Gerrit instances to one of the Provider constants.
+	ProviderHosts map[string]string
+	// Language is one of Languages to display messages in. It defaults to
+	// English.
+	Language string
+	// MaxFileSize is the size in bytes above which changed files are skipped
+	// instead of blamed, DefaultMaxFileSize if 0. BlameTimeout is how long
+	// blaming a single file may take before it is skipped, DefaultBlameTimeout
+	// if 0. Negative values remove the limits.
+	MaxFileSize  int64
+	BlameTimeout time.Duration
+	// Topics boosts candidates whose past commit messages mention the same
+	// topics as the commits under review and Title, such as "caching",
+	// catching expertise file ownership misses.
+	Topics bool
+	// Title describes the changes under review, such as the title of their
+	// pull request.
+	Title string
+	// Tickets, if set, boosts candidates who worked on tickets in the same
+	// areas as the tickets referenced by the commits under review and Title.
+	// Tickets are found with TicketPattern, DefaultTicketPattern if nil.
+	Tickets       TicketProvider
+	TicketPattern *regexp.Regexp
+	// Availability slightly boosts candidates whose working hours overlap
+	// those of the author of the changes, so reviews don't wait a day on
+	// someone asleep. Timezones maps emails to IANA time zone names, such as
+	// "Europe/Paris"; the author's zone defaults to the offset of their
+	// latest commit and candidates not in Timezones aren't boosted.
+	Availability bool
+	Timezones    map[string]string
+	// WarnTakeovers warns about changed files the branch makes someone the
+	// majority owner of, taking them over from someone else who may want to
+	// review the changes. It blames the changed files a second time, at the
+	// head of the branch.
+	WarnTakeovers bool
+	// BalanceLoad leaves out candidates who have as many open reviews as
+	// their capacity or more, suggesting the next best candidates in their
+	// place. Capacities maps emails to how many reviews each person takes on
+	// at a time, such as in a week; people not in it have no cap.
+	// OpenReviews counts the open reviews someone is asked for, as the
+	// provider hosting the reviews reports them. Mandatory and default
+	// reviewers are still suggested at capacity.
+	BalanceLoad bool
+	Capacities  map[string]int
+	OpenReviews func(email string) (int, error)
+	// FirstParent follows only the first parent of merge commits when
+	// blaming and reading history, so lines and commits that came from a
+	// merged branch, such as a long-lived fork, are credited to the merge
+	// instead of to the commits made on that branch.
+	FirstParent bool
+	// MaxHistory limits how far back blame follows history, such as 90d, 6w,
+	// 18m or 2y, counted back from the same moment the default Since is.
+	// Blaming old files gets faster, and lines older than the limit are
+	// left uncredited, so Since has no effect past it.
+	MaxHistory string
+	// BlameAt is one of BlameAtOptions and picks the revision changed files
+	// are blamed at. It defaults to the base revision.
+	BlameAt string
+	// ExcludeLines names kinds of lines, out of ExcludeLineKinds, that count
+	// towards nobody's ownership, since owning boilerplate isn't expertise.
+	// Lines matching one of ExcludeLinePatterns, regular expressions, are left
+	// out too.
+	ExcludeLines        []string
+	ExcludeLinePatterns []string
+	// OwnersPolicy is one of OwnersPolicies and picks how OWNERS files listing
+	// the owners of each directory weigh in. It defaults to OwnersOff.
+	// OwnerEmails maps the usernames they list, lower-cased, to emails, such
+	// as IdentityCache.Emails does.
+	OwnersPolicy string
+	OwnerEmails  map[string]string
+	// AsOf measures ownership as it was at a revision or YYYY-MM-DD date in
+	// the base revision's history, for historical audits: changed files are
+	// blamed there, later commits don't count and Since and RecentDays count
+	// back from it. It overrides BlameAt.
+	AsOf string
+
+	// SinceFrom is one of SinceFromOptions and picks what the default Since
+	// counts 6 months back from. It defaults to SinceFromMergeBase.
+	SinceFrom string
+	// GitBin is the git executable to run, "git" from PATH if empty. It must
+	// be gitcmd.MinVersion or newer.
+	GitBin string
+	// Runner runs git commands, FS opens files such as mailmap and teams
+	// files, and User finds the home directory. They default to running
+	// GitBin in Dir, the real filesystem and the user running the process,
+	// and let tests simulate them.
+	Runner CommandRunner
+	FS     FS
+	User   UserInfo
+	// NoExec guarantees that no external process is started and no network
+	// request is made, for restricted sandboxes: history is read and blamed
+	// with go-git instead of git, and provider APIs only answer from the
+	// cache. Renames aren't detected, merges and reverts are credited like
+	// any commit, and commands that need git fail with ErrExecDisabled.
+	NoExec bool
+	// Ranker is one of RankerOptions and picks how candidates are ranked. It
+	// defaults to RankerHeuristic.
+	Ranker string
+	// Sort is one of SortOptions and orders the table of FindReviewers. It
+	// only changes how the suggested reviewers are listed, not which ones
+	// are picked, and they are listed in the order they were picked if it is
+	// empty.
+	Sort string
+
+	// added holds the files FindFiles found added on the branch, see
+	// AddedFiles, and standIns the existing files it considered in their
+	// place, whose lines are weighed by DirWeight.
+	added    []string
+	standIns map[string]bool
+	// changes holds every file FindFiles found changed, including those it
+	// left out, see ChangedFiles.
+	changes []ChangedFile
+	// asOfPoint is AsOf once resolved, see asOf.
+	asOfPoint *asOfPoint
+	// historyStart is the day MaxHistory goes back to, once resolved, see
+	// resolveHistoryStart.
+	historyStart string
+	// creditBranch credits the commits made on the branch when blaming at its
+	// head, to measure ownership as it will be once merged rather than who
+	// could review it, see countsAt.
+	creditBranch bool
+	// skipRevs holds the commits, sorted, whose lines credit nobody, see
+	// skipped.
+	skipRevs []string
+	// badCapacities holds the reviewer.capacity entries ApplyConfig couldn't
+	// read, for Validate to report.
+	badCapacities []string
+	// ctx stops the git commands of the copy blaming for SuggestStream once
+	// it is done, see runContext.
+	ctx context.Context
 }
 
 // Stat contains information about a collaborator and the total "experience"
@@ -40,6 +259,45 @@ type ContributionCounter struct {
 type Stat struct {
 	Reviewer   string
 	Percentage float64
+	// Learner marks a reviewer suggested to spread knowledge of the code
+	// rather than for their experience with it.
+	Learner bool
+	// Lines and Files count the lines owned and the changed files touched.
+	Lines int
+	Files int
+	// Required names the sensitive rules that make this reviewer mandatory.
+	Required []string
+	// Boost is added to Percentage when ranking reviewers, for expertise that
+	// doesn't show in the lines they own. Topics lists the topics of the
+	// changes their commit messages mention, and Tickets the tickets they
+	// worked on in the same areas as the changes.
+	Boost   float64
+	Topics  []string
+	Tickets []string
+	// Owners lists the OWNERS files naming the reviewer as an owner of the
+	// changed files.
+	Owners []string
+	// Defaults names the DefaultRules the reviewer is suggested by, for
+	// changed files nobody owns.
+	Defaults []string
+	// Infra counts the changed infrastructure files the reviewer is routed
+	// as one of InfraReviewers.
+	Infra int
+	// Overlap counts the working hours the reviewer shares with the author
+	// of the changes, see Availability.
+	Overlap int
+	// Substitutes names the reviewer at capacity this one is suggested in
+	// place of, see BalanceLoad.
+	Substitutes string
+	// Trend counts the lines the reviewer owns by the quarter they were
+	// committed in, for the last TrendQuarters quarters, oldest first.
+	Trend []int
+	// Score is the chance RankerLearned gives the reviewer of reviewing the
+	// changes. It ranks reviewers ahead of experience when set.
+	Score float64
+	// LastTouched is the most recent day one of the reviewer's lines was
+	// committed, as YYYY-MM-DD.
+	LastTouched string
 }
 
 // String shows Stat information in a format suitable for shell reporting.
@@ -47,6 +305,40 @@ func (cs *Stat) String() string {
 	return fmt.Sprintf("  %.2f%%\t%s", cs.Percentage*100.0, cs.Reviewer)
 }
 
+// Notes describes why a reviewer is suggested other than for experience, to
+// be shown after their name. It is empty for regular suggestions.
+func (cs *Stat) Notes() string {
+	var notes string
+	if cs.Learner {
+		notes += " (learning reviewer)"
+	}
+	if len(cs.Required) > 0 {
+		notes += fmt.Sprintf(" (mandatory: %s)", strings.Join(cs.Required, ", "))
+	}
+	if len(cs.Topics) > 0 {
+		notes += fmt.Sprintf(" (topics: %s)", strings.Join(cs.Topics, ", "))
+	}
+	if len(cs.Tickets) > 0 {
+		notes += fmt.Sprintf(" (tickets: %s)", strings.Join(cs.Tickets, ", "))
+	}
+	if len(cs.Owners) > 0 {
+		notes += fmt.Sprintf(" (owners: %s)", strings.Join(cs.Owners, ", "))
+	}
+	if len(cs.Defaults) > 0 {
+		notes += fmt.Sprintf(" (default: %s)", strings.Join(cs.Defaults, ", "))
+	}
+	if cs.Infra > 0 {
+		notes += fmt.Sprintf(" (infra: %s)", pluralize(cs.Infra, "file"))
+	}
+	if cs.Overlap > 0 {
+		notes += fmt.Sprintf(" (overlap: %s)", pluralize(cs.Overlap, "hour"))
+	}
+	if cs.Substitutes != "" {
+		notes += fmt.Sprintf(" (instead of %s, at capacity)", cs.Substitutes)
+	}
+	return notes
+}
+
 // Stats is a collection of all the collaboration statistics obtained across
 // changes in a repository. By defining our own slice type, we are able to
 // add methods to implement the Heap interface, which we use to determine
@@ -58,11 +350,25 @@ func (s Stats) Len() int {
 	return len(s)
 }
 
-// Less sorts Stats by percentage of "owned" lines per collaborator.
+// Less sorts Stats by learned score, then by percentage of "owned" lines per
+// collaborator, boosted by other signals of expertise. Ties are broken by
+// email, so reviewers who rank the same are listed alphabetically from one
+// run to the next.
 func (s Stats) Less(i, j int) bool {
+	return ranksBelow(s[i], s[j])
+}
+
+// ranksBelow reports whether 'a' is a worse pick than 'b'.
+func ranksBelow(a, b *Stat) bool {
 	// This behavior determines the priority order when Stats is Heapified.
 	// We want Pop to give us the highest, not lowest, priority.
-	return s[i].Percentage < s[j].Percentage
+	if a.Score != b.Score {
+		return a.Score < b.Score
+	}
+	if a.Percentage+a.Boost != b.Percentage+b.Boost {
+		return a.Percentage+a.Boost < b.Percentage+b.Boost
+	}
+	return a.Reviewer > b.Reviewer
 }
 
 // Swap moves elements around to their proper location in the heap
@@ -117,25 +423,49 @@ func (r *ContributionCounter) BuildMailmap(paths ...string) {
 	// If no paths specified, attempt by guessing that it will be in the user's
 	// home path.
 	if len(paths) == 0 {
-		if path, err := guessUserMailmap(); err == nil {
+		if path, err := r.guessUserMailmap(); err == nil {
 			paths = append(paths, path)
 		}
 	}
 
-	if mm, err := readMailmap(paths); err == nil {
+	if mm, err := readMailmap(r.open, paths); err == nil {
 		r.Mailmap = mm
 	}
 }
 
+// BuildTeams reads the team each collaborator belongs to from the file at
+// 'path'. Each line names a team followed by the email of a member, e.g.
+// "Platform <abe@git-reviewer.com>". Emails are resolved through the mailmap,
+// so BuildMailmap should be called first.
+func (r *ContributionCounter) BuildTeams(path string) error {
+	f, err := r.open(path)
+	if err != nil {
+		return errors.Wrap(err, "unable to open teams file")
+	}
+	defer f.Close()
+
+	teams, err := readTeams(f)
+	if err != nil {
+		return errors.Wrap(err, "unable to read teams file")
+	}
+
+	r.Teams = make(map[string]string)
+	for email, team := range teams {
+		r.Teams[reviewerKey(email, r.Mailmap)] = team
+	}
+
+	return nil
+}
+
 // Attempt to guess the user's mailmap path by looking for it in the home
 // directory.
-func guessUserMailmap() (string, error) {
-	u, err := user.Current()
+func (r *ContributionCounter) guessUserMailmap() (string, error) {
+	home, err := r.homeDir()
 	if err != nil {
 		return "", err
 	}
-	path := u.HomeDir + "/.mailmap"
-	if f, err := os.Open(path); err == nil {
+	path := home + "/.mailmap"
+	if f, err := r.open(path); err == nil {
 		f.Close()
 		return path, nil
 	} else {
@@ -143,125 +473,511 @@ func guessUserMailmap() (string, error) {
 	}
 }
 
-// BranchBehind determines if the current branch is "behind"
-// by comparing the current branch HEAD reference to that of the local ref of
-// the master branch.
-func (r *ContributionCounter) BranchBehind() (bool, error) {
+// BranchStatus describes how the current branch relates to its base.
+type BranchStatus struct {
+	// Base names the branch HEAD is compared to.
+	Base string
+	// Ahead counts the commits on HEAD that are not in Base, and Behind the
+	// commits in Base that HEAD doesn't have yet.
+	Ahead  int
+	Behind int
+	// MergeBase is the commit where HEAD diverged from Base.
+	MergeBase string
+}
+
+// IsBehind reports whether Base has commits the branch hasn't merged yet.
+func (s BranchStatus) IsBehind() bool {
+	return s.Behind > 0
+}
+
+// Err returns a *BranchBehindError if the branch is behind, or nil.
+func (s BranchStatus) Err() error {
+	if s.IsBehind() {
+		return &BranchBehindError{Status: s}
+	}
+	return nil
+}
+
+// String describes the status in the words of `git status`.
+func (s BranchStatus) String() string {
+	switch {
+	case s.Ahead > 0 && s.Behind > 0:
+		return fmt.Sprintf("Your branch and %s have diverged, and have %s and %s "+
+			"each, respectively", s.Base, pluralize(s.Ahead, "commit"),
+			pluralize(s.Behind, "commit"))
+	case s.Behind > 0:
+		return fmt.Sprintf("Your branch is %s behind %s", pluralize(s.Behind, "commit"), s.Base)
+	case s.Ahead > 0:
+		return fmt.Sprintf("Your branch is %s ahead of %s", pluralize(s.Ahead, "commit"), s.Base)
+	default:
+		return fmt.Sprintf("Your branch is up to date with %s", s.Base)
+	}
+}
+
+// BranchStatus counts the commits the head revision is ahead and behind the
+// base revision and finds the point where they diverged.
+func (r *ContributionCounter) BranchStatus() (BranchStatus, error) {
+	defer r.Summary.stage("branch status", time.Now())
+
 	var (
-		behind bool
-		h      *plumbing.Reference
-		hObj   *object.Commit
-		m      *plumbing.Reference
-		mObj   *object.Commit
+		status = BranchStatus{Base: r.baseRev()}
+		base   string
+		head   string
+		out    []byte
 		rg     runGuard
 	)
 
-	rg.maybeRunMany(
-		func() {
-			m, rg.err = r.Repo.Reference(plumbing.Master, true)
-			rg.msg = "issue opening master reference"
-		},
-		func() {
-			h, rg.err = r.Repo.Reference(plumbing.HEAD, true)
-			rg.msg = "issue opening HEAD reference"
-		},
-		func() {
-			mObj, rg.err = r.Repo.CommitObject(m.Hash())
-			rg.msg = "issue opening master commit"
-		},
-		func() {
-			hObj, rg.err = r.Repo.CommitObject(h.Hash())
-			rg.msg = "issue opening HEAD commit"
-		},
-		func() {
-			behind = hObj.Committer.When.Before(mObj.Committer.When)
-			rg.msg = "issue comparing commit dates"
-		},
-	)
+	if r.NoExec {
+		rg.maybeRunMany(
+			func() {
+				base, head, rg.err = r.branchTips()
+				rg.msg = "issue opening branch tips"
+			},
+			func() {
+				status.MergeBase, status.Behind, status.Ahead, rg.err = r.compareGoGit(base, head)
+				rg.msg = "issue comparing branches"
+			},
+		)
+	} else {
+		rg.maybeRunMany(
+			func() {
+				base, head, rg.err = r.branchTips()
+				rg.msg = "issue opening branch tips"
+			},
+			func() {
+				status.MergeBase, rg.err = r.mergeBase(base, head)
+				rg.msg = "issue finding merge base"
+			},
+			func() {
+				out, rg.err = r.output("rev-list", "--left-right", "--count",
+					base+"..."+head)
+				rg.msg = "issue counting commits"
+			},
+			func() {
+				_, rg.err = fmt.Sscan(string(out), &status.Behind, &status.Ahead)
+				rg.msg = "issue parsing commit counts"
+			},
+		)
+	}
 
-	if rg.err != nil && rg.msg != "" && r.Verbose {
-		fmt.Printf("Error comparing branches: '%s'\n", rg.msg)
+	if rg.err != nil && rg.msg != "" {
+		r.logf("Error comparing branches: '%s'\n", rg.msg)
 	}
 
-	return behind, rg.err
+	return status, rg.err
+}
+
+// BranchBehind determines if the current branch is "behind" "master", that is
+// if "master" has commits the branch doesn't.
+//
+// Deprecated: use BranchStatus, which also reports how far ahead and behind
+// the branch is and where it diverged.
+func (r *ContributionCounter) BranchBehind() (bool, error) {
+	status, err := r.BranchStatus()
+	return status.IsBehind(), err
 }
 
 // FindFiles returns a list of paths to files that have been changed
-// in this branch with respect to "master".
+// in this branch with respect to the base revision, "master" by default. If WorkingTree is set, uncommitted
+// changes to tracked files are included as well. Files tracked by Git LFS are
+// left out unless IncludeLFS is set. If IncludeAdded is set, files added on the
+// branch are replaced by their closest relatives in "master". New files that
+// aren't committed yet are replaced the same way if WorkingTree and
+// IncludeUntracked are set.
 func (r *ContributionCounter) FindFiles() ([]string, error) {
+	defer r.Summary.stage("diff", time.Now())
+
 	var (
 		changes object.Changes
-		h       *plumbing.Reference
+		h       plumbing.Hash
 		hc      *object.Commit
 		ht      *object.Tree
-		m       *plumbing.Reference
+		m       plumbing.Hash
 		mc      *object.Commit
 		mt      *object.Tree
 		paths   []string
-		rg      runGuard
+		added   []string
+		// uncommitted holds new files of the working tree, see
+		// IncludeUntracked
+		uncommitted []string
+		renamed     map[string]string
+		rg          runGuard
 	)
 
 	set := make(map[string]bool)
+	standIns := make(map[string]bool)
+
+	// changed holds every change seen, by name at head, and atBase the ones
+	// that existed at base by their name there, which the filters of 'set'
+	// go by
+	changed := make(map[string]*ChangedFile)
+	atBase := make(map[string]*ChangedFile)
+	note := func(f ChangedFile) *ChangedFile {
+		if c, ok := changed[f.Path]; ok {
+			return c
+		}
+		c := &f
+		changed[f.Path] = c
+		switch {
+		case f.From != "":
+			atBase[f.From] = c
+		case f.Change != ChangeAdded:
+			atBase[f.Path] = c
+		}
+		return c
+	}
 
 	rg.maybeRunMany(
 		func() {
-			m, rg.err = r.Repo.Reference(plumbing.Master, true)
-			rg.msg = "issue opening master ref"
+			m, rg.err = r.resolve(r.baseRev())
+			rg.msg = "issue resolving base revision"
 		},
 		func() {
-			mc, rg.err = r.Repo.CommitObject(m.Hash())
-			rg.msg = "issue opening master commit"
+			mc, rg.err = r.Repo.CommitObject(m)
+			rg.msg = "issue opening base commit"
 		},
 		func() {
 			mt, rg.err = mc.Tree()
-			rg.msg = "issue opening tree at master"
+			rg.msg = "issue opening tree at base"
 		},
 		func() {
-			h, rg.err = r.Repo.Reference(plumbing.HEAD, true)
-			rg.msg = "issue opening HEAD ref"
+			h, rg.err = r.resolve(r.headRev())
+			rg.msg = "issue resolving head revision"
 		},
 		func() {
-			hc, rg.err = r.Repo.CommitObject(h.Hash())
-			rg.msg = "issue opening HEAD commit"
+			hc, rg.err = r.Repo.CommitObject(h)
+			rg.msg = "issue opening head commit"
 		},
 		func() {
 			ht, rg.err = hc.Tree()
-			rg.msg = "issue opening tree at HEAD"
+			rg.msg = "issue opening tree at head"
 		},
 		func() {
 			changes, rg.err = object.DiffTree(mt, ht)
-			rg.msg = "issue diffing master and head trees"
+			rg.msg = "issue diffing base and head trees"
+		},
+		func() {
+			renamed, rg.err = r.renames(m.String(), h.String())
+			rg.msg = "issue detecting renamed files"
 		},
 		func() {
+			targets := make(map[string]bool)
+			for _, to := range renamed {
+				targets[to] = true
+			}
+
 			for _, ch := range changes {
 				// Only keep the names that existed in "master" before the change.
 				// Otherwise we'll try to 'blame' files that don't exist in master if a
 				// file was created or renamed in the development branch.
 				n := ch.From.Name
-				if len(n) > 0 && considerExt(n, r) && considerPath(n, r) {
+				switch {
+				case len(n) == 0:
+					if !targets[ch.To.Name] {
+						added = append(added, ch.To.Name)
+						note(ChangedFile{Path: ch.To.Name, Change: ChangeAdded})
+					}
+				case renamed[n] != "":
+					r.Summary.diffed(n)
+					c := note(ChangedFile{Path: renamed[n], From: n, Change: ChangeRenamed})
+					if considerMove(n, renamed[n], r) {
+						set[n] = true
+					} else {
+						r.Summary.filtered(n, "moved")
+						c.Excluded = "moved"
+					}
+				default:
+					c := note(ChangedFile{Path: n, Change: ChangeModified})
+					if len(ch.To.Name) == 0 {
+						c.Change = ChangeDeleted
+					}
+					if r.consider(n) {
+						set[n] = true
+					} else {
+						c.Excluded = r.filterReason(n)
+					}
+				}
+			}
+		},
+		func() {
+			if !r.WorkingTree {
+				return
+			}
+
+			var names []string
+			names, rg.err = r.workingTreeChanges(m.String())
+			rg.msg = "issue diffing master and the working tree"
+
+			for _, n := range names {
+				// Same as above: uncommitted files that don't exist in master have
+				// nothing to blame.
+				if _, err := mt.FindEntry(n); err != nil {
+					if _, err := ht.FindEntry(n); err != nil {
+						note(ChangedFile{Path: n, Change: ChangeAdded})
+						if r.IncludeUntracked {
+							uncommitted = append(uncommitted, n)
+						}
+					}
+					continue
+				}
+
+				c := note(ChangedFile{Path: n, Change: ChangeModified})
+				if _, err := os.Lstat(filepath.Join(r.Dir, n)); os.IsNotExist(err) {
+					c.Change = ChangeDeleted
+				}
+				if r.consider(n) {
 					set[n] = true
+				} else {
+					c.Excluded = r.filterReason(n)
+				}
+			}
+		},
+		func() {
+			if !r.WorkingTree || !r.IncludeUntracked {
+				return
+			}
+
+			var names []string
+			names, rg.err = r.untrackedFiles()
+			rg.msg = "issue listing untracked files"
+			uncommitted = append(uncommitted, names...)
+			for _, n := range names {
+				note(ChangedFile{Path: n, Change: ChangeAdded})
+			}
+		},
+		func() {
+			newFiles := uncommitted
+			if r.IncludeAdded {
+				newFiles = append(append([]string{}, added...), uncommitted...)
+			}
+
+			for _, n := range newFiles {
+				if !r.consider(n) {
+					continue
+				}
+
+				relatives := closestRelatives(mt, n, r)
+				changed[n].Relatives = relatives
+				for _, rel := range relatives {
+					r.logf("Considering owners of %s for new file %s\n", rel, n)
+					// Changed files count fully even if they stand in for
+					// new ones too
+					if !set[rel] {
+						standIns[rel] = true
+					}
+					set[rel] = true
+				}
+			}
+		},
+		func() {
+			// LFS files are attributed by commit history when included, see
+			// generateCounts
+			if r.IncludeLFS {
+				return
+			}
+
+			var names []string
+			for n := range set {
+				names = append(names, n)
+			}
+			for n := range lfsPaths(mt, names) {
+				r.logf("Skipping Git LFS file %s\n", n)
+				r.Summary.filtered(n, "lfs")
+				if c := atBase[n]; c != nil {
+					c.Excluded = "lfs"
 				}
+				delete(set, n)
+			}
+		},
+		func() {
+			// Extension filters miss binaries with unusual extensions, so
+			// the content at base is checked too
+			var names []string
+			for n := range set {
+				names = append(names, n)
+			}
+			for n := range binaryPaths(mt, names) {
+				r.logf("Skipping binary file %s\n", n)
+				r.Summary.filtered(n, "binary")
+				if c := atBase[n]; c != nil {
+					c.Excluded = "binary"
+				}
+				delete(set, n)
 			}
 		},
 	)
 
-	if rg.err != nil && rg.msg != "" && r.Verbose {
-		fmt.Printf("Error finding diff files: '%s'\n", rg.msg)
+	if rg.err != nil && rg.msg != "" {
+		r.logf("Error finding diff files: '%s'\n", rg.msg)
 	}
 
 	for path := range set {
 		paths = append(paths, path)
 	}
 
+	r.standIns = standIns
+
+	r.added = nil
+	for _, n := range append(added, uncommitted...) {
+		if r.consider(n) {
+			r.added = append(r.added, n)
+		}
+	}
+	sort.Strings(r.added)
+
+	// Added files are only blamed through their relatives
+	r.changes = nil
+	for _, c := range changed {
+		if c.Change == ChangeAdded && c.Excluded == "" {
+			c.Excluded = r.filterReason(c.Path)
+			if c.Excluded == "" && len(c.Relatives) == 0 {
+				c.Excluded = "added"
+			}
+		}
+		r.changes = append(r.changes, *c)
+	}
+	sort.Slice(r.changes, func(i, j int) bool { return r.changes[i].Path < r.changes[j].Path })
+
 	return paths, rg.err
 }
 
+// AddedFiles lists the files the last call to FindFiles found added on the
+// branch. FindFiles leaves them out since they have no history to blame, but
+// they are matched against DefaultRules when suggesting reviewers.
+func (r *ContributionCounter) AddedFiles() []string {
+	return r.added
+}
+
+// untrackedFiles lists the files of the working tree git doesn't track yet,
+// leaving out those it ignores.
+func (r *ContributionCounter) untrackedFiles() ([]string, error) {
+	out, err := r.output("ls-files", "-z", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list untracked files")
+	}
+
+	var names []string
+	for _, n := range bytes.Split(out, []byte{0}) {
+		if len(n) > 0 {
+			names = append(names, string(n))
+		}
+	}
+
+	return names, nil
+}
+
+// workingTreeChanges lists the paths that differ between a revision and the
+// working tree, including staged and unstaged changes.
+func (r *ContributionCounter) workingTreeChanges(rev string) ([]string, error) {
+	out, err := r.output("diff", "--name-only", "-z", "--no-renames", rev)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to execute external git diff command")
+	}
+
+	var names []string
+	for _, n := range bytes.Split(out, []byte{0}) {
+		if len(n) > 0 {
+			names = append(names, string(n))
+		}
+	}
+
+	return names, nil
+}
+
+// renames finds the files git detects as renamed between two revisions and
+// maps their old names to their new ones. go-git only reports renames as a
+// deletion and an addition, so we rely on git's similarity detection.
+func (r *ContributionCounter) renames(base, head string) (map[string]string, error) {
+	if r.NoExec {
+		return map[string]string{}, nil
+	}
+
+	out, err := r.output("diff", "-M", "--diff-filter=R", "--name-status", "-z",
+		base, head)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to execute external git diff command")
+	}
+
+	return parseRenames(out), nil
+}
+
+// parseRenames reads the NUL separated "R<score> old new" records of
+// `git diff --name-status -z` output.
+func parseRenames(out []byte) map[string]string {
+	renamed := make(map[string]string)
+
+	fields := bytes.Split(out, []byte{0})
+	for i := 0; i+2 < len(fields); i += 3 {
+		if !bytes.HasPrefix(fields[i], []byte("R")) {
+			break
+		}
+		renamed[string(fields[i+1])] = string(fields[i+2])
+	}
+
+	return renamed
+}
+
+// consider applies the extension and path filters to a changed path and
+// records why it was left out, if it was.
+func (r *ContributionCounter) consider(path string) bool {
+	r.Summary.diffed(path)
+
+	if reason := r.filterReason(path); reason != "" {
+		r.Summary.filtered(path, reason)
+		return false
+	}
+
+	return true
+}
+
+// filterReason names the filter leaving 'path' out, "extension" or "path", or
+// is empty if the filters keep it.
+func (r *ContributionCounter) filterReason(path string) string {
+	switch {
+	case !considerExt(path, r):
+		return "extension"
+	case !considerPath(path, r):
+		return "path"
+	}
+
+	return ""
+}
+
+// considerMove applies the extension and path filters to a file renamed from
+// 'from' to 'to' on the branch. Ignore filters win: the file is skipped if
+// either name is ignored. Only filters keep the file if either name matches,
+// so moving code into or out of a considered path still counts.
+func considerMove(from, to string, opts *ContributionCounter) bool {
+	ext := considerExt(from, opts) && considerExt(to, opts)
+	if len(opts.OnlyExtensions) > 0 {
+		ext = considerExt(from, opts) || considerExt(to, opts)
+	}
+
+	path := considerPath(from, opts) && considerPath(to, opts)
+	if len(opts.OnlyPaths) > 0 {
+		path = considerPath(from, opts) || considerPath(to, opts)
+	}
+
+	return ext && path
+}
+
+// defaultIgnoredExtensions returns the extensions ignored unless
+// NoDefaultIgnores is set: DefaultIgnoredExtensions or the built-in list.
+func (r *ContributionCounter) defaultIgnoredExtensions() []string {
+	if r.DefaultIgnoredExtensions != nil {
+		return r.DefaultIgnoredExtensions
+	}
+	return defaultIgnoreExt
+}
+
 // considerExt determines whether a path should be used to calculate the final
 // collaborators score based on the inclusion or absence of its extension in the
 // list of paths to exlusively include or exclude, respectively.
 func considerExt(path string, opts *ContributionCounter) bool {
 	ignExt := []string{}
-	ignExt = append(ignExt, defaultIgnoreExt...)
+	if !opts.NoDefaultIgnores {
+		ignExt = append(ignExt, opts.defaultIgnoredExtensions()...)
+	}
 	ignExt = append(ignExt, opts.IgnoredExtensions...)
 
 	lAllow, lIgnore := len(opts.OnlyExtensions), len(ignExt)
@@ -272,14 +988,14 @@ func considerExt(path string, opts *ContributionCounter) bool {
 
 	if lAllow > 0 {
 		for _, ext := range opts.OnlyExtensions {
-			if strings.HasSuffix(path, ext) {
+			if matchesExt(path, ext) {
 				return true
 			}
 		}
 	} else if lIgnore > 0 {
 		passes := true
 		for _, ext := range ignExt {
-			passes = passes && !strings.HasSuffix(path, ext)
+			passes = passes && !matchesExt(path, ext)
 		}
 
 		return passes
@@ -290,25 +1006,25 @@ func considerExt(path string, opts *ContributionCounter) bool {
 
 // considerPath determines whether a path should be used to calculate the final
 // collaborators score based on its inclusion or absence in the list of paths to
-// exlusively include or exclude, respectively.
+// exlusively include or exclude, respectively. Filters are paths or globs, see
+// matchesGlob.
 func considerPath(path string, opts *ContributionCounter) bool {
 	lAllow, lIgnore := len(opts.OnlyPaths), len(opts.IgnoredPaths)
-	pLen := len(path)
 
 	if lAllow == 0 && lIgnore == 0 {
 		return true
 	}
 
 	if lAllow > 0 {
-		for _, prefix := range opts.OnlyPaths {
-			if len(strings.TrimPrefix(path, prefix)) < pLen {
+		for _, filter := range opts.OnlyPaths {
+			if matchesGlob(path, filter) {
 				return true
 			}
 		}
 	} else if lIgnore > 0 {
 		passes := true
-		for _, prefix := range opts.IgnoredPaths {
-			passes = passes && len(strings.TrimPrefix(path, prefix)) == pLen
+		for _, filter := range opts.IgnoredPaths {
+			passes = passes && !matchesGlob(path, filter)
 		}
 
 		return passes
@@ -328,258 +1044,688 @@ func considerPath(path string, opts *ContributionCounter) bool {
 // - https://github.com/src-d/go-git/issues/457
 // - https://github.com/src-d/go-git/issues/458
 func (r *ContributionCounter) FindReviewers(paths []string) (string, error) {
-	var final Stats
+	if len(paths) == 0 && len(r.added) == 0 && len(r.SensitiveChanges(paths)) == 0 {
+		return "", &NoChangesError{Base: r.baseRev(), Head: r.headRev()}
+	}
 
-	if len(r.Since) == 0 {
-		// Calculate 6 months ago from today's date and set the 'since' argument
-		r.Since = time.Now().AddDate(0, -6, 0).Format("2006-01-02")
+	r.defaultSince()
+
+	// Re-running without new commits or different options should return the
+	// previous answer without blaming anything.
+	var key string
+	if r.cacheResults() && !r.WorkingTree && r.Signals == nil && r.Bundle == nil {
+		if base, head, err := r.branchTips(); err == nil {
+			key = r.suggestionKey(base, head, paths)
+			cached, ok := r.readCachedSuggestion(key)
+			r.Summary.cache(ok)
+			if ok {
+				return cached, nil
+			}
+		}
 	}
 
-	// Example shell call:
-	// git blame -ce 9901bf79f808a8339b9820c08e209f5ec9649bda src/reviewers.go
-	linesByCommitter, totalLines, err := r.generateCounts(paths)
+	topN, counts, err := r.suggestions(paths)
 	if err != nil {
 		return "", err
 	}
+	out := r.formatSuggestions(topN, counts)
 
-	for author, lines := range linesByCommitter {
-		// Calculate percent of lines touched in-place
-		lines := lines
-		linesByCommitter[author] = lines / float64(totalLines)
+	// A timeout may not happen again, so the next run gets another chance
+	if key != "" && !counts.timedOut() {
+		r.writeCachedSuggestion(key, out)
 	}
 
-	final = make(Stats, len(linesByCommitter))
-	idx := 0
-	for c, p := range linesByCommitter {
-		final[idx] = &Stat{c, p}
-		idx++
+	return out, nil
+}
+
+// formatSuggestions lays out the suggested reviewers as a table, followed by
+// warnings about the files they were picked from.
+func (r *ContributionCounter) formatSuggestions(topN Stats, counts *contributions) string {
+	var buffer bytes.Buffer
+
+	topN = append(Stats{}, topN...)
+	SortStats(topN, r.Sort)
+	tw := tabwriter.NewWriter(&buffer, 0, 8, 1, '\t', 0)
+
+	fmt.Fprint(tw, TableHeader(r.Language))
+
+	for i := range topN {
+		name := topN[i].Reviewer + topN[i].Notes()
+		fmt.Fprintf(tw, "%s\t%s\n", name, topN[i].Experience(r.Show))
 	}
+	tw.Flush()
 
-	maxStats := 3
-	if l := len(final); l < maxStats {
-		maxStats = l
+	// Verbose output helps tell a current maintainer from a past one
+	if r.Verbose {
+		buffer.WriteString(trendTable(topN, r.Language))
 	}
-	topN := chooseTopN(maxStats, final)
 
-	var buffer bytes.Buffer
-	tw := tabwriter.NewWriter(&buffer, 0, 8, 1, '\t', 0)
+	if unowned := r.unownedFiles(counts); len(unowned) > 0 {
+		fmt.Fprintf(&buffer, r.tr("\nWARNING: nobody active owns more than %.0f%% of these files:\n"),
+			r.OwnershipAlert*100.0)
+		for _, path := range unowned {
+			fmt.Fprintf(&buffer, "  %s\n", path)
+		}
+	}
+
+	if len(counts.takeovers) > 0 {
+		fmt.Fprint(&buffer, r.tr("\nWARNING: the branch makes its author the main owner of files others owned:\n"))
+		for _, t := range counts.takeovers {
+			fmt.Fprintf(&buffer, r.tr("  %s: %s takes over from %s (%.0f%% of the lines, up from %.0f%%)\n"),
+				t.Path, t.Author, t.Previous, t.After*100, t.Before*100)
+		}
+		fmt.Fprint(&buffer, r.tr("Consider asking the previous owners to review.\n"))
+	}
+
+	if skipped := counts.skippedFiles(); len(skipped) > 0 {
+		fmt.Fprint(&buffer, r.tr("\nWARNING: these files were too large or too slow to blame:\n"))
+		for _, path := range skipped {
+			fmt.Fprintf(&buffer, "  %s\n", path)
+		}
+	}
+
+	return buffer.String()
+}
+
+// SuggestReviewers is like FindReviewers but returns the suggested reviewers
+// for programs to use instead of a table. Results are not cached.
+func (r *ContributionCounter) SuggestReviewers(paths []string) (Stats, error) {
+	if len(paths) == 0 && len(r.added) == 0 && len(r.SensitiveChanges(paths)) == 0 {
+		return nil, &NoChangesError{Base: r.baseRev(), Head: r.headRev()}
+	}
+
+	r.defaultSince()
+
+	topN, _, err := r.suggestions(paths)
+	return topN, err
+}
+
+// suggestions blames 'paths' and picks the reviewers to suggest, along with
+// the counts they were picked from.
+func (r *ContributionCounter) suggestions(paths []string) (Stats, *contributions, error) {
+	// Example shell call:
+	// git blame --porcelain 9901bf79f808a8339b9820c08e209f5ec9649bda -- src/reviewers.go
+	counts, err := r.generateCounts(paths)
+	if err != nil {
+		return nil, nil, err
+	}
+	r.Summary.blamed(counts, len(paths))
+
+	if r.WarnTakeovers {
+		if counts.takeovers, err = r.takeovers(counts, paths); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	topN, err := r.rank(counts, paths, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return topN, counts, nil
+}
+
+// rank picks the reviewers to suggest for 'paths' out of the authors in
+// 'counts'. Signals that need more than blame, such as Topics, Tickets and
+// Signals, are only looked up if 'complete' is set.
+func (r *ContributionCounter) rank(counts *contributions, paths []string, complete bool) (Stats, error) {
+	var err error
+
+	final := r.candidates(counts)
+
+	if complete && r.Topics {
+		if final, err = r.addTopics(final, counts); err != nil {
+			return nil, err
+		}
+	}
+
+	if complete && r.Tickets != nil {
+		if final, err = r.addTickets(final, counts); err != nil {
+			return nil, err
+		}
+	}
+
+	if complete && r.Availability {
+		if err := r.boostAvailable(final); err != nil {
+			return nil, err
+		}
+	}
+
+	var owners *ownership
+	if complete && r.ownersPolicy() != OwnersOff {
+		if owners, err = r.findOwners(paths); err != nil {
+			return nil, err
+		}
+		if r.ownersPolicy() == OwnersBoost {
+			final = boostOwners(final, owners, len(paths))
+		}
+	}
+
+	if complete && r.Ranker == RankerLearned {
+		if err := r.scoreLearned(final, paths); err != nil {
+			return nil, err
+		}
+	}
+
+	if complete && r.Signals != nil {
+		if err := r.collectSignals(counts, paths); err != nil {
+			return nil, err
+		}
+	}
+
+	if complete && r.Bundle != nil {
+		r.captureBundle(counts, final, paths, owners)
+	}
+
+	return r.pick(final, counts, paths, owners)
+}
+
+// candidates measures the experience of every author in 'counts'.
+func (r *ContributionCounter) candidates(counts *contributions) Stats {
+	final := make(Stats, 0, len(counts.byAuthor))
+	for author, lines := range counts.byAuthor {
+		// Calculate percent of lines touched
+		final = append(final, &Stat{
+			Reviewer:    author,
+			Percentage:  counts.share(author, r.PerFile),
+			Lines:       lines,
+			Files:       counts.filesTouched(author),
+			Trend:       counts.trend(author, r.now()),
+			LastTouched: counts.lastTouched[author],
+		})
+	}
+
+	return final
+}
+
+// pick selects the reviewers to suggest out of the ranked candidates, then
+// adds the ones sensitive paths, default rules and, if required, OWNERS
+// files call for.
+func (r *ContributionCounter) pick(final Stats, counts *contributions, paths []string,
+	owners *ownership) (Stats, error) {
+	topN := r.selectReviewers(final, counts)
+	topN = r.addRequired(topN, final, r.changedPaths(paths))
+	topN = r.addDefaults(topN, final, counts, paths)
+	topN = r.routeInfra(topN, final, paths)
+	if owners != nil && r.ownersPolicy() == OwnersRequired {
+		topN = requireOwners(topN, final, owners)
+	}
 
 	if len(topN) == 0 {
-		return "", noReviewersErr{}
+		return nil, noReviewersErr{}
 	}
 
-	fmt.Fprintln(tw, "Reviewer\tExperience")
-	fmt.Fprintln(tw, "--------\t----------")
+	return topN, nil
+}
+
+// defaultSince sets the 'since' option to 6 months before the day the branch
+// diverged from its base, today or the day of AsOf, see sinceAnchor, if the
+// client did not specify one.
+func (r *ContributionCounter) defaultSince() {
+	r.asOf()
+	if len(r.Since) == 0 {
+		r.Since = r.sinceAnchor().AddDate(0, -defaultSinceMonths, 0).Format("2006-01-02")
+	}
+	r.resolveHistoryStart()
+}
 
-	for i := range topN {
-		fmt.Fprintf(tw, "%s\t%.2f%%\n", topN[i].Reviewer, topN[i].Percentage*100.0)
+// dirWeight returns DirWeight, or 1 if it isn't set.
+func (r *ContributionCounter) dirWeight() float64 {
+	if r.DirWeight == nil {
+		return 1
+	}
+	return *r.DirWeight
+}
+
+// attribution credits a single blamed line to an author.
+type attribution struct {
+	author string
+	date   string
+}
+
+// fileReport holds the blamed lines of one changed file, or the error that
+// prevented blaming it.
+type fileReport struct {
+	path         string
+	attributions []attribution
+	lines        int
+	err          error
+}
+
+// contributions aggregates blame results across all changed files while
+// keeping enough per-file detail to reason about individual files.
+type contributions struct {
+	// byAuthor counts lines owned by each author across all files.
+	byAuthor map[string]int
+	// byFile counts lines owned by each author in each file.
+	byFile map[string]map[string]int
+	// fileLines counts every line of each file, including lines committed
+	// before Since.
+	fileLines map[string]int
+	// lastTouched holds the most recent date (YYYY-MM-DD) each author
+	// committed one of the blamed lines.
+	lastTouched map[string]string
+	// byQuarter counts lines owned by each author by the quarter they were
+	// committed in, see quarterOf.
+	byQuarter map[string]map[int]int
+	// fileTotal counts the blamed lines of each file, like total does for
+	// all files.
+	fileTotal map[string]int
+	total     int
+	// skipped holds why changed files were left out, such as being too
+	// large to blame.
+	skipped map[string]string
+	// takeovers holds the changed files someone takes over on the branch,
+	// when WarnTakeovers is set.
+	takeovers []Takeover
+	// topics holds how often each author wrote about the topics of the
+	// changes, when Topics is set, and tickets how often they worked on
+	// related tickets, when Tickets is set.
+	topics  map[string]messageMatch
+	tickets map[string]messageMatch
+	// weights holds how much the lines of each file count towards the
+	// share of their owners, 1 unless set, see DirWeight.
+	weights map[string]float64
+}
+
+func newContributions() *contributions {
+	return &contributions{
+		byAuthor:    make(map[string]int),
+		byFile:      make(map[string]map[string]int),
+		fileLines:   make(map[string]int),
+		lastTouched: make(map[string]string),
+		byQuarter:   make(map[string]map[int]int),
+		fileTotal:   make(map[string]int),
+		skipped:     make(map[string]string),
+		weights:     make(map[string]float64),
+	}
+}
+
+// add records the attributions of a single file with 'lines' lines.
+func (c *contributions) add(path string, attributions []attribution, lines int) {
+	if _, ok := c.byFile[path]; !ok {
+		c.byFile[path] = make(map[string]int)
+	}
+	c.fileLines[path] += lines
+
+	for _, a := range attributions {
+		c.total++
+		c.fileTotal[path]++
+
+		// Nobody to suggest for lines from an initial import, but they still
+		// make the rest of the file look less owned
+		if a.author == initialImportAuthor {
+			continue
+		}
+
+		c.byAuthor[a.author]++
+		c.byFile[path][a.author]++
+
+		if a.date > c.lastTouched[a.author] {
+			c.lastTouched[a.author] = a.date
+		}
+
+		if q, ok := quarterOf(a.date); ok {
+			if c.byQuarter[a.author] == nil {
+				c.byQuarter[a.author] = make(map[int]int)
+			}
+			c.byQuarter[a.author][q]++
+		}
+	}
+}
+
+// share computes the fraction of blamed lines an author owns. With 'perFile'
+// it is the average of the author's share of each file instead, so every file
+// counts the same no matter its size.
+func (c *contributions) share(author string, perFile bool) float64 {
+	if !perFile && len(c.weights) == 0 {
+		return float64(c.byAuthor[author]) / float64(c.total)
+	}
+
+	if !perFile {
+		var owned, total float64
+		for path, lines := range c.fileTotal {
+			owned += c.weight(path) * float64(c.byFile[path][author])
+			total += c.weight(path) * float64(lines)
+		}
+
+		if total == 0 {
+			return 0
+		}
+		return owned / total
+	}
+
+	var sum, files float64
+	for path, total := range c.fileTotal {
+		if total == 0 {
+			continue
+		}
+		sum += c.weight(path) * float64(c.byFile[path][author]) / float64(total)
+		files += c.weight(path)
+	}
+
+	if files == 0 {
+		return 0
+	}
+	return sum / files
+}
+
+// weight is how much the lines of 'path' count, see weights.
+func (c *contributions) weight(path string) float64 {
+	if w, ok := c.weights[path]; ok {
+		return w
+	}
+	return 1
+}
+
+// filesTouched counts the files in which an author owns at least one line.
+func (c *contributions) filesTouched(author string) int {
+	var files int
+	for _, owners := range c.byFile {
+		if owners[author] > 0 {
+			files++
+		}
 	}
-	tw.Flush()
 
-	return buffer.String(), nil
+	return files
 }
 
-func (r *ContributionCounter) generateCounts(paths []string) (map[string]float64, uint16, error) {
+func (r *ContributionCounter) generateCounts(paths []string) (*contributions, error) {
+	defer r.Summary.stage("blame", time.Now())
+
+	counts, reporter, blamed, err := r.startBlame(context.Background(), paths)
+	if err != nil {
+		return nil, err
+	}
+
+	// Collect all the git-blame responses as they come in. Every blame process
+	// reports exactly once, whether it succeeded or not, so we know when all of
+	// them have finished. We keep the first error to report.
+	var firstErr error
+	for i := 0; i < blamed; i++ {
+		if err := r.record(counts, <-reporter); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	return counts, nil
+}
+
+// startBlame blames each of 'paths' concurrently, skipping files that are too
+// large. It returns the counts to record the results in, the channel each
+// blamed file reports on, and how many files will report.
+func (r *ContributionCounter) startBlame(ctx context.Context, paths []string) (*contributions, <-chan fileReport, int, error) {
+	if r.IgnoreMerges && r.massRevs == nil {
+		r.massRevs = newRevCache()
+	}
+	if r.IgnoreMerges && !r.gitAtLeast(gitcmd.IgnoreRevVersion) {
+		r.logf("Crediting merges and reverts like any commit, which needs git %s or newer\n",
+			gitcmd.IgnoreRevVersion)
+	}
+
+	counts := newContributions()
+	if w := r.dirWeight(); w != 1 {
+		for p := range r.standIns {
+			counts.weights[p] = w
+		}
+	}
+
 	var (
-		linesByCommitter = make(map[string]float64)
-		m                *plumbing.Reference
-		mc               *object.Commit
-		rg               runGuard
-		totalLines       uint16
-		wg               sync.WaitGroup
+		m  plumbing.Hash
+		mc *object.Commit
+		mt *object.Tree
+		b  plumbing.Hash
+		rg runGuard
 	)
 
-	// Set up tracking for each of these files to be blamed concurrently with
-	// results from each reported on a single channel.
-	wg.Add(len(paths))
-	reporter := make(chan []string)
+	// Each of these files is blamed concurrently with results from each
+	// reported on a single channel. It has room for every report so blaming
+	// finishes even if nobody collects the results, such as when a stream of
+	// suggestions is canceled.
+	reporter := make(chan fileReport, len(paths))
 
 	// Get the master commit so we can determine what the experience was *before*
-	// the author got to the file.
+	// the author got to the file, unless BlameAt picks another revision.
 	rg.maybeRunMany(
 		func() {
-			m, rg.err = r.Repo.Reference(plumbing.Master, true)
-			rg.msg = "unable to find ref for master"
+			m, rg.err = r.blameRev()
+			rg.msg = "unable to resolve revision to blame at"
 		},
 		func() {
-			mc, rg.err = r.Repo.CommitObject(m.Hash())
-			rg.msg = "unable to find commit for master"
+			mc, rg.err = r.Repo.CommitObject(m)
+			rg.msg = "unable to find commit to blame at"
 		},
 		func() {
-			for _, p := range paths {
-				go func(p string) {
-					// A separate run has already indicated a blame error. Skip
-					if rg.err != nil {
-						rg.msg = "Issue running git blame for " + p
-						return
-					}
-
-					err := r.runAndReport(p, m.Hash().String(), reporter)
-					// Report any errors to the rungroup so future goroutines don't
-					// attempt any further processsing.
-					if err != nil {
-						rg.err = err
-					}
-				}(p)
-			}
+			mt, rg.err = mc.Tree()
+			rg.msg = "unable to find tree to blame at"
+		},
+		func() {
+			// Changed files are named as they are in base, so those deleted
+			// or moved since are blamed there instead
+			b, rg.err = r.resolve(r.baseRev())
+			rg.msg = "unable to resolve base revision"
 		},
 	)
 
-	// Bail early from further processing if we couldn't run a git-blame for each
-	// path identified
+	// Bail early from further processing if we couldn't find the commit to
+	// blame at
 	if rg.err != nil {
-		if rg.msg != "" && r.Verbose {
-			fmt.Println("Error blaming changed files:", rg.msg)
+		if rg.msg != "" {
+			r.logf("Error blaming changed files: %s\n", rg.msg)
 		}
 
-		return nil, 0, rg.err
+		return nil, nil, 0, rg.err
 	}
 
-	// Collect all the git-blame line responses as they come in. This loop will
-	// continue as long as the reporter channel is open. We'll close the channel
-	// when all blame processes report they have finished.
-	go func() {
-		for attributions := range reporter {
-			for _, author := range attributions {
-				linesByCommitter[author]++
-				totalLines++
-			}
-			wg.Done()
+	// Blaming stops once 'ctx' is done, which only concerns the files of this
+	// call
+	blamer := *r
+	blamer.ctx = ctx
+
+	// The author of the branch is the one asking for a review, so the lines
+	// they wrote at its head count towards the size of the files but credit
+	// nobody
+	if r.AsOf == "" && r.BlameAt == BlameAtHead && !r.creditBranch {
+		own, err := r.branchCommits()
+		if err != nil {
+			return nil, nil, 0, err
 		}
-	}()
+		blamer.skipRevs = own
+	}
 
-	wg.Wait()
-	close(reporter)
+	rev := mc.Hash.String()
+	attribute := blamer.chooseAttributor(rev, paths)
 
-	return linesByCommitter, totalLines, nil
-}
+	// Blaming an LFS pointer credits whoever last updated the pointer, so the
+	// commits that touched the file are used instead.
+	if lfs := lfsPaths(mt, paths); r.IncludeLFS && len(lfs) > 0 {
+		blame := attribute
+		attribute = func(path string, rev string) ([]attribution, int, error) {
+			if lfs[path] {
+				return blamer.commitAttributions(path, rev)
+			}
+			return blame(path, rev)
+		}
+	}
 
-// runAndReport executes an external call to git to calculate blame statistics
-// for a file at a specific commit (usually "master" or whatever the base branch
-// is) and send extracted statistics to the 'reporter' channel.
-func (r *ContributionCounter) runAndReport(path string, rev string, reporter chan []string) error {
-	out, err := exec.Command("git", "blame", "-ce", rev, path).Output()
-	if err != nil {
-		return errors.Wrap(err, "unable to execute external git blame command")
+	// Blaming at the tip of the branch measures the files as the branch
+	// leaves them: renamed files are blamed under their new name and deleted
+	// ones have no lines left
+	atHead := r.AsOf == "" && r.BlameAt == BlameAtHead
+	moved := make(map[string]string)
+	if atHead {
+		for _, c := range r.changes {
+			if c.From != "" {
+				moved[c.From] = c.Path
+			}
+		}
+	}
+	if len(moved) > 0 {
+		blame := attribute
+		attribute = func(path string, rev string) ([]attribution, int, error) {
+			if to, ok := moved[path]; ok {
+				path = to
+			}
+			return blame(path, rev)
+		}
 	}
 
-	scn := bufio.NewScanner(bytes.NewReader(out))
-	var attributions []string
+	var blamed int
+	for _, p := range paths {
+		name := p
+		if to, ok := moved[p]; ok {
+			name = to
+		}
 
-	for scn.Scan() {
-		if bi, err := parseBlameLine(scn.Bytes()); err == nil {
-			// r.Since is a string, not a date. However, since the format is just
-			// a "YYYY-MM-DD" string, we can rely on ASCII sorting and just compare
-			// the strings to determine if a line change was committed before or after
-			// our boundary
-			if r.Since > string(bi.date) {
-				continue
-			}
+		f, err := mt.File(name)
+		if err == nil && r.maxFileSize() > 0 && f.Size > r.maxFileSize() {
+			r.logf("Skipping %s, which is larger than %d bytes\n", p, r.maxFileSize())
+			counts.skip(p, skipTooLarge)
+			continue
+		}
 
-			email := string(bi.email)
-			// Normalize scanned email based on what we found in the mailmap
-			if e, ok := r.Mailmap[email]; ok {
-				attributions = append(attributions, e)
-			} else {
-				attributions = append(attributions, email)
-			}
-		} else {
-			return errors.Wrap(err, "issue parsing a line in git blame output")
+		switch {
+		case err != nil && atHead:
+			counts.add(p, nil, 0)
+			continue
+		case err != nil && b != m:
+			go runAndReport(ctx, p, b.String(), attribute, reporter)
+		default:
+			go runAndReport(ctx, p, rev, attribute, reporter)
 		}
+		blamed++
 	}
 
-	reporter <- attributions
-	return scn.Err()
+	return counts, reporter, blamed, nil
 }
 
-// blameInfo holds anything we might be interested in reporting out of a git
-// blame shell command result
-type blameInfo struct {
-	email []byte
-	date  []byte
+// record adds the report of a blamed file to 'counts'. Files that took too
+// long to blame are skipped rather than failing the whole run.
+func (r *ContributionCounter) record(counts *contributions, report fileReport) error {
+	switch {
+	case Is(report.err, ErrBlameTimedOut):
+		r.logf("Skipping %s: %v\n", report.path, report.err)
+		counts.skip(report.path, skipTimedOut)
+	case report.err != nil:
+		r.logf("Error blaming changed files: Issue running git blame for %s\n", report.path)
+		return report.err
+	default:
+		counts.add(report.path, report.attributions, report.lines)
+	}
+
+	return nil
 }
 
-// parseBlameLine takes the bytes for one line of the output of running git
-// blame on the shell with the `-ce` options (that is, returning in a specific
-// machine format as well as returning the author email instead of name) and
-// extracts the relevant information into a blameInfo struct
-func parseBlameLine(line []byte) (blameInfo, error) {
-	// Format of blame result:
-	// somerev        (author@domain.com> YYYY-MM-DD HH:MM:SS -0700       3)stuff.
-	var (
-		bi    blameInfo
-		date  []byte
-		email []byte
-	)
-	rdr := bytes.NewReader(line)
-
-	// Scan over the rev
-	for {
-		if r, _, err := rdr.ReadRune(); err == nil {
-			if r == ' ' || r == '\t' {
-				rdr.UnreadRune()
-				break
-			}
-		} else {
-			return bi, errors.Wrap(err, "unable to read over rev")
-		}
+// runAndReport executes an external call to git to calculate blame statistics
+// for a file at a specific commit (usually "master" or whatever the base branch
+// is) and send extracted statistics to the 'reporter' channel. Files are not
+// blamed once 'ctx' is done.
+func runAndReport(ctx context.Context, path string, rev string, attribute attributor, reporter chan fileReport) {
+	if err := ctx.Err(); err != nil {
+		reporter <- fileReport{path: path, err: err}
+		return
 	}
 
-	// Scan over the whitespace gap
-	for {
-		r, _, err := rdr.ReadRune()
+	attributions, lines, err := attribute(path, rev)
+	reporter <- fileReport{path, attributions, lines, err}
+}
+
+// initialImportAuthor is the pseudo-author boundary commit lines are credited
+// to when InitialImport is set. Its lines count towards the total but it is
+// never suggested as a reviewer.
+const initialImportAuthor = "(initial import)"
+
+// blameAttributions runs git blame for a file at a specific commit and returns
+// the canonical author and date of each counted line, see counted, along
+// with the total number of lines blamed. Any line ranges, in the forms git
+// blame -L takes, limit blame to them.
+//
+// Lines that are not committed yet, and lines matching ExcludeLines or
+// ExcludeLinePatterns, are skipped entirely. Boundary commit lines
+// are credited to their author unless InitialImport is set, or they are older
+// than MaxHistory. If IgnoreMerges is
+// set, lines from merge and revert commits are credited to the commits they
+// brought in, see ignoredRevs, as long as git is new enough to ignore them.
+func (r *ContributionCounter) blameAttributions(path string, rev string, ranges ...string) ([]attribution, int, error) {
+	opts := blame.Options{Lines: ranges}
+	blamed, err := r.blame(path, rev, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if r.IgnoreMerges && r.gitAtLeast(gitcmd.IgnoreRevVersion) {
+		ignored, err := r.ignoredRevs(blamedRevs(blamed))
 		if err != nil {
-			return bi, errors.Wrap(err, "unable to skip whitespace before author")
+			return nil, 0, err
 		}
 
-		if !(r == ' ' || r == '\t') {
-			rdr.UnreadRune()
-			break
+		if len(ignored) > 0 {
+			opts.IgnoreRevs = ignored
+			if blamed, err = r.blame(path, rev, opts); err != nil {
+				return nil, 0, err
+			}
 		}
 	}
 
-	// Read over author signature header
-	if r, _, _ := rdr.ReadRune(); r != '(' {
-		return bi, fmt.Errorf("expected opening parens of email")
-	}
-	if r, _, _ := rdr.ReadRune(); r != '<' {
-		return bi, fmt.Errorf("expected opening bracket of email")
-	}
+	var (
+		attributions []attribution
+		lines        int
+		// Ranges of lines don't start with the license header
+		exclude = r.lineExcluder(path, len(ranges) == 0)
+	)
 
-	// Scan the email bytes into place
-	for {
-		b, err := rdr.ReadByte()
-		if err != nil {
-			return bi, errors.Wrap(err, "unable to scan author email")
+	for _, l := range blamed {
+		if l.Uncommitted() || exclude.excluded([]byte(l.Content)) {
+			continue
 		}
+		lines++
 
-		if b == '>' {
-			rdr.UnreadRune()
-			break
+		date := l.AuthorTime.Format("2006-01-02")
+		if !r.counted(date) || l.Boundary && r.beyondHistory(date) || r.skipped(l.Commit) {
+			continue
 		}
 
-		email = append(email, b)
-	}
+		// Normalize the email based on what we found in the mailmap
+		author := reviewerKey(l.AuthorEmail, r.Mailmap)
+		if r.InitialImport && l.Boundary {
+			author = initialImportAuthor
+		}
 
-	// Read over the next space before reading the date
-	if r, _, err := rdr.ReadRune(); err != nil || !(r == ' ' || r == '\t') {
-		fmt.Println("Error reading expected space after email")
-		fmt.Println("Instead of space, got", string(r))
-		return bi, err
+		attributions = append(attributions, attribution{
+			author: author,
+			date:   date,
+		})
 	}
 
-	// Read the date into place (10 bytes for YYYY-MM-DD)
-	for i := 0; i < 10; i++ {
-		b, err := rdr.ReadByte()
-		if err != nil {
-			return bi, errors.Wrap(err, "unable to read date bytes")
-		}
+	return attributions, lines, nil
+}
+
+// blame runs git blame for a file at a specific commit with 'opts', adding the
+// options of the counter, and returns every line blamed.
+func (r *ContributionCounter) blame(path string, rev string, opts blame.Options) ([]blame.LineAttribution, error) {
+	opts.FirstParent = r.FirstParent
+	opts.Since = r.historyStart
 
-		date = append(date, b)
+	ctx, cancel := r.blameContext()
+	defer cancel()
+
+	if r.NoExec {
+		lines, err := r.goGitBlame(ctx, path, rev, opts)
+		return lines, errors.Wrap(r.timedOut(ctx, path, err), "unable to blame without git")
+	}
+
+	out, err := r.outputContext(ctx, blame.Args(rev, path, opts)...)
+	if err != nil {
+		return nil, errors.Wrap(r.timedOut(ctx, path, err),
+			"unable to execute external git blame command")
 	}
 
-	bi = blameInfo{email, date}
-	return bi, nil
+	lines, err := blame.Parse(bytes.NewReader(out))
+	return lines, errors.Wrap(err, "issue parsing git blame output")
 }
 
 // reviewerKey resolves an author email to its canonical in the mailmap
@@ -599,7 +1745,7 @@ func chooseTopN(n int, s Stats) Stats {
 	for _, stat := range s {
 		stat := stat
 
-		if top.Len() < n || stat.Percentage > top[0].Percentage {
+		if top.Len() < n || ranksBelow(top[0], stat) {
 			// Replace the largest item in the heap with this one
 			// This way our heap never grows larger than it needs to be
 			if top.Len() == n {
@@ -613,18 +1759,3 @@ func chooseTopN(n int, s Stats) Stats {
 
 	return top
 }
-
-type NoReviewersErr interface {
-	Error() string
-	Help() string
-}
-
-type noReviewersErr struct{}
-
-func (nre noReviewersErr) Error() string {
-	return "no reviewers found"
-}
-
-func (nre noReviewersErr) Help() string {
-	return "Try using a wider date range"
-}
@@ -1,6 +1,8 @@
 package gitreviewers
 
 import (
+	"os"
+	"strings"
 	"testing"
 )
 
@@ -31,6 +33,15 @@ func TestDefaultIgnoreExtensions(t *testing.T) {
 	if considerExt("myfile.json", opts) {
 		t.Error("Expected JSON files to be ignored when other ignores defined")
 	}
+
+	// Defaults turned off
+	opts = &ContributionCounter{NoDefaultIgnores: true, IgnoredExtensions: []string{"coffee"}}
+	if !considerExt("myfile.json", opts) {
+		t.Error("Expected JSON files to be considered without default ignores")
+	}
+	if considerExt("myfile.coffee", opts) {
+		t.Error("Expected explicit ignores to apply without default ignores")
+	}
 }
 
 func TestChooseTopN(t *testing.T) {
@@ -41,7 +52,7 @@ func TestChooseTopN(t *testing.T) {
 	)
 
 	for i := 0; i < srcSize; i++ {
-		stats = append(stats, &Stat{"", float64(i)})
+		stats = append(stats, &Stat{Reviewer: "", Percentage: float64(i)})
 	}
 
 	actual := chooseTopN(outputSize, stats)
@@ -59,3 +70,108 @@ func TestChooseTopN(t *testing.T) {
 	}
 
 }
+
+func TestChooseTopNTies(t *testing.T) {
+	stats := Stats{
+		{Reviewer: "tom@git-reviewer.com", Percentage: 0.4},
+		{Reviewer: "ben@git-reviewer.com", Percentage: 0.2},
+		{Reviewer: "cal@git-reviewer.com", Percentage: 0.4},
+		{Reviewer: "abe@git-reviewer.com", Percentage: 0.4},
+	}
+
+	actual := chooseTopN(2, stats)
+	if len(actual) != 2 || actual[0].Reviewer != "abe@git-reviewer.com" ||
+		actual[1].Reviewer != "cal@git-reviewer.com" {
+		t.Errorf("Got %v, expected abe then cal\n", actual)
+	}
+}
+
+func TestParseRenames(t *testing.T) {
+	out := []byte("R100\x00vendor/a.go\x00src/a.go\x00R087\x00b.go\x00lib/b.go\x00")
+	renamed := parseRenames(out)
+
+	if len(renamed) != 2 || renamed["vendor/a.go"] != "src/a.go" ||
+		renamed["b.go"] != "lib/b.go" {
+		t.Errorf("Got %v, expected two renames\n", renamed)
+	}
+
+	if renamed := parseRenames(nil); len(renamed) != 0 {
+		t.Errorf("Got %v, expected no renames\n", renamed)
+	}
+}
+
+func TestConsiderMove(t *testing.T) {
+	cases := []struct {
+		From, To string
+		Opts     ContributionCounter
+		Expected bool
+	}{
+		{"vendor/a.go", "src/a.go", ContributionCounter{IgnoredPaths: []string{"vendor"}}, false},
+		{"src/a.go", "vendor/a.go", ContributionCounter{IgnoredPaths: []string{"vendor"}}, false},
+		{"lib/a.go", "src/a.go", ContributionCounter{IgnoredPaths: []string{"vendor"}}, true},
+		{"lib/a.go", "src/a.go", ContributionCounter{OnlyPaths: []string{"src"}}, true},
+		{"src/a.go", "lib/a.go", ContributionCounter{OnlyPaths: []string{"src"}}, true},
+		{"lib/a.go", "pkg/a.go", ContributionCounter{OnlyPaths: []string{"src"}}, false},
+		{"a.js", "a.json", ContributionCounter{}, false},
+		{"a.js", "a.ts", ContributionCounter{OnlyExtensions: []string{"ts"}}, true},
+	}
+
+	for _, c := range cases {
+		if actual := considerMove(c.From, c.To, &c.Opts); actual != c.Expected {
+			t.Errorf("%s -> %s: got %t, expected %t\n", c.From, c.To, actual, c.Expected)
+		}
+	}
+}
+
+func TestBranchStatusString(t *testing.T) {
+	cases := []struct {
+		Status   BranchStatus
+		Expected string
+	}{
+		{BranchStatus{Base: "master"}, "Your branch is up to date with master"},
+		{BranchStatus{Base: "master", Behind: 3}, "Your branch is 3 commits behind master"},
+		{BranchStatus{Base: "master", Ahead: 1}, "Your branch is 1 commit ahead of master"},
+		{
+			BranchStatus{Base: "master", Ahead: 2, Behind: 1},
+			"Your branch and master have diverged, and have 2 commits and 1 commit each, respectively",
+		},
+	}
+
+	for _, c := range cases {
+		if actual := c.Status.String(); actual != c.Expected {
+			t.Errorf("Got '%s', expected '%s'\n", actual, c.Expected)
+		}
+	}
+}
+
+func TestBranchStatus(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	mergeBase := strings.TrimSpace(runGit(t, dir, "rev-parse", "HEAD"))
+	runGit(t, dir, "checkout", "-q", "-b", "feature")
+	runGit(t, dir, "commit", "-q", "--allow-empty", "-m", "Feature 1")
+	runGit(t, dir, "commit", "-q", "--allow-empty", "-m", "Feature 2")
+	runGit(t, dir, "checkout", "-q", "master")
+	runGit(t, dir, "commit", "-q", "--allow-empty", "-m", "Master 1")
+	runGit(t, dir, "checkout", "-q", "feature")
+
+	repo, _, err := OpenRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := ContributionCounter{Repo: repo, Dir: dir}
+	status, err := r.BranchStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := BranchStatus{Base: "master", Ahead: 2, Behind: 1, MergeBase: mergeBase}
+	if status != expected {
+		t.Errorf("Got %+v, expected %+v\n", status, expected)
+	}
+	if !status.IsBehind() {
+		t.Error("Expected the branch to be behind master")
+	}
+}
@@ -0,0 +1,158 @@
+package gitreviewers
+
+import (
+	"path"
+	"sort"
+)
+
+// maxReviewers is the number of reviewers suggested for a branch.
+const maxReviewers = 3
+
+// selectReviewers picks the reviewers to suggest out of every candidate. It
+// starts from the candidates with the most experience and then applies the
+// constraints the client asked for, swapping in lower-ranked candidates where
+// the top of the list doesn't satisfy them. Candidates at capacity are left
+// out first, then constraints are applied in order: diversity, recency, then
+// the learning reviewer slot.
+func (r *ContributionCounter) selectReviewers(candidates Stats, c *contributions) Stats {
+	if r.BalanceLoad {
+		candidates = r.balanceLoad(candidates)
+	}
+
+	n := maxReviewers
+	if l := len(candidates); l < n {
+		n = l
+	}
+	top := chooseTopN(n, candidates)
+	ranked := chooseTopN(len(candidates), candidates)
+
+	if r.Diverse {
+		top = diversify(top, ranked, func(author string) string {
+			if team, ok := r.Teams[author]; ok {
+				return team
+			}
+			return dominantDir(author, c)
+		})
+	}
+
+	// Stale experts alone often can't review recent changes, so someone who
+	// touched the code lately takes the last slot if nobody selected has.
+	if r.RecentDays > 0 {
+		cutoff := r.now().AddDate(0, 0, -r.RecentDays).Format("2006-01-02")
+		top = ensureOne(top, ranked, func(author string) bool {
+			return c.lastTouched[author] >= cutoff
+		})
+	}
+
+	if r.IncludeLearners {
+		top = withLearner(top, ranked)
+	}
+
+	return top
+}
+
+// learnerMaxShare is the largest share of the changed lines a collaborator may
+// own and still be considered a learner rather than an expert.
+const learnerMaxShare = 0.1
+
+// withLearner reserves the last slot of the selection for a learning reviewer:
+// the most experienced candidate who has some, but little, recent activity in
+// the changed code. Reviews are a good way to spread knowledge, and this
+// person has enough context to follow along. The selection is returned
+// untouched if nobody qualifies.
+func withLearner(selected, ranked Stats) Stats {
+	if len(selected) == 0 {
+		return selected
+	}
+
+	chosen := make(map[string]bool)
+	for _, s := range selected {
+		chosen[s.Reviewer] = true
+	}
+
+	for _, candidate := range ranked {
+		if chosen[candidate.Reviewer] || candidate.Percentage <= 0 ||
+			candidate.Percentage > learnerMaxShare {
+			continue
+		}
+
+		learner := *candidate
+		learner.Learner = true
+
+		// Keep every expert when there is room for another reviewer
+		if len(selected) < maxReviewers {
+			return append(append(Stats{}, selected...), &learner)
+		}
+		return append(append(Stats{}, selected[:len(selected)-1]...), &learner)
+	}
+
+	return selected
+}
+
+// diversify makes sure the selected reviewers don't all belong to the same
+// group, as named by the 'group' function. If they do, the lowest-ranked
+// selection is replaced by the highest-ranked candidate from another group.
+func diversify(selected, ranked Stats, group func(string) string) Stats {
+	if len(selected) < 2 {
+		return selected
+	}
+
+	g := group(selected[0].Reviewer)
+	return ensureOne(selected, ranked, func(author string) bool {
+		return group(author) != g
+	})
+}
+
+// ensureOne makes sure at least one selected reviewer satisfies 'ok'. If none
+// does, the lowest-ranked selection is replaced by the highest-ranked
+// candidate that does. The selection is returned untouched if no candidate
+// qualifies.
+func ensureOne(selected, ranked Stats, ok func(string) bool) Stats {
+	if len(selected) == 0 {
+		return selected
+	}
+
+	chosen := make(map[string]bool)
+	for _, s := range selected {
+		if ok(s.Reviewer) {
+			return selected
+		}
+		chosen[s.Reviewer] = true
+	}
+
+	for _, candidate := range ranked {
+		if chosen[candidate.Reviewer] || !ok(candidate.Reviewer) {
+			continue
+		}
+
+		replaced := append(Stats{}, selected[:len(selected)-1]...)
+		replaced = append(replaced, candidate)
+		sort.Stable(sort.Reverse(replaced))
+		return replaced
+	}
+
+	return selected
+}
+
+// dominantDir finds the directory in which an author owns the most lines
+// among the changed files. Ties go to the directory that sorts first.
+func dominantDir(author string, c *contributions) string {
+	byDir := make(map[string]int)
+	for file, owners := range c.byFile {
+		if lines := owners[author]; lines > 0 {
+			byDir[path.Dir(file)] += lines
+		}
+	}
+
+	var (
+		best  string
+		lines int
+	)
+	for dir, l := range byDir {
+		if l > lines || (l == lines && dir < best) {
+			best, lines = dir, l
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,174 @@
+package gitreviewers
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func testContributions() *contributions {
+	c := newContributions()
+	c.add("src/a.go", []attribution{
+		{"abe@git-reviewer.com", "2017-01-01"},
+		{"abe@git-reviewer.com", "2017-01-01"},
+		{"abe@git-reviewer.com", "2017-01-01"},
+		{"abe@git-reviewer.com", "2017-01-01"},
+		{"george@git-reviewer.com", "2017-01-01"},
+		{"george@git-reviewer.com", "2017-01-01"},
+		{"george@git-reviewer.com", "2017-01-01"},
+		{"ben@git-reviewer.com", "2017-01-01"},
+		{"ben@git-reviewer.com", "2017-01-01"},
+	}, 9)
+	c.add("docs/readme.md", []attribution{
+		{"tom@git-reviewer.com", "2017-01-01"},
+	}, 1)
+
+	return c
+}
+
+func statsFor(c *contributions) Stats {
+	var s Stats
+	for author, lines := range c.byAuthor {
+		s = append(s, &Stat{Reviewer: author, Percentage: float64(lines) / float64(c.total)})
+	}
+	return s
+}
+
+func TestContributionsShare(t *testing.T) {
+	c := testContributions()
+
+	tests := []struct {
+		author   string
+		perFile  bool
+		weight   float64
+		expected string
+	}{
+		{"abe@git-reviewer.com", false, 0, "0.400"},
+		{"tom@git-reviewer.com", false, 0, "0.100"},
+		{"abe@git-reviewer.com", true, 0, "0.222"},
+		{"tom@git-reviewer.com", true, 0, "0.500"},
+		{"nobody@git-reviewer.com", true, 0, "0.000"},
+		// The readme stands in for an added file and counts half
+		{"abe@git-reviewer.com", false, 0.5, "0.421"},
+		{"tom@git-reviewer.com", false, 0.5, "0.053"},
+		{"abe@git-reviewer.com", true, 0.5, "0.296"},
+		{"tom@git-reviewer.com", true, 0.5, "0.333"},
+		{"tom@git-reviewer.com", false, 1, "0.100"},
+	}
+
+	for _, tt := range tests {
+		c.weights = make(map[string]float64)
+		if tt.weight != 0 {
+			c.weights["docs/readme.md"] = tt.weight
+		}
+		if got := fmt.Sprintf("%.3f", c.share(tt.author, tt.perFile)); got != tt.expected {
+			t.Errorf("Got '%s', expected '%s' for %s (per file: %t, weight: %g)\n", got,
+				tt.expected, tt.author, tt.perFile, tt.weight)
+		}
+	}
+}
+
+func TestSelectReviewers(t *testing.T) {
+	c := testContributions()
+
+	r := &ContributionCounter{}
+	top := r.selectReviewers(statsFor(c), c)
+	expected := []string{"abe@git-reviewer.com", "george@git-reviewer.com",
+		"ben@git-reviewer.com"}
+	for i := range expected {
+		if top[i].Reviewer != expected[i] {
+			t.Errorf("Got reviewer '%s' at %d, expected '%s'\n",
+				top[i].Reviewer, i, expected[i])
+		}
+	}
+
+	// Everybody in the top 3 works in src, so the docs owner is swapped in
+	r.Diverse = true
+	top = r.selectReviewers(statsFor(c), c)
+	expected = []string{"abe@git-reviewer.com", "george@git-reviewer.com",
+		"tom@git-reviewer.com"}
+	for i := range expected {
+		if top[i].Reviewer != expected[i] {
+			t.Errorf("Got diverse reviewer '%s' at %d, expected '%s'\n",
+				top[i].Reviewer, i, expected[i])
+		}
+	}
+
+	// Teams take precedence over directories
+	r.Teams = map[string]string{
+		"abe@git-reviewer.com":    "Core",
+		"george@git-reviewer.com": "Platform",
+	}
+	top = r.selectReviewers(statsFor(c), c)
+	if top[2].Reviewer != "ben@git-reviewer.com" {
+		t.Errorf("Expected mixed teams to keep the top reviewers, got '%s'\n",
+			top[2].Reviewer)
+	}
+}
+
+func TestDominantDir(t *testing.T) {
+	c := testContributions()
+
+	if d := dominantDir("abe@git-reviewer.com", c); d != "src" {
+		t.Errorf("Got dominant dir '%s', expected 'src'\n", d)
+	}
+
+	if d := dominantDir("tom@git-reviewer.com", c); d != "docs" {
+		t.Errorf("Got dominant dir '%s', expected 'docs'\n", d)
+	}
+}
+
+func TestRecentDaysFloor(t *testing.T) {
+	c := testContributions()
+	recent := time.Now().Format("2006-01-02")
+	c.add("src/b.go", []attribution{{"sam@git-reviewer.com", recent}}, 1)
+
+	r := &ContributionCounter{}
+	top := r.selectReviewers(statsFor(c), c)
+	if top[2].Reviewer != "ben@git-reviewer.com" {
+		t.Errorf("Got last reviewer '%s', expected 'ben@git-reviewer.com'\n",
+			top[2].Reviewer)
+	}
+
+	r.RecentDays = 30
+	top = r.selectReviewers(statsFor(c), c)
+	if top[2].Reviewer != "sam@git-reviewer.com" {
+		t.Errorf("Got last reviewer '%s', expected the recent contributor\n",
+			top[2].Reviewer)
+	}
+}
+
+func TestEnsureOneWithoutCandidates(t *testing.T) {
+	selected := Stats{
+		{Reviewer: "abe@git-reviewer.com", Percentage: 0.6},
+		{Reviewer: "ben@git-reviewer.com", Percentage: 0.4},
+	}
+
+	actual := ensureOne(selected, selected, func(string) bool { return false })
+	if len(actual) != 2 || actual[1].Reviewer != "ben@git-reviewer.com" {
+		t.Error("Expected the selection to stay untouched without candidates")
+	}
+}
+
+func TestIncludeLearners(t *testing.T) {
+	c := testContributions()
+
+	r := &ContributionCounter{IncludeLearners: true}
+	top := r.selectReviewers(statsFor(c), c)
+
+	if len(top) != maxReviewers {
+		t.Fatalf("Got %d reviewers, expected %d\n", len(top), maxReviewers)
+	}
+
+	last := top[len(top)-1]
+	if last.Reviewer != "tom@git-reviewer.com" || !last.Learner {
+		t.Errorf("Expected 'tom@git-reviewer.com' as the learner, got '%s' (learner: %t)\n",
+			last.Reviewer, last.Learner)
+	}
+
+	for _, s := range top[:len(top)-1] {
+		if s.Learner {
+			t.Errorf("Expected '%s' not to be marked as a learner\n", s.Reviewer)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package gitreviewers
+
+import (
+	"bytes"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SensitiveRule makes reviewers mandatory for changes touching sensitive
+// paths, such as authentication or cryptography code, whatever blame says.
+type SensitiveRule struct {
+	// Name describes the rule in the output, such as "security".
+	Name string
+	// Paths are glob patterns: '*' and '?' match within a directory and '**'
+	// matches any number of directories. A pattern without wildcards matches
+	// the file or everything under the directory of that name.
+	Paths []string
+	// Reviewers are added to every suggestion for changes matching Paths.
+	Reviewers []string
+}
+
+// matches reports whether any of the rule's patterns matches 'path'.
+func (s SensitiveRule) matches(path string) bool {
+	for _, pattern := range s.Paths {
+		if matchesGlob(path, pattern) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sensitiveRules reads rules out of [reviewer "<name>"] sections with
+// 'sensitivePath' and 'mandatoryReviewer' settings, where the name of the
+// section names the rule. Sections without both are left out.
+func (c *Config) sensitiveRules() []SensitiveRule {
+	var rules []SensitiveRule
+	for _, name := range c.subsections() {
+		rule := SensitiveRule{Name: name}
+		for _, v := range c.GetAll(configSection + name + ".sensitivePath") {
+			rule.Paths = append(rule.Paths, splitList(v)...)
+		}
+		for _, v := range c.GetAll(configSection + name + ".mandatoryReviewer") {
+			rule.Reviewers = append(rule.Reviewers, splitList(v)...)
+		}
+
+		if len(rule.Paths) > 0 && len(rule.Reviewers) > 0 {
+			rules = append(rules, rule)
+		}
+	}
+
+	return rules
+}
+
+// changedPaths lists 'paths' along with every other file the last call to
+// FindFiles found changed, such as added files and files the filters left
+// out, under both names if renamed. Files standing in for added ones haven't
+// changed and are left out. Rules about what the branch touches, such as
+// SensitiveRules, go by these rather than by the files that are blamed.
+func (r *ContributionCounter) changedPaths(paths []string) []string {
+	set := make(map[string]bool)
+	for _, p := range paths {
+		if !r.standIns[p] {
+			set[p] = true
+		}
+	}
+	for _, p := range r.added {
+		set[p] = true
+	}
+	for _, c := range r.changes {
+		set[c.Path] = true
+		if c.From != "" {
+			set[c.From] = true
+		}
+	}
+
+	changed := make([]string, 0, len(set))
+	for p := range set {
+		changed = append(changed, p)
+	}
+	sort.Strings(changed)
+
+	return changed
+}
+
+// SensitiveChanges lists the files changed on the branch, whether they are
+// blamed or not, that match one of SensitiveRules. 'paths' are the files
+// FindFiles returned.
+func (r *ContributionCounter) SensitiveChanges(paths []string) []string {
+	var sensitive []string
+	for _, path := range r.changedPaths(paths) {
+		for _, rule := range r.SensitiveRules {
+			if rule.matches(path) {
+				sensitive = append(sensitive, path)
+				break
+			}
+		}
+	}
+
+	return sensitive
+}
+
+// addRequired adds the reviewers of every sensitive rule matching one of
+// 'paths' to the suggestions in 'top', marking them as required. Reviewers
+// that weren't suggested keep whatever experience they have in 'all'.
+func (r *ContributionCounter) addRequired(top, all Stats, paths []string) Stats {
+	for _, rule := range r.SensitiveRules {
+		matched := false
+		for _, path := range paths {
+			if rule.matches(path) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		var owners Stats
+		top, owners = r.mergeOwners(top, all, rule.Reviewers)
+		for _, stat := range owners {
+			if !containsString(stat.Required, rule.Name) {
+				stat.Required = append(stat.Required, rule.Name)
+			}
+		}
+	}
+
+	return top
+}
+
+// mergeOwners finds the Stats of the 'wanted' reviewers in 'top', adding the
+// ones missing to the end of it with what they have in 'all', if anything, so
+// rules can bring in reviewers the ranking left out. It returns 'top' along
+// with the Stats of 'wanted', once each and in order.
+func (r *ContributionCounter) mergeOwners(top, all Stats, wanted []string) (Stats, Stats) {
+	var owners Stats
+	for _, reviewer := range wanted {
+		reviewer = reviewerKey(reviewer, r.Mailmap)
+		if findStat(owners, reviewer) != nil {
+			continue
+		}
+
+		stat := findStat(top, reviewer)
+		if stat == nil {
+			stat = &Stat{Reviewer: reviewer}
+			if existing := findStat(all, reviewer); existing != nil {
+				copied := *existing
+				stat = &copied
+			}
+			top = append(top, stat)
+		}
+		owners = append(owners, stat)
+	}
+
+	return top, owners
+}
+
+func findStat(stats Stats, reviewer string) *Stat {
+	for _, s := range stats {
+		if s.Reviewer == reviewer {
+			return s
+		}
+	}
+	return nil
+}
+
+// matchesGlob reports whether a repository-relative path matches a pattern
+// of a SensitiveRule or a path filter. Patterns without wildcards match like
+// matchesPath.
+func matchesGlob(path, pattern string) bool {
+	pattern = strings.TrimPrefix(pattern, "/")
+	if !strings.ContainsAny(pattern, "*?") {
+		return matchesPath(path, pattern)
+	}
+
+	re, err := regexp.Compile(globExpr(pattern))
+	if err != nil {
+		return false
+	}
+	return re.MatchString(path)
+}
+
+// globExpr translates a glob pattern into an anchored regular expression.
+func globExpr(pattern string) string {
+	var expr bytes.Buffer
+	expr.WriteString("^")
+
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			expr.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			expr.WriteString(".*")
+			i++
+		case c == '*':
+			expr.WriteString("[^/]*")
+		case c == '?':
+			expr.WriteString("[^/]")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	expr.WriteString("$")
+	return expr.String()
+}
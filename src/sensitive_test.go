@@ -0,0 +1,154 @@
+package gitreviewers
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMatchesGlob(t *testing.T) {
+	tests := []struct {
+		path     string
+		pattern  string
+		expected bool
+	}{
+		{"auth/login.go", "auth/**", true},
+		{"auth/oauth/token.go", "auth/**", true},
+		{"src/auth/login.go", "auth/**", false},
+		{"src/auth/login.go", "**/auth/**", true},
+		{"auth/login.go", "**/auth/**", true},
+		{"crypto/aes.go", "crypto/*.go", true},
+		{"crypto/aes/aes.go", "crypto/*.go", false},
+		{"crypto/aes/aes.go", "crypto/**/*.go", true},
+		{"crypto/aes.go", "crypto/**/*.go", true},
+		{"keys/a.pem", "**/*.pem", true},
+		{"a.pem", "**/*.pem", true},
+		{"auth/a.go", "auth", true},
+		{"authz/a.go", "auth", false},
+		{"auth/a.go", "/auth/", true},
+		{"auth/a.go", "auth/?.go", true},
+		{"auth/ab.go", "auth/?.go", false},
+		{"auth.go", "auth.*", true},
+		{"authgo", "auth.go", false},
+	}
+
+	for _, tt := range tests {
+		if got := matchesGlob(tt.path, tt.pattern); got != tt.expected {
+			t.Errorf("Got %t, expected %t for '%s' against '%s'\n", got, tt.expected,
+				tt.path, tt.pattern)
+		}
+	}
+}
+
+func TestSensitiveRules(t *testing.T) {
+	c := &Config{Entries: []ConfigEntry{
+		{Key: "reviewer.security.sensitivepath", Value: "auth/**, crypto/**"},
+		{Key: "reviewer.security.mandatoryreviewer", Value: "sec@git-reviewer.com"},
+		{Key: "reviewer.since", Value: "2017-01-01"},
+		{Key: "reviewer.incomplete.sensitivepath", Value: "db/**"},
+		{Key: "reviewer.security.sensitivepath", Value: "keys/"},
+	}}
+
+	expected := []SensitiveRule{{
+		Name:      "security",
+		Paths:     []string{"auth/**", "crypto/**", "keys/"},
+		Reviewers: []string{"sec@git-reviewer.com"},
+	}}
+
+	if got := c.sensitiveRules(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Got '%+v', expected '%+v'\n", got, expected)
+	}
+}
+
+func TestAddRequired(t *testing.T) {
+	r := ContributionCounter{SensitiveRules: []SensitiveRule{
+		{"security", []string{"auth/**"}, []string{"abe@git-reviewer.com", "sec@git-reviewer.com"}},
+		{"billing", []string{"billing/**"}, []string{"ben@git-reviewer.com"}},
+		{"data", []string{"db/**"}, []string{"sec@git-reviewer.com"}},
+	}}
+
+	all := Stats{
+		{Reviewer: "abe@git-reviewer.com", Percentage: 0.6},
+		{Reviewer: "george@git-reviewer.com", Percentage: 0.3},
+		{Reviewer: "sec@git-reviewer.com", Percentage: 0.1},
+	}
+	top := Stats{all[0], all[1]}
+
+	got := r.addRequired(top, all, []string{"auth/login.go", "db/schema.sql"})
+
+	var summary []string
+	for _, s := range got {
+		summary = append(summary, fmt.Sprintf("%s %.1f %v", s.Reviewer, s.Percentage, s.Required))
+	}
+	expected := []string{
+		"abe@git-reviewer.com 0.6 [security]",
+		"george@git-reviewer.com 0.3 []",
+		"sec@git-reviewer.com 0.1 [security data]",
+	}
+
+	if !reflect.DeepEqual(summary, expected) {
+		t.Errorf("Got '%v', expected '%v'\n", summary, expected)
+	}
+}
+
+func TestRequiredForUnblamedFiles(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	cases := []struct {
+		branch string
+		file   string
+	}{
+		// Added files have nothing to blame
+		{"add-secret", "secrets/token.go"},
+		// and neither have files the filters leave out
+		{"change-workflow", "deploy/ci.yml"},
+	}
+
+	for _, c := range cases {
+		runGit(t, dir, "checkout", "-q", "-b", c.branch, "master")
+		if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(c.file)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, c.file), []byte("x\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		runGit(t, dir, "add", ".")
+		runGit(t, dir, "commit", "-q", "-m", "Add "+c.file,
+			"--author", "Ben Franklin <ben@git-reviewer.com>")
+
+		repo, _, err := OpenRepository(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := ContributionCounter{Repo: repo, Dir: dir, Since: "2000-01-01",
+			IgnoredPaths: []string{"deploy"},
+			SensitiveRules: []SensitiveRule{
+				{"security", []string{"secrets/**", "deploy/**"}, []string{"sec@git-reviewer.com"}},
+			}}
+
+		paths, err := r.FindFiles()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(paths) != 0 {
+			t.Fatalf("Got %v, expected nothing to blame on %s\n", paths, c.branch)
+		}
+
+		if sensitive := r.SensitiveChanges(paths); !reflect.DeepEqual(sensitive, []string{c.file}) {
+			t.Errorf("Got sensitive changes %v on %s, expected %s\n", sensitive, c.branch, c.file)
+		}
+
+		stats, err := r.SuggestReviewers(paths)
+		if err != nil {
+			t.Fatalf("Unexpected error suggesting reviewers on %s: %v\n", c.branch, err)
+		}
+		if len(stats) != 1 || stats[0].Reviewer != "sec@git-reviewer.com" ||
+			!reflect.DeepEqual(stats[0].Required, []string{"security"}) {
+			t.Errorf("Got %+v on %s, expected sec@git-reviewer.com to be required\n", stats, c.branch)
+		}
+	}
+}
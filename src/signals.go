@@ -0,0 +1,130 @@
+package gitreviewers
+
+import (
+	"bufio"
+	"bytes"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Signals holds the raw evidence of each author's experience with a set of
+// changes, before it is weighed into suggestions, so teams can audit and
+// tune the ranking offline. Set ContributionCounter.Signals to a new Signals
+// to collect them while finding reviewers.
+type Signals struct {
+	Base    string          `json:"base"`
+	Head    string          `json:"head"`
+	Since   string          `json:"since"`
+	Paths   []string        `json:"paths"`
+	Authors []AuthorSignals `json:"authors"`
+}
+
+// AuthorSignals holds the signals of a single author.
+type AuthorSignals struct {
+	Author string `json:"author"`
+	// Lines counts blamed lines owned in the changed files, and Share is
+	// the fraction of blamed lines they make up.
+	Lines int     `json:"lines"`
+	Share float64 `json:"share"`
+	// Files counts the changed files in which the author owns lines.
+	Files int `json:"files"`
+	// Commits counts commits to the changed files since Since.
+	Commits int `json:"commits"`
+	// LastTouched is the most recent day one of the owned lines was
+	// committed, as YYYY-MM-DD.
+	LastTouched string `json:"lastTouched,omitempty"`
+	// Reviews counts the reviews the author gave since Since, anywhere in
+	// the repository, according to commit trailers.
+	Reviews int `json:"reviews"`
+	// TopicCommits counts the author's commits since Since whose messages
+	// mention the topics of the changes, and Topics lists those topics. They
+	// are only collected when Topics is set.
+	TopicCommits int      `json:"topicCommits,omitempty"`
+	Topics       []string `json:"topics,omitempty"`
+	// Tickets lists the author's tickets in the same areas as the tickets
+	// of the changes, when Tickets is set.
+	Tickets []string `json:"tickets,omitempty"`
+}
+
+// collectSignals fills Signals from the blame counts of 'paths' along with
+// commit and review counts, which are only looked up when signals are
+// wanted.
+func (r *ContributionCounter) collectSignals(counts *contributions, paths []string) error {
+	defer r.Summary.stage("signals", time.Now())
+
+	commits, err := r.commitCounts(paths)
+	if err != nil {
+		return err
+	}
+
+	reviews := make(map[string]int)
+	if r.Repo != nil {
+		history, err := r.ReviewHistory()
+		if err != nil {
+			return errors.Wrap(err, "unable to read review history")
+		}
+		for _, review := range history {
+			reviews[review.Reviewer]++
+		}
+	}
+
+	authors := make(map[string]bool)
+	for _, m := range []map[string]int{counts.byAuthor, commits, reviews} {
+		for author := range m {
+			authors[author] = true
+		}
+	}
+	for _, matches := range []map[string]messageMatch{counts.topics, counts.tickets} {
+		for author, m := range matches {
+			if m.matched > 0 {
+				authors[author] = true
+			}
+		}
+	}
+
+	s := r.Signals
+	s.Base, s.Head, s.Since = r.baseRev(), r.headRev(), r.Since
+	s.Paths = append([]string{}, paths...)
+	s.Authors = nil
+	for author := range authors {
+		s.Authors = append(s.Authors, AuthorSignals{
+			Author:       author,
+			Lines:        counts.byAuthor[author],
+			Share:        counts.share(author, r.PerFile),
+			Files:        counts.filesTouched(author),
+			Commits:      commits[author],
+			LastTouched:  counts.lastTouched[author],
+			Reviews:      reviews[author],
+			TopicCommits: counts.topics[author].matched,
+			Topics:       counts.topics[author].top(len(counts.topics[author].terms)),
+			Tickets:      counts.tickets[author].top(len(counts.tickets[author].terms)),
+		})
+	}
+	sort.Slice(s.Authors, func(i, j int) bool {
+		return s.Authors[i].Author < s.Authors[j].Author
+	})
+
+	return nil
+}
+
+// commitCounts counts the commits each author made to 'paths' in the history
+// of the base revision, or AsOf, since Since.
+func (r *ContributionCounter) commitCounts(paths []string) (map[string]int, error) {
+	args := []string{"log", "--format=%ae", "--since=" + r.Since, r.historyRev(), "--"}
+	out, err := r.output(r.history(append(args, paths...)...)...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to count commits")
+	}
+
+	counts := make(map[string]int)
+	scn := bufio.NewScanner(bytes.NewReader(out))
+	for scn.Scan() {
+		if email := scn.Text(); email != "" {
+			counts[reviewerKey(email, r.Mailmap)]++
+		}
+	}
+
+	return counts, scn.Err()
+}
@@ -0,0 +1,51 @@
+package gitreviewers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCollectSignals(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "src", "a.go"),
+		[]byte("package a\n\nvar b = 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "commit", "-q", "-a", "--author", "Ben Franklin <ben@git-reviewer.com>",
+		"-m", "Add b\n\nReviewed-by: Abraham Lincoln <abe@git-reviewer.com>")
+	runGit(t, dir, "checkout", "-q", "-b", "feature")
+
+	repo, _, err := OpenRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := ContributionCounter{Repo: repo, Dir: dir, Since: "2000-01-01", Signals: &Signals{}}
+	if _, err := r.SuggestReviewers([]string{"src/a.go"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The root commit is a boundary, so its lines still count
+	var got []AuthorSignals
+	for _, a := range r.Signals.Authors {
+		a.LastTouched = ""
+		got = append(got, a)
+	}
+	expected := []AuthorSignals{
+		{Author: "abe@git-reviewer.com", Lines: 1, Share: 1.0 / 3.0, Files: 1, Commits: 1,
+			Reviews: 1},
+		{Author: "ben@git-reviewer.com", Lines: 2, Share: 2.0 / 3.0, Files: 1, Commits: 1},
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Got '%+v', expected '%+v'\n", got, expected)
+	}
+	if !reflect.DeepEqual(r.Signals.Paths, []string{"src/a.go"}) {
+		t.Errorf("Got paths %v, expected [src/a.go]\n", r.Signals.Paths)
+	}
+}
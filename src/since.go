@@ -0,0 +1,57 @@
+package gitreviewers
+
+import (
+	"strings"
+	"time"
+
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+// Moments the default Since counts back from, set through
+// ContributionCounter.SinceFrom.
+const (
+	// SinceFromMergeBase counts back from the day the branch diverged from
+	// its base, so long-running branches get the same window as fresh ones.
+	// It is the default.
+	SinceFromMergeBase = "merge-base"
+	// SinceFromToday counts back from today.
+	SinceFromToday = "today"
+)
+
+// SinceFromOptions lists the valid values of ContributionCounter.SinceFrom.
+var SinceFromOptions = []string{SinceFromMergeBase, SinceFromToday}
+
+// defaultSinceMonths is how far back the default Since goes.
+const defaultSinceMonths = 6
+
+// sinceAnchor is the moment the default Since counts back from: the day of
+// AsOf, today, or the day the base and head revisions diverged, picked by
+// SinceFrom. It falls back on today when there is no merge base to date.
+func (r *ContributionCounter) sinceAnchor() time.Time {
+	if r.AsOf != "" || r.SinceFrom == SinceFromToday {
+		return r.now()
+	}
+
+	mergeBase, err := r.mergeBase(r.baseRev(), r.headRev())
+	if err != nil {
+		return r.now()
+	}
+	if r.NoExec {
+		c, err := r.Repo.CommitObject(plumbing.NewHash(mergeBase))
+		if err != nil {
+			return r.now()
+		}
+		return c.Committer.When
+	}
+
+	out, err := r.output("show", "-s", "--format=%cI", mergeBase)
+	if err != nil {
+		return r.now()
+	}
+	t, err := time.Parse(time.RFC3339, strings.TrimSpace(string(out)))
+	if err != nil {
+		return r.now()
+	}
+
+	return t
+}
@@ -0,0 +1,58 @@
+package gitreviewers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDefaultSince(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	write := func(content string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, "src", "a.go"),
+			[]byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The branch diverges from a commit made long ago
+	os.Setenv("GIT_COMMITTER_DATE", "2017-03-01T12:00:00Z")
+	defer os.Unsetenv("GIT_COMMITTER_DATE")
+	write("package a\n\nvar x = 1\n")
+	runGit(t, dir, "commit", "-q", "-a", "-m", "Add x")
+	os.Unsetenv("GIT_COMMITTER_DATE")
+
+	runGit(t, dir, "checkout", "-q", "-b", "feature")
+	write("package a\n\nvar x = 2\n")
+	runGit(t, dir, "commit", "-q", "-a", "-m", "Change x")
+
+	today := time.Now().AddDate(0, -6, 0).Format("2006-01-02")
+	cases := []struct {
+		SinceFrom string
+		Since     string
+		Expected  string
+	}{
+		{"", "", "2016-09-01"},
+		{SinceFromMergeBase, "", "2016-09-01"},
+		{SinceFromToday, "", today},
+		{"", "2015-01-01", "2015-01-01"},
+	}
+
+	for _, c := range cases {
+		r := ContributionCounter{Dir: dir, Head: "feature", SinceFrom: c.SinceFrom, Since: c.Since}
+		r.defaultSince()
+
+		if r.Since != c.Expected {
+			t.Errorf("Got %s, expected %s from '%s'\n", r.Since, c.Expected, c.SinceFrom)
+		}
+	}
+
+	r := ContributionCounter{Dir: dir, Head: "feature", Base: "missing"}
+	if r.defaultSince(); r.Since != today {
+		t.Errorf("Got %s, expected %s without a merge base\n", r.Since, today)
+	}
+}
@@ -0,0 +1,136 @@
+package gitreviewers
+
+import (
+	"context"
+	"time"
+)
+
+// stableSnapshots is how many snapshots in a row must rank the same
+// reviewers, in the same order, before the ranking is marked stable.
+const stableSnapshots = 3
+
+// Suggestion is a snapshot of the suggested reviewers while the changed files
+// are being blamed.
+type Suggestion struct {
+	// Reviewers holds the suggestions given the files blamed so far.
+	Reviewers Stats
+	// Blamed counts the changed files blamed so far, out of Total. Files
+	// skipped for being too large are never blamed.
+	Blamed int
+	Total  int
+	// Stable is set once the ranking stopped changing over the last few
+	// files. Later files can still change it, but rarely do.
+	Stable bool
+	// Final is set on the last suggestion, made from every changed file
+	// along with Topics and Tickets if they are set. Nothing is sent after
+	// it.
+	Final bool
+	// Err is set, on the final suggestion, if finding reviewers failed.
+	Err error
+}
+
+// SuggestStream finds the files changed on the branch and sends provisional
+// suggestions as each of them is blamed, so programs can show early results
+// for huge changes. Each suggestion is a snapshot: it replaces the previous
+// one rather than adding to it. The channel is closed after the final
+// suggestion, or as soon as 'ctx' is done, which also kills the git blame
+// commands still running.
+//
+// Results are not cached, and snapshots made from files with nobody to suggest
+// yet are left out.
+func (r *ContributionCounter) SuggestStream(ctx context.Context) (<-chan Suggestion, error) {
+	paths, err := r.FindFiles()
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, &NoChangesError{Base: r.baseRev(), Head: r.headRev()}
+	}
+
+	r.defaultSince()
+
+	start := time.Now()
+	counts, reporter, blamed, err := r.startBlame(ctx, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan Suggestion)
+	go func() {
+		defer close(out)
+
+		send := func(s Suggestion) bool {
+			select {
+			case out <- s:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		var (
+			done     []string
+			previous Stats
+			same     int
+			firstErr error
+		)
+		for i := 0; i < blamed; i++ {
+			var report fileReport
+			select {
+			case report = <-reporter:
+			case <-ctx.Done():
+				return
+			}
+
+			if err := r.record(counts, report); err != nil && firstErr == nil {
+				firstErr = err
+			}
+			done = append(done, report.path)
+			if firstErr != nil {
+				continue
+			}
+
+			topN, err := r.rank(counts, done, false)
+			if err != nil {
+				continue
+			}
+			if sameRanking(topN, previous) {
+				same++
+			} else {
+				same = 1
+			}
+			previous = topN
+
+			if !send(Suggestion{Reviewers: topN, Blamed: i + 1, Total: len(paths),
+				Stable: same >= stableSnapshots}) {
+				return
+			}
+		}
+		r.Summary.stage("blame", start)
+
+		final := Suggestion{Blamed: blamed, Total: len(paths), Stable: true, Final: true}
+		if firstErr != nil {
+			final.Err = firstErr
+		} else {
+			r.Summary.blamed(counts, len(paths))
+			final.Reviewers, final.Err = r.rank(counts, paths, true)
+		}
+		send(final)
+	}()
+
+	return out, nil
+}
+
+// sameRanking reports whether two rankings suggest the same reviewers in the
+// same order.
+func sameRanking(a, b Stats) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Reviewer != b[i].Reviewer {
+			return false
+		}
+	}
+	return true
+}
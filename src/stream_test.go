@@ -0,0 +1,102 @@
+package gitreviewers
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSuggestStream(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	for i := 0; i < 5; i++ {
+		if err := ioutil.WriteFile(filepath.Join(dir, "src", fmt.Sprintf("f%d.go", i)),
+			[]byte("package a\n\nvar x = 1\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-q", "--author", "Ben Franklin <ben@git-reviewer.com>",
+		"-m", "Add files")
+
+	runGit(t, dir, "checkout", "-q", "-b", "feature")
+	for i := 0; i < 5; i++ {
+		if err := ioutil.WriteFile(filepath.Join(dir, "src", fmt.Sprintf("f%d.go", i)),
+			[]byte("package a\n\nvar x = 2\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runGit(t, dir, "commit", "-q", "-a", "-m", "Change files")
+
+	repo, _, err := OpenRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := ContributionCounter{Repo: repo, Dir: dir, Since: "2000-01-01", Head: "feature"}
+
+	stream, err := r.SuggestStream(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []Suggestion
+	for s := range stream {
+		got = append(got, s)
+	}
+
+	if len(got) != 6 {
+		t.Fatalf("Got %d suggestions, expected 5 snapshots and a final one\n", len(got))
+	}
+	for i, s := range got[:5] {
+		if s.Blamed != i+1 || s.Total != 5 || s.Final {
+			t.Errorf("Got snapshot %+v, expected %d of 5 files blamed\n", s, i+1)
+		}
+		if expected := i+1 >= stableSnapshots; s.Stable != expected {
+			t.Errorf("Got stable %t, expected %t after %d files\n", s.Stable, expected, i+1)
+		}
+	}
+
+	final := got[5]
+	if !final.Final || !final.Stable || final.Err != nil {
+		t.Fatalf("Got final %+v, expected a stable final suggestion\n", final)
+	}
+	if len(final.Reviewers) != 1 || final.Reviewers[0].Reviewer != "ben@git-reviewer.com" {
+		t.Errorf("Got reviewers %v, expected ben\n", final.Reviewers)
+	}
+}
+
+func TestSuggestStreamCanceled(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	runGit(t, dir, "checkout", "-q", "-b", "feature")
+	if err := ioutil.WriteFile(filepath.Join(dir, "src", "a.go"),
+		[]byte("package b\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "commit", "-q", "-a", "-m", "Rename package")
+
+	repo, _, err := OpenRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := ContributionCounter{Repo: repo, Dir: dir, Since: "2000-01-01", Head: "feature"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := r.SuggestStream(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+
+	// Ranging over the stream only ends if it is closed once canceled
+	for s := range stream {
+		if s.Err != nil {
+			t.Errorf("Got error %s, expected none\n", s.Err)
+		}
+	}
+}
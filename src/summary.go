@@ -0,0 +1,175 @@
+package gitreviewers
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RunSummary collects statistics about a run to help explain surprising
+// suggestions. Set ContributionCounter.Summary to a new RunSummary to start
+// collecting. It is safe for concurrent use, and a nil RunSummary collects
+// nothing.
+type RunSummary struct {
+	mu sync.Mutex
+
+	// Diffed holds every path that changed between the base and head.
+	Diffed map[string]bool
+	// Filtered holds the reason each filtered path was left out, such as
+	// "extension", "path", "lfs" or "binary".
+	Filtered map[string]string
+	// Skipped holds the reason each changed file was left out of the blame
+	// stage, such as being "too large".
+	Skipped map[string]string
+	// FilesBlamed, Lines and Authors describe the blame stage.
+	FilesBlamed int
+	Lines       int
+	Authors     int
+	// CacheHits and CacheMisses count lookups of cached suggestions.
+	CacheHits   int
+	CacheMisses int
+	// Stages holds how long each stage took, in the order they finished.
+	Stages []StageTime
+}
+
+// StageTime records how long a stage of a run took.
+type StageTime struct {
+	Name     string
+	Duration time.Duration
+}
+
+func (s *RunSummary) diffed(path string) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Diffed == nil {
+		s.Diffed = make(map[string]bool)
+	}
+	s.Diffed[path] = true
+}
+
+func (s *RunSummary) filtered(path, reason string) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Filtered == nil {
+		s.Filtered = make(map[string]string)
+	}
+	s.Filtered[path] = reason
+}
+
+func (s *RunSummary) blamed(c *contributions, files int) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Skipped == nil {
+		s.Skipped = make(map[string]string)
+	}
+	for path, reason := range c.skipped {
+		s.Skipped[path] = reason
+	}
+
+	s.FilesBlamed += files - len(c.skipped)
+	s.Lines += c.total
+	s.Authors += len(c.byAuthor)
+}
+
+func (s *RunSummary) cache(hit bool) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if hit {
+		s.CacheHits++
+	} else {
+		s.CacheMisses++
+	}
+}
+
+// stage records the time since 'start' under 'name'. It is meant to be
+// deferred at the beginning of a stage.
+func (s *RunSummary) stage(name string, start time.Time) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Stages = append(s.Stages, StageTime{name, time.Since(start)})
+}
+
+// Print writes the summary to 'w' along with the total time of the run.
+func (s *RunSummary) Print(w io.Writer, total time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	why := countReasons(s.Filtered)
+	skipped := countReasons(s.Skipped)
+
+	var stages []string
+	for _, st := range s.Stages {
+		stages = append(stages, fmt.Sprintf("%s %s", st.Name, roundDuration(st.Duration)))
+	}
+
+	fmt.Fprintln(w, "Summary:")
+	fmt.Fprintf(w, "  files diffed:  %d\n", len(s.Diffed))
+	if len(why) > 0 {
+		fmt.Fprintf(w, "  filtered out:  %d (%s)\n", len(s.Filtered), strings.Join(why, ", "))
+	} else {
+		fmt.Fprintf(w, "  filtered out:  0\n")
+	}
+	if len(skipped) > 0 {
+		fmt.Fprintf(w, "  skipped:       %d (%s)\n", len(s.Skipped), strings.Join(skipped, ", "))
+	}
+	fmt.Fprintf(w, "  files blamed:  %d\n", s.FilesBlamed)
+	fmt.Fprintf(w, "  lines counted: %d by %s\n", s.Lines, pluralize(s.Authors, "author"))
+	fmt.Fprintf(w, "  cache:         %s, %s\n", pluralize(s.CacheHits, "hit"),
+		pluralize(s.CacheMisses, "miss"))
+	if len(stages) > 0 {
+		fmt.Fprintf(w, "  stages:        %s\n", strings.Join(stages, ", "))
+	}
+	fmt.Fprintf(w, "  total:         %s\n", roundDuration(total))
+}
+
+// countReasons tallies the reasons of a set of paths, such as "2 lfs".
+func countReasons(paths map[string]string) []string {
+	reasons := make(map[string]int)
+	for _, reason := range paths {
+		reasons[reason]++
+	}
+
+	var why []string
+	for reason, n := range reasons {
+		why = append(why, fmt.Sprintf("%d %s", n, reason))
+	}
+	sort.Strings(why)
+
+	return why
+}
+
+// roundDuration keeps durations readable by dropping sub-millisecond noise.
+func roundDuration(d time.Duration) time.Duration {
+	if d < time.Millisecond {
+		return d
+	}
+	return d.Round(time.Millisecond)
+}
@@ -0,0 +1,53 @@
+package gitreviewers
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunSummary(t *testing.T) {
+	s := &RunSummary{}
+
+	r := ContributionCounter{IgnoredExtensions: []string{"md"}, Summary: s}
+	for _, path := range []string{"a.go", "README.md", "a.go"} {
+		r.consider(path)
+	}
+	s.filtered("big.psd", "lfs")
+
+	c := newContributions()
+	c.add("a.go", []attribution{{author: "abe@git-reviewer.com"}, {author: "bob@git-reviewer.com"}}, 2)
+	c.skip("data.csv", skipTooLarge)
+	s.blamed(c, 2)
+	s.cache(false)
+	s.Stages = append(s.Stages, StageTime{"blame", 1500 * time.Microsecond})
+
+	var buf bytes.Buffer
+	s.Print(&buf, 2*time.Second)
+
+	for _, expected := range []string{
+		"files diffed:  2\n",
+		"filtered out:  2 (1 extension, 1 lfs)\n",
+		"skipped:       1 (1 too large)\n",
+		"files blamed:  1\n",
+		"lines counted: 2 by 2 authors\n",
+		"cache:         0 hits, 1 miss\n",
+		"stages:        blame 2ms\n",
+		"total:         2s\n",
+	} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("Expected '%s' in summary:\n%s", strings.TrimSpace(expected), buf.String())
+		}
+	}
+}
+
+func TestNilRunSummary(t *testing.T) {
+	var s *RunSummary
+
+	// Collecting into a nil summary is a no-op
+	s.diffed("a.go")
+	s.filtered("a.go", "path")
+	s.cache(true)
+	s.stage("diff", time.Now())
+}
@@ -0,0 +1,77 @@
+package gitreviewers
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/user"
+)
+
+// CommandRunner runs the git commands of a ContributionCounter. gitcmd.Runner
+// is the one used by default; tests can plug in one that answers with canned
+// output instead of running git.
+type CommandRunner interface {
+	// Output runs git with 'args' and returns its standard output. Git
+	// failing is reported as an *exec.ExitError carrying its standard error,
+	// and the command is killed if 'ctx' is done before it finishes.
+	Output(ctx context.Context, args ...string) ([]byte, error)
+}
+
+// FS opens the files a ContributionCounter reads outside of the repository,
+// such as mailmap and teams files.
+type FS interface {
+	Open(name string) (io.ReadCloser, error)
+}
+
+// UserInfo tells who is running the ContributionCounter, to find the files
+// in their home directory.
+type UserInfo interface {
+	HomeDir() (string, error)
+}
+
+// osFS opens files from the real filesystem.
+type osFS struct{}
+
+func (osFS) Open(name string) (io.ReadCloser, error) {
+	return os.Open(name)
+}
+
+// currentUser is the user running the process.
+type currentUser struct{}
+
+func (currentUser) HomeDir() (string, error) {
+	u, err := user.Current()
+	if err != nil {
+		return "", err
+	}
+	return u.HomeDir, nil
+}
+
+// commandRunner returns Runner, or a gitcmd.Runner for GitBin in Dir if it
+// isn't set. With NoExec, every command is refused.
+func (r *ContributionCounter) commandRunner() CommandRunner {
+	if r.NoExec {
+		return noExecRunner{}
+	}
+	if r.Runner != nil {
+		return r.Runner
+	}
+	return r.runner()
+}
+
+// open opens 'name' through FS, or from the real filesystem if it isn't set.
+func (r *ContributionCounter) open(name string) (io.ReadCloser, error) {
+	if r.FS != nil {
+		return r.FS.Open(name)
+	}
+	return osFS{}.Open(name)
+}
+
+// homeDir finds the home directory of User, or of the user running the
+// process if it isn't set.
+func (r *ContributionCounter) homeDir() (string, error) {
+	if r.User != nil {
+		return r.User.HomeDir()
+	}
+	return currentUser{}.HomeDir()
+}
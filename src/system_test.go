@@ -0,0 +1,175 @@
+package gitreviewers
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// fakeRunner answers git commands with canned output, keyed by their
+// arguments joined with spaces, and fails for any other command.
+type fakeRunner map[string]string
+
+func (f fakeRunner) Output(ctx context.Context, args ...string) ([]byte, error) {
+	out, ok := f[strings.Join(args, " ")]
+	if !ok {
+		return nil, errors.Errorf("unexpected git %s", strings.Join(args, " "))
+	}
+	return []byte(out), nil
+}
+
+// mapFS holds the content of files by path.
+type mapFS map[string]string
+
+func (m mapFS) Open(name string) (io.ReadCloser, error) {
+	content, ok := m[name]
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	return ioutil.NopCloser(strings.NewReader(content)), nil
+}
+
+// homeDir is a user whose home directory is the string itself, or who has
+// none if it is empty.
+type homeDir string
+
+func (h homeDir) HomeDir() (string, error) {
+	if h == "" {
+		return "", errors.New("no home directory")
+	}
+	return string(h), nil
+}
+
+func TestBlameAttributionsWithRunner(t *testing.T) {
+	r := ContributionCounter{
+		Since: "2017-01-01",
+		Runner: fakeRunner{
+			"blame --porcelain master -- src/a.go": "" +
+				"ff2ccfe9aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa 1 1 1\n" +
+				"author-mail <abe@gmail.com>\n" +
+				"author-time 1483376400\n" +
+				"author-tz -0700\n" +
+				"\tpackage a\n" +
+				"ad672de0bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb 2 2 1\n" +
+				"author-mail <ben@git-reviewer.com>\n" +
+				"author-time 1457082000\n" +
+				"author-tz +0100\n" +
+				"\t\n" +
+				"0000000000000000000000000000000000000000 3 3 1\n" +
+				"author-mail <not.committed.yet>\n" +
+				"author-time 1508214476\n" +
+				"author-tz +0000\n" +
+				"\t// wip\n",
+		},
+		Mailmap: mailmap{"abe@gmail.com": "abe@git-reviewer.com"},
+	}
+
+	attributions, lines, err := r.blameAttributions("src/a.go", "master")
+	if err != nil {
+		t.Fatalf("Unexpected error blaming: %v\n", err)
+	}
+
+	// Ben's line is too old to count and the uncommitted one isn't counted
+	// at all
+	expected := []attribution{{author: "abe@git-reviewer.com", date: "2017-01-02"}}
+	if lines != 2 || !reflect.DeepEqual(attributions, expected) {
+		t.Errorf("Got %v over %d lines, expected %v over 2\n", attributions, lines, expected)
+	}
+
+	if _, _, err := r.blameAttributions("src/b.go", "master"); err == nil {
+		t.Error("Expected an error when git fails")
+	}
+}
+
+func TestRunAndReport(t *testing.T) {
+	attribute := func(path, rev string) ([]attribution, int, error) {
+		return []attribution{{author: rev + "@git-reviewer.com"}}, 1, nil
+	}
+
+	reporter := make(chan fileReport, 1)
+	runAndReport(context.Background(), "a.go", "abe", attribute, reporter)
+
+	expected := fileReport{"a.go", []attribution{{author: "abe@git-reviewer.com"}}, 1, nil}
+	if actual := <-reporter; !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %+v, expected %+v\n", actual, expected)
+	}
+}
+
+func TestRunAndReportCanceled(t *testing.T) {
+	attribute := func(path, rev string) ([]attribution, int, error) {
+		t.Errorf("Blamed %s, expected nothing to be blamed once canceled\n", path)
+		return nil, 0, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	reporter := make(chan fileReport, 1)
+	runAndReport(ctx, "a.go", "abe", attribute, reporter)
+
+	if actual := <-reporter; actual.err != context.Canceled {
+		t.Errorf("Got error %v, expected %v\n", actual.err, context.Canceled)
+	}
+}
+
+func TestBlameCanceled(t *testing.T) {
+	dir := newTestRepo(t)
+	repo, _, err := OpenRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	r := ContributionCounter{Repo: repo, Dir: dir, ctx: ctx}
+	if _, _, err := r.blameAttributions("src/a.go", "master"); err == nil {
+		t.Error("Expected an error blaming once canceled")
+	}
+}
+
+func TestBuildMailmapGuessesHome(t *testing.T) {
+	fs := mapFS{
+		"/home/abe/.mailmap": "<abe@git-reviewer.com> <abe@gmail.com>\n",
+		"/repo/.mailmap":     "<ben@git-reviewer.com> <ben@gmail.com>\n",
+		"/repo/teams":        "Platform <abe@gmail.com>\n",
+	}
+
+	cases := []struct {
+		user     homeDir
+		paths    []string
+		expected string
+	}{
+		{"/home/abe", nil, "abe@git-reviewer.com"},
+		{"", nil, "abe@gmail.com"},
+		{"/home/ben", nil, "abe@gmail.com"},
+		// Explicit paths are read instead of the home directory, skipping
+		// missing files
+		{"/home/abe", []string{"/repo/missing", "/repo/.mailmap"}, "abe@gmail.com"},
+	}
+
+	for _, c := range cases {
+		r := ContributionCounter{FS: fs, User: c.user}
+		r.BuildMailmap(c.paths...)
+
+		if actual := reviewerKey("abe@gmail.com", r.Mailmap); actual != c.expected {
+			t.Errorf("Got %s for %q with %v, expected %s\n", actual, c.user, c.paths,
+				c.expected)
+		}
+	}
+
+	r := ContributionCounter{FS: fs, User: homeDir("/home/abe")}
+	r.BuildMailmap()
+	if err := r.BuildTeams("/repo/teams"); err != nil {
+		t.Fatalf("Unexpected error reading teams: %v\n", err)
+	}
+	if team := r.Teams["abe@git-reviewer.com"]; team != "Platform" {
+		t.Errorf("Got team '%s', expected Platform\n", team)
+	}
+}
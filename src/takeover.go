@@ -0,0 +1,98 @@
+package gitreviewers
+
+import (
+	"sort"
+	"time"
+)
+
+// Takeover is a changed file the branch makes someone the majority owner of,
+// taking it over from the person who owned the most of it before.
+type Takeover struct {
+	Path string
+	// Author will own After of the lines of the file once the branch merges,
+	// up from Before.
+	Author string
+	Before float64
+	After  float64
+	// Previous owned PreviousShare of the lines before the branch.
+	Previous      string
+	PreviousShare float64
+}
+
+// countsAt blames 'paths' at the revision 'blameAt' names, one of
+// BlameAtOptions, whatever BlameAt and AsOf are set to. The commits of the
+// branch are credited, as they will be once it merges.
+func (r *ContributionCounter) countsAt(blameAt string, paths []string) (*contributions, error) {
+	at := *r
+	at.BlameAt, at.AsOf, at.asOfPoint, at.Summary = blameAt, "", nil, nil
+	at.creditBranch = true
+	return at.generateCounts(paths)
+}
+
+// blamedAtBase reports whether changed files are blamed at the base
+// revision, as they are by default.
+func (r *ContributionCounter) blamedAtBase() bool {
+	return r.AsOf == "" && (r.BlameAt == "" || r.BlameAt == BlameAtBase)
+}
+
+// takeovers finds the changed files in 'paths' someone takes over on the
+// branch, see WarnTakeovers. 'counts' are the blame counts suggestions were
+// made from, which are reused as the ownership before the branch if they
+// were measured at the base revision.
+func (r *ContributionCounter) takeovers(counts *contributions, paths []string) ([]Takeover, error) {
+	defer r.Summary.stage("takeovers", time.Now())
+
+	// Files standing in for added ones don't change
+	var changed []string
+	for _, p := range paths {
+		if !r.standIns[p] {
+			changed = append(changed, p)
+		}
+	}
+
+	before := counts
+	if !r.blamedAtBase() {
+		var err error
+		if before, err = r.countsAt(BlameAtBase, changed); err != nil {
+			return nil, err
+		}
+	}
+	after, err := r.countsAt(BlameAtHead, changed)
+	if err != nil {
+		return nil, err
+	}
+
+	return findTakeovers(before, after, changed), nil
+}
+
+// findTakeovers compares who owns each of 'paths' 'before' and 'after' the
+// branch. A file is taken over when someone who gained lines on the branch
+// owns most of it afterwards, but didn't before, and someone else owned the
+// most of it then. Takeovers are sorted by path.
+func findTakeovers(before, after *contributions, paths []string) []Takeover {
+	var takeovers []Takeover
+	for _, path := range paths {
+		if before.fileLines[path] == 0 || after.fileLines[path] == 0 {
+			continue
+		}
+
+		author, lines := topOwner(after.byFile[path])
+		share := float64(lines) / float64(after.fileLines[path])
+		previous, previousLines := topOwner(before.byFile[path])
+		had := before.byFile[path][author]
+		if author == "" || share <= 0.5 || previous == "" || previous == author ||
+			lines <= had {
+			continue
+		}
+
+		takeovers = append(takeovers, Takeover{Path: path, Author: author,
+			Before: float64(had) / float64(before.fileLines[path]), After: share,
+			Previous:      previous,
+			PreviousShare: float64(previousLines) / float64(before.fileLines[path])})
+	}
+	sort.Slice(takeovers, func(i, j int) bool {
+		return takeovers[i].Path < takeovers[j].Path
+	})
+
+	return takeovers
+}
@@ -0,0 +1,62 @@
+package gitreviewers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFindTakeovers(t *testing.T) {
+	before, after := newContributions(), newContributions()
+
+	abe, ben, cal := attribution{author: "abe"}, attribution{author: "ben"}, attribution{author: "cal"}
+	// Ben takes a.go over from Abe, but only adds to Cal's b.go
+	before.add("a.go", []attribution{abe, abe, ben}, 3)
+	after.add("a.go", []attribution{abe, abe, ben, ben, ben}, 5)
+	before.add("b.go", []attribution{cal, cal}, 2)
+	after.add("b.go", []attribution{cal, cal, ben}, 3)
+	// Abe owned most of c.go already, and nobody gained lines in d.go
+	before.add("c.go", []attribution{abe, abe, ben}, 3)
+	after.add("c.go", []attribution{abe, abe, abe, ben}, 4)
+	before.add("d.go", []attribution{abe, ben, ben}, 4)
+	after.add("d.go", []attribution{ben, ben}, 2)
+
+	expected := []Takeover{{Path: "a.go", Author: "ben", Before: 1.0 / 3, After: 0.6,
+		Previous: "abe", PreviousShare: 2.0 / 3}}
+	actual := findTakeovers(before, after, []string{"d.go", "c.go", "b.go", "a.go"})
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %+v, expected %+v\n", actual, expected)
+	}
+}
+
+func TestWarnTakeovers(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	runGit(t, dir, "checkout", "-q", "-b", "feature")
+	if err := ioutil.WriteFile(filepath.Join(dir, "src", "a.go"),
+		[]byte("package a\n\nvar x = 1\nvar y = 2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "commit", "-q", "-a", "-m", "Add x and y",
+		"--author", "Ben Franklin <ben@git-reviewer.com>")
+
+	repo, _, err := OpenRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := ContributionCounter{Repo: repo, Dir: dir, Since: "2000-01-01", WarnTakeovers: true}
+
+	out, err := r.FindReviewers([]string{"src/a.go"})
+	if err != nil {
+		t.Fatalf("Unexpected error finding reviewers: %v\n", err)
+	}
+	expected := "  src/a.go: ben@git-reviewer.com takes over from abe@git-reviewer.com" +
+		" (75% of the lines, up from 0%)\n"
+	if !strings.Contains(out, expected) {
+		t.Errorf("Got %s, expected it to contain %s\n", out, expected)
+	}
+}
@@ -0,0 +1,60 @@
+package gitreviewers
+
+import (
+	"path"
+	"strings"
+)
+
+// testDirs are directories whose files are all tests or test fixtures.
+var testDirs = map[string]bool{
+	"test":      true,
+	"tests":     true,
+	"__tests__": true,
+	"spec":      true,
+	"testdata":  true,
+}
+
+// IsTestFile reports whether 'p' looks like test code rather than production
+// code, following common conventions: Go's "_test.go" files, JavaScript's
+// ".test." and ".spec." files, Python's "test_" and "_test.py" files, Java's
+// "Test.java" classes, and anything under test, tests, __tests__, spec or
+// testdata directories.
+func IsTestFile(p string) bool {
+	for _, dir := range strings.Split(path.Dir(p), "/") {
+		if testDirs[dir] {
+			return true
+		}
+	}
+
+	base := path.Base(p)
+	ext := path.Ext(base)
+	name := strings.TrimSuffix(base, ext)
+
+	switch {
+	case strings.HasSuffix(name, "_test"):
+		return true
+	case strings.Contains(base, ".test.") || strings.Contains(base, ".spec."):
+		return true
+	case ext == ".py" && strings.HasPrefix(name, "test_"):
+		return true
+	case ext == ".java" && (strings.HasSuffix(name, "Test") || strings.HasSuffix(name, "Tests")):
+		return true
+	}
+
+	return false
+}
+
+// SplitTests separates changed files into production code and tests, so
+// reviewers can be suggested for each. The best reviewer of an implementation
+// isn't always whoever owns its test harness.
+func SplitTests(paths []string) (production, tests []string) {
+	for _, p := range paths {
+		if IsTestFile(p) {
+			tests = append(tests, p)
+		} else {
+			production = append(production, p)
+		}
+	}
+
+	return production, tests
+}
@@ -0,0 +1,47 @@
+package gitreviewers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIsTestFile(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"src/reviewers.go", false},
+		{"src/reviewers_test.go", true},
+		{"test/helpers.sh", true},
+		{"pkg/tests/fixtures.json", true},
+		{"web/__tests__/app.js", true},
+		{"spec/models/user_spec.rb", true},
+		{"src/testdata/blame/a.blame", true},
+		{"web/app.test.js", true},
+		{"web/app.spec.ts", true},
+		{"lib/test_parser.py", true},
+		{"lib/parser_test.py", true},
+		{"src/main/java/ParserTest.java", true},
+		{"src/main/java/Parser.java", false},
+		{"latest/notes.md", false},
+		{"contest.go", false},
+		{"testing/fake.go", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsTestFile(tt.path); got != tt.expected {
+			t.Errorf("Got %t, expected %t for %s\n", got, tt.expected, tt.path)
+		}
+	}
+}
+
+func TestSplitTests(t *testing.T) {
+	production, tests := SplitTests([]string{"a.go", "a_test.go", "test/b.sh", "b.go"})
+
+	if expected := []string{"a.go", "b.go"}; !reflect.DeepEqual(production, expected) {
+		t.Errorf("Got '%v', expected '%v'\n", production, expected)
+	}
+	if expected := []string{"a_test.go", "test/b.sh"}; !reflect.DeepEqual(tests, expected) {
+		t.Errorf("Got '%v', expected '%v'\n", tests, expected)
+	}
+}
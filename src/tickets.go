@@ -0,0 +1,366 @@
+package gitreviewers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ticketWeight is the most working on tickets of the same areas as the
+// changes adds to the share of lines a candidate owns when ranking them.
+const ticketWeight = 0.25
+
+// DefaultTicketPattern matches Jira-style keys such as "PROJ-123" and issue
+// numbers such as "#123" in commit messages.
+var DefaultTicketPattern = regexp.MustCompile(`\b[A-Z][A-Z0-9_]+-[0-9]+\b|(?:^|[^\w&/])(#[0-9]+)\b`)
+
+// TicketProvider looks up what tickets referenced in commit messages are
+// about, so people who worked on related tickets can be suggested.
+type TicketProvider interface {
+	// Area names the component, epic or project 'ticket' belongs to, or
+	// returns an empty string if it belongs to none or is unknown.
+	Area(ticket string) (string, error)
+}
+
+// BatchTicketProvider is a TicketProvider that can look up many tickets at
+// once, which saves a request per ticket.
+type BatchTicketProvider interface {
+	TicketProvider
+	// Areas looks up the area of each of 'tickets', leaving out those that
+	// belong to none or are unknown.
+	Areas(tickets []string) (map[string]string, error)
+}
+
+// ExtractTickets finds the distinct tickets referenced in 'text' with
+// 'pattern', in order of appearance. A pattern with a capturing group
+// extracts what its first group matched instead of the whole match.
+func ExtractTickets(text string, pattern *regexp.Regexp) []string {
+	var (
+		tickets []string
+		seen    = make(map[string]bool)
+	)
+
+	for _, m := range pattern.FindAllStringSubmatch(text, -1) {
+		ticket := m[0]
+		for _, group := range m[1:] {
+			if group != "" {
+				ticket = group
+				break
+			}
+		}
+
+		if !seen[ticket] {
+			seen[ticket] = true
+			tickets = append(tickets, ticket)
+		}
+	}
+
+	return tickets
+}
+
+// ProjectProvider groups Jira-style tickets by project, the part of their key
+// before the dash, without asking any ticket system. Issue numbers such as
+// "#123" have no project.
+type ProjectProvider struct{}
+
+// Area returns the project of a Jira-style key.
+func (ProjectProvider) Area(ticket string) (string, error) {
+	if i := strings.LastIndex(ticket, "-"); i > 0 && !strings.HasPrefix(ticket, "#") {
+		return "project " + ticket[:i], nil
+	}
+	return "", nil
+}
+
+// JiraProvider looks up the epic or component of tickets in Jira through its
+// REST API. Tickets that aren't Jira keys or that Jira doesn't know belong to
+// no area.
+type JiraProvider struct {
+	// URL is the address of the Jira instance, such as
+	// "https://example.atlassian.net".
+	URL string
+	// User and Token authenticate with an API token. Without User, Token is
+	// sent as a bearer token, as for personal access tokens.
+	User  string
+	Token string
+	// Client sends the requests, a client with a 10 second timeout if nil.
+	Client *http.Client
+}
+
+// NewJiraProvider builds a JiraProvider for the Jira instance at 'url'.
+func NewJiraProvider(url, user, token string) *JiraProvider {
+	return &JiraProvider{URL: strings.TrimSuffix(url, "/"), User: user, Token: token}
+}
+
+// TicketProvider picks how tickets are looked up: in the Jira instance at
+// reviewer.jiraUrl if set, authenticated as reviewer.jiraUser with the API
+// token in the JIRA_API_TOKEN environment variable, or by project otherwise.
+func (c *Config) TicketProvider() TicketProvider {
+	if u, _ := c.Get("reviewer.jiraUrl"); u != "" {
+		user, _ := c.Get("reviewer.jiraUser")
+		return NewJiraProvider(u, user, os.Getenv("JIRA_API_TOKEN"))
+	}
+	return ProjectProvider{}
+}
+
+// jiraBatchSize is how many tickets JiraProvider looks up per search.
+const jiraBatchSize = 100
+
+// jiraIssue holds the fields of a Jira issue we use.
+type jiraIssue struct {
+	Key    string `json:"key"`
+	Fields struct {
+		Parent *struct {
+			Key string `json:"key"`
+		} `json:"parent"`
+		Components []struct {
+			Name string `json:"name"`
+		} `json:"components"`
+	} `json:"fields"`
+}
+
+// Area returns the epic of the ticket, or its first component if it isn't
+// part of an epic.
+func (j *JiraProvider) Area(ticket string) (string, error) {
+	if strings.HasPrefix(ticket, "#") {
+		return "", nil
+	}
+
+	resp, err := j.get("/rest/api/2/issue/" + url.PathEscape(ticket) + "?fields=parent,components")
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to look up %s in Jira", ticket)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return "", nil
+	case resp.StatusCode != http.StatusOK:
+		return "", errors.Errorf("unable to look up %s in Jira: %s", ticket, resp.Status)
+	}
+
+	var issue jiraIssue
+	if err := json.NewDecoder(resp.Body).Decode(&issue); err != nil {
+		return "", errors.Wrapf(err, "unable to read %s from Jira", ticket)
+	}
+	return issue.area(), nil
+}
+
+// Areas looks up the epic or first component of 'tickets' with as few searches
+// as it can, leaving out those that belong to neither. Jira only warns about
+// keys it doesn't know.
+func (j *JiraProvider) Areas(tickets []string) (map[string]string, error) {
+	var keys []string
+	for _, t := range tickets {
+		if !strings.HasPrefix(t, "#") {
+			keys = append(keys, t)
+		}
+	}
+
+	areas := make(map[string]string)
+	for start := 0; start < len(keys); start += jiraBatchSize {
+		end := start + jiraBatchSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		q := url.Values{}
+		q.Set("jql", fmt.Sprintf("key in (%s)", strings.Join(keys[start:end], ",")))
+		q.Set("fields", "parent,components")
+		q.Set("maxResults", strconv.Itoa(end-start))
+		q.Set("validateQuery", "warn")
+
+		resp, err := j.get("/rest/api/2/search?" + q.Encode())
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to search Jira")
+		}
+		var found struct {
+			Issues []jiraIssue `json:"issues"`
+		}
+		if resp.StatusCode == http.StatusOK {
+			err = json.NewDecoder(resp.Body).Decode(&found)
+		} else {
+			err = errors.Errorf("unable to search Jira: %s", resp.Status)
+		}
+		resp.Body.Close()
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to read tickets from Jira")
+		}
+
+		for _, issue := range found.Issues {
+			if area := issue.area(); area != "" {
+				areas[issue.Key] = area
+			}
+		}
+	}
+
+	return areas, nil
+}
+
+// area names the epic of the issue, or its first component if it isn't part
+// of an epic.
+func (i jiraIssue) area() string {
+	switch {
+	case i.Fields.Parent != nil && i.Fields.Parent.Key != "":
+		return "epic " + i.Fields.Parent.Key
+	case len(i.Fields.Components) > 0:
+		return "component " + i.Fields.Components[0].Name
+	default:
+		return ""
+	}
+}
+
+// CheckAuth checks that Jira accepts the credentials by asking who they belong
+// to.
+func (j *JiraProvider) CheckAuth() error {
+	resp, err := j.get("/rest/api/2/myself")
+	if err != nil {
+		return errors.Wrap(err, "unable to reach Jira")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("Jira refused the credentials: %s", resp.Status)
+	}
+	return nil
+}
+
+// get sends an authenticated GET request for 'path' of the REST API.
+func (j *JiraProvider) get(path string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", j.URL+path, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to build Jira request")
+	}
+	req.Header.Set("Accept", "application/json")
+	switch {
+	case j.User != "":
+		req.SetBasicAuth(j.User, j.Token)
+	case j.Token != "":
+		req.Header.Set("Authorization", "Bearer "+j.Token)
+	}
+
+	client := j.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return client.Do(req)
+}
+
+// ticketAreas looks up the area of tickets through Tickets, asking about each
+// ticket only once. Tickets that can't be looked up belong to no area.
+type ticketAreas struct {
+	r        *ContributionCounter
+	provider TicketProvider
+	areas    map[string]string
+}
+
+// lookup looks up every ticket of 'tickets' not looked up yet, at once if the
+// provider is a BatchTicketProvider.
+func (t *ticketAreas) lookup(tickets []string) {
+	var (
+		missing []string
+		seen    = make(map[string]bool)
+	)
+	for _, ticket := range tickets {
+		if _, ok := t.areas[ticket]; !ok && !seen[ticket] {
+			seen[ticket] = true
+			missing = append(missing, ticket)
+		}
+	}
+
+	batch, ok := t.provider.(BatchTicketProvider)
+	if !ok || len(missing) == 0 {
+		return
+	}
+	areas, err := batch.Areas(missing)
+	if err != nil {
+		t.r.logf("Unable to look up tickets: %v\n", err)
+	}
+	// Without an error, tickets left out belong to no area
+	for _, ticket := range missing {
+		if area, ok := areas[ticket]; ok || err == nil {
+			t.areas[ticket] = area
+		}
+	}
+}
+
+func (t *ticketAreas) area(ticket string) string {
+	if area, ok := t.areas[ticket]; ok {
+		return area
+	}
+
+	area, err := t.provider.Area(ticket)
+	if err != nil {
+		t.r.logf("Unable to look up %s: %v\n", ticket, err)
+	}
+	t.areas[ticket] = area
+
+	return area
+}
+
+// ticketPattern is the pattern tickets are extracted with.
+func (r *ContributionCounter) ticketPattern() *regexp.Regexp {
+	if r.TicketPattern != nil {
+		return r.TicketPattern
+	}
+	return DefaultTicketPattern
+}
+
+// addTickets boosts the candidates who worked on tickets of the same areas as
+// the tickets the changes reference, adding the authors who own no changed
+// lines.
+func (r *ContributionCounter) addTickets(candidates Stats, counts *contributions) (Stats, error) {
+	defer r.Summary.stage("tickets", time.Now())
+
+	msgs, err := r.branchMessages()
+	if err != nil {
+		return nil, err
+	}
+
+	lookup := &ticketAreas{r: r, provider: r.Tickets, areas: make(map[string]string)}
+	branchTickets := ExtractTickets(msgs, r.ticketPattern())
+	lookup.lookup(branchTickets)
+	areas := make(map[string]bool)
+	for _, ticket := range branchTickets {
+		if area := lookup.area(ticket); area != "" {
+			areas[area] = true
+		}
+	}
+	if len(areas) == 0 {
+		return candidates, nil
+	}
+
+	history, err := r.historyMessages()
+	if err != nil {
+		return nil, err
+	}
+	// Every ticket of the history is looked up at once, when possible
+	var tickets []string
+	for _, c := range history {
+		tickets = append(tickets, ExtractTickets(c.msg, r.ticketPattern())...)
+	}
+	lookup.lookup(tickets)
+
+	matches := matchMessages(history, func(msg string) []string {
+		var related []string
+		for _, ticket := range ExtractTickets(msg, r.ticketPattern()) {
+			if areas[lookup.area(ticket)] {
+				related = append(related, ticket)
+			}
+		}
+		return related
+	})
+	counts.tickets = matches
+
+	return boost(candidates, matches, ticketWeight, func(s *Stat, terms []string) {
+		s.Tickets = terms
+	}), nil
+}
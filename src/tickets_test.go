@@ -0,0 +1,200 @@
+package gitreviewers
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtractTickets(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected []string
+	}{
+		{"PROJ-12: Fix the cache", []string{"PROJ-12"}},
+		{"Fix #12 and #34 (see #12)", []string{"#12", "#34"}},
+		{"#7 then AB2-3, and AB2-3 again", []string{"#7", "AB2-3"}},
+		{"utf-8, a#1, &#38; and x/#2 aren't tickets", nil},
+		{"Merge pull request #99 from abe/PROJ-4-cache", []string{"#99", "PROJ-4"}},
+	}
+
+	for _, tt := range tests {
+		if got := ExtractTickets(tt.text, DefaultTicketPattern); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("Got '%v', expected '%v' for %q\n", got, tt.expected, tt.text)
+		}
+	}
+}
+
+func TestProjectProvider(t *testing.T) {
+	tests := []struct {
+		ticket, expected string
+	}{
+		{"PROJ-12", "project PROJ"},
+		{"MY_PROJ-3", "project MY_PROJ"},
+		{"#12", ""},
+	}
+
+	for _, tt := range tests {
+		if got, _ := (ProjectProvider{}).Area(tt.ticket); got != tt.expected {
+			t.Errorf("Got '%s', expected '%s'\n", got, tt.expected)
+		}
+	}
+}
+
+func TestJiraProvider(t *testing.T) {
+	issues := map[string]string{
+		"PROJ-1": `{"fields": {"parent": {"key": "PROJ-100"}, "components": [{"name": "API"}]}}`,
+		"PROJ-2": `{"fields": {"components": [{"name": "API"}, {"name": "Web"}]}}`,
+		"PROJ-3": `{"fields": {"components": []}}`,
+	}
+	var searches int
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if user, token, _ := req.BasicAuth(); user != "me" || token != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch req.URL.Path {
+		case "/rest/api/2/myself":
+			fmt.Fprint(w, `{"name": "me"}`)
+			return
+		case "/rest/api/2/search":
+			searches++
+			jql := req.URL.Query().Get("jql")
+			keys := strings.Split(strings.TrimSuffix(strings.TrimPrefix(jql, "key in ("), ")"), ",")
+			var found []string
+			for _, key := range keys {
+				if issue, ok := issues[key]; ok {
+					found = append(found, fmt.Sprintf(`{"key": "%s", %s`, key, issue[1:]))
+				}
+			}
+			fmt.Fprintf(w, `{"issues": [%s]}`, strings.Join(found, ", "))
+			return
+		}
+		issue, ok := issues[filepath.Base(req.URL.Path)]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, issue)
+	}))
+	defer srv.Close()
+
+	j := NewJiraProvider(srv.URL+"/", "me", "secret")
+	tests := []struct {
+		ticket, expected string
+	}{
+		{"PROJ-1", "epic PROJ-100"},
+		{"PROJ-2", "component API"},
+		{"PROJ-3", ""},
+		{"PROJ-4", ""},
+		{"#5", ""},
+	}
+
+	for _, tt := range tests {
+		got, err := j.Area(tt.ticket)
+		if err != nil {
+			t.Fatalf("Unexpected error looking up %s: %v\n", tt.ticket, err)
+		}
+		if got != tt.expected {
+			t.Errorf("Got '%s', expected '%s' for %s\n", got, tt.expected, tt.ticket)
+		}
+	}
+
+	if err := j.CheckAuth(); err != nil {
+		t.Errorf("Unexpected error checking credentials: %v\n", err)
+	}
+
+	// Tickets are searched for together
+	areas, err := j.Areas([]string{"PROJ-1", "PROJ-2", "PROJ-3", "PROJ-4", "#5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{"PROJ-1": "epic PROJ-100", "PROJ-2": "component API"}
+	if !reflect.DeepEqual(areas, expected) || searches != 1 {
+		t.Errorf("Got %v in %d searches, expected %v in one\n", areas, searches, expected)
+	}
+
+	j.Token = "wrong"
+	if _, err := j.Area("PROJ-1"); err == nil {
+		t.Errorf("Expected an error with the wrong token\n")
+	}
+	if err := j.CheckAuth(); err == nil {
+		t.Errorf("Expected an error checking the wrong token\n")
+	}
+}
+
+// mapProvider looks the area of tickets up in a map.
+type mapProvider map[string]string
+
+func (m mapProvider) Area(ticket string) (string, error) {
+	return m[ticket], nil
+}
+
+// flakyProvider fails to look up the tickets missing from its map.
+type flakyProvider map[string]string
+
+func (f flakyProvider) Area(ticket string) (string, error) {
+	area, ok := f[ticket]
+	if !ok {
+		return "", errors.New("timed out")
+	}
+	return area, nil
+}
+
+func TestAddTickets(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	commit := func(file, author, msg string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, file), []byte(msg+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		runGit(t, dir, "add", ".")
+		runGit(t, dir, "commit", "-q", "--author", author, "-m", msg)
+	}
+
+	commit("a.txt", "Cam <cam@git-reviewer.com>", "PAY-1: Charge cards")
+	commit("b.txt", "Cam <cam@git-reviewer.com>", "Refund cards (PAY-2)")
+	commit("c.txt", "Dee <dee@git-reviewer.com>", "WEB-9: Restyle")
+	commit("d.txt", "Dee <dee@git-reviewer.com>", "WEB-10: Restyle more")
+
+	runGit(t, dir, "checkout", "-q", "-b", "feature")
+	commit("src/a.go", "Abe <abe@git-reviewer.com>", "PAY-3: Retry failed charges")
+
+	r := ContributionCounter{Dir: dir, Since: "2000-01-01", Head: "feature",
+		Tickets: mapProvider{"PAY-1": "epic payments", "PAY-2": "epic payments",
+			"PAY-3": "epic payments", "WEB-9": "epic web", "WEB-10": "epic web"}}
+	counts := newContributions()
+
+	got, err := r.addTickets(nil, counts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 1 {
+		t.Fatalf("Got %d candidates, expected 1\n", len(got))
+	}
+	if got[0].Reviewer != "cam@git-reviewer.com" || got[0].Boost != ticketWeight ||
+		!reflect.DeepEqual(got[0].Tickets, []string{"PAY-1", "PAY-2"}) {
+		t.Errorf("Got '%+v', expected cam with tickets PAY-1 and PAY-2\n", got[0])
+	}
+
+	// Tickets that can't be looked up are skipped
+	r.Tickets = flakyProvider{"PAY-1": "epic payments", "PAY-2": "epic payments",
+		"PAY-3": "epic payments"}
+	got, err = r.addTickets(nil, newContributions())
+	if err != nil {
+		t.Fatalf("Unexpected error when lookups fail: %v\n", err)
+	}
+	if len(got) != 1 || got[0].Reviewer != "cam@git-reviewer.com" {
+		t.Errorf("Got '%+v', expected cam despite the web tickets failing\n", got)
+	}
+}
@@ -0,0 +1,234 @@
+package gitreviewers
+
+import (
+	"bufio"
+	"bytes"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// topicWeight is the most a topic match adds to the share of lines a
+// candidate owns when ranking them. An author whose every commit mentions
+// the branch's topics gets all of it.
+const topicWeight = 0.25
+
+// minMatchedCommits is how many past commits must match before an author
+// counts as knowing a topic or area, so a single coincidence doesn't make an
+// expert.
+const minMatchedCommits = 2
+
+// maxTermNotes limits how many matched topics or tickets are shown for a
+// reviewer.
+const maxTermNotes = 3
+
+// topicStopWords are words too common in commit messages to say anything
+// about what a change is about.
+var topicStopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "from": true,
+	"into": true, "this": true, "that": true, "when": true, "not": true,
+	"are": true, "was": true, "but": true, "all": true, "its": true,
+	"add": true, "adds": true, "added": true, "fix": true, "fixes": true,
+	"fixed": true, "update": true, "updates": true, "updated": true,
+	"remove": true, "removes": true, "removed": true, "use": true,
+	"make": true, "makes": true, "change": true, "changes": true,
+	"merge": true, "branch": true, "revert": true, "bump": true,
+	"more": true, "less": true, "new": true, "some": true, "now": true,
+	"signed": true, "off": true, "reviewed": true, "co": true,
+	"authored": true, "wip": true, "http": true, "https": true, "www": true,
+	"com": true, "github": true, "pull": true, "request": true,
+}
+
+// messageMatch describes how often an author's past commit messages mention
+// terms related to the changes under review, such as their topics.
+type messageMatch struct {
+	// commits counts the author's past commits, and matched those mentioning
+	// at least one term.
+	commits int
+	matched int
+	// terms counts how many commits mentioned each term.
+	terms map[string]int
+}
+
+// score is the boost the match adds when ranking the author, at most
+// 'weight'.
+func (m messageMatch) score(weight float64) float64 {
+	if m.matched < minMatchedCommits || m.commits == 0 {
+		return 0
+	}
+	return weight * float64(m.matched) / float64(m.commits)
+}
+
+// top lists the terms mentioned most often, most frequent first.
+func (m messageMatch) top(n int) []string {
+	var terms []string
+	for t := range m.terms {
+		terms = append(terms, t)
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if m.terms[terms[i]] != m.terms[terms[j]] {
+			return m.terms[terms[i]] > m.terms[terms[j]]
+		}
+		return terms[i] < terms[j]
+	})
+
+	if len(terms) > n {
+		terms = terms[:n]
+	}
+	return terms
+}
+
+// topicWords splits free text such as a commit message into the distinct,
+// lowercased words that may name a topic. Short words, numbers and stop
+// words are left out.
+func topicWords(text string) map[string]bool {
+	words := make(map[string]bool)
+
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, w := range fields {
+		if len(w) < 3 || topicStopWords[w] || strings.IndexFunc(w, unicode.IsLetter) < 0 {
+			continue
+		}
+		words[w] = true
+	}
+
+	return words
+}
+
+// branchMessages returns Title followed by the messages of the commits
+// under review.
+func (r *ContributionCounter) branchMessages() (string, error) {
+	out, err := r.output(r.history("log", "--no-merges", "--format=%s%n%b",
+		r.baseRev()+".."+r.headRev())...)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to read branch commit messages")
+	}
+
+	return r.Title + "\n" + string(out), nil
+}
+
+// commitMessage is the message of a commit and who wrote it.
+type commitMessage struct {
+	author string
+	msg    string
+}
+
+// historyMessages reads the commit messages of the base revision's, or AsOf's,
+// history since Since.
+func (r *ContributionCounter) historyMessages() ([]commitMessage, error) {
+	out, err := r.output(r.history("log", "--no-merges", "--since="+r.Since,
+		"--format=%x00%ae%n%s%n%b", r.historyRev())...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read commit messages")
+	}
+
+	var history []commitMessage
+	for _, commit := range bytes.Split(out, []byte{0}) {
+		scn := bufio.NewScanner(bytes.NewReader(commit))
+		if !scn.Scan() {
+			continue
+		}
+		author := reviewerKey(scn.Text(), r.Mailmap)
+
+		var msg bytes.Buffer
+		for scn.Scan() {
+			msg.Write(scn.Bytes())
+			msg.WriteByte('\n')
+		}
+		history = append(history, commitMessage{author, msg.String()})
+	}
+
+	return history, nil
+}
+
+// matchMessages goes through the commit messages of 'history' and counts, for
+// each author, the commits for which 'match' finds related terms.
+func matchMessages(history []commitMessage, match func(msg string) []string) map[string]messageMatch {
+	matches := make(map[string]messageMatch)
+	for _, c := range history {
+		author, terms := c.author, match(c.msg)
+
+		m := matches[author]
+		if m.terms == nil {
+			m.terms = make(map[string]int)
+		}
+		m.commits++
+		for _, t := range terms {
+			m.terms[t]++
+		}
+		if len(terms) > 0 {
+			m.matched++
+		}
+		matches[author] = m
+	}
+
+	return matches
+}
+
+// boost adds the score of each match to the matching candidate, adding the
+// authors who own no changed lines but still match, and returns the
+// candidates. 'note' records the top terms of the match on the Stat.
+func boost(candidates Stats, matches map[string]messageMatch, weight float64,
+	note func(s *Stat, terms []string)) Stats {
+	known := make(map[string]*Stat)
+	for _, s := range candidates {
+		known[s.Reviewer] = s
+	}
+
+	for author, m := range matches {
+		score := m.score(weight)
+		if score == 0 {
+			continue
+		}
+
+		s, ok := known[author]
+		if !ok {
+			s = &Stat{Reviewer: author}
+			candidates = append(candidates, s)
+		}
+		s.Boost += score
+		note(s, m.top(maxTermNotes))
+	}
+
+	return candidates
+}
+
+// addTopics boosts the candidates whose commit messages are about the same
+// topics as the branch, adding the authors who own no changed lines but
+// still know the topics well.
+func (r *ContributionCounter) addTopics(candidates Stats, counts *contributions) (Stats, error) {
+	defer r.Summary.stage("topics", time.Now())
+
+	msgs, err := r.branchMessages()
+	if err != nil {
+		return nil, err
+	}
+	topics := topicWords(msgs)
+	if len(topics) == 0 {
+		return candidates, nil
+	}
+
+	history, err := r.historyMessages()
+	if err != nil {
+		return nil, err
+	}
+	matches := matchMessages(history, func(msg string) []string {
+		var terms []string
+		for w := range topicWords(msg) {
+			if topics[w] {
+				terms = append(terms, w)
+			}
+		}
+		return terms
+	})
+	counts.topics = matches
+
+	return boost(candidates, matches, topicWeight, func(s *Stat, terms []string) {
+		s.Topics = terms
+	}), nil
+}
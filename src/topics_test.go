@@ -0,0 +1,96 @@
+package gitreviewers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTopicWords(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected map[string]bool
+	}{
+		{"Fix cache invalidation", map[string]bool{"cache": true, "invalidation": true}},
+		{"Add authz checks to the API\n\nSigned-off-by: Abe <abe@git-reviewer.com>",
+			map[string]bool{"authz": true, "checks": true, "api": true, "abe": true,
+				"git": true, "reviewer": true}},
+		{"Bump to 1.2.3 (#42)", map[string]bool{}},
+		{"Caché: café", map[string]bool{"caché": true, "café": true}},
+	}
+
+	for _, tt := range tests {
+		if got := topicWords(tt.text); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("Got '%v', expected '%v'\n", got, tt.expected)
+		}
+	}
+}
+
+func TestMessageMatch(t *testing.T) {
+	tests := []struct {
+		match    messageMatch
+		score    float64
+		expected []string
+	}{
+		{messageMatch{}, 0, nil},
+		{messageMatch{commits: 4, matched: 1, terms: map[string]int{"cache": 1}}, 0, []string{"cache"}},
+		{messageMatch{commits: 4, matched: 2, terms: map[string]int{"ttl": 1, "cache": 2}},
+			topicWeight / 2, []string{"cache", "ttl"}},
+		{messageMatch{commits: 2, matched: 2, terms: map[string]int{"d": 1, "c": 1, "b": 1, "a": 2}},
+			topicWeight, []string{"a", "b", "c"}},
+	}
+
+	for _, tt := range tests {
+		if got := tt.match.score(topicWeight); got != tt.score {
+			t.Errorf("Got score %f, expected %f\n", got, tt.score)
+		}
+		if got := tt.match.top(maxTermNotes); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("Got '%v', expected '%v'\n", got, tt.expected)
+		}
+	}
+}
+
+func TestAddTopics(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	commit := func(file, author, msg string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, file), []byte(msg+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		runGit(t, dir, "add", ".")
+		runGit(t, dir, "commit", "-q", "--author", author, "-m", msg)
+	}
+
+	commit("cache.go", "Cam <cam@git-reviewer.com>", "Expire cache entries")
+	commit("ttl.go", "Cam <cam@git-reviewer.com>", "Tune cache TTL")
+	commit("docs.md", "Dee <dee@git-reviewer.com>", "Document cache flags")
+
+	runGit(t, dir, "checkout", "-q", "-b", "feature")
+	commit("src/a.go", "Abe <abe@git-reviewer.com>", "Invalidate the cache on writes")
+
+	r := ContributionCounter{Dir: dir, Since: "2000-01-01", Head: "feature",
+		Title: "Cache invalidation"}
+	counts := newContributions()
+	candidates := Stats{{Reviewer: "abe@git-reviewer.com", Percentage: 1}}
+
+	got, err := r.addTopics(candidates, counts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Dee mentioned the cache once, which could be a coincidence
+	if len(got) != 2 {
+		t.Fatalf("Got %d candidates, expected 2\n", len(got))
+	}
+	cam := got[1]
+	if cam.Reviewer != "cam@git-reviewer.com" || cam.Boost != topicWeight ||
+		!reflect.DeepEqual(cam.Topics, []string{"cache"}) {
+		t.Errorf("Got '%+v', expected cam with topic 'cache'\n", cam)
+	}
+	if got[0].Boost != 0 {
+		t.Errorf("Got boost %f for abe, expected 0\n", got[0].Boost)
+	}
+}
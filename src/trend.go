@@ -0,0 +1,82 @@
+package gitreviewers
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
+
+// TrendQuarters is how many quarters Stat.Trend covers, two years being
+// enough to tell a current maintainer from someone who moved on.
+const TrendQuarters = 8
+
+// sparks are the bars a trend is drawn with, from fewest to most lines.
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// quarterOf numbers the quarter of a YYYY-MM-DD date so consecutive quarters
+// get consecutive numbers.
+func quarterOf(date string) (int, bool) {
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return 0, false
+	}
+	return t.Year()*4 + (int(t.Month())-1)/3, true
+}
+
+// trend counts the lines an author owns by the quarter they were committed
+// in, for the TrendQuarters quarters up to the one 'now' is in.
+func (c *contributions) trend(author string, now time.Time) []int {
+	current, _ := quarterOf(now.Format("2006-01-02"))
+
+	trend := make([]int, TrendQuarters)
+	for q, lines := range c.byQuarter[author] {
+		if i := q - (current - TrendQuarters + 1); i >= 0 && i < TrendQuarters {
+			trend[i] += lines
+		}
+	}
+
+	return trend
+}
+
+// Sparkline draws Trend as a bar per quarter, scaled to the busiest quarter,
+// with a blank for quarters without lines. It is empty if there is no trend.
+func (cs *Stat) Sparkline() string {
+	var most int
+	for _, lines := range cs.Trend {
+		if lines > most {
+			most = lines
+		}
+	}
+	if most == 0 {
+		return ""
+	}
+
+	var b bytes.Buffer
+	for _, lines := range cs.Trend {
+		if lines == 0 {
+			b.WriteRune(' ')
+			continue
+		}
+		b.WriteRune(sparks[(lines*len(sparks)-1)/most])
+	}
+	return b.String()
+}
+
+// trendTable describes the trend of each of 'stats', for verbose output.
+func trendTable(stats Stats, lang string) string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, Translate(lang, "\nLines owned by quarter over the last %d quarters, oldest first:\n"),
+		TrendQuarters)
+	var width int
+	for _, s := range stats {
+		if len(s.Reviewer) > width {
+			width = len(s.Reviewer)
+		}
+	}
+	for _, s := range stats {
+		if spark := s.Sparkline(); spark != "" {
+			fmt.Fprintf(&b, "  %-*s [%s]\n", width, s.Reviewer, spark)
+		}
+	}
+	return b.String()
+}
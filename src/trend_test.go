@@ -0,0 +1,55 @@
+package gitreviewers
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestContributionsTrend(t *testing.T) {
+	counts := newContributions()
+	counts.add("a.go", []attribution{
+		{"alice", "2016-11-02"},
+		{"alice", "2017-02-14"},
+		{"alice", "2017-03-31"},
+		{"alice", "2017-05-01"},
+		{"bob", "2018-12-01"},
+		{"bob", "2014-01-01"},
+		{"bob", "not a date"},
+	}, 7)
+
+	now := time.Date(2018, time.December, 24, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		author   string
+		expected []int
+	}{
+		{"alice", []int{2, 1, 0, 0, 0, 0, 0, 0}},
+		{"bob", []int{0, 0, 0, 0, 0, 0, 0, 1}},
+		{"carol", []int{0, 0, 0, 0, 0, 0, 0, 0}},
+	}
+
+	for _, tt := range tests {
+		if got := counts.trend(tt.author, now); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("Got %v, expected %v for %s\n", got, tt.expected, tt.author)
+		}
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	tests := []struct {
+		trend    []int
+		expected string
+	}{
+		{nil, ""},
+		{[]int{0, 0, 0}, ""},
+		{[]int{1, 0, 8, 4}, "▁ █▄"},
+		{[]int{1, 100}, "▁█"},
+	}
+
+	for _, tt := range tests {
+		s := &Stat{Trend: tt.trend}
+		if got := s.Sparkline(); got != tt.expected {
+			t.Errorf("Got '%s', expected '%s' for %v\n", got, tt.expected, tt.trend)
+		}
+	}
+}
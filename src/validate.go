@@ -0,0 +1,267 @@
+package gitreviewers
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/thedahv/git-reviewer/src/gitcmd"
+)
+
+// ValidationError describes an invalid option and how to fix it.
+type ValidationError struct {
+	// Option names the option the way the command line does, such as "since".
+	Option  string `json:"option"`
+	Problem string `json:"problem"`
+	Fix     string `json:"fix"`
+}
+
+// Error describes the problem without the suggested fix.
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Option, e.Problem)
+}
+
+// ValidationErrors collects every problem found while validating options so
+// they can all be reported at once.
+type ValidationErrors []ValidationError
+
+// Error lists every problem on a single line.
+func (errs ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(errs))
+	for _, e := range errs {
+		msgs = append(msgs, e.Error())
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// Validate checks every option for problems and returns them all as
+// ValidationErrors, or nil if there are none. The git executable and the base
+// and head revisions are only checked when Repo is set.
+func (r *ContributionCounter) Validate() error {
+	var errs ValidationErrors
+
+	if r.Since != "" {
+		if since, err := time.Parse("2006-01-02", r.Since); err != nil {
+			errs = append(errs, ValidationError{"since",
+				fmt.Sprintf("'%s' is not a valid date", r.Since),
+				"Use the YYYY-MM-DD format, such as 2017-06-01"})
+		} else if since.After(time.Now()) {
+			errs = append(errs, ValidationError{"since",
+				fmt.Sprintf("%s is in the future", r.Since),
+				"Pick a date in the past, or leave it out to look 6 months back"})
+		}
+	}
+
+	if r.MaxHistory != "" {
+		if _, _, _, err := parseMaxHistory(r.MaxHistory); err != nil {
+			errs = append(errs, ValidationError{"max-history", err.Error(),
+				"Use a number of days, weeks, months or years, such as 90d, 6w, 18m or 2y"})
+		}
+	}
+
+	if len(r.OnlyExtensions) > 0 && len(r.IgnoredExtensions) > 0 {
+		errs = append(errs, ValidationError{"ignore-extension",
+			"has no effect together with only-extension",
+			"Use one of them or the other"})
+	}
+	if len(r.OnlyPaths) > 0 && len(r.IgnoredPaths) > 0 {
+		errs = append(errs, ValidationError{"ignore-path",
+			"has no effect together with only-path",
+			"Use one of them or the other"})
+	}
+
+	for _, filter := range []struct {
+		option string
+		paths  []string
+	}{{"ignore-path", r.IgnoredPaths}, {"only-path", r.OnlyPaths}} {
+		for _, p := range filter.paths {
+			if _, err := normalizePath(r.Dir, p); err != nil {
+				errs = append(errs, ValidationError{filter.option, err.Error(),
+					"Use a path inside the repository, relative to its root"})
+			}
+		}
+	}
+
+	if r.RecentDays < 0 {
+		errs = append(errs, ValidationError{"recent-days",
+			fmt.Sprintf("%d is negative", r.RecentDays),
+			"Use a number of days, or 0 to turn it off"})
+	}
+
+	if r.OwnershipAlert < 0 || r.OwnershipAlert > 1 {
+		errs = append(errs, ValidationError{"ownership-alert",
+			fmt.Sprintf("%.0f%% is not a percentage", r.OwnershipAlert*100.0),
+			"Use a value between 0 and 100"})
+	}
+
+	// First-parent credits merged branches to their merges, the very commits
+	// IgnoreMerges looks past
+	if r.FirstParent && r.IgnoreMerges {
+		errs = append(errs, ValidationError{"first-parent",
+			"credits merged branches to the merges ignore-merges looks past",
+			"Use one of them or the other"})
+	}
+
+	if r.NoExec && r.WorkingTree {
+		errs = append(errs, ValidationError{"no-exec",
+			"the working tree can't be compared without running git",
+			"Commit the changes to review, or leave out --no-exec"})
+	}
+
+	for _, mapping := range r.badCapacities {
+		errs = append(errs, ValidationError{"reviewer.capacity",
+			fmt.Sprintf("'%s' is not an email and a number of reviews", mapping),
+			"Use email=count, such as alice@example.com=5"})
+	}
+
+	var emails []string
+	for email := range r.Timezones {
+		emails = append(emails, email)
+	}
+	sort.Strings(emails)
+	for _, email := range emails {
+		zone := r.Timezones[email]
+		if _, err := time.LoadLocation(zone); err != nil {
+			errs = append(errs, ValidationError{"timezone",
+				fmt.Sprintf("unknown time zone '%s' for %s", zone, email),
+				"Use an IANA time zone name, such as America/New_York"})
+		}
+	}
+
+	presets := r.presets()
+	for _, name := range r.Presets {
+		if _, ok := presets[strings.ToLower(name)]; !ok {
+			errs = append(errs, ValidationError{"preset",
+				fmt.Sprintf("unknown preset '%s'", name),
+				fmt.Sprintf("Use one of %s, or define it in %s", strings.Join(r.presetNames(), ", "),
+					ConfigFile)})
+		}
+	}
+
+	if w := r.dirWeight(); w < 0 || w > 1 {
+		errs = append(errs, ValidationError{"dir-weight",
+			fmt.Sprintf("%g is out of range", w),
+			"Use a value between 0 and 1, such as 0.3"})
+	}
+
+	if r.Show != "" && !containsString(ShowOptions, r.Show) {
+		errs = append(errs, ValidationError{"show",
+			fmt.Sprintf("unknown value '%s'", r.Show),
+			fmt.Sprintf("Use one of %s", strings.Join(ShowOptions, ", "))})
+	}
+
+	if r.OwnersPolicy != "" && !containsString(OwnersPolicies, r.OwnersPolicy) {
+		errs = append(errs, ValidationError{"reviewer.owners",
+			fmt.Sprintf("unknown policy '%s'", r.OwnersPolicy),
+			fmt.Sprintf("Use one of %s", strings.Join(OwnersPolicies, ", "))})
+	}
+
+	for _, kind := range r.ExcludeLines {
+		if !containsString(ExcludeLineKinds, kind) {
+			errs = append(errs, ValidationError{"reviewer.excludeLines",
+				fmt.Sprintf("unknown kind of line '%s'", kind),
+				fmt.Sprintf("Use %s", strings.Join(ExcludeLineKinds, ", "))})
+		}
+	}
+	for _, pattern := range r.ExcludeLinePatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			errs = append(errs, ValidationError{"reviewer.excludeLinePattern",
+				fmt.Sprintf("'%s' is not a valid regular expression", pattern),
+				"Use Go regular expression syntax, such as ^\\s*// Code generated"})
+		}
+	}
+
+	if r.BlameAt != "" && !containsString(BlameAtOptions, r.BlameAt) {
+		errs = append(errs, ValidationError{"blame-at",
+			fmt.Sprintf("unknown revision '%s'", r.BlameAt),
+			fmt.Sprintf("Use one of %s", strings.Join(BlameAtOptions, ", "))})
+	}
+
+	if r.SinceFrom != "" && !containsString(SinceFromOptions, r.SinceFrom) {
+		errs = append(errs, ValidationError{"since-from",
+			fmt.Sprintf("unknown value '%s'", r.SinceFrom),
+			fmt.Sprintf("Use one of %s", strings.Join(SinceFromOptions, ", "))})
+	}
+
+	if r.Sort != "" && !containsString(SortOptions, r.Sort) {
+		errs = append(errs, ValidationError{"sort",
+			fmt.Sprintf("unknown order '%s'", r.Sort),
+			fmt.Sprintf("Use one of %s", strings.Join(SortOptions, ", "))})
+	}
+
+	if r.Ranker != "" && !containsString(RankerOptions, r.Ranker) {
+		errs = append(errs, ValidationError{"ranker",
+			fmt.Sprintf("unknown ranker '%s'", r.Ranker),
+			fmt.Sprintf("Use one of %s", strings.Join(RankerOptions, ", "))})
+	}
+
+	if r.AsOf != "" && r.BlameAt != "" && r.BlameAt != BlameAtBase {
+		errs = append(errs, ValidationError{"as-of",
+			"already picks the revision to blame at",
+			"Leave out blame-at"})
+	}
+
+	if r.Language != "" && !containsString(Languages, r.Language) {
+		errs = append(errs, ValidationError{"lang",
+			fmt.Sprintf("unsupported language '%s'", r.Language),
+			fmt.Sprintf("Use one of %s", strings.Join(Languages, ", "))})
+	}
+
+	// Revisions can't be checked without a git to run
+	var gitErr error
+	if r.Repo != nil && !r.NoExec {
+		if _, gitErr = r.runner().CheckVersion(context.Background()); gitErr != nil {
+			errs = append(errs, ValidationError{"git-bin", gitErr.Error(),
+				fmt.Sprintf("Install git %s or newer, or point --git-bin at it", gitcmd.MinVersion)})
+		}
+	}
+
+	if r.Repo != nil && gitErr == nil {
+		_, headErr := r.resolve(r.headRev())
+		switch {
+		case headErr != nil && r.Head == "":
+			errs = append(errs, ValidationError{"head",
+				"the repository has no commits yet", "Commit your changes first"})
+		case headErr != nil:
+			errs = append(errs, ValidationError{"head", headErr.Error(),
+				"Check the spelling, or fetch it if it only exists on a remote"})
+		}
+
+		_, baseErr := r.resolve(r.baseRev())
+		switch {
+		case baseErr != nil && r.Base == "" && headErr == nil:
+			errs = append(errs, ValidationError{"base",
+				fmt.Sprintf("no '%s' branch found", strings.Join(defaultBases, "' or '")),
+				"Name the branch to compare to with --base"})
+		case baseErr != nil && r.Base != "":
+			errs = append(errs, ValidationError{"base", baseErr.Error(),
+				"Check the spelling, or fetch it if it only exists on a remote"})
+		}
+
+		if _, err := r.asOf(); err != nil && baseErr == nil {
+			errs = append(errs, ValidationError{"as-of", err.Error(),
+				"Use a revision, or a YYYY-MM-DD date after the first commit"})
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// containsString reports whether a list of strings contains a value.
+func containsString(list []string, val string) bool {
+	for _, v := range list {
+		if v == val {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,145 @@
+package gitreviewers
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	gogit "gopkg.in/src-d/go-git.v4"
+)
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Counter  ContributionCounter
+		Expected []string
+	}{
+		{"defaults", ContributionCounter{}, nil},
+		{"valid", ContributionCounter{Since: "2017-01-02", OnlyPaths: []string{"src"},
+			OwnershipAlert: 0.1, Show: ShowBoth}, nil},
+		{"bad date", ContributionCounter{Since: "2017-13-01"}, []string{"since"}},
+		{"future", ContributionCounter{Since: "2999-01-01"}, []string{"since"}},
+		{
+			"conflicts",
+			ContributionCounter{OnlyExtensions: []string{"go"}, IgnoredExtensions: []string{"js"},
+				OnlyPaths: []string{"src"}, IgnoredPaths: []string{"vendor"}},
+			[]string{"ignore-extension", "ignore-path"},
+		},
+		{
+			"outside",
+			ContributionCounter{OnlyPaths: []string{"src", "../.."}},
+			[]string{"only-path"},
+		},
+		{
+			"ranges",
+			ContributionCounter{RecentDays: -1, OwnershipAlert: 2, Show: "lines"},
+			[]string{"recent-days", "ownership-alert", "show"},
+		},
+		{
+			"merges",
+			ContributionCounter{FirstParent: true, IgnoreMerges: true},
+			[]string{"first-parent"},
+		},
+		{
+			"language",
+			ContributionCounter{Language: "fr"},
+			[]string{"lang"},
+		},
+		{
+			"blame revision",
+			ContributionCounter{BlameAt: "tip"},
+			[]string{"blame-at"},
+		},
+		{
+			"since anchor",
+			ContributionCounter{SinceFrom: "yesterday"},
+			[]string{"since-from"},
+		},
+		{
+			"sort order",
+			ContributionCounter{Sort: "age"},
+			[]string{"sort"},
+		},
+		{
+			"ranker",
+			ContributionCounter{Ranker: "magic"},
+			[]string{"ranker"},
+		},
+		{
+			"presets",
+			ContributionCounter{Presets: []string{"Frontend", "mobile", "docs"},
+				CustomPresets: []Preset{{Name: "docs", IgnoredPaths: []string{"site/"}}}},
+			[]string{"preset"},
+		},
+		{
+			"max history",
+			ContributionCounter{MaxHistory: "2 years"},
+			[]string{"max-history"},
+		},
+		{
+			"as-of and blame revision",
+			ContributionCounter{AsOf: "v1.0", BlameAt: BlameAtHead},
+			[]string{"as-of"},
+		},
+		{
+			"excluded lines",
+			ContributionCounter{ExcludeLines: []string{"comments", "boilerplate"},
+				ExcludeLinePatterns: []string{"^// Code generated", "(unclosed"}},
+			[]string{"reviewer.excludeLines", "reviewer.excludeLinePattern"},
+		},
+		{
+			"owners policy",
+			ContributionCounter{OwnersPolicy: "strict"},
+			[]string{"reviewer.owners"},
+		},
+	}
+
+	for _, c := range cases {
+		var options []string
+
+		err := c.Counter.Validate()
+		if err != nil {
+			errs, ok := err.(ValidationErrors)
+			if !ok {
+				t.Errorf("%s: got %T, expected ValidationErrors\n", c.Name, err)
+				continue
+			}
+			for _, e := range errs {
+				options = append(options, e.Option)
+			}
+		}
+
+		if !reflect.DeepEqual(options, c.Expected) {
+			t.Errorf("%s: got problems with %v, expected %v\n", c.Name, options, c.Expected)
+		}
+	}
+}
+
+func TestValidateRevisions(t *testing.T) {
+	dir := newTestRepo(t)
+	defer os.RemoveAll(dir)
+
+	repo, err := gogit.PlainOpen(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := ContributionCounter{Repo: repo, Dir: dir}
+	if err := r.Validate(); err != nil {
+		t.Errorf("Unexpected error: %v\n", err)
+	}
+
+	r.Base = "main"
+	errs, ok := r.Validate().(ValidationErrors)
+	if !ok || len(errs) != 1 || errs[0].Option != "base" {
+		t.Errorf("Got %v, expected a problem with the base\n", errs)
+	}
+
+	// Without a base branch, the problem says how to name one
+	runGit(t, dir, "branch", "-q", "-m", "master", "trunk")
+	r.Base = ""
+	errs, ok = r.Validate().(ValidationErrors)
+	if !ok || len(errs) != 1 || errs[0].Problem != "no 'master' or 'main' branch found" {
+		t.Errorf("Got %v, expected a problem naming the missing base\n", errs)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+
+	gr "github.com/thedahv/git-reviewer/src"
+)
+
+// stack suggests reviewers for every branch of a stack of branches, each
+// compared to the branch below it, followed by suggestions for the whole stack
+// compared to the base branch. Branches are listed from the bottom of the
+// stack up.
+func stack(r *gr.ContributionCounter, branches []string) {
+	root := r.Base
+	if root == "" {
+		root = "master"
+	}
+
+	base := root
+	for _, branch := range branches {
+		layer := *r
+		layer.Base, layer.Head = base, branch
+
+		fmt.Printf(tr("%s (on %s)\n\n"), branch, base)
+		suggest(&layer)
+		base = branch
+	}
+
+	whole := *r
+	whole.Base, whole.Head = root, branches[len(branches)-1]
+
+	fmt.Printf(tr("Whole stack (%s on %s)\n\n"), whole.Head, root)
+	suggest(&whole)
+}
+
+// suggest prints reviewers for the changes between the base and head
+// revisions of 'r'.
+func suggest(r *gr.ContributionCounter) {
+	files, err := r.FindFiles()
+	if err != nil {
+		fmt.Printf(tr("There was an error finding files: %v\n\n"), err)
+		return
+	}
+
+	if len(files) == 0 {
+		fmt.Printf(tr("No changes in %s!\n\n"), r.Head)
+		return
+	}
+
+	suggestFiles(r, files)
+}
+
+// suggestFiles prints reviewers for changes to 'files', followed by an empty
+// line.
+func suggestFiles(r *gr.ContributionCounter, files []string) {
+	reviewers, err := r.FindReviewers(files)
+	if err != nil {
+		switch e := err.(type) {
+		case gr.NoReviewersErr:
+			fmt.Printf(tr("Problem finding reviewers: %s\n\n"), e.Help())
+		default:
+			fmt.Printf(tr("There was an error finding reviewers: %v\n\n"), err)
+		}
+		return
+	}
+
+	fmt.Println(reviewers)
+}
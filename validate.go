@@ -0,0 +1,269 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	gr "github.com/thedahv/git-reviewer/src"
+)
+
+// arguments holds the options only the command line knows about.
+type arguments struct {
+	// command is the subcommand and action what the hook command does.
+	command, action string
+	// rest are the arguments left after the flags.
+	rest        []string
+	format      string
+	stack       []string
+	merge, base string
+	interval    time.Duration
+
+	dumpSignals, exportBundle, replay string
+
+	// Flags that only work with some commands, formats or other flags
+	packages, split, actions bool
+	all, effective, refresh  bool
+	prePush                  bool
+	assign, balanceLoad      bool
+	noExec, firstParent      bool
+}
+
+// argumentProblems checks the arguments only the command line knows about,
+// alone and together. Options of the reviewer search itself are checked by
+// its Validate method.
+func argumentProblems(a arguments) gr.ValidationErrors {
+	var problems gr.ValidationErrors
+
+	formats, ok := commandFormats[a.command]
+	if !ok {
+		problems = append(problems, gr.ValidationError{Option: "command",
+			Problem: fmt.Sprintf("unknown command '%s'", a.command),
+			Fix:     fmt.Sprintf("Use %s, or no command to suggest reviewers", commandList())})
+	} else if !contains(formats, a.format) {
+		problems = append(problems, gr.ValidationError{Option: "format",
+			Problem: fmt.Sprintf("'%s' is not available here", a.format),
+			Fix:     fmt.Sprintf("Use one of %s", strings.Join(formats, ", "))})
+	}
+
+	// Only query and identities take arguments after the flags, and the
+	// pre-push hook gets the remote's name and URL from git
+	takesRest := a.command == "query" || a.command == "identities" ||
+		(a.command == "hook" && a.action == "pre-push")
+	if len(a.rest) > 0 && !takesRest {
+		if _, isCommand := commandFormats[a.rest[0]]; a.command == "" && isCommand {
+			problems = append(problems, gr.ValidationError{Option: "command",
+				Problem: fmt.Sprintf("'%s' comes after the flags", a.rest[0]),
+				Fix:     fmt.Sprintf("Run 'git reviewer %s' followed by the flags", a.rest[0])})
+		} else {
+			problems = append(problems, gr.ValidationError{Option: "arguments",
+				Problem: fmt.Sprintf("unexpected '%s'", strings.Join(a.rest, " ")),
+				Fix:     "Leave them out, and put every flag right after the command"})
+		}
+	}
+
+	// Options that only change how reviewers are suggested as a table
+	suggesting := a.command == "" && a.format == "table" && len(a.stack) == 0
+
+	if len(a.stack) > 0 && (a.command != "" || a.format != "table") {
+		problems = append(problems, gr.ValidationError{Option: "stack",
+			Problem: "only works when suggesting reviewers as a table",
+			Fix:     "Leave out the command and format"})
+	}
+
+	if a.merge != "" {
+		switch {
+		case a.command == "watch" || a.command == "history":
+			problems = append(problems, gr.ValidationError{Option: "merge",
+				Problem: fmt.Sprintf("does not work with '%s'", a.command),
+				Fix:     "Use it to suggest reviewers or with annotate"})
+		case len(a.stack) > 0 || a.base != "":
+			problems = append(problems, gr.ValidationError{Option: "merge",
+				Problem: "already picks the revisions to compare",
+				Fix:     "Leave out base and stack"})
+		}
+	}
+
+	if a.interval <= 0 {
+		problems = append(problems, gr.ValidationError{Option: "interval",
+			Problem: fmt.Sprintf("%s is not a positive duration", a.interval),
+			Fix:     "Use a duration such as 2s or 1m"})
+	}
+
+	// Signals and bundles are also captured when suggesting GitHub users
+	capturing := (a.command == "" || a.command == "gh") && a.format == "table" &&
+		len(a.stack) == 0
+	if a.dumpSignals != "" && !capturing {
+		problems = append(problems, gr.ValidationError{Option: "dump-signals",
+			Problem: "only works when suggesting reviewers as a table",
+			Fix:     "Leave out the command, format and stack"})
+	}
+	if a.exportBundle != "" && !capturing {
+		problems = append(problems, gr.ValidationError{Option: "export-bundle",
+			Problem: "only works when suggesting reviewers as a table",
+			Fix:     "Leave out the command, format and stack"})
+	}
+	if a.replay != "" && (!suggesting || a.exportBundle != "") {
+		problems = append(problems, gr.ValidationError{Option: "replay",
+			Problem: "only works on its own",
+			Fix:     "Leave out the command, format, stack and export-bundle"})
+	}
+
+	if a.packages && !suggesting {
+		problems = append(problems, gr.ValidationError{Option: "by-package",
+			Problem: "only works when suggesting reviewers as a table",
+			Fix:     "Leave out the command, format and stack"})
+	}
+	if a.split && !suggesting {
+		problems = append(problems, gr.ValidationError{Option: "split-tests",
+			Problem: "only works when suggesting reviewers as a table",
+			Fix:     "Leave out the command, format and stack"})
+	}
+	if a.split && a.packages {
+		problems = append(problems, gr.ValidationError{Option: "split-tests",
+			Problem: "does not work with --by-package",
+			Fix:     "Pick one way to group the changes"})
+	}
+	if a.actions && !suggesting {
+		problems = append(problems, gr.ValidationError{Option: "github-actions",
+			Problem: "only works when suggesting reviewers as a table",
+			Fix:     "Leave out the command, format and stack"})
+	}
+	if a.actions && os.Getenv("GITHUB_OUTPUT") == "" {
+		problems = append(problems, gr.ValidationError{Option: "github-actions",
+			Problem: "needs GITHUB_OUTPUT, which GitHub Actions sets",
+			Fix:     "Run it in a step of a GitHub Actions workflow"})
+	}
+
+	if a.command == "ownership" && !a.all {
+		problems = append(problems, gr.ValidationError{Option: "all",
+			Problem: "is needed by the ownership command",
+			Fix:     "Run 'git reviewer ownership --all'"})
+	}
+	if a.all && a.command != "ownership" {
+		problems = append(problems, gr.ValidationError{Option: "all",
+			Problem: "only works with the ownership command",
+			Fix:     "Run 'git reviewer ownership --all'"})
+	}
+	if a.command == "config" && !a.effective {
+		problems = append(problems, gr.ValidationError{Option: "effective",
+			Problem: "is needed by the config command",
+			Fix:     "Run 'git reviewer config --effective'"})
+	}
+	if a.effective && a.command != "config" {
+		problems = append(problems, gr.ValidationError{Option: "effective",
+			Problem: "only works with the config command",
+			Fix:     "Run 'git reviewer config --effective'"})
+	}
+
+	switch {
+	case a.command == "hook" && a.action != "install" && a.action != "uninstall" &&
+		a.action != "pre-push":
+		problems = append(problems, gr.ValidationError{Option: "hook",
+			Problem: fmt.Sprintf("unknown action '%s'", a.action),
+			Fix:     "Run 'git reviewer hook install --pre-push'"})
+	case a.command == "hook" && a.action != "pre-push" && !a.prePush:
+		problems = append(problems, gr.ValidationError{Option: "pre-push",
+			Problem: fmt.Sprintf("names the hook to %s", a.action),
+			Fix:     fmt.Sprintf("Run 'git reviewer hook %s --pre-push'", a.action)})
+	case a.prePush && (a.command != "hook" || a.action == "pre-push"):
+		problems = append(problems, gr.ValidationError{Option: "pre-push",
+			Problem: "only works with 'hook install' and 'hook uninstall'",
+			Fix:     "Run 'git reviewer hook install --pre-push'"})
+	}
+
+	if a.assign && a.command != "gh" && a.command != "pr" {
+		problems = append(problems, gr.ValidationError{Option: "assign",
+			Problem: "only works with the gh and pr commands",
+			Fix:     "Run 'git reviewer gh --assign' or 'git reviewer pr --assign'"})
+	}
+	if a.balanceLoad && a.command != "gh" && a.command != "pr" {
+		problems = append(problems, gr.ValidationError{Option: "balance-load",
+			Problem: "only works with the gh and pr commands, whose providers count open reviews",
+			Fix:     "Run 'git reviewer gh --balance-load' or 'git reviewer pr --balance-load'"})
+	}
+
+	if a.noExec && (a.command == "gh" || a.command == "pr" || a.command == "watch" ||
+		a.command == "doctor" || (a.command == "hook" && a.action != "pre-push")) {
+		problems = append(problems, gr.ValidationError{Option: "no-exec",
+			Problem: fmt.Sprintf("the %s command needs to run external programs", a.command),
+			Fix:     fmt.Sprintf("Leave out --no-exec to run '%s'", a.command)})
+	}
+	// Accounts are looked up with the gh CLI
+	if a.noExec && a.actions {
+		problems = append(problems, gr.ValidationError{Option: "no-exec",
+			Problem: "--github-actions needs to run the gh CLI",
+			Fix:     "Leave out --no-exec or --github-actions"})
+	}
+	// Blaming without git always follows every parent of merges
+	if a.noExec && a.firstParent {
+		problems = append(problems, gr.ValidationError{Option: "no-exec",
+			Problem: "--first-parent needs git to blame",
+			Fix:     "Leave out --no-exec or --first-parent"})
+	}
+
+	if a.refresh && a.command != "identities" {
+		problems = append(problems, gr.ValidationError{Option: "refresh",
+			Problem: "only works with the identities command",
+			Fix:     "Run 'git reviewer identities --refresh'"})
+	}
+
+	return problems
+}
+
+// commandList names every command of commandFormats in order, such as
+// "annotate, config or watch".
+func commandList() string {
+	var commands []string
+	for command := range commandFormats {
+		if command != "" {
+			commands = append(commands, command)
+		}
+	}
+	sort.Strings(commands)
+
+	last := len(commands) - 1
+	return strings.Join(commands[:last], ", ") + " or " + commands[last]
+}
+
+// reportProblems prints every problem with the arguments along with how to
+// fix it, as JSON on stderr with the json format, and exits with status 2 so
+// scripts can tell the run failed.
+func reportProblems(problems gr.ValidationErrors, format string) {
+	defer os.Exit(2)
+
+	if format == "json" {
+		reportError(format, "", problems)
+		return
+	}
+
+	if len(problems) == 1 {
+		fmt.Println(tr("There is a problem with the arguments:"))
+	} else {
+		fmt.Printf(tr("There are %d problems with the arguments:\n"), len(problems))
+	}
+
+	for _, p := range problems {
+		fmt.Printf("  %s: %s. %s.\n", p.Option, p.Problem, p.Fix)
+	}
+
+	fmt.Println(tr("Run 'git reviewer -h' for help."))
+}
+
+// reportError prints an error that stopped the run on stderr, where it
+// doesn't get mixed up with results. With the json format it is a
+// gr.ErrorReport, so programs can tell what went wrong without reading
+// messages meant for people. Otherwise 'msg' is translated and printed with
+// the error.
+func reportError(format, msg string, err error) {
+	if format == "json" {
+		b, _ := json.Marshal(gr.Report(err))
+		fmt.Fprintln(os.Stderr, string(b))
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, tr(msg), err)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestArgumentProblems(t *testing.T) {
+	os.Unsetenv("GITHUB_OUTPUT")
+
+	// valid holds the arguments of a plain suggestion
+	valid := arguments{format: "table", interval: 2 * time.Second}
+	with := func(change func(a *arguments)) arguments {
+		a := valid
+		change(&a)
+		return a
+	}
+
+	cases := []struct {
+		Name      string
+		Arguments arguments
+		Expected  []string
+	}{
+		{"defaults", valid, nil},
+		{"unknown command", with(func(a *arguments) { a.command = "blame" }), []string{"command"}},
+		{"unknown format", with(func(a *arguments) { a.format = "xml" }), []string{"format"}},
+		{"stack with a command", with(func(a *arguments) {
+			a.command, a.stack = "history", []string{"a", "b"}
+		}), []string{"stack"}},
+		{"merge with base", with(func(a *arguments) { a.merge, a.base = "HEAD", "main" }),
+			[]string{"merge"}},
+		{"interval", with(func(a *arguments) { a.interval = 0 }), []string{"interval"}},
+		{"signals with gh", with(func(a *arguments) {
+			a.command, a.dumpSignals, a.exportBundle = "gh", "s.json", "b.tar.gz"
+		}), nil},
+		{"signals with a format", with(func(a *arguments) {
+			a.format, a.dumpSignals, a.exportBundle = "emails", "s.json", "b.tar.gz"
+		}), []string{"dump-signals", "export-bundle"}},
+		{"replay and export", with(func(a *arguments) {
+			a.replay, a.exportBundle = "b.tar.gz", "c.tar.gz"
+		}), []string{"replay"}},
+		{"grouping twice", with(func(a *arguments) { a.packages, a.split = true, true }),
+			[]string{"split-tests"}},
+		{"grouping with a command", with(func(a *arguments) {
+			a.command, a.packages, a.split = "describe", true, false
+		}), []string{"by-package"}},
+		{"actions outside of a workflow", with(func(a *arguments) { a.actions = true }),
+			[]string{"github-actions"}},
+		{"ownership without all", with(func(a *arguments) { a.command = "ownership" }),
+			[]string{"all"}},
+		{"all without ownership", with(func(a *arguments) { a.all = true }), []string{"all"}},
+		{"config without effective", with(func(a *arguments) { a.command = "config" }),
+			[]string{"effective"}},
+		{"effective without config", with(func(a *arguments) { a.effective = true }),
+			[]string{"effective"}},
+		{"hook action", with(func(a *arguments) { a.command, a.action = "hook", "remove" }),
+			[]string{"hook"}},
+		{"hook install", with(func(a *arguments) { a.command, a.action = "hook", "install" }),
+			[]string{"pre-push"}},
+		{"hook uninstall", with(func(a *arguments) {
+			a.command, a.action, a.prePush = "hook", "uninstall", true
+		}), nil},
+		{"pre-push without hook", with(func(a *arguments) { a.prePush = true }),
+			[]string{"pre-push"}},
+		{"assign without provider", with(func(a *arguments) {
+			a.assign, a.balanceLoad = true, true
+		}), []string{"assign", "balance-load"}},
+		{"assign with gh", with(func(a *arguments) {
+			a.command, a.assign, a.balanceLoad = "gh", true, true
+		}), nil},
+		{"no exec with pr", with(func(a *arguments) { a.command, a.noExec = "pr", true }),
+			[]string{"no-exec"}},
+		{"no exec in a workflow", with(func(a *arguments) { a.noExec, a.actions = true, true }),
+			[]string{"github-actions", "no-exec"}},
+		{"no exec with first parent", with(func(a *arguments) {
+			a.noExec, a.firstParent = true, true
+		}), []string{"no-exec"}},
+		{"refresh without identities", with(func(a *arguments) { a.refresh = true }),
+			[]string{"refresh"}},
+		{"command after the flags", with(func(a *arguments) { a.rest = []string{"files"} }),
+			[]string{"command"}},
+		{"extra arguments", with(func(a *arguments) {
+			a.command, a.rest = "files", []string{"src"}
+		}), []string{"arguments"}},
+		{"query arguments", with(func(a *arguments) {
+			a.command, a.rest = "query", []string{"why", "bob@example.com"}
+		}), nil},
+		{"pre-push arguments", with(func(a *arguments) {
+			a.command, a.action, a.rest = "hook", "pre-push", []string{"origin", "/tmp/r.git"}
+		}), nil},
+	}
+
+	for _, c := range cases {
+		var options []string
+		for _, p := range argumentProblems(c.Arguments) {
+			options = append(options, p.Option)
+		}
+
+		if !reflect.DeepEqual(options, c.Expected) {
+			t.Errorf("%s: got problems with %v, expected %v\n", c.Name, options, c.Expected)
+		}
+	}
+}
+
+func TestCommandList(t *testing.T) {
+	list := commandList()
+	if !strings.HasPrefix(list, "annotate, config, conflicts, ") ||
+		!strings.HasSuffix(list, ", query or watch") {
+		t.Errorf("Got '%s', expected the commands in order\n", list)
+	}
+
+	// Every command is listed, including the last one added
+	for command := range commandFormats {
+		if !strings.Contains(list, command) {
+			t.Errorf("Got '%s', expected it to list %s\n", list, command)
+		}
+	}
+}
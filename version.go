@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// version is the release of git-reviewer. Packagers can override it along
+// with the commit and build date at link time:
+//
+//	go build -ldflags "-X main.version=0.0.6 -X main.commit=$(git rev-parse HEAD)
+//	  -X main.buildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	version   = "0.0.5"
+	commit    = "unknown"
+	buildDate = "unknown"
+)
+
+// buildInfo describes the binary for bug reports and package managers.
+type buildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"buildDate"`
+	GoVersion string `json:"goVersion"`
+	Platform  string `json:"platform"`
+}
+
+// printVersion reports the version and build metadata of the binary, either
+// as human readable lines or as JSON.
+func printVersion(format string) {
+	info := buildInfo{
+		Version:   version,
+		Commit:    commit,
+		BuildDate: buildDate,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+
+	if format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(info); err != nil {
+			fmt.Printf("There was an error printing the version: %v\n", err)
+		}
+		return
+	}
+
+	fmt.Printf("git-reviewer version %s\n", info.Version)
+	fmt.Printf("  commit:     %s\n", info.Commit)
+	fmt.Printf("  built:      %s\n", info.BuildDate)
+	fmt.Printf("  go version: %s\n", info.GoVersion)
+	fmt.Printf("  platform:   %s\n", info.Platform)
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	gr "github.com/thedahv/git-reviewer/src"
+)
+
+// watch polls the repository for new commits and edits in the working tree,
+// printing a fresh set of suggested reviewers whenever something changes. It
+// runs until the process is interrupted.
+//
+// Each check runs git rev-parse and git diff against HEAD, and with
+// IncludeUntracked git ls-files and a read of every untracked file, so it
+// costs about as much as git status every 'interval' whether anything changed
+// or not. Blaming only happens once something did. Large working trees may
+// want a longer interval.
+func watch(r *gr.ContributionCounter, interval time.Duration) {
+	// Suggestions depend on uncommitted changes here, so caching by branch tip
+	// would show stale results.
+	r.WorkingTree = true
+	r.Cache = nil
+	r.CacheDir = ""
+
+	base := r.Base
+	if base == "" {
+		base = "master"
+	}
+
+	var last string
+	for {
+		state, err := workingTreeState(r.Dir, base, r.IncludeUntracked)
+		if err != nil {
+			fmt.Printf(tr("Unable to read repository state: %v\n"), err)
+			return
+		}
+
+		if state != last {
+			last = state
+			refresh(r)
+		}
+
+		time.Sleep(interval)
+	}
+}
+
+// refresh clears the terminal and prints suggestions for the current state of
+// the branch and working tree.
+func refresh(r *gr.ContributionCounter) {
+	fmt.Print("\033[H\033[2J")
+	fmt.Printf("git-reviewer watch (%s)\n\n", time.Now().Format("15:04:05"))
+
+	files, err := r.FindFiles()
+	if err != nil {
+		fmt.Printf(tr("There was an error finding files: %v\n"), err)
+		return
+	}
+
+	if len(files) == 0 {
+		fmt.Println(tr("No changes on this branch yet"))
+		return
+	}
+
+	fmt.Printf(tr("%d changed files\n\n"), len(files))
+
+	reviewers, err := r.FindReviewers(files)
+	if err != nil {
+		fmt.Printf(tr("There was an error finding reviewers: %v\n"), err)
+		return
+	}
+
+	fmt.Println(reviewers)
+}
+
+// workingTreeState summarizes the tips of 'base' and HEAD and the uncommitted
+// changes, along with the untracked files and their contents with
+// 'untracked', so watch can tell when suggestions need to be recomputed.
+func workingTreeState(dir, base string, untracked bool) (string, error) {
+	tips, err := git(dir, "rev-parse", base, "HEAD").Output()
+	if err != nil {
+		return "", err
+	}
+
+	h := sha1.New()
+	changes, err := git(dir, "diff", "--no-color", "--no-ext-diff", "HEAD").Output()
+	if err != nil {
+		return "", err
+	}
+	h.Write(changes)
+
+	if untracked {
+		files, err := git(dir, "ls-files", "-z", "--others", "--exclude-standard").Output()
+		if err != nil {
+			return "", err
+		}
+		for _, name := range strings.Split(strings.TrimSuffix(string(files), "\x00"), "\x00") {
+			if name == "" {
+				continue
+			}
+			// Files can disappear between listing and reading them, which
+			// changes the state too
+			content, _ := ioutil.ReadFile(filepath.Join(dir, name))
+			fmt.Fprintf(h, "%s\x00%d\x00", name, len(content))
+			h.Write(content)
+		}
+	}
+
+	return fmt.Sprintf("%s%x", tips, h.Sum(nil)), nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestWorkingTreeState(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "git-reviewer-watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(name, content string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"symbolic-ref", "HEAD", "refs/heads/main"},
+		{"-c", "user.name=Abe", "-c", "user.email=abe@git-reviewer.com", "commit", "-q",
+			"--allow-empty", "-m", "Initial commit"},
+	} {
+		if out, err := git(dir, args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	// Repositories without a master branch are watched against their base
+	state, err := workingTreeState(dir, "main", true)
+	if err != nil {
+		t.Fatalf("Unexpected error reading the state: %v\n", err)
+	}
+
+	cases := []struct {
+		Name, File, Content string
+		Changed             bool
+	}{
+		{"new untracked file", "new.go", "package a\n", true},
+		{"same content", "new.go", "package a\n", false},
+		{"edited untracked file", "new.go", "package b\n", true},
+	}
+
+	for _, c := range cases {
+		write(c.File, c.Content)
+		next, err := workingTreeState(dir, "main", true)
+		if err != nil {
+			t.Fatalf("%s: unexpected error reading the state: %v\n", c.Name, err)
+		}
+		if changed := next != state; changed != c.Changed {
+			t.Errorf("%s: got changed %t, expected %t\n", c.Name, changed, c.Changed)
+		}
+		state = next
+	}
+}